@@ -1,14 +1,31 @@
 package main
 
 import (
+	"context"
+	"ecommerce-platform/internal/apikey"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/config"
 	"ecommerce-platform/internal/database"
+	"ecommerce-platform/internal/health"
+	"ecommerce-platform/internal/interceptor"
+	"ecommerce-platform/internal/lifecycle"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/internal/metrics"
+	"ecommerce-platform/internal/migrate"
+	"ecommerce-platform/internal/mtls"
+	"ecommerce-platform/internal/openapi"
+	"ecommerce-platform/internal/telemetry"
+	"ecommerce-platform/migrations"
 	"ecommerce-platform/services/inventory/api/handler"
 	"ecommerce-platform/services/inventory/repository/postgres"
 	"ecommerce-platform/services/inventory/service"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
+	"time"
 
 	pb "ecommerce-platform/pkg/grpc/inventory"
 	inventory_grpc "ecommerce-platform/services/inventory/grpc"
@@ -16,52 +33,205 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
+// Config is the configuration of the inventory service, see package config
+// for where the values come from.
+type Config struct {
+	Port            int              `yaml:"port" env:"PORT" flag:"port" default:"8082" usage:"HTTP port"`
+	ShutdownTimeout time.Duration    `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" default:"20s" usage:"how long in-flight work may take on shutdown"`
+	Database        config.Database  `yaml:"database"`
+	GRPCPort        int              `yaml:"grpc_port" env:"GRPC_PORT" flag:"grpc-port" default:"9090" usage:"gRPC port"`
+	RabbitMQ        config.RabbitMQ  `yaml:"rabbitmq"`
+	Telemetry       config.Telemetry `yaml:"telemetry"`
+	Auth            config.Auth      `yaml:"auth"`
+	TLS             config.TLS       `yaml:"tls"`
+}
+
+func (c *Config) Validate() error {
+	return errors.Join(config.ValidatePort("port", c.Port), config.ValidatePort("grpc_port", c.GRPCPort))
+}
+
 func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil)).With("service", "product-service")
 
-	db := database.InitDb("postgres_db", "5432", "user", "password", "ecommerce_db")
-	defer db.Close()
+	var cfg Config
+	args, err := config.Load(&cfg, os.Args[1:])
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Configuration loaded", "config", cfg)
+
+	// "migrate up|down|status|to N" changes the schema instead of serving.
+	if len(args) > 0 && args[0] == "migrate" {
+		db := database.InitDb(cfg.Database)
+		migrator, err := migrate.New(db, migrations.FS, logger)
+		if err == nil {
+			err = migrator.Command(context.Background(), args[1:], os.Stdout)
+		}
+		db.Close()
+		if err != nil {
+			logger.Error("Migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), "inventory-service", cfg.Telemetry)
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Servers and workers stop on SIGTERM, then the connections registered
+	// here are closed.
+	runner := lifecycle.NewRunner(cfg.ShutdownTimeout, logger)
+	runner.Close("tracing", func() error { return shutdownTracing(context.Background()) })
+
+	db := database.InitDb(cfg.Database)
+	runner.Close("postgres", db.Close)
+
+	migrator, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
+		logger.Error("Failed to read migrations", "error", err)
+		os.Exit(1)
+	}
+	if err := migrator.Startup(context.Background(), cfg.Database.Migrate); err != nil {
+		logger.Error("Database schema is not usable", "error", err)
+		os.Exit(1)
+	}
+
+	// Price changes are published so the order service can drop the prices
+	// it cached.
+	broker, err := messaging.NewRabbitMQ(cfg.RabbitMQ.URL.Value(), logger)
+	if err != nil {
+		logger.Error("Failed to connect to message broker", "error", err)
+		os.Exit(1)
+	}
+	runner.Close("rabbitmq", broker.Close)
 
 	inventoryRepo, err := postgres.NewInventoryPgRepository(db, logger)
 	if err != nil {
 		panic(err)
 	}
-	inventoryService := service.NewInventoryService(inventoryRepo, logger)
+	inventoryService := service.NewInventoryService(inventoryRepo, broker, logger)
 	inventoryHandler := handler.NewInventoryHandler(inventoryService, logger)
 
+	keySource, err := auth.NewKeySource(cfg.Auth)
+	if err != nil {
+		logger.Error("Failed to configure token verification", "error", err)
+		os.Exit(1)
+	}
+	// Partners may use API keys instead of access tokens.
+	apiKeyStore, err := apikey.NewPgStore(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	apiKeys := apikey.NewAuthenticator(apiKeyStore, logger)
+	verifier := auth.NewVerifier(keySource).WithAPIKeys(apiKeys)
+
+	policy := handler.Policy(logger)
+
+	spec, err := openapi.Load(handler.OpenAPI)
+	if err != nil {
+		logger.Error("Invalid OpenAPI document", "error", err)
+		os.Exit(1)
+	}
+	validator := openapi.NewValidator(spec, logger)
+
+	// Dependencies checked on /readyz.
+	checks := health.NewRegistry(2*time.Second, logger)
+	checks.Register("postgres", health.DB(db))
+	checks.Register("rabbitmq", broker.Ping)
+
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
+	r.Use(telemetry.Middleware("inventory-service"))
+	r.Use(metrics.Middleware)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
+	r.Use(auth.OptionalMiddleware(verifier, logger))
+	r.Use(apiKeys.RateLimit())
+	r.Use(policy.Middleware(r))
+	r.Use(validator.Middleware(r))
 
-	r.Route("/products", func(r chi.Router) {
-		r.Post("/", inventoryHandler.AddProduct)
-		r.Get("/{id}", inventoryHandler.GetPrice)
-	})
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/healthz", checks.Live)
+	r.Get("/readyz", checks.Ready)
+	r.Get("/openapi.json", spec.ServeHTTP)
+
+	handler.Routes(r, inventoryHandler)
 
-	go func() {
-		http.ListenAndServe(":8082", r)
-	}()
+	if err := spec.Check(r, "/metrics", "/healthz", "/readyz", "/openapi.json"); err != nil {
+		logger.Error("OpenAPI document is out of date", "error", err)
+		os.Exit(1)
+	}
 
-	grpcPort := os.Getenv("GRPC_PORT")
+	runner.HTTP("http server", &http.Server{Addr: fmt.Sprintf(":%d", cfg.Port), Handler: r})
 
-	lis, err := net.Listen("tcp", ":"+grpcPort)
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCPort))
 	if err != nil {
 		logger.Error("Failed to listen for gRPC", "error", err)
 		os.Exit(1)
 	}
 
-	grpcServer := grpc.NewServer()
+	certs, err := mtls.NewReloader(cfg.TLS, logger)
+	if err != nil {
+		logger.Error("Failed to load certificates", "error", err)
+		os.Exit(1)
+	}
+	runner.Go("certificate reloader", func(ctx context.Context) error {
+		certs.Watch(ctx, 30*time.Second)
+		return nil
+	})
+
+	permissions := inventory_grpc.Permissions()
+	permissions[healthpb.Health_Check_FullMethodName] = auth.Public
+	permissions[healthpb.Health_List_FullMethodName] = auth.Public
+	permissions[healthpb.Health_Watch_FullMethodName] = auth.Public
+
+	// Only the order service may change stock levels.
+	peers := map[string][]string{
+		pb.InventoryService_AdjustStock_FullMethodName: {"order-service"},
+	}
+
+	grpcServer := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(certs.ServerConfig())),
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		interceptor.Server(logger, 10*time.Second,
+			mtls.UnaryPeerInterceptor(peers, logger),
+			// Every gRPC method needs an entry here, others are denied.
+			auth.UnaryServerInterceptor(verifier, permissions, logger),
+		),
+		interceptor.Stream(logger,
+			mtls.StreamPeerInterceptor(peers, logger),
+			auth.StreamServerInterceptor(verifier, permissions, logger),
+		),
+	)
 	inventoryServer := inventory_grpc.NewInventoryGRPCServer(inventoryService)
 	pb.RegisterInventoryServiceServer(grpcServer, inventoryServer)
 
-	logger.Info("gRPC inventory server is starting", "port", grpcPort)
-	if err := grpcServer.Serve(lis); err != nil {
-		logger.Error("gRPC server failed to start", "error", err)
+	// The standard health service reports the inventory service as serving
+	// while the checks of /readyz pass.
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	runner.Go("grpc health", func(ctx context.Context) error {
+		checks.UpdateGRPC(ctx, healthServer, 10*time.Second, "", pb.InventoryService_ServiceDesc.ServiceName)
+		return nil
+	})
+
+	logger.Info("gRPC inventory server is starting", "port", cfg.GRPCPort)
+	runner.GRPC("grpc server", grpcServer, lis)
+
+	if err := runner.Run(); err != nil {
+		logger.Error("Service stopped with error", "error", err)
 		os.Exit(1)
 	}
 }
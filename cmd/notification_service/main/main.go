@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/config"
+	"ecommerce-platform/internal/database"
+	"ecommerce-platform/internal/health"
+	"ecommerce-platform/internal/lifecycle"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/internal/metrics"
+	"ecommerce-platform/internal/migrate"
+	"ecommerce-platform/internal/telemetry"
+	"ecommerce-platform/migrations"
+	"ecommerce-platform/services/notification/api/consumer"
+	"ecommerce-platform/services/notification/api/handler"
+	"ecommerce-platform/services/notification/repository/postgres"
+	"ecommerce-platform/services/notification/sender"
+	"ecommerce-platform/services/notification/service"
+	"ecommerce-platform/services/notification/templates"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	_ "github.com/lib/pq"
+)
+
+// Config is the configuration of the notification service, see package config
+// for where the values come from.
+type Config struct {
+	Port            int              `yaml:"port" env:"PORT" flag:"port" default:"8084" usage:"HTTP port"`
+	ShutdownTimeout time.Duration    `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" default:"20s" usage:"how long in-flight work may take on shutdown"`
+	Database        config.Database  `yaml:"database"`
+	RabbitMQ        config.RabbitMQ  `yaml:"rabbitmq"`
+	Sender          string           `yaml:"sender" env:"NOTIFICATION_SENDER" flag:"sender" default:"log" usage:"where messages go: smtp, file or log"`
+	OutboxDir       string           `yaml:"outbox_dir" env:"NOTIFICATION_OUTBOX_DIR" flag:"outbox-dir" usage:"directory of the file sender"`
+	SMTP            SMTPConfig       `yaml:"smtp"`
+	Telemetry       config.Telemetry `yaml:"telemetry"`
+	Auth            config.Auth      `yaml:"auth"`
+}
+
+func (c *Config) Validate() error {
+	switch c.Sender {
+	case "smtp":
+		if c.SMTP.Addr == "" || c.SMTP.From == "" {
+			return errors.New("smtp.addr and smtp.from must be set for the smtp sender")
+		}
+	case "file":
+		if c.OutboxDir == "" {
+			return errors.New("outbox_dir must be set for the file sender")
+		}
+	case "log":
+	default:
+		return fmt.Errorf("unknown sender %q", c.Sender)
+	}
+	return config.ValidatePort("port", c.Port)
+}
+
+type SMTPConfig struct {
+	Addr     string        `yaml:"addr" env:"SMTP_ADDR" flag:"smtp-addr" usage:"host:port of the SMTP server"`
+	From     string        `yaml:"from" env:"SMTP_FROM" flag:"smtp-from" usage:"sender address"`
+	Username string        `yaml:"username" env:"SMTP_USERNAME" flag:"smtp-username" usage:"SMTP user"`
+	Password config.Secret `yaml:"password" env:"SMTP_PASSWORD" usage:"SMTP password"`
+}
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil)).With("service", "notification-service")
+
+	var cfg Config
+	args, err := config.Load(&cfg, os.Args[1:])
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Configuration loaded", "config", cfg)
+
+	// "migrate up|down|status|to N" changes the schema instead of serving.
+	if len(args) > 0 && args[0] == "migrate" {
+		db := database.InitDb(cfg.Database)
+		migrator, err := migrate.New(db, migrations.FS, logger)
+		if err == nil {
+			err = migrator.Command(context.Background(), args[1:], os.Stdout)
+		}
+		db.Close()
+		if err != nil {
+			logger.Error("Migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), "notification-service", cfg.Telemetry)
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Servers and workers stop on SIGTERM, then the connections registered
+	// here are closed.
+	runner := lifecycle.NewRunner(cfg.ShutdownTimeout, logger)
+	runner.Close("tracing", func() error { return shutdownTracing(context.Background()) })
+
+	db := database.InitDb(cfg.Database)
+	runner.Close("postgres", db.Close)
+
+	migrator, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
+		logger.Error("Failed to read migrations", "error", err)
+		os.Exit(1)
+	}
+	if err := migrator.Startup(context.Background(), cfg.Database.Migrate); err != nil {
+		logger.Error("Database schema is not usable", "error", err)
+		os.Exit(1)
+	}
+
+	broker, err := messaging.NewRabbitMQ(cfg.RabbitMQ.URL.Value(), logger)
+	if err != nil {
+		logger.Error("Failed to connect to message broker", "error", err)
+		os.Exit(1)
+	}
+	runner.Close("rabbitmq", broker.Close)
+
+	renderer, err := templates.NewRenderer()
+	if err != nil {
+		logger.Error("Failed to parse templates", "error", err)
+		os.Exit(1)
+	}
+
+	var messageSender sender.Sender
+	switch cfg.Sender {
+	case "smtp":
+		messageSender = sender.NewSMTPSender(cfg.SMTP.Addr, cfg.SMTP.From, cfg.SMTP.Username, cfg.SMTP.Password.Value())
+	case "file":
+		messageSender, err = sender.NewFileSender(cfg.OutboxDir, cfg.SMTP.From)
+		if err != nil {
+			logger.Error("Failed to create outbox directory", "error", err)
+			os.Exit(1)
+		}
+	default:
+		messageSender = sender.NewLogSender(logger)
+	}
+
+	notificationRepo, err := postgres.NewNotificationPgRepository(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	recipientRepo, err := postgres.NewRecipientPgRepository(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	notificationService := service.NewNotificationService(notificationRepo, recipientRepo, renderer, messageSender, logger)
+	notificationHandler := handler.NewNotificationHandler(notificationService, logger)
+
+	// Access tokens are verified with the user service's keys, see auth.NewKeySource.
+	keySource, err := auth.NewKeySource(cfg.Auth)
+	if err != nil {
+		logger.Error("Failed to configure token verification", "error", err)
+		os.Exit(1)
+	}
+	verifier := auth.NewVerifier(keySource)
+
+	policy := handler.Policy(logger)
+
+	eventConsumer := consumer.NewEventConsumer(notificationService, logger)
+	runner.Go("event consumer", func(ctx context.Context) error {
+		return broker.Subscribe(ctx, consumer.QueueName, consumer.EventTypes, eventConsumer.Handle)
+	})
+
+	runner.Go("notification retries", func(ctx context.Context) error {
+		notificationService.RunRetryLoop(ctx, 15*time.Second)
+		return nil
+	})
+
+	// Dependencies checked on /readyz.
+	checks := health.NewRegistry(2*time.Second, logger)
+	checks.Register("postgres", health.DB(db))
+	checks.Register("rabbitmq", broker.Ping)
+
+	r := chi.NewRouter()
+
+	r.Use(middleware.RequestID)
+	r.Use(telemetry.Middleware("notification-service"))
+	r.Use(metrics.Middleware)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Recoverer)
+
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/healthz", checks.Live)
+	r.Get("/readyz", checks.Ready)
+
+	// Everything but metrics and health checks needs a token.
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Middleware(verifier, logger))
+		r.Use(policy.Middleware(r))
+
+		handler.Routes(r, notificationHandler)
+	})
+
+	runner.HTTP("http server", &http.Server{Addr: fmt.Sprintf(":%d", cfg.Port), Handler: r})
+
+	if err := runner.Run(); err != nil {
+		logger.Error("Service stopped with error", "error", err)
+		os.Exit(1)
+	}
+}
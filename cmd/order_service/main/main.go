@@ -1,58 +1,260 @@
 package main
 
 import (
+	"context"
+	"ecommerce-platform/internal/apikey"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/config"
 	"ecommerce-platform/internal/database"
+	"ecommerce-platform/internal/health"
+	"ecommerce-platform/internal/interceptor"
+	"ecommerce-platform/internal/lifecycle"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/internal/metrics"
+	"ecommerce-platform/internal/migrate"
+	"ecommerce-platform/internal/mtls"
+	"ecommerce-platform/internal/openapi"
+	"ecommerce-platform/internal/resilience"
+	"ecommerce-platform/internal/telemetry"
+	"ecommerce-platform/migrations"
+	"ecommerce-platform/services/order/api/consumer"
 	"ecommerce-platform/services/order/api/handler"
+	"ecommerce-platform/services/order/client"
 	"ecommerce-platform/services/order/repository/postgres"
 	"ecommerce-platform/services/order/service"
+	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"time"
 
 	pb "ecommerce-platform/pkg/grpc/inventory"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
 )
 
+// Config is the configuration of the order service, see package config
+// for where the values come from.
+type Config struct {
+	Port            int                       `yaml:"port" env:"PORT" flag:"port" default:"8081" usage:"HTTP port"`
+	ShutdownTimeout time.Duration             `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" default:"20s" usage:"how long in-flight work may take on shutdown"`
+	Database        config.Database           `yaml:"database"`
+	RabbitMQ        config.RabbitMQ           `yaml:"rabbitmq"`
+	InventoryAddr   string                    `yaml:"inventory_grpc_addr" env:"INVENTORY_SERVICE_GRPC_ADDR" flag:"inventory-addr" required:"true" usage:"host:port of the inventory gRPC server"`
+	InventoryClient config.GRPCClient         `yaml:"inventory_client"`
+	ProductCache    client.ProductCacheConfig `yaml:"product_cache"`
+	PaymentURL      string                    `yaml:"payment_url" env:"PAYMENT_SERVICE_URL" flag:"payment-url" required:"true" usage:"base URL of the payment service"`
+	PaymentAPIKey   config.Secret             `yaml:"payment_api_key" env:"PAYMENT_API_KEY" usage:"API key (scope payments:refund) to call the payment service with instead of the caller's credentials"`
+	Telemetry       config.Telemetry          `yaml:"telemetry"`
+	Auth            config.Auth               `yaml:"auth"`
+	TLS             config.TLS                `yaml:"tls"`
+}
+
+func (c *Config) Validate() error {
+	return config.ValidatePort("port", c.Port)
+}
+
 func main() {
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil)).With("service", "order-service")
 
-	inventorySvcAddr := os.Getenv("INVENTORY_SERVICE_GRPC_ADDR") // e.g., "inventory-service:9090"
+	var cfg Config
+	args, err := config.Load(&cfg, os.Args[1:])
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Configuration loaded", "config", cfg)
+
+	// "migrate up|down|status|to N" changes the schema instead of serving.
+	if len(args) > 0 && args[0] == "migrate" {
+		db := database.InitDb(cfg.Database)
+		migrator, err := migrate.New(db, migrations.FS, logger)
+		if err == nil {
+			err = migrator.Command(context.Background(), args[1:], os.Stdout)
+		}
+		db.Close()
+		if err != nil {
+			logger.Error("Migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), "order-service", cfg.Telemetry)
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Servers and workers stop on SIGTERM, then the connections registered
+	// here are closed.
+	runner := lifecycle.NewRunner(cfg.ShutdownTimeout, logger)
+	runner.Close("tracing", func() error { return shutdownTracing(context.Background()) })
+
+	// The inventory service only accepts callers with a certificate from the
+	// platform CA, see cmd/devcerts for local development.
+	certs, err := mtls.NewReloader(cfg.TLS, logger)
+	if err != nil {
+		logger.Error("Failed to load certificates", "error", err)
+		os.Exit(1)
+	}
+	runner.Go("certificate reloader", func(ctx context.Context) error {
+		certs.Watch(ctx, 30*time.Second)
+		return nil
+	})
+
+	inventoryHost, _, err := net.SplitHostPort(cfg.InventoryAddr)
+	if err != nil {
+		logger.Error("Invalid inventory service address", "address", cfg.InventoryAddr, "error", err)
+		os.Exit(1)
+	}
+
+	// Product lookups are reads and stock adjustments carry a reference the
+	// inventory service deduplicates, so both may be retried and hedged.
+	inventoryResilience := resilience.New("inventory", cfg.InventoryClient, logger,
+		pb.InventoryService_GetProductInfo_FullMethodName,
+		pb.InventoryService_AdjustStock_FullMethodName,
+	)
 
 	// Create the gRPC connection to the inventory service
-	conn, err := grpc.NewClient(inventorySvcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	// The caller's token is forwarded, so the inventory service authorizes calls as the same user.
+	conn, err := grpc.NewClient(cfg.InventoryAddr,
+		grpc.WithTransportCredentials(credentials.NewTLS(certs.ClientConfig(inventoryHost))),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		interceptor.Client(logger, 5*time.Second, inventoryResilience.UnaryClientInterceptor(), auth.UnaryClientInterceptor()),
+	)
 	if err != nil {
 		logger.Error("Failed to connect to inventory service", "error", err)
 		os.Exit(1)
 	}
-	defer conn.Close()
+	runner.Close("inventory connection", conn.Close)
 
 	// Create the gRPC client from the connection
 	inventoryClient := pb.NewInventoryServiceClient(conn)
-	db := database.InitDb("postgres_db", "5432", "user", "password", "ecommerce_db")
-	defer db.Close()
+	db := database.InitDb(cfg.Database)
+	runner.Close("postgres", db.Close)
+
+	migrator, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
+		logger.Error("Failed to read migrations", "error", err)
+		os.Exit(1)
+	}
+	if err := migrator.Startup(context.Background(), cfg.Database.Migrate); err != nil {
+		logger.Error("Database schema is not usable", "error", err)
+		os.Exit(1)
+	}
+
+	broker, err := messaging.NewRabbitMQ(cfg.RabbitMQ.URL.Value(), logger)
+	if err != nil {
+		logger.Error("Failed to connect to message broker", "error", err)
+		os.Exit(1)
+	}
+	runner.Close("rabbitmq", broker.Close)
 
 	orderRepo, err := postgres.NewOrderPgRepository(db, logger)
 	if err != nil {
 		panic(err)
 	}
-	orderService := service.NewOrderService(orderRepo, logger, inventoryClient)
+	// Prices are cached between orders. The inventory service announces
+	// price changes, and every instance drops them from its own cache.
+	productCache := client.NewProductCache(inventoryClient, cfg.ProductCache, logger)
+	productConsumer := consumer.NewProductConsumer(productCache, logger)
+	runner.Go("product consumer", func(ctx context.Context) error {
+		return broker.Subscribe(ctx, "", consumer.ProductEventTypes, productConsumer.Handle)
+	})
+
+	orderService := service.NewOrderService(orderRepo, logger, productCache, broker)
 	orderHandler := handler.NewOrderHandler(orderService, logger)
 
+	eventConsumer := consumer.NewEventConsumer(orderService, logger)
+	runner.Go("event consumer", func(ctx context.Context) error {
+		return broker.Subscribe(ctx, consumer.QueueName, consumer.EventTypes, eventConsumer.Handle)
+	})
+
+	shipmentRepo, err := postgres.NewShipmentPgRepository(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	shipmentService := service.NewShipmentService(shipmentRepo, orderRepo, broker, logger)
+	shipmentHandler := handler.NewShipmentHandler(shipmentService, logger)
+
+	paymentClient := client.NewPaymentClient(cfg.PaymentURL, cfg.PaymentAPIKey, logger)
+
+	returnRepo, err := postgres.NewReturnPgRepository(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	returnService := service.NewReturnService(returnRepo, orderRepo, inventoryClient, paymentClient, logger)
+	returnHandler := handler.NewReturnHandler(returnService, logger)
+
+	// Access tokens are verified with the user service's keys, see auth.NewKeySource.
+	keySource, err := auth.NewKeySource(cfg.Auth)
+	if err != nil {
+		logger.Error("Failed to configure token verification", "error", err)
+		os.Exit(1)
+	}
+	// Partners may use API keys instead of access tokens.
+	apiKeyStore, err := apikey.NewPgStore(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	apiKeys := apikey.NewAuthenticator(apiKeyStore, logger)
+	verifier := auth.NewVerifier(keySource).WithAPIKeys(apiKeys)
+
+	policy := handler.Policy(logger)
+
+	spec, err := openapi.Load(handler.OpenAPI)
+	if err != nil {
+		logger.Error("Invalid OpenAPI document", "error", err)
+		os.Exit(1)
+	}
+	validator := openapi.NewValidator(spec, logger)
+
+	// Dependencies checked on /readyz.
+	checks := health.NewRegistry(2*time.Second, logger)
+	checks.Register("postgres", health.DB(db))
+	checks.Register("rabbitmq", broker.Ping)
+	checks.Register("inventory", health.GRPC(conn, pb.InventoryService_ServiceDesc.ServiceName))
+
 	r := chi.NewRouter()
 
 	r.Use(middleware.RequestID)
+	r.Use(telemetry.Middleware("order-service"))
+	r.Use(metrics.Middleware)
 	r.Use(middleware.RealIP)
 	r.Use(middleware.Recoverer)
 
-	r.Route("/orders", func(r chi.Router) {
-		r.Post("/", orderHandler.CreateOrder)
-		r.Get("/{id}", orderHandler.GetOrderByID)
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/healthz", checks.Live)
+	r.Get("/readyz", checks.Ready)
+	r.Get("/openapi.json", spec.ServeHTTP)
+
+	// Everything but metrics, health checks and the API document needs a
+	// token or API key.
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Middleware(verifier, logger))
+		r.Use(apiKeys.RateLimit())
+		r.Use(policy.Middleware(r))
+		r.Use(validator.Middleware(r))
+
+		handler.Routes(r, orderHandler, shipmentHandler, returnHandler)
 	})
 
-	http.ListenAndServe(":8081", r)
+	if err := spec.Check(r, "/metrics", "/healthz", "/readyz", "/openapi.json"); err != nil {
+		logger.Error("OpenAPI document is out of date", "error", err)
+		os.Exit(1)
+	}
+
+	runner.HTTP("http server", &http.Server{Addr: fmt.Sprintf(":%d", cfg.Port), Handler: r})
+
+	if err := runner.Run(); err != nil {
+		logger.Error("Service stopped with error", "error", err)
+		os.Exit(1)
+	}
 }
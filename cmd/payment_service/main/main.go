@@ -1,11 +1,162 @@
 package main
 
 import (
+	"context"
+	"ecommerce-platform/internal/apikey"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/config"
+	"ecommerce-platform/internal/database"
+	"ecommerce-platform/internal/health"
+	"ecommerce-platform/internal/lifecycle"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/internal/metrics"
+	"ecommerce-platform/internal/migrate"
+	"ecommerce-platform/internal/telemetry"
+	"ecommerce-platform/migrations"
+	"ecommerce-platform/services/payment/api/handler"
+	"ecommerce-platform/services/payment/provider"
+	"ecommerce-platform/services/payment/repository/postgres"
+	"ecommerce-platform/services/payment/service"
 	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
 
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
 	_ "github.com/lib/pq"
 )
 
+// Config is the configuration of the payment service, see package config
+// for where the values come from.
+type Config struct {
+	Port            int              `yaml:"port" env:"PORT" flag:"port" default:"8083" usage:"HTTP port"`
+	ShutdownTimeout time.Duration    `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" default:"20s" usage:"how long in-flight work may take on shutdown"`
+	Database        config.Database  `yaml:"database"`
+	RabbitMQ        config.RabbitMQ  `yaml:"rabbitmq"`
+	Telemetry       config.Telemetry `yaml:"telemetry"`
+	Auth            config.Auth      `yaml:"auth"`
+}
+
+func (c *Config) Validate() error {
+	return config.ValidatePort("port", c.Port)
+}
+
 func main() {
-	fmt.Println("Test")
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil)).With("service", "payment-service")
+
+	var cfg Config
+	args, err := config.Load(&cfg, os.Args[1:])
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Configuration loaded", "config", cfg)
+
+	// "migrate up|down|status|to N" changes the schema instead of serving.
+	if len(args) > 0 && args[0] == "migrate" {
+		db := database.InitDb(cfg.Database)
+		migrator, err := migrate.New(db, migrations.FS, logger)
+		if err == nil {
+			err = migrator.Command(context.Background(), args[1:], os.Stdout)
+		}
+		db.Close()
+		if err != nil {
+			logger.Error("Migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), "payment-service", cfg.Telemetry)
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Servers and workers stop on SIGTERM, then the connections registered
+	// here are closed.
+	runner := lifecycle.NewRunner(cfg.ShutdownTimeout, logger)
+	runner.Close("tracing", func() error { return shutdownTracing(context.Background()) })
+
+	db := database.InitDb(cfg.Database)
+	runner.Close("postgres", db.Close)
+
+	migrator, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
+		logger.Error("Failed to read migrations", "error", err)
+		os.Exit(1)
+	}
+	if err := migrator.Startup(context.Background(), cfg.Database.Migrate); err != nil {
+		logger.Error("Database schema is not usable", "error", err)
+		os.Exit(1)
+	}
+
+	broker, err := messaging.NewRabbitMQ(cfg.RabbitMQ.URL.Value(), logger)
+	if err != nil {
+		logger.Error("Failed to connect to message broker", "error", err)
+		os.Exit(1)
+	}
+	runner.Close("rabbitmq", broker.Close)
+
+	paymentRepo, err := postgres.NewPaymentPgRepository(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	refundRepo, err := postgres.NewRefundPgRepository(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	paymentService := service.NewPaymentService(paymentRepo, refundRepo, provider.NewSimulatedProvider(logger), broker, logger)
+	paymentHandler := handler.NewPaymentHandler(paymentService, logger)
+
+	// Access tokens are verified with the user service's keys, see auth.NewKeySource.
+	keySource, err := auth.NewKeySource(cfg.Auth)
+	if err != nil {
+		logger.Error("Failed to configure token verification", "error", err)
+		os.Exit(1)
+	}
+	// The order service may call with an API key instead of the caller's token.
+	apiKeyStore, err := apikey.NewPgStore(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	apiKeys := apikey.NewAuthenticator(apiKeyStore, logger)
+	verifier := auth.NewVerifier(keySource).WithAPIKeys(apiKeys)
+
+	policy := handler.Policy(logger)
+
+	// Dependencies checked on /readyz.
+	checks := health.NewRegistry(2*time.Second, logger)
+	checks.Register("postgres", health.DB(db))
+	checks.Register("rabbitmq", broker.Ping)
+
+	r := chi.NewRouter()
+
+	r.Use(middleware.RequestID)
+	r.Use(telemetry.Middleware("payment-service"))
+	r.Use(metrics.Middleware)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Recoverer)
+
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/healthz", checks.Live)
+	r.Get("/readyz", checks.Ready)
+
+	// Everything but metrics and health checks needs a token or API key.
+	r.Group(func(r chi.Router) {
+		r.Use(auth.Middleware(verifier, logger))
+		r.Use(apiKeys.RateLimit())
+		r.Use(policy.Middleware(r))
+
+		handler.Routes(r, paymentHandler)
+	})
+
+	runner.HTTP("http server", &http.Server{Addr: fmt.Sprintf(":%d", cfg.Port), Handler: r})
+
+	if err := runner.Run(); err != nil {
+		logger.Error("Service stopped with error", "error", err)
+		os.Exit(1)
+	}
 }
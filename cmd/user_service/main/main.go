@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"crypto/rsa"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/config"
+	"ecommerce-platform/internal/database"
+	"ecommerce-platform/internal/health"
+	"ecommerce-platform/internal/lifecycle"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/internal/metrics"
+	"ecommerce-platform/internal/migrate"
+	"ecommerce-platform/internal/telemetry"
+	"ecommerce-platform/migrations"
+	"ecommerce-platform/services/user/api/handler"
+	"ecommerce-platform/services/user/repository/postgres"
+	"ecommerce-platform/services/user/service"
+	"ecommerce-platform/services/user/token"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	_ "github.com/lib/pq"
+)
+
+// Config is the configuration of the user service, see package config
+// for where the values come from.
+type Config struct {
+	Port            int              `yaml:"port" env:"PORT" flag:"port" default:"8085" usage:"HTTP port"`
+	ShutdownTimeout time.Duration    `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" default:"20s" usage:"how long in-flight work may take on shutdown"`
+	Database        config.Database  `yaml:"database"`
+	RabbitMQ        config.RabbitMQ  `yaml:"rabbitmq"`
+	JWTKeyFile      string           `yaml:"jwt_private_key_file" env:"JWT_PRIVATE_KEY_FILE" flag:"jwt-key-file" usage:"PEM encoded RSA key tokens are signed with, a temporary key is generated if unset"`
+	Telemetry       config.Telemetry `yaml:"telemetry"`
+}
+
+func (c *Config) Validate() error {
+	return config.ValidatePort("port", c.Port)
+}
+
+func main() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil)).With("service", "user-service")
+
+	var cfg Config
+	args, err := config.Load(&cfg, os.Args[1:])
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	logger.Info("Configuration loaded", "config", cfg)
+
+	// "migrate up|down|status|to N" changes the schema instead of serving.
+	if len(args) > 0 && args[0] == "migrate" {
+		db := database.InitDb(cfg.Database)
+		migrator, err := migrate.New(db, migrations.FS, logger)
+		if err == nil {
+			err = migrator.Command(context.Background(), args[1:], os.Stdout)
+		}
+		db.Close()
+		if err != nil {
+			logger.Error("Migration failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	shutdownTracing, err := telemetry.Setup(context.Background(), "user-service", cfg.Telemetry)
+	if err != nil {
+		logger.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Servers and workers stop on SIGTERM, then the connections registered
+	// here are closed.
+	runner := lifecycle.NewRunner(cfg.ShutdownTimeout, logger)
+	runner.Close("tracing", func() error { return shutdownTracing(context.Background()) })
+
+	db := database.InitDb(cfg.Database)
+	runner.Close("postgres", db.Close)
+
+	migrator, err := migrate.New(db, migrations.FS, logger)
+	if err != nil {
+		logger.Error("Failed to read migrations", "error", err)
+		os.Exit(1)
+	}
+	if err := migrator.Startup(context.Background(), cfg.Database.Migrate); err != nil {
+		logger.Error("Database schema is not usable", "error", err)
+		os.Exit(1)
+	}
+
+	broker, err := messaging.NewRabbitMQ(cfg.RabbitMQ.URL.Value(), logger)
+	if err != nil {
+		logger.Error("Failed to connect to message broker", "error", err)
+		os.Exit(1)
+	}
+	runner.Close("rabbitmq", broker.Close)
+
+	var signingKey *rsa.PrivateKey
+	if cfg.JWTKeyFile != "" {
+		signingKey, err = token.LoadKey(cfg.JWTKeyFile)
+	} else {
+		logger.Warn("JWT_PRIVATE_KEY_FILE is not set, generating a temporary signing key")
+		signingKey, err = token.GenerateKey()
+	}
+	if err != nil {
+		logger.Error("Failed to load signing key", "error", err)
+		os.Exit(1)
+	}
+	signer := token.NewSigner(signingKey, 15*time.Minute, 30*24*time.Hour)
+
+	userRepo, err := postgres.NewUserPgRepository(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	tokenRepo, err := postgres.NewTokenPgRepository(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	userService := service.NewUserService(userRepo, tokenRepo, signer, broker, logger)
+	userHandler := handler.NewUserHandler(userService, logger)
+
+	apiKeyRepo, err := postgres.NewAPIKeyPgRepository(db, logger)
+	if err != nil {
+		panic(err)
+	}
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo, logger)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, logger)
+
+	// Dependencies checked on /readyz.
+	checks := health.NewRegistry(2*time.Second, logger)
+	checks.Register("postgres", health.DB(db))
+	checks.Register("rabbitmq", broker.Ping)
+
+	r := chi.NewRouter()
+
+	r.Use(middleware.RequestID)
+	r.Use(telemetry.Middleware("user-service"))
+	r.Use(metrics.Middleware)
+	r.Use(middleware.RealIP)
+	r.Use(middleware.Recoverer)
+
+	r.Handle("/metrics", metrics.Handler())
+	r.Get("/healthz", checks.Live)
+	r.Get("/readyz", checks.Ready)
+
+	authenticated := auth.Middleware(auth.NewVerifier(signer.Keys()), logger)
+
+	r.Route("/users", func(r chi.Router) {
+		r.Post("/", userHandler.Register)
+		r.With(authenticated).Get("/me", userHandler.GetCurrentUser)
+		r.With(authenticated, auth.Require(auth.PermRolesManage, logger)).Get("/{id}", userHandler.GetUser)
+		r.With(authenticated, auth.Require(auth.PermRolesManage, logger)).Put("/{id}/roles", userHandler.SetRoles)
+	})
+
+	// Users manage their own keys. The user service doesn't accept API keys,
+	// so a key can't be used to create further keys.
+	r.Route("/api-keys", func(r chi.Router) {
+		r.Use(authenticated)
+		r.Post("/", apiKeyHandler.CreateAPIKey)
+		r.Get("/", apiKeyHandler.ListAPIKeys)
+		r.Delete("/{id}", apiKeyHandler.RevokeAPIKey)
+		r.Post("/{id}/rotate", apiKeyHandler.RotateAPIKey)
+	})
+
+	r.Route("/auth", func(r chi.Router) {
+		r.Post("/login", userHandler.Login)
+		r.Post("/refresh", userHandler.Refresh)
+		r.Post("/logout", userHandler.Logout)
+		r.Post("/password-reset", userHandler.RequestPasswordReset)
+		r.Post("/password-reset/confirm", userHandler.ResetPassword)
+	})
+
+	r.Get("/.well-known/jwks.json", userHandler.GetJWKS)
+
+	runner.HTTP("http server", &http.Server{Addr: fmt.Sprintf(":%d", cfg.Port), Handler: r})
+
+	if err := runner.Run(); err != nil {
+		logger.Error("Service stopped with error", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,53 @@
+// Package apikey implements the API keys partners use instead of user logins.
+//
+// Keys look like "ecp_<prefix>_<secret>". The prefix is stored in plain text
+// to look the key up, the key itself only as SHA-256 hash. The user service
+// manages the keys, the other services only verify them.
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const keyScheme = "ecp"
+
+var ErrMalformedKey = errors.New("malformed API key")
+
+// Generate returns a new random key with its prefix and hash.
+func Generate() (key, prefix, hash string, err error) {
+	raw := make([]byte, 6+32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", "", err
+	}
+
+	prefix = hex.EncodeToString(raw[:6])
+	key = keyScheme + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(raw[6:])
+
+	return key, prefix, Hash(key), nil
+}
+
+// Prefix returns the lookup prefix of a key.
+func Prefix(key string) (string, error) {
+	rest, ok := strings.CutPrefix(key, keyScheme+"_")
+	if !ok {
+		return "", ErrMalformedKey
+	}
+
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || secret == "" {
+		return "", ErrMalformedKey
+	}
+
+	return prefix, nil
+}
+
+// Hash returns the hash a key is stored with.
+func Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,188 @@
+package apikey
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/auth"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"golang.org/x/time/rate"
+)
+
+// lastUsedInterval is how often the last use of a key is written at most.
+const lastUsedInterval = time.Minute
+
+// idleTimeout is how long the state of an unused key is kept. A limiter
+// refills within a minute, so forgetting it later loses nothing.
+const idleTimeout = 10 * time.Minute
+
+// ErrRateLimited rejects requests of API keys that exceeded their rate limit.
+var ErrRateLimited = apperr.New(apperr.RateLimited, "rate_limited", "Too many requests")
+
+// Authenticator verifies API keys and enforces their rate limits. It
+// implements auth.APIKeyAuthenticator.
+//
+// Rate limits are enforced per process: with several replicas of a service,
+// a key can make up to its rate limit at every replica.
+type Authenticator struct {
+	store  Store
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]*keyState
+	lastSweep time.Time
+}
+
+// keyState is what the authenticator remembers about a key it has seen.
+type keyState struct {
+	limiter *rate.Limiter
+	// lastUsed is when the last use was written to the store.
+	lastUsed time.Time
+	lastSeen time.Time
+}
+
+func NewAuthenticator(store Store, logger *slog.Logger) *Authenticator {
+	return &Authenticator{
+		store:  store,
+		logger: logger.With("file", "authenticator.go"),
+		now:    time.Now,
+		keys:   make(map[string]*keyState),
+	}
+}
+
+// AuthenticateAPIKey returns a principal acting as the key's owner, limited
+// to the key's scopes.
+func (a *Authenticator) AuthenticateAPIKey(ctx context.Context, key string) (*auth.Principal, error) {
+	prefix, err := Prefix(key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidToken, err)
+	}
+
+	now := a.now()
+
+	stored, err := a.store.FindActive(ctx, prefix, now)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: unknown, expired or revoked API key", auth.ErrInvalidToken)
+		}
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(Hash(key)), []byte(stored.Hash)) != 1 {
+		return nil, fmt.Errorf("%w: wrong API key", auth.ErrInvalidToken)
+	}
+
+	a.touch(ctx, stored.ID, now)
+
+	scopes := make([]auth.Permission, len(stored.Scopes))
+	for i, scope := range stored.Scopes {
+		scopes[i] = auth.Permission(scope)
+	}
+
+	return &auth.Principal{
+		UserID:    stored.UserID,
+		Roles:     stored.Roles,
+		APIKeyID:  stored.ID,
+		Scopes:    scopes,
+		RateLimit: stored.RateLimit,
+	}, nil
+}
+
+// touch records the use of the key, at most once per lastUsedInterval.
+func (a *Authenticator) touch(ctx context.Context, id string, now time.Time) {
+	a.mu.Lock()
+	state := a.state(id, now)
+	write := now.Sub(state.lastUsed) >= lastUsedInterval
+	if write {
+		state.lastUsed = now
+	}
+	a.mu.Unlock()
+
+	if write {
+		// Failing to record the use shouldn't fail the request.
+		a.store.TouchLastUsed(ctx, id, now)
+	}
+}
+
+// allow takes a request from the key's limiter, which is created or adjusted
+// to the key's rate limit first. If the limit is used up, it returns how long
+// to wait.
+func (a *Authenticator) allow(id string, rateLimit int, now time.Time) (bool, time.Duration) {
+	perSecond := rate.Limit(float64(rateLimit) / 60)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	state := a.state(id, now)
+	if state.limiter == nil {
+		state.limiter = rate.NewLimiter(perSecond, rateLimit)
+	} else if state.limiter.Limit() != perSecond || state.limiter.Burst() != rateLimit {
+		state.limiter.SetLimitAt(now, perSecond)
+		state.limiter.SetBurstAt(now, rateLimit)
+	}
+
+	if state.limiter.AllowN(now, 1) {
+		return true, 0
+	}
+	reservation := state.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	reservation.CancelAt(now)
+	return false, delay
+}
+
+// state returns the state of the key and forgets keys that were idle for
+// idleTimeout. a.mu must be held.
+func (a *Authenticator) state(id string, now time.Time) *keyState {
+	if now.Sub(a.lastSweep) >= idleTimeout {
+		for other, state := range a.keys {
+			if now.Sub(state.lastSeen) >= idleTimeout {
+				delete(a.keys, other)
+			}
+		}
+		a.lastSweep = now
+	}
+
+	state, ok := a.keys[id]
+	if !ok {
+		state = &keyState{}
+		a.keys[id] = state
+	}
+	state.lastSeen = now
+	return state
+}
+
+// RateLimit answers requests made with an API key with 429 once the key has
+// used up its rate limit. It has to run after the auth middleware.
+func (a *Authenticator) RateLimit() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal := auth.PrincipalFromContext(r.Context())
+			if principal == nil || principal.APIKeyID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if ok, delay := a.allow(principal.APIKeyID, principal.RateLimit, a.now()); !ok {
+				a.logger.Error("API key exceeded its rate limit", "request_id", middleware.GetReqID(r.Context()),
+					"api_key_id", principal.APIKeyID, "path", r.URL.Path)
+
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+				apperr.Write(w, r, ErrRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/internal/auth"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// store holds a single key.
+type store struct {
+	prefix  string
+	key     Key
+	touches int
+}
+
+func (s *store) FindActive(ctx context.Context, prefix string, now time.Time) (*Key, error) {
+	if prefix != s.prefix {
+		return nil, sql.ErrNoRows
+	}
+	key := s.key
+	return &key, nil
+}
+
+func (s *store) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	s.touches++
+	return nil
+}
+
+// newAuthenticator returns an authenticator for a new key with the rate
+// limit and a clock that is advanced through the returned pointer.
+func newAuthenticator(t *testing.T, rateLimit int) (*Authenticator, *store, string, *time.Time) {
+	t.Helper()
+	key, prefix, hash, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &store{prefix: prefix, key: Key{
+		ID:        "key-1",
+		UserID:    "user-1",
+		Hash:      hash,
+		Scopes:    []string{string(auth.PermStockRead)},
+		RateLimit: rateLimit,
+		Roles:     []string{string(auth.RoleWarehouseStaff)},
+	}}
+
+	a := NewAuthenticator(s, slog.New(slog.DiscardHandler))
+	now := time.Unix(0, 0)
+	a.now = func() time.Time { return now }
+	return a, s, key, &now
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	a, s, key, _ := newAuthenticator(t, 60)
+	other, _, _, err := Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The prefix of the key with the secret of another one.
+	wrong := key[:len(key)-4] + other[len(other)-4:]
+
+	tests := []struct {
+		name    string
+		key     string
+		wantErr bool
+	}{
+		{name: "valid key", key: key},
+		{name: "wrong secret", key: wrong, wantErr: true},
+		{name: "unknown prefix", key: other, wantErr: true},
+		{name: "malformed", key: "not-a-key", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := a.AuthenticateAPIKey(context.Background(), tt.key)
+			if tt.wantErr {
+				if !errors.Is(err, auth.ErrInvalidToken) {
+					t.Errorf("error %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("AuthenticateAPIKey: %v", err)
+			}
+			if principal.UserID != "user-1" || principal.APIKeyID != "key-1" || principal.RateLimit != 60 {
+				t.Errorf("principal %+v", principal)
+			}
+		})
+	}
+
+	if s.touches != 1 {
+		t.Errorf("last use written %d times, want once per interval", s.touches)
+	}
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	a, _, key, _ := newAuthenticator(t, 60)
+	principal, err := a.AuthenticateAPIKey(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		permission auth.Permission
+		want       bool
+	}{
+		// In the scopes and granted by the owner's role.
+		{permission: auth.PermStockRead, want: true},
+		// Granted by the owner's role, but not in the scopes.
+		{permission: auth.PermStockAdjust, want: false},
+		// Neither.
+		{permission: auth.PermRolesManage, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.permission), func(t *testing.T) {
+			if got := principal.Can(tt.permission); got != tt.want {
+				t.Errorf("Can(%s) = %v, want %v", tt.permission, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	a, _, key, now := newAuthenticator(t, 2)
+	principal, err := a.AuthenticateAPIKey(context.Background(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler := a.RateLimit()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(principal *auth.Principal) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/products", nil)
+		r = r.WithContext(auth.WithPrincipal(r.Context(), principal))
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for i := range 2 {
+		if w := serve(principal); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200 within the burst", i, w.Code)
+		}
+	}
+	w := serve(principal)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429 after the burst", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Errorf("Retry-After %q, want 30", got)
+	}
+
+	*now = now.Add(30 * time.Second)
+	if w := serve(principal); w.Code != http.StatusOK {
+		t.Errorf("status %d, want 200 after waiting", w.Code)
+	}
+
+	// A key the authenticator hasn't seen gets a limiter as well.
+	unseen := &auth.Principal{UserID: "user-2", APIKeyID: "key-2", RateLimit: 1}
+	serve(unseen)
+	if w := serve(unseen); w.Code != http.StatusTooManyRequests {
+		t.Errorf("unseen key: status %d, want 429", w.Code)
+	}
+
+	// Tokens are not limited.
+	token := &auth.Principal{UserID: "user-1"}
+	for range 3 {
+		if w := serve(token); w.Code != http.StatusOK {
+			t.Errorf("token: status %d, want 200", w.Code)
+		}
+	}
+}
+
+func TestIdleKeysAreForgotten(t *testing.T) {
+	a, _, _, now := newAuthenticator(t, 60)
+
+	a.allow("idle", 60, *now)
+	*now = now.Add(idleTimeout / 2)
+	a.allow("active", 60, *now)
+
+	*now = now.Add(idleTimeout / 2)
+	a.allow("active", 60, *now)
+
+	if _, ok := a.keys["idle"]; ok {
+		t.Error("idle key is still kept")
+	}
+	if _, ok := a.keys["active"]; !ok {
+		t.Error("active key was forgotten")
+	}
+}
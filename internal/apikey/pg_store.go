@@ -0,0 +1,79 @@
+package apikey
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/lib/pq"
+)
+
+// Key is what is needed to verify a stored key.
+type Key struct {
+	ID        string
+	UserID    string
+	Hash      string
+	Scopes    []string
+	RateLimit int
+	// Roles are the current roles of the key's owner.
+	Roles []string
+}
+
+type Store interface {
+	// FindActive returns the key with the prefix if it is neither revoked nor
+	// expired.
+	FindActive(ctx context.Context, prefix string, now time.Time) (*Key, error)
+	TouchLastUsed(ctx context.Context, id string, at time.Time) error
+}
+
+type PgStore struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func (ps *PgStore) FindActive(ctx context.Context, prefix string, now time.Time) (*Key, error) {
+	repoLogger := ps.logger.With("request_id", middleware.GetReqID(ctx))
+
+	query := `SELECT k.id, k.user_id, k.key_hash, k.scopes, k.rate_limit,
+			ARRAY(SELECT role FROM user_roles r WHERE r.user_id = k.user_id ORDER BY role)
+		FROM api_keys k
+		WHERE k.prefix = $1 AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > $2)`
+
+	var key Key
+	err := ps.db.QueryRowContext(ctx, query, prefix, now).Scan(&key.ID, &key.UserID, &key.Hash,
+		pq.Array(&key.Scopes), &key.RateLimit, pq.Array(&key.Roles))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Error("Error finding API key", "prefix", prefix, "error", err)
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (ps *PgStore) TouchLastUsed(ctx context.Context, id string, at time.Time) error {
+	repoLogger := ps.logger.With("request_id", middleware.GetReqID(ctx))
+
+	_, err := ps.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = $2 WHERE id = $1`, id, at)
+	if err != nil {
+		repoLogger.Error("Could not update last use of API key", "api_key_id", id, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func NewPgStore(db *sql.DB, logger *slog.Logger) (*PgStore, error) {
+	if err := db.Ping(); err != nil {
+		return nil, errors.New("failed to connect to the database: " + err.Error())
+	}
+
+	return &PgStore{
+		db:     db,
+		logger: logger.With("file", "pg_store.go"),
+	}, nil
+}
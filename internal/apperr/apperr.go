@@ -0,0 +1,227 @@
+// Package apperr defines the domain errors of the services and how they are
+// reported to clients: as RFC 7807 problem+json bodies over HTTP and as
+// status codes over gRPC.
+//
+// Services declare their errors with New, e.g.
+//
+//	var ErrOrderNotShippable = apperr.New(apperr.Conflict, "order_not_shippable", "Order is not in a state that allows shipping")
+//
+// and return them as they are or with a cause attached by Wrap. The code is
+// part of the API and must not change once clients may rely on it. Errors
+// match their declaration and their kind with errors.Is:
+//
+//	errors.Is(err, ErrOrderNotShippable)
+//	errors.Is(err, apperr.Conflict)
+package apperr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"maps"
+	"net"
+	"net/http"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Kind is the class of an error. It decides the HTTP status and gRPC code
+// the error is reported with.
+type Kind string
+
+const (
+	Validation        Kind = "validation"
+	Unauthenticated   Kind = "unauthenticated"
+	PermissionDenied  Kind = "permission_denied"
+	NotFound          Kind = "not_found"
+	Conflict          Kind = "conflict"
+	InsufficientStock Kind = "insufficient_stock"
+	RateLimited       Kind = "rate_limited"
+	// Upstream means a service this one depends on failed or couldn't be
+	// reached. The call may succeed when retried.
+	Upstream Kind = "upstream"
+	Internal Kind = "internal"
+)
+
+func (k Kind) Error() string {
+	return string(k)
+}
+
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case Validation:
+		return http.StatusBadRequest
+	case Unauthenticated:
+		return http.StatusUnauthorized
+	case PermissionDenied:
+		return http.StatusForbidden
+	case NotFound:
+		return http.StatusNotFound
+	case Conflict, InsufficientStock:
+		return http.StatusConflict
+	case RateLimited:
+		return http.StatusTooManyRequests
+	case Upstream:
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+func (k Kind) GRPCCode() codes.Code {
+	switch k {
+	case Validation:
+		return codes.InvalidArgument
+	case Unauthenticated:
+		return codes.Unauthenticated
+	case PermissionDenied:
+		return codes.PermissionDenied
+	case NotFound:
+		return codes.NotFound
+	case Conflict, InsufficientStock:
+		return codes.FailedPrecondition
+	case RateLimited:
+		return codes.ResourceExhausted
+	case Upstream:
+		return codes.Unavailable
+	}
+	return codes.Internal
+}
+
+// FieldError describes why the value of a single field is invalid. Field is
+// the path of the field in the request, e.g. "items[0].quantity", or empty
+// for the request as a whole.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Violation describes a precondition a request failed, e.g. Type "STOCK"
+// and Subject "products/<id>" for a product without enough stock.
+type Violation struct {
+	Type        string
+	Subject     string
+	Description string
+}
+
+// Error is a domain error with a stable, machine-readable code.
+type Error struct {
+	Kind Kind
+	// Code identifies the error for clients, e.g. "order_not_shippable".
+	Code string
+	// Message explains the error to humans. It is sent to clients, the cause
+	// is not.
+	Message string
+	Fields  []FieldError
+	// Violations are the failed preconditions of Conflict and
+	// InsufficientStock errors.
+	Violations []Violation
+	// Metadata names what the error is about, e.g. "product_ids". Over gRPC
+	// it is sent as the metadata of the ErrorInfo detail.
+	Metadata map[string]string
+	Err      error
+}
+
+// Generic errors for failures no service declared an error for.
+var (
+	// ErrInvalidRequest rejects malformed requests. Its fields name what is
+	// invalid, if known.
+	ErrInvalidRequest = New(Validation, "invalid_request", "Invalid request")
+	ErrNotFound       = New(NotFound, "not_found", "Not found")
+	ErrUnavailable    = New(Upstream, "upstream_unavailable", "A service the request depends on is unavailable")
+	ErrTimeout        = New(Upstream, "timeout", "A service the request depends on did not answer in time")
+	ErrInternal       = New(Internal, "internal", "Internal error")
+)
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether e has the kind, or the code of the error, target.
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case Kind:
+		return e.Kind == t
+	case *Error:
+		return e.Code == t.Code
+	}
+	return false
+}
+
+// Wrap returns a copy of e caused by err.
+func (e *Error) Wrap(err error) *Error {
+	c := *e
+	c.Err = err
+	return &c
+}
+
+// WithMessage returns a copy of e with a more specific message.
+func (e *Error) WithMessage(message string) *Error {
+	c := *e
+	c.Message = message
+	return &c
+}
+
+// WithFields returns a copy of e with the invalid fields.
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	c := *e
+	c.Fields = append(c.Fields[:len(c.Fields):len(c.Fields)], fields...)
+	return &c
+}
+
+// WithViolations returns a copy of e with the failed preconditions.
+func (e *Error) WithViolations(violations ...Violation) *Error {
+	c := *e
+	c.Violations = append(c.Violations[:len(c.Violations):len(c.Violations)], violations...)
+	return &c
+}
+
+// WithMetadata returns a copy of e with the metadata key set to value.
+func (e *Error) WithMetadata(key, value string) *Error {
+	c := *e
+	c.Metadata = maps.Clone(c.Metadata)
+	if c.Metadata == nil {
+		c.Metadata = make(map[string]string)
+	}
+	c.Metadata[key] = value
+	return &c
+}
+
+// From returns err as an *Error. Errors no service declared are classified
+// by their cause: missing rows are not found, deadlines are timeouts, lost
+// database or network connections are unavailable, gRPC errors of other
+// services keep their class and everything else is internal.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrNotFound.Wrap(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout.Wrap(err)
+	case errors.Is(err, driver.ErrBadConn), errors.Is(err, sql.ErrConnDone):
+		return ErrUnavailable.Wrap(err)
+	}
+	if e := fromStatus(err); e != nil {
+		return e
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrUnavailable.Wrap(err)
+	}
+	return ErrInternal.Wrap(err)
+}
@@ -0,0 +1,123 @@
+package apperr
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/middleware"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errOrderNotShippable = New(Conflict, "order_not_shippable", "Order is not in a state that allows shipping")
+
+func TestFrom(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantKind Kind
+		wantCode string
+	}{
+		{name: "declared error", err: fmt.Errorf("ship: %w", errOrderNotShippable), wantKind: Conflict, wantCode: "order_not_shippable"},
+		{name: "missing row", err: fmt.Errorf("find order: %w", sql.ErrNoRows), wantKind: NotFound, wantCode: "not_found"},
+		{name: "deadline", err: context.DeadlineExceeded, wantKind: Upstream, wantCode: "timeout"},
+		{name: "lost connection", err: driver.ErrBadConn, wantKind: Upstream, wantCode: "upstream_unavailable"},
+		{name: "unavailable service", err: status.Error(codes.Unavailable, "connection refused"), wantKind: Upstream, wantCode: "upstream_unavailable"},
+		{name: "denied call", err: status.Error(codes.PermissionDenied, "denied"), wantKind: Internal, wantCode: "internal"},
+		{name: "anything else", err: errors.New("boom"), wantKind: Internal, wantCode: "internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := From(tt.err)
+			if e.Kind != tt.wantKind || e.Code != tt.wantCode {
+				t.Errorf("From = %s/%s, want %s/%s", e.Kind, e.Code, tt.wantKind, tt.wantCode)
+			}
+			if !errors.Is(e, tt.wantKind) {
+				t.Errorf("errors.Is(From, %s) = false", tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestCopies(t *testing.T) {
+	wrapped := errOrderNotShippable.Wrap(sql.ErrNoRows).WithMetadata("order_id", "1").WithFields(FieldError{Field: "items", Message: "must not be empty"})
+
+	if !errors.Is(wrapped, errOrderNotShippable) || !errors.Is(wrapped, sql.ErrNoRows) {
+		t.Errorf("%v doesn't match its code and cause", wrapped)
+	}
+	if errOrderNotShippable.Err != nil || errOrderNotShippable.Metadata != nil || errOrderNotShippable.Fields != nil {
+		t.Errorf("declared error was modified: %+v", errOrderNotShippable)
+	}
+	if other := wrapped.WithMetadata("order_id", "2"); wrapped.Metadata["order_id"] != "1" || other.Metadata["order_id"] != "2" {
+		t.Errorf("metadata %v and %v, want copies", wrapped.Metadata, other.Metadata)
+	}
+}
+
+// Errors sent by one service over gRPC arrive with code, fields, violations
+// and metadata in the other.
+func TestGRPCRoundTrip(t *testing.T) {
+	sent := New(InsufficientStock, "insufficient_stock", "Not enough stock").
+		WithMetadata("product_ids", "p-1").
+		WithFields(FieldError{Field: "quantity", Message: "must be positive"}).
+		WithViolations(Violation{Type: "STOCK", Subject: "products/p-1", Description: "2 available"})
+
+	err := GRPCError(sent)
+	if code := status.Code(err); code != codes.FailedPrecondition {
+		t.Fatalf("code %s, want %s", code, codes.FailedPrecondition)
+	}
+
+	// What the client gets is the status, not the error.
+	got := From(status.Convert(err).Err())
+	if got.Kind != Conflict || got.Code != "insufficient_stock" || got.Message != "Not enough stock" {
+		t.Errorf("received %s/%s %q", got.Kind, got.Code, got.Message)
+	}
+	if got.Metadata["product_ids"] != "p-1" {
+		t.Errorf("metadata %v", got.Metadata)
+	}
+	if len(got.Fields) != 1 || got.Fields[0] != sent.Fields[0] {
+		t.Errorf("fields %v, want %v", got.Fields, sent.Fields)
+	}
+	if len(got.Violations) != 1 || got.Violations[0] != sent.Violations[0] {
+		t.Errorf("violations %v, want %v", got.Violations, sent.Violations)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/orders/1/shipments", nil)
+	r = r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, "req-1"))
+	w := httptest.NewRecorder()
+
+	Write(w, r, errOrderNotShippable.Wrap(errors.New("order is PENDING")))
+
+	if w.Code != http.StatusConflict {
+		t.Errorf("status %d, want 409", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("Content-Type %q, want %q", ct, ContentType)
+	}
+	var problem Problem
+	if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+		t.Fatal(err)
+	}
+	want := Problem{
+		Type:      "about:blank",
+		Title:     "Conflict",
+		Status:    http.StatusConflict,
+		Detail:    errOrderNotShippable.Message,
+		Instance:  "/orders/1/shipments",
+		Code:      "order_not_shippable",
+		RequestID: "req-1",
+	}
+	if problem.Type != want.Type || problem.Title != want.Title || problem.Status != want.Status || problem.Detail != want.Detail ||
+		problem.Instance != want.Instance || problem.Code != want.Code || problem.RequestID != want.RequestID {
+		t.Errorf("problem %+v, want %+v", problem, want)
+	}
+}
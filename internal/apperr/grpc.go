@@ -0,0 +1,118 @@
+package apperr
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Domain is the ErrorInfo domain of the errors of the services. Details of
+// other domains are not decoded.
+const Domain = "ecommerce-platform"
+
+// GRPCStatus lets the gRPC server report e with the code of its kind. The
+// code of e is sent as the reason of an ErrorInfo detail, fields as a
+// BadRequest and violations as a PreconditionFailure detail.
+func (e *Error) GRPCStatus() *status.Status {
+	s := status.New(e.Kind.GRPCCode(), e.Message)
+
+	details := []protoadapt.MessageV1{
+		&errdetails.ErrorInfo{Reason: e.Code, Domain: Domain, Metadata: e.Metadata},
+	}
+	if len(e.Fields) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, f := range e.Fields {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+			})
+		}
+		details = append(details, badRequest)
+	}
+	if len(e.Violations) > 0 {
+		failure := &errdetails.PreconditionFailure{}
+		for _, v := range e.Violations {
+			failure.Violations = append(failure.Violations, &errdetails.PreconditionFailure_Violation{
+				Type:        v.Type,
+				Subject:     v.Subject,
+				Description: v.Description,
+			})
+		}
+		details = append(details, failure)
+	}
+
+	withDetails, err := s.WithDetails(details...)
+	if err != nil {
+		return s
+	}
+	return withDetails
+}
+
+// GRPCError returns err as it should be returned by a gRPC handler: status
+// errors stay as they are, everything else is converted by From.
+func GRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return From(err)
+}
+
+// fromStatus converts the gRPC error of another service, or returns nil if
+// err is no gRPC error. Failures of the other service are upstream errors
+// of this one; errors about the request keep their class, and their code
+// and details if the other service sent them.
+func fromStatus(err error) *Error {
+	s, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+
+	var e *Error
+	switch s.Code() {
+	case codes.InvalidArgument, codes.OutOfRange:
+		e = New(Validation, "invalid_request", "Invalid request")
+	case codes.NotFound:
+		e = ErrNotFound
+	case codes.AlreadyExists, codes.Aborted, codes.FailedPrecondition:
+		e = New(Conflict, "conflict", "Conflict")
+	case codes.Unavailable, codes.ResourceExhausted:
+		e = ErrUnavailable
+	case codes.DeadlineExceeded:
+		e = ErrTimeout
+	default:
+		// Denied calls between services are a misconfiguration, not
+		// something the client can fix.
+		return ErrInternal.Wrap(err)
+	}
+
+	// A copy, so the code can be set.
+	e = e.Wrap(err)
+	if s.Message() != "" {
+		e.Message = s.Message()
+	}
+	for _, detail := range s.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			if d.GetDomain() != Domain {
+				continue
+			}
+			e.Code = d.GetReason()
+			for key, value := range d.GetMetadata() {
+				e = e.WithMetadata(key, value)
+			}
+		case *errdetails.BadRequest:
+			for _, v := range d.GetFieldViolations() {
+				e = e.WithFields(FieldError{Field: v.GetField(), Message: v.GetDescription()})
+			}
+		case *errdetails.PreconditionFailure:
+			for _, v := range d.GetViolations() {
+				e = e.WithViolations(Violation{Type: v.GetType(), Subject: v.GetSubject(), Description: v.GetDescription()})
+			}
+		}
+	}
+	return e
+}
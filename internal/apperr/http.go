@@ -0,0 +1,48 @@
+package apperr
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// ContentType is the media type of problem details.
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details body with the error code and the
+// request ID as extension members.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// Write responds to r with the problem details of err. Headers like
+// WWW-Authenticate or Retry-After have to be set before.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	e := From(err)
+	status := e.Kind.HTTPStatus()
+
+	problem := Problem{
+		// The code tells problems apart, so the type carries no meaning.
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  r.URL.Path,
+		Code:      e.Code,
+		RequestID: middleware.GetReqID(r.Context()),
+		Errors:    e.Fields,
+	}
+
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(problem)
+}
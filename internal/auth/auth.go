@@ -0,0 +1,58 @@
+// Package auth verifies the JWTs issued by the user service and makes the
+// authenticated caller available to handlers.
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// Issuer is the "iss" claim of every token issued by the user service.
+	Issuer = "ecommerce-platform/user-service"
+
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+var (
+	ErrMissingToken = errors.New("request has no bearer token")
+	ErrInvalidToken = errors.New("token is invalid")
+)
+
+// Claims are the claims of access and refresh tokens. Type tells them apart,
+// so a refresh token can't be used as access token.
+type Claims struct {
+	Email string   `json:"email,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+	Type  string   `json:"typ"`
+	jwt.RegisteredClaims
+}
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID string
+	Email  string
+	Roles  []string
+
+	// APIKeyID is set when the caller authenticated with an API key of the
+	// user. Scopes then limits the permissions to the ones the key was
+	// created for, and RateLimit is the key's requests per minute.
+	APIKeyID  string
+	Scopes    []Permission
+	RateLimit int
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the caller, or nil if the request was not authenticated.
+func PrincipalFromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/go-chi/chi/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	authorizationMetadata = "authorization"
+	apiKeyMetadata        = "x-api-key"
+)
+
+// UnaryClientInterceptor forwards the bearer token or API key of the incoming
+// HTTP request, so the called service authorizes the call as the same user.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if token := tokenFromContext(ctx); token != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, authorizationMetadata, "Bearer "+token)
+		} else if key := apiKeyFromContext(ctx); key != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, apiKeyMetadata, key)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryServerInterceptor authenticates calls and checks the permission
+// required for the method, keyed by full method name such as
+// "/inventory.InventoryService/AdjustStock". Methods without an entry are
+// denied, methods with Public need no credentials.
+func UnaryServerInterceptor(verifier *Verifier, permissions map[string]Permission, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	logger = logger.With("file", "grpc.go")
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authorizeCall(ctx, info.FullMethod, verifier, permissions, logger)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streams. Streams are
+// authorized once, when they are opened.
+func StreamServerInterceptor(verifier *Verifier, permissions map[string]Permission, logger *slog.Logger) grpc.StreamServerInterceptor {
+	logger = logger.With("file", "grpc.go")
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorizeCall(ss.Context(), info.FullMethod, verifier, permissions, logger)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// authorizeCall returns ctx with the principal of the call, or the status error
+// the call is rejected with.
+func authorizeCall(ctx context.Context, method string, verifier *Verifier, permissions map[string]Permission, logger *slog.Logger) (context.Context, error) {
+	permission, ok := permissions[method]
+	if !ok {
+		logger.With("request_id", middleware.GetReqID(ctx)).Error("No permission configured for method, denying", "method", method)
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+	if permission == Public {
+		return ctx, nil
+	}
+
+	var tokenString, apiKey string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(authorizationMetadata); len(values) > 0 {
+			scheme, token, found := strings.Cut(values[0], " ")
+			if found && strings.EqualFold(scheme, "Bearer") {
+				tokenString = strings.TrimSpace(token)
+			}
+		}
+		if values := md.Get(apiKeyMetadata); len(values) > 0 {
+			apiKey = values[0]
+		}
+	}
+	if tokenString == "" && apiKey == "" {
+		return nil, status.Error(codes.Unauthenticated, "missing credentials")
+	}
+
+	var principal *Principal
+	var err error
+	if tokenString != "" {
+		principal, err = verifier.Verify(ctx, tokenString)
+		ctx = context.WithValue(ctx, tokenKey{}, tokenString)
+	} else {
+		principal, err = verifier.VerifyAPIKey(ctx, apiKey)
+		ctx = context.WithValue(ctx, apiKeyKey{}, apiKey)
+	}
+	if err != nil {
+		logger.With("request_id", middleware.GetReqID(ctx)).Error("Invalid credentials", "method", method, "api_key", tokenString == "", "error", err)
+		return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+	}
+
+	if !principal.Can(permission) {
+		logger.With("request_id", middleware.GetReqID(ctx)).Error("Permission denied", "method", method, "user_id", principal.UserID, "permission", permission)
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	return WithPrincipal(ctx, principal), nil
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
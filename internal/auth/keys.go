@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"ecommerce-platform/internal/config"
+
+	"golang.org/x/sync/singleflight"
+)
+
+var ErrUnknownKey = errors.New("no key with the given key ID")
+
+// JWK is the public part of an RSA signing key as JSON Web Key (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK returns the JWK of an RSA public key.
+func NewJWK(kid string, key *rsa.PublicKey) JWK {
+	return JWK{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func (k JWK) PublicKey() (*rsa.PublicKey, error) {
+	if k.Kty != "RSA" {
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+}
+
+// KeySource looks up the public key a token was signed with.
+type KeySource interface {
+	Key(ctx context.Context, kid string) (*rsa.PublicKey, error)
+}
+
+// StaticKeys is a fixed set of keys, e.g. read from a file.
+type StaticKeys map[string]*rsa.PublicKey
+
+func (s StaticKeys) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	if key, ok := s[kid]; ok {
+		return key, nil
+	}
+	// A single key without ID accepts every token, which is convenient for a
+	// PEM file holding just the public key.
+	if key, ok := s[""]; ok && len(s) == 1 {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// LoadKeyFile reads either a JWKS document or a PEM encoded RSA public key.
+func LoadKeyFile(path string) (StaticKeys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "-----BEGIN") {
+		block, _ := pem.Decode(data)
+		if block == nil {
+			return nil, fmt.Errorf("no PEM data in %s", path)
+		}
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := parsed.(*rsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("key in %s is not an RSA key", path)
+		}
+		return StaticKeys{"": key}, nil
+	}
+
+	var set JWKS
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	return keysFromJWKS(set)
+}
+
+func keysFromJWKS(set JWKS) (StaticKeys, error) {
+	keys := make(StaticKeys)
+	for _, jwk := range set.Keys {
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+// RemoteKeys fetches the keys from a JWKS URL. The keys are cached and fetched
+// again when they are older than the TTL or a token names an unknown key ID,
+// which happens after the user service rotated its key. Known keys are
+// served from the cache while a refresh is running.
+type RemoteKeys struct {
+	url    string
+	client *http.Client
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	keys      StaticKeys
+	fetchedAt time.Time
+	// attemptedAt and lastErr are the time and error of the last fetch,
+	// successful or not.
+	attemptedAt time.Time
+	lastErr     error
+
+	flights singleflight.Group
+}
+
+// minRefetchInterval limits how often tokens with unknown key IDs can make us
+// fetch the JWKS, also while the user service is failing.
+const minRefetchInterval = 30 * time.Second
+
+func NewRemoteKeys(url string) *RemoteKeys {
+	return &RemoteKeys{
+		url:    url,
+		client: &http.Client{Timeout: 5 * time.Second},
+		ttl:    5 * time.Minute,
+		now:    time.Now,
+	}
+}
+
+func (rk *RemoteKeys) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	rk.mu.Lock()
+	key, ok := rk.keys[kid]
+	fresh := ok && rk.now().Sub(rk.fetchedAt) < rk.ttl
+	mayFetch := rk.attemptedAt.IsZero() || rk.now().Sub(rk.attemptedAt) >= minRefetchInterval
+	lastErr := rk.lastErr
+	rk.mu.Unlock()
+
+	if fresh {
+		return key, nil
+	}
+	if !mayFetch {
+		if ok {
+			return key, nil
+		}
+		if lastErr != nil {
+			return nil, lastErr
+		}
+		return nil, ErrUnknownKey
+	}
+
+	// The fetch is shared by all callers and outlives the one that started it.
+	results := rk.flights.DoChan("jwks", func() (any, error) {
+		return nil, rk.refresh(context.WithoutCancel(ctx))
+	})
+	if ok {
+		// Keep using the stale key, also if the user service is unavailable.
+		return key, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-results:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+	}
+
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+	if key, ok := rk.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, ErrUnknownKey
+}
+
+// refresh fetches the keys and records the attempt.
+func (rk *RemoteKeys) refresh(ctx context.Context) error {
+	keys, err := rk.fetch(ctx)
+
+	rk.mu.Lock()
+	defer rk.mu.Unlock()
+
+	rk.attemptedAt = rk.now()
+	rk.lastErr = err
+	if err == nil {
+		rk.keys = keys
+		rk.fetchedAt = rk.attemptedAt
+	}
+	return err
+}
+
+func (rk *RemoteKeys) fetch(ctx context.Context) (StaticKeys, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rk.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := rk.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	return keysFromJWKS(set)
+}
+
+// NewKeySource returns the key source for the JWKS URL or, for setups
+// without the user service, the key file.
+func NewKeySource(cfg config.Auth) (KeySource, error) {
+	if cfg.JWKSURL != "" {
+		return NewRemoteKeys(cfg.JWKSURL), nil
+	}
+	if cfg.KeyFile != "" {
+		return LoadKeyFile(cfg.KeyFile)
+	}
+	return nil, errors.New("neither a JWKS URL nor a key file is configured")
+}
@@ -0,0 +1,139 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer serves the JWKS with key ID "k1" and counts the fetches.
+type jwksServer struct {
+	*httptest.Server
+	fetches atomic.Int32
+	failing atomic.Bool
+	key     *rsa.PrivateKey
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &jwksServer{key: key}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.fetches.Add(1)
+		if s.failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(JWKS{Keys: []JWK{NewJWK("k1", &key.PublicKey)}})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func TestRemoteKeys(t *testing.T) {
+	// step looks up a key ID after advancing the clock.
+	type step struct {
+		after       time.Duration
+		kid         string
+		failing     bool
+		wantErr     bool
+		wantFetches int32
+	}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "keys are cached",
+			steps: []step{
+				{kid: "k1", wantFetches: 1},
+				{after: time.Minute, kid: "k1", wantFetches: 1},
+			},
+		},
+		{
+			name: "keys are fetched again after the TTL",
+			steps: []step{
+				{kid: "k1", wantFetches: 1},
+				{after: 5 * time.Minute, kid: "k1", wantFetches: 2},
+			},
+		},
+		{
+			name: "unknown key IDs don't fetch more often than the minimum interval",
+			steps: []step{
+				{kid: "k1", wantFetches: 1},
+				{kid: "other", wantErr: true, wantFetches: 1},
+				{after: minRefetchInterval, kid: "other", wantErr: true, wantFetches: 2},
+				{after: time.Second, kid: "other", wantErr: true, wantFetches: 2},
+			},
+		},
+		{
+			name: "failed fetches are not repeated before the minimum interval",
+			steps: []step{
+				{kid: "k1", failing: true, wantErr: true, wantFetches: 1},
+				{after: time.Second, kid: "k1", failing: true, wantErr: true, wantFetches: 1},
+				{after: minRefetchInterval, kid: "k1", wantFetches: 2},
+			},
+		},
+		{
+			name: "stale keys are used while the user service fails",
+			steps: []step{
+				{kid: "k1", wantFetches: 1},
+				{after: 5 * time.Minute, kid: "k1", failing: true, wantFetches: 2},
+				{after: time.Second, kid: "k1", failing: true, wantFetches: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newJWKSServer(t)
+			keys := NewRemoteKeys(server.URL)
+			now := time.Unix(0, 0)
+			keys.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.after)
+				server.failing.Store(s.failing)
+
+				key, err := keys.Key(context.Background(), s.kid)
+				if (err != nil) != s.wantErr {
+					t.Fatalf("step %d: error %v, want error %v", i, err, s.wantErr)
+				}
+				if err == nil && !key.Equal(&server.key.PublicKey) {
+					t.Errorf("step %d: wrong key", i)
+				}
+				// A refresh in the background for a stale key may still run.
+				keys.flights.Do("jwks", func() (any, error) { return nil, nil })
+				if fetches := server.fetches.Load(); fetches != s.wantFetches {
+					t.Errorf("step %d: %d fetches, want %d", i, fetches, s.wantFetches)
+				}
+			}
+		})
+	}
+}
+
+func TestStaticKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := (StaticKeys{"": &key.PublicKey}).Key(context.Background(), "any"); err != nil || got != &key.PublicKey {
+		t.Errorf("single key without ID: %v, %v", got, err)
+	}
+	if _, err := (StaticKeys{"a": &key.PublicKey, "b": &key.PublicKey}).Key(context.Background(), "c"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown key ID: error %v, want ErrUnknownKey", err)
+	}
+}
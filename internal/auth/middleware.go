@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"ecommerce-platform/internal/apperr"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APIKeyHeader is the header API keys are sent in instead of a bearer token.
+const APIKeyHeader = "X-API-Key"
+
+// Errors of requests that are not let through.
+var (
+	ErrUnauthenticated  = apperr.New(apperr.Unauthenticated, "unauthenticated", "Missing or invalid access token or API key")
+	ErrPermissionDenied = apperr.New(apperr.PermissionDenied, "permission_denied", "Not allowed to call this endpoint")
+)
+
+// APIKeyAuthenticator resolves API keys to the principal of their owner.
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+// Verifier validates access tokens and, if enabled, API keys.
+type Verifier struct {
+	keys    KeySource
+	apiKeys APIKeyAuthenticator
+}
+
+func NewVerifier(keys KeySource) *Verifier {
+	return &Verifier{keys: keys}
+}
+
+// WithAPIKeys makes the verifier accept API keys besides access tokens.
+func (v *Verifier) WithAPIKeys(apiKeys APIKeyAuthenticator) *Verifier {
+	v.apiKeys = apiKeys
+	return v
+}
+
+// Verify checks signature, issuer, expiry and type of an access token and
+// returns its principal.
+func (v *Verifier) Verify(ctx context.Context, tokenString string) (*Principal, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(ctx, kid)
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithIssuer(Issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Type != TokenTypeAccess || claims.Subject == "" {
+		return nil, fmt.Errorf("%w: not an access token", ErrInvalidToken)
+	}
+
+	return &Principal{UserID: claims.Subject, Email: claims.Email, Roles: claims.Roles}, nil
+}
+
+// VerifyAPIKey returns the principal of an API key. It fails if the verifier
+// doesn't accept API keys.
+func (v *Verifier) VerifyAPIKey(ctx context.Context, key string) (*Principal, error) {
+	if v.apiKeys == nil {
+		return nil, fmt.Errorf("%w: API keys are not accepted", ErrInvalidToken)
+	}
+	return v.apiKeys.AuthenticateAPIKey(ctx, key)
+}
+
+// Middleware rejects requests without a valid bearer token or API key with
+// 401 and puts the principal of valid ones into the request context.
+func Middleware(verifier *Verifier, logger *slog.Logger) func(http.Handler) http.Handler {
+	return authenticate(verifier, logger, true)
+}
+
+// OptionalMiddleware is like Middleware but lets requests without
+// credentials through without principal, for routes that are also public.
+// Invalid credentials are still rejected.
+func OptionalMiddleware(verifier *Verifier, logger *slog.Logger) func(http.Handler) http.Handler {
+	return authenticate(verifier, logger, false)
+}
+
+func authenticate(verifier *Verifier, logger *slog.Logger, required bool) func(http.Handler) http.Handler {
+	logger = logger.With("file", "middleware.go")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			reqLogger := logger.With("request_id", middleware.GetReqID(r.Context()))
+
+			tokenString, hasToken := bearerToken(r)
+			apiKey := r.Header.Get(APIKeyHeader)
+			if !hasToken && apiKey == "" {
+				if !required {
+					next.ServeHTTP(w, r)
+					return
+				}
+				reqLogger.Error("Request without credentials", "path", r.URL.Path)
+				unauthorized(w, r)
+				return
+			}
+
+			ctx := r.Context()
+
+			var principal *Principal
+			var err error
+			if hasToken {
+				principal, err = verifier.Verify(ctx, tokenString)
+				ctx = context.WithValue(ctx, tokenKey{}, tokenString)
+			} else {
+				principal, err = verifier.VerifyAPIKey(ctx, apiKey)
+				ctx = context.WithValue(ctx, apiKeyKey{}, apiKey)
+			}
+			if err != nil {
+				reqLogger.Error("Invalid credentials", "path", r.URL.Path, "api_key", !hasToken, "error", err)
+				unauthorized(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithPrincipal(ctx, principal)))
+		})
+	}
+}
+
+// Require rejects requests whose principal lacks the permission.
+func Require(permission Permission, logger *slog.Logger) func(http.Handler) http.Handler {
+	logger = logger.With("file", "middleware.go")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !authorize(w, r, permission, logger) {
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// authorize writes 401 for anonymous and 403 for unauthorized callers and
+// reports whether the request may continue.
+func authorize(w http.ResponseWriter, r *http.Request, permission Permission, logger *slog.Logger) bool {
+	principal := PrincipalFromContext(r.Context())
+	if principal == nil {
+		unauthorized(w, r)
+		return false
+	}
+
+	if !principal.Can(permission) {
+		logger.Error("Permission denied", "request_id", middleware.GetReqID(r.Context()), "user_id", principal.UserID,
+			"permission", permission, "method", r.Method, "path", r.URL.Path)
+		apperr.Write(w, r, ErrPermissionDenied.WithMessage("Requires permission "+string(permission)))
+		return false
+	}
+
+	return true
+}
+
+type tokenKey struct{}
+
+type apiKeyKey struct{}
+
+// tokenFromContext returns the bearer token the request was authenticated
+// with, so it can be forwarded to other services.
+func tokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(tokenKey{}).(string)
+	return token
+}
+
+// apiKeyFromContext is like tokenFromContext for API keys.
+func apiKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(apiKeyKey{}).(string)
+	return key
+}
+
+// Transport wraps base so outgoing requests without credentials of their own
+// carry the bearer token or API key of the incoming request, like
+// UnaryClientInterceptor does for gRPC calls.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return forwardingTransport{base: base}
+}
+
+type forwardingTransport struct {
+	base http.RoundTripper
+}
+
+func (t forwardingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Header.Get("Authorization") != "" || r.Header.Get(APIKeyHeader) != "" {
+		return t.base.RoundTrip(r)
+	}
+
+	token, key := tokenFromContext(r.Context()), apiKeyFromContext(r.Context())
+	if token == "" && key == "" {
+		return t.base.RoundTrip(r)
+	}
+
+	// A RoundTripper must not modify the request.
+	r = r.Clone(r.Context())
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		r.Header.Set(APIKeyHeader, key)
+	}
+	return t.base.RoundTrip(r)
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="ecommerce-platform"`)
+	apperr.Write(w, r, ErrUnauthenticated)
+}
@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVerify(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier := NewVerifier(StaticKeys{"": &key.PublicKey})
+
+	valid := func() Claims {
+		return Claims{
+			Email: "user@example.com",
+			Roles: []string{string(RoleCustomer)},
+			Type:  TokenTypeAccess,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    Issuer,
+				Subject:   "user-1",
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+		}
+	}
+	rs256 := func(claims Claims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		token   func() string
+		wantErr bool
+	}{
+		{name: "valid access token", token: func() string { return rs256(valid()) }},
+		{
+			name: "unsigned",
+			token: func() string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodNone, valid()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantErr: true,
+		},
+		{
+			// HS256 with the public key as secret, in case the key were
+			// taken for an HMAC secret.
+			name: "HS256",
+			token: func() string {
+				token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, valid()).SignedString(key.PublicKey.N.Bytes())
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantErr: true,
+		},
+		{
+			name: "signed by another key",
+			token: func() string {
+				other, err := rsa.GenerateKey(rand.Reader, 2048)
+				if err != nil {
+					t.Fatal(err)
+				}
+				token, err := jwt.NewWithClaims(jwt.SigningMethodRS256, valid()).SignedString(other)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return token
+			},
+			wantErr: true,
+		},
+		{
+			name: "other issuer",
+			token: func() string {
+				claims := valid()
+				claims.Issuer = "someone-else"
+				return rs256(claims)
+			},
+			wantErr: true,
+		},
+		{
+			name: "expired",
+			token: func() string {
+				claims := valid()
+				claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+				return rs256(claims)
+			},
+			wantErr: true,
+		},
+		{
+			name: "without expiry",
+			token: func() string {
+				claims := valid()
+				claims.ExpiresAt = nil
+				return rs256(claims)
+			},
+			wantErr: true,
+		},
+		{
+			name: "refresh token",
+			token: func() string {
+				claims := valid()
+				claims.Type = TokenTypeRefresh
+				return rs256(claims)
+			},
+			wantErr: true,
+		},
+		{
+			name: "without subject",
+			token: func() string {
+				claims := valid()
+				claims.Subject = ""
+				return rs256(claims)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := verifier.Verify(context.Background(), tt.token())
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidToken) {
+					t.Errorf("error %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if principal.UserID != "user-1" || principal.Email != "user@example.com" || len(principal.Roles) != 1 {
+				t.Errorf("principal %+v", principal)
+			}
+		})
+	}
+}
+
+func TestTransport(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		// header is set on the outgoing request by the caller.
+		header     http.Header
+		wantAuth   string
+		wantAPIKey string
+	}{
+		{name: "no credentials", ctx: context.Background()},
+		{name: "token is forwarded", ctx: context.WithValue(context.Background(), tokenKey{}, "t0ken"), wantAuth: "Bearer t0ken"},
+		{name: "API key is forwarded", ctx: context.WithValue(context.Background(), apiKeyKey{}, "k3y"), wantAPIKey: "k3y"},
+		{
+			name:       "own credentials are kept",
+			ctx:        context.WithValue(context.Background(), tokenKey{}, "t0ken"),
+			header:     http.Header{http.CanonicalHeaderKey(APIKeyHeader): {"service-key"}},
+			wantAPIKey: "service-key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got http.Header
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r.Header
+			}))
+			defer server.Close()
+
+			req, err := http.NewRequestWithContext(tt.ctx, http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for key := range tt.header {
+				req.Header.Set(key, tt.header.Get(key))
+			}
+
+			res, err := (&http.Client{Transport: Transport(http.DefaultTransport)}).Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if auth := got.Get("Authorization"); auth != tt.wantAuth {
+				t.Errorf("Authorization %q, want %q", auth, tt.wantAuth)
+			}
+			if key := got.Get(APIKeyHeader); key != tt.wantAPIKey {
+				t.Errorf("%s %q, want %q", APIKeyHeader, key, tt.wantAPIKey)
+			}
+			if req.Header.Get("Authorization") != "" && tt.header == nil {
+				t.Error("Transport modified the caller's request")
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"ecommerce-platform/internal/apperr"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+// Rule grants access to a route to callers with the permission. Pattern is
+// the full chi route pattern, e.g. "/orders/{id}/returns".
+type Rule struct {
+	Method     string
+	Pattern    string
+	Permission Permission
+}
+
+// Policy authorizes requests by their route. Mutating requests (anything but
+// GET, HEAD and OPTIONS) to routes without a rule are denied, so a new
+// endpoint is closed until somebody decides who may call it. Reading routes
+// without a rule are left to the handlers.
+type Policy struct {
+	rules  map[string]Permission
+	logger *slog.Logger
+}
+
+func NewPolicy(logger *slog.Logger, rules ...Rule) *Policy {
+	p := &Policy{
+		rules:  make(map[string]Permission),
+		logger: logger.With("file", "policy.go"),
+	}
+	for _, rule := range rules {
+		p.rules[ruleKey(rule.Method, rule.Pattern)] = rule.Permission
+	}
+	return p
+}
+
+// Middleware enforces the policy for the routes of router. It has to run after
+// the authentication middleware.
+func (p *Policy) Middleware(router chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Routing has not happened yet, so look up the pattern the request
+			// is going to match. Unknown routes end in the router's 404.
+			rctx := chi.NewRouteContext()
+			if !router.Match(rctx, r.Method, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			pattern := rctx.RoutePattern()
+
+			permission, ok := p.rules[ruleKey(r.Method, pattern)]
+			if !ok {
+				if isSafeMethod(r.Method) {
+					next.ServeHTTP(w, r)
+					return
+				}
+				p.logger.Error("No policy rule for route, denying", "request_id", middleware.GetReqID(r.Context()),
+					"method", r.Method, "pattern", pattern)
+				apperr.Write(w, r, ErrPermissionDenied)
+				return
+			}
+
+			if !authorize(w, r, permission, p.logger) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ruleKey(method, pattern string) string {
+	if len(pattern) > 1 {
+		pattern = strings.TrimSuffix(pattern, "/")
+	}
+	return strings.ToUpper(method) + " " + pattern
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	return false
+}
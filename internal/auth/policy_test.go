@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+func TestPolicy(t *testing.T) {
+	policy := NewPolicy(slog.New(slog.DiscardHandler),
+		Rule{Method: http.MethodPost, Pattern: "/orders", Permission: PermOrdersCreate},
+		Rule{Method: http.MethodGet, Pattern: "/stock/{id}", Permission: PermStockRead},
+	)
+
+	r := chi.NewRouter()
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	r.Group(func(r chi.Router) {
+		r.Use(policy.Middleware(r))
+		r.Post("/orders", ok)
+		r.Get("/orders/{id}", ok)
+		r.Delete("/orders/{id}", ok)
+		r.Get("/stock/{id}", ok)
+	})
+
+	customer := &Principal{UserID: "user-1", Roles: []string{string(RoleCustomer)}}
+	superadmin := &Principal{UserID: "user-2", Roles: []string{string(RoleSuperadmin)}}
+
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		principal *Principal
+		want      int
+	}{
+		{name: "rule with the permission", method: http.MethodPost, path: "/orders", principal: customer, want: http.StatusOK},
+		{name: "rule without the permission", method: http.MethodGet, path: "/stock/1", principal: customer, want: http.StatusForbidden},
+		{name: "rule for anonymous caller", method: http.MethodPost, path: "/orders", want: http.StatusUnauthorized},
+		{name: "reading route without rule", method: http.MethodGet, path: "/orders/1", principal: customer, want: http.StatusOK},
+		{name: "mutating route without rule", method: http.MethodDelete, path: "/orders/1", principal: customer, want: http.StatusForbidden},
+		{name: "mutating route without rule for superadmin", method: http.MethodDelete, path: "/orders/1", principal: superadmin, want: http.StatusForbidden},
+		{name: "unknown route", method: http.MethodPost, path: "/unknown", principal: customer, want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.principal != nil {
+				req = req.WithContext(WithPrincipal(req.Context(), tt.principal))
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestCan(t *testing.T) {
+	tests := []struct {
+		name       string
+		principal  *Principal
+		permission Permission
+		want       bool
+	}{
+		{name: "granted by role", principal: &Principal{Roles: []string{string(RoleCustomer)}}, permission: PermOrdersCreate, want: true},
+		{name: "not granted by role", principal: &Principal{Roles: []string{string(RoleCustomer)}}, permission: PermStockAdjust},
+		{name: "granted by one of the roles", principal: &Principal{Roles: []string{string(RoleCustomer), string(RoleSupport)}}, permission: PermPaymentsRefund, want: true},
+		{name: "unknown role", principal: &Principal{Roles: []string{"admin"}}, permission: PermOrdersCreate},
+		{name: "no roles", principal: &Principal{}, permission: PermOrdersCreate},
+		{name: "superadmin", principal: &Principal{Roles: []string{string(RoleSuperadmin)}}, permission: PermRolesManage, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.principal.Can(tt.permission); got != tt.want {
+				t.Errorf("Can(%s) = %v, want %v", tt.permission, got, tt.want)
+			}
+		})
+	}
+}
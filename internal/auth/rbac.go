@@ -0,0 +1,119 @@
+package auth
+
+import "slices"
+
+type Role string
+
+const (
+	RoleCustomer       Role = "customer"
+	RoleCatalogAdmin   Role = "catalog-admin"
+	RoleWarehouseStaff Role = "warehouse-staff"
+	RoleSupport        Role = "support"
+	RoleSuperadmin     Role = "superadmin"
+)
+
+type Permission string
+
+const (
+	// Authenticated is granted to every caller with a valid token.
+	Authenticated Permission = "authenticated"
+	// Public needs no credentials at all, e.g. for health checks. Only gRPC
+	// methods use it, HTTP routes without authentication don't use the
+	// auth middleware.
+	Public Permission = "public"
+
+	PermProductsWrite     Permission = "products:write"
+	PermStockRead         Permission = "stock:read"
+	PermStockAdjust       Permission = "stock:adjust"
+	PermOrdersCreate      Permission = "orders:create"
+	PermOrdersReadAll     Permission = "orders:read-all"
+	PermShipmentsManage   Permission = "shipments:manage"
+	PermReturnsRequest    Permission = "returns:request"
+	PermReturnsDecide     Permission = "returns:decide"
+	PermReturnsInspect    Permission = "returns:inspect"
+	PermReturnsRefund     Permission = "returns:refund"
+	PermPaymentsReadAll   Permission = "payments:read-all"
+	PermPaymentsRefund    Permission = "payments:refund"
+	PermNotificationsRead Permission = "notifications:read"
+	PermRolesManage       Permission = "roles:manage"
+)
+
+// rolePermissions lists what every role may do. The superadmin may do
+// everything and is not listed.
+var rolePermissions = map[Role][]Permission{
+	RoleCustomer: {
+		PermOrdersCreate,
+		PermReturnsRequest,
+	},
+	RoleCatalogAdmin: {
+		PermProductsWrite,
+		PermStockRead,
+	},
+	RoleWarehouseStaff: {
+		PermStockRead,
+		PermStockAdjust,
+		PermOrdersReadAll,
+		PermShipmentsManage,
+		PermReturnsInspect,
+	},
+	RoleSupport: {
+		PermStockRead,
+		PermOrdersReadAll,
+		PermReturnsDecide,
+		PermReturnsRefund,
+		PermPaymentsReadAll,
+		PermPaymentsRefund,
+		PermNotificationsRead,
+	},
+}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	if Role(role) == RoleSuperadmin {
+		return true
+	}
+	_, ok := rolePermissions[Role(role)]
+	return ok
+}
+
+// ValidPermission reports whether permission is one of the known permissions.
+func ValidPermission(permission Permission) bool {
+	if permission == PermRolesManage {
+		return true
+	}
+	for _, permissions := range rolePermissions {
+		for _, p := range permissions {
+			if p == permission {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Can reports whether one of the principal's roles grants the permission. For
+// API keys the permission also has to be in the key's scopes.
+func (p *Principal) Can(permission Permission) bool {
+	if p == nil {
+		return false
+	}
+	if permission == Authenticated {
+		return true
+	}
+	if p.APIKeyID != "" && !slices.Contains(p.Scopes, permission) {
+		return false
+	}
+
+	for _, role := range p.Roles {
+		if Role(role) == RoleSuperadmin {
+			return true
+		}
+		for _, granted := range rolePermissions[Role(role)] {
+			if granted == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,268 @@
+// Package config loads the typed configuration of a service.
+//
+// A configuration is a struct whose fields are tagged with where their value
+// comes from:
+//
+//	Port int `yaml:"port" env:"PORT" flag:"port" default:"8081" usage:"HTTP port"`
+//
+// Values are taken from, in increasing precedence, the default tag, the YAML
+// file named by the -config flag or CONFIG_FILE, the environment variable and
+// the command line flag. Fields tagged required:"true" must end up non-zero.
+// Secret fields can also be read from the file named by the environment
+// variable with a _FILE suffix, e.g. DB_PASSWORD_FILE. After loading, Load
+// calls the Validate method of every struct in the configuration that has one.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the YAML file if the -config flag is not given.
+const FileEnv = "CONFIG_FILE"
+
+// Validator is implemented by configuration structs that check their values
+// beyond the required tag.
+type Validator interface {
+	Validate() error
+}
+
+// field is a leaf of the configuration struct.
+type field struct {
+	value reflect.Value
+	path  string
+	env   string
+	flag  string
+	def   string
+	usage string
+	req   bool
+}
+
+// Load fills cfg, a pointer to a configuration struct, from its sources.
+// args are the command line arguments without the program name. The
+// arguments after the flags are returned, e.g. a subcommand.
+func Load(cfg any, args []string) ([]string, error) {
+	root := reflect.ValueOf(cfg)
+	if root.Kind() != reflect.Pointer || root.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("config: Load needs a pointer to a struct")
+	}
+
+	fields := collect(root.Elem(), "")
+
+	// Flags are parsed first to find the file, but applied last.
+	flagValues := make(map[string]string)
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv(FileEnv), "YAML configuration `file`")
+	for _, f := range fields {
+		if f.flag != "" {
+			fs.Var(&flagValue{name: f.flag, kind: f.value.Kind(), values: flagValues}, f.flag, f.usage)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+		if err := set(f.value, f.def); err != nil {
+			return nil, fmt.Errorf("config: default of %s: %w", f.path, err)
+		}
+	}
+
+	if *configFile != "" {
+		data, err := os.ReadFile(*configFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("config: parsing %s: %w", *configFile, err)
+		}
+	}
+
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		if f.value.Type() == secretType {
+			if path := os.Getenv(f.env + "_FILE"); path != "" {
+				data, err := os.ReadFile(path)
+				if err != nil {
+					return nil, fmt.Errorf("config: %s_FILE: %w", f.env, err)
+				}
+				f.value.SetString(strings.TrimRight(string(data), "\r\n"))
+			}
+		}
+		if value, ok := os.LookupEnv(f.env); ok {
+			if err := set(f.value, value); err != nil {
+				return nil, fmt.Errorf("config: %s: %w", f.env, err)
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if value, ok := flagValues[f.flag]; ok {
+			if err := set(f.value, value); err != nil {
+				return nil, fmt.Errorf("config: -%s: %w", f.flag, err)
+			}
+		}
+	}
+
+	return fs.Args(), validate(root.Elem(), fields)
+}
+
+// collect returns the leaves of the struct v, descending into nested structs.
+func collect(v reflect.Value, prefix string) []field {
+	var fields []field
+	for i := range v.NumField() {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(sf.Tag.Get("yaml"), ",")
+		if name == "" {
+			name = strings.ToLower(sf.Name)
+		}
+		path := prefix + name
+
+		if sf.Type.Kind() == reflect.Struct && sf.Type != secretType {
+			fields = append(fields, collect(v.Field(i), path+".")...)
+			continue
+		}
+
+		fields = append(fields, field{
+			value: v.Field(i),
+			path:  path,
+			env:   sf.Tag.Get("env"),
+			flag:  sf.Tag.Get("flag"),
+			def:   sf.Tag.Get("default"),
+			usage: sf.Tag.Get("usage"),
+			req:   sf.Tag.Get("required") == "true",
+		})
+	}
+	return fields
+}
+
+func set(v reflect.Value, s string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported type %s", v.Type())
+		}
+		var items []string
+		for item := range strings.SplitSeq(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// validate checks the required fields and runs the Validate methods, so all
+// problems are reported at once.
+func validate(root reflect.Value, fields []field) error {
+	var errs []error
+	for _, f := range fields {
+		if f.req && f.value.IsZero() {
+			errs = append(errs, fmt.Errorf("%s is required%s", f.path, sources(f)))
+		}
+	}
+
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := range v.NumField() {
+			if fv := v.Field(i); v.Type().Field(i).IsExported() && fv.Kind() == reflect.Struct && fv.Type() != secretType {
+				walk(fv)
+			}
+		}
+		if validator, ok := v.Addr().Interface().(Validator); ok {
+			if err := validator.Validate(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	walk(root)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+// sources describes where a field can be set, e.g. " (env PORT, flag -port)".
+func sources(f field) string {
+	var s []string
+	if f.env != "" {
+		s = append(s, "env "+f.env)
+	}
+	if f.flag != "" {
+		s = append(s, "flag -"+f.flag)
+	}
+	if len(s) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(s, ", ") + ")"
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// flagValue records the value of a flag, so it can be applied after the
+// file and the environment.
+type flagValue struct {
+	name   string
+	kind   reflect.Kind
+	values map[string]string
+}
+
+func (f *flagValue) String() string {
+	if f == nil || f.values == nil {
+		return ""
+	}
+	return f.values[f.name]
+}
+
+func (f *flagValue) Set(s string) error {
+	f.values[f.name] = s
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.kind == reflect.Bool
+}
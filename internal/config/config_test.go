@@ -0,0 +1,302 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Port    int           `yaml:"port" env:"CONFIG_TEST_PORT" flag:"port" default:"8080"`
+	Name    string        `yaml:"name" env:"CONFIG_TEST_NAME" flag:"name" default:"default"`
+	Timeout time.Duration `yaml:"timeout" env:"CONFIG_TEST_TIMEOUT" flag:"timeout" default:"1s"`
+	Tags    []string      `yaml:"tags" env:"CONFIG_TEST_TAGS" flag:"tags"`
+	Verbose bool          `yaml:"verbose" env:"CONFIG_TEST_VERBOSE" flag:"verbose"`
+	Nested  testSection   `yaml:"nested"`
+}
+
+type testSection struct {
+	Host     string `yaml:"host" env:"CONFIG_TEST_HOST" flag:"host" default:"localhost"`
+	Password Secret `yaml:"password" env:"CONFIG_TEST_PASSWORD"`
+}
+
+// writeFile writes content to a file in a temporary directory.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		// fileEnv names the file through CONFIG_FILE instead of -config.
+		fileEnv  bool
+		env      map[string]string
+		args     []string
+		want     testConfig
+		wantArgs []string
+	}{
+		{
+			name: "defaults",
+			want: testConfig{Port: 8080, Name: "default", Timeout: time.Second, Nested: testSection{Host: "localhost"}},
+		},
+		{
+			name: "file overrides defaults",
+			yaml: "port: 9000\ntags: [a, b]\nnested:\n  host: db\n",
+			want: testConfig{Port: 9000, Name: "default", Timeout: time.Second, Tags: []string{"a", "b"}, Nested: testSection{Host: "db"}},
+		},
+		{
+			name:    "file named by the environment",
+			yaml:    "name: from-file\n",
+			fileEnv: true,
+			want:    testConfig{Port: 8080, Name: "from-file", Timeout: time.Second, Nested: testSection{Host: "localhost"}},
+		},
+		{
+			name: "environment overrides file",
+			yaml: "port: 9000\nname: from-file\n",
+			env:  map[string]string{"CONFIG_TEST_PORT": "9001", "CONFIG_TEST_TAGS": "x, y,", "CONFIG_TEST_HOST": "env-host"},
+			want: testConfig{Port: 9001, Name: "from-file", Timeout: time.Second, Tags: []string{"x", "y"}, Nested: testSection{Host: "env-host"}},
+		},
+		{
+			name: "flags override environment",
+			yaml: "port: 9000\n",
+			env:  map[string]string{"CONFIG_TEST_PORT": "9001", "CONFIG_TEST_TIMEOUT": "5s"},
+			args: []string{"-port", "9002", "-verbose", "-host=flag-host"},
+			want: testConfig{Port: 9002, Name: "default", Timeout: 5 * time.Second, Verbose: true, Nested: testSection{Host: "flag-host"}},
+		},
+		{
+			name:     "arguments after the flags are returned",
+			args:     []string{"-name", "flag", "migrate", "up"},
+			want:     testConfig{Port: 8080, Name: "flag", Timeout: time.Second, Nested: testSection{Host: "localhost"}},
+			wantArgs: []string{"migrate", "up"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(FileEnv, "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			args := tt.args
+			if tt.yaml != "" {
+				path := writeFile(t, "config.yaml", tt.yaml)
+				if tt.fileEnv {
+					t.Setenv(FileEnv, path)
+				} else {
+					args = append([]string{"-config", path}, args...)
+				}
+			}
+
+			var cfg testConfig
+			rest, err := Load(&cfg, args)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if fmt.Sprintf("%#v", cfg) != fmt.Sprintf("%#v", tt.want) {
+				t.Errorf("config %+v, want %+v", cfg, tt.want)
+			}
+			if !slices.Equal(rest, tt.wantArgs) {
+				t.Errorf("arguments %q, want %q", rest, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFile(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  string
+		want string
+	}{
+		{name: "file", file: "s3cret\n", want: "s3cret"},
+		{name: "only line breaks are trimmed", file: " s3cret \r\n", want: " s3cret "},
+		{name: "environment overrides file", file: "s3cret\n", env: "other", want: "other"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(FileEnv, "")
+			t.Setenv("CONFIG_TEST_PASSWORD_FILE", writeFile(t, "password", tt.file))
+			if tt.env != "" {
+				t.Setenv("CONFIG_TEST_PASSWORD", tt.env)
+			}
+
+			var cfg testConfig
+			if _, err := Load(&cfg, nil); err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got := cfg.Nested.Password.Value(); got != tt.want {
+				t.Errorf("password %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv(FileEnv, "")
+		t.Setenv("CONFIG_TEST_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+		var cfg testConfig
+		if _, err := Load(&cfg, nil); err == nil || !strings.Contains(err.Error(), "CONFIG_TEST_PASSWORD_FILE") {
+			t.Errorf("error %v, want one naming CONFIG_TEST_PASSWORD_FILE", err)
+		}
+	})
+}
+
+type validatedConfig struct {
+	Name    string           `yaml:"name" env:"CONFIG_TEST_NAME" flag:"name" required:"true"`
+	Section validatedSection `yaml:"section"`
+}
+
+type validatedSection struct {
+	Max int `yaml:"max" env:"CONFIG_TEST_MAX" default:"10"`
+}
+
+var errMaxTooLarge = errors.New("section.max must be at most 100")
+
+func (s validatedSection) Validate() error {
+	if s.Max > 100 {
+		return errMaxTooLarge
+	}
+	return nil
+}
+
+func TestLoadValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		// wantErrs are parts of the error, nil if Load must succeed.
+		wantErrs []string
+	}{
+		{
+			name: "valid",
+			args: []string{"-name", "x"},
+		},
+		{
+			name:     "required field missing",
+			wantErrs: []string{"name is required (env CONFIG_TEST_NAME, flag -name)"},
+		},
+		{
+			name:     "Validate of a section fails",
+			env:      map[string]string{"CONFIG_TEST_MAX": "101"},
+			args:     []string{"-name", "x"},
+			wantErrs: []string{errMaxTooLarge.Error()},
+		},
+		{
+			name:     "all problems are reported",
+			env:      map[string]string{"CONFIG_TEST_MAX": "101"},
+			wantErrs: []string{"name is required", errMaxTooLarge.Error()},
+		},
+		{
+			name:     "unparsable value",
+			env:      map[string]string{"CONFIG_TEST_MAX": "many"},
+			wantErrs: []string{"CONFIG_TEST_MAX"},
+		},
+		{
+			name:     "unknown flag",
+			args:     []string{"-nme", "x"},
+			wantErrs: []string{"-nme"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(FileEnv, "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			var cfg validatedConfig
+			_, err := Load(&cfg, tt.args)
+			if tt.wantErrs == nil {
+				if err != nil {
+					t.Errorf("Load: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Load succeeded, want an error")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error %q does not contain %q", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSectionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		section Validator
+		wantErr bool
+	}{
+		{name: "database", section: Database{Host: "db", Port: 5432, Name: "shop"}},
+		{name: "database port out of range", section: Database{Host: "db", Port: 70000, Name: "shop"}, wantErr: true},
+		{name: "auth with JWKS URL", section: Auth{JWKSURL: "http://user-service/.well-known/jwks.json"}},
+		{name: "auth with key file", section: Auth{KeyFile: "keys.pem"}},
+		{name: "auth without keys", section: Auth{}, wantErr: true},
+		{name: "telemetry", section: Telemetry{TracesExporter: "otlp"}},
+		{name: "telemetry file exporter without file", section: Telemetry{TracesExporter: "file"}, wantErr: true},
+		{name: "telemetry unknown exporter", section: Telemetry{TracesExporter: "zipkin"}, wantErr: true},
+		{name: "grpc client", section: GRPCClient{CallTimeout: time.Second, RetryAttempts: 1, RetryMaxDelay: time.Second, BreakerFailures: 1, BreakerOpenTimeout: time.Second}},
+		{name: "grpc client without attempts", section: GRPCClient{CallTimeout: time.Second, BreakerFailures: 1, BreakerOpenTimeout: time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.section.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecretRedaction(t *testing.T) {
+	section := testSection{Host: "db", Password: "s3cret"}
+
+	var logged bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logged, nil)).Info("loaded", "config", section, "password", section.Password)
+	marshalled, err := json.Marshal(section)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs := map[string]string{
+		"%v":   fmt.Sprintf("%v", section),
+		"%+v":  fmt.Sprintf("%+v", section),
+		"%#v":  fmt.Sprintf("%#v", section),
+		"%s":   fmt.Sprintf("%s", section.Password),
+		"json": string(marshalled),
+		"slog": logged.String(),
+	}
+	for name, output := range outputs {
+		if strings.Contains(output, "s3cret") || !strings.Contains(output, redacted) {
+			t.Errorf("%s: %s shows the secret or doesn't redact it", name, output)
+		}
+	}
+
+	if got := section.Password.Value(); got != "s3cret" {
+		t.Errorf("Value() = %q, want the secret", got)
+	}
+	if got := Secret("").String(); got != "" {
+		t.Errorf("empty secret printed as %q, want it empty", got)
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"log/slog"
+	"reflect"
+)
+
+const redacted = "[REDACTED]"
+
+var secretType = reflect.TypeFor[Secret]()
+
+// Secret is a configuration value like a password that must not show up in
+// logs. Printing, logging or marshalling it gives "[REDACTED]", Value
+// returns the actual value.
+type Secret string
+
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) GoString() string {
+	return `"` + s.String() + `"`
+}
+
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s Secret) LogValue() slog.Value {
+	return slog.StringValue(s.String())
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Database is the Postgres connection of a service. The defaults match the
+// docker-compose setup.
+type Database struct {
+	Host     string `yaml:"host" env:"DB_HOST" flag:"db-host" default:"postgres_db" usage:"Postgres host"`
+	Port     int    `yaml:"port" env:"DB_PORT" flag:"db-port" default:"5432" usage:"Postgres port"`
+	User     string `yaml:"user" env:"DB_USER" flag:"db-user" default:"user" usage:"Postgres user"`
+	Password Secret `yaml:"password" env:"DB_PASSWORD" default:"password" usage:"Postgres password"`
+	Name     string `yaml:"name" env:"DB_NAME" flag:"db-name" default:"ecommerce_db" usage:"Postgres database"`
+	SSLMode  string `yaml:"sslmode" env:"DB_SSLMODE" flag:"db-sslmode" default:"disable" usage:"Postgres sslmode"`
+	// Migrate applies pending migrations at startup, see internal/migrate.
+	Migrate bool `yaml:"migrate" env:"DB_MIGRATE" flag:"db-migrate" default:"true" usage:"apply pending migrations at startup"`
+}
+
+// DSN returns the connection string including the password, don't log it.
+func (d Database) DSN() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(d.User, d.Password.Value()),
+		Host:     fmt.Sprintf("%s:%d", d.Host, d.Port),
+		Path:     "/" + d.Name,
+		RawQuery: url.Values{"sslmode": {d.SSLMode}}.Encode(),
+	}
+	return u.String()
+}
+
+func (d Database) Validate() error {
+	if d.Port < 1 || d.Port > 65535 {
+		return fmt.Errorf("database.port %d is out of range", d.Port)
+	}
+	if d.Host == "" || d.Name == "" {
+		return errors.New("database.host and database.name must be set")
+	}
+	return nil
+}
+
+// RabbitMQ is the broker connection of a service. The URL holds the
+// credentials, so it is a secret.
+type RabbitMQ struct {
+	URL Secret `yaml:"url" env:"RABBITMQ_URL" required:"true" usage:"AMQP URL of the broker"`
+}
+
+// GRPCClient is how a service calls the gRPC server of another service, see
+// internal/resilience.
+type GRPCClient struct {
+	CallTimeout    time.Duration `yaml:"call_timeout" env:"GRPC_CALL_TIMEOUT" flag:"grpc-call-timeout" default:"2s" usage:"deadline of a single attempt of a gRPC call"`
+	RetryAttempts  int           `yaml:"retry_attempts" env:"GRPC_RETRY_ATTEMPTS" flag:"grpc-retry-attempts" default:"3" usage:"attempts of an idempotent gRPC call, including the first"`
+	RetryBaseDelay time.Duration `yaml:"retry_base_delay" env:"GRPC_RETRY_BASE_DELAY" flag:"grpc-retry-base-delay" default:"50ms" usage:"backoff before the first retry, doubled for every further one"`
+	RetryMaxDelay  time.Duration `yaml:"retry_max_delay" env:"GRPC_RETRY_MAX_DELAY" flag:"grpc-retry-max-delay" default:"1s" usage:"upper bound of the backoff between retries"`
+	// HedgeDelay sends a second attempt of an idempotent call if the first
+	// has not answered within it, to cut tail latency. 0 disables hedging.
+	HedgeDelay time.Duration `yaml:"hedge_delay" env:"GRPC_HEDGE_DELAY" flag:"grpc-hedge-delay" default:"0s" usage:"delay before a hedged second attempt, 0 disables hedging"`
+	// BreakerFailures consecutive failures open the circuit breaker; calls
+	// then fail fast until a probe call after BreakerOpenTimeout succeeds.
+	BreakerFailures    int           `yaml:"breaker_failures" env:"GRPC_BREAKER_FAILURES" flag:"grpc-breaker-failures" default:"5" usage:"consecutive failures that open the circuit breaker"`
+	BreakerOpenTimeout time.Duration `yaml:"breaker_open_timeout" env:"GRPC_BREAKER_OPEN_TIMEOUT" flag:"grpc-breaker-open-timeout" default:"10s" usage:"how long the circuit breaker stays open before a probe call"`
+}
+
+func (g GRPCClient) Validate() error {
+	if g.CallTimeout <= 0 {
+		return errors.New("grpc call_timeout must be positive")
+	}
+	if g.RetryAttempts < 1 {
+		return fmt.Errorf("grpc retry_attempts %d must be at least 1", g.RetryAttempts)
+	}
+	if g.RetryBaseDelay < 0 || g.RetryMaxDelay < g.RetryBaseDelay {
+		return errors.New("grpc retry delays must not be negative and retry_max_delay not below retry_base_delay")
+	}
+	if g.HedgeDelay < 0 {
+		return errors.New("grpc hedge_delay must not be negative")
+	}
+	if g.BreakerFailures < 1 || g.BreakerOpenTimeout <= 0 {
+		return errors.New("grpc breaker_failures and breaker_open_timeout must be positive")
+	}
+	return nil
+}
+
+// Auth is where a service gets the keys to verify access tokens with, see
+// internal/auth. KeyFile is for setups without the user service.
+type Auth struct {
+	JWKSURL string `yaml:"jwks_url" env:"AUTH_JWKS_URL" flag:"auth-jwks-url" usage:"URL of the user service's JWKS"`
+	KeyFile string `yaml:"key_file" env:"AUTH_KEY_FILE" flag:"auth-key-file" usage:"JWKS or PEM file with the token signing keys"`
+}
+
+func (a Auth) Validate() error {
+	if a.JWKSURL == "" && a.KeyFile == "" {
+		return errors.New("auth.jwks_url or auth.key_file must be set")
+	}
+	return nil
+}
+
+// TLS holds the certificate and key of a service and the CA that signed the
+// certificates of all services, see internal/mtls.
+type TLS struct {
+	CertFile string `yaml:"cert_file" env:"TLS_CERT_FILE" flag:"tls-cert-file" required:"true" usage:"certificate of the service"`
+	KeyFile  string `yaml:"key_file" env:"TLS_KEY_FILE" flag:"tls-key-file" required:"true" usage:"private key of the certificate"`
+	CAFile   string `yaml:"ca_file" env:"TLS_CA_FILE" flag:"tls-ca-file" required:"true" usage:"CA of the platform"`
+}
+
+// Telemetry selects where spans go, see internal/telemetry. The otlp
+// exporter is configured with the standard OTEL_EXPORTER_OTLP_* variables.
+type Telemetry struct {
+	TracesExporter string `yaml:"traces_exporter" env:"OTEL_TRACES_EXPORTER" flag:"traces-exporter" default:"none" usage:"otlp, stdout, file or none"`
+	TracesFile     string `yaml:"traces_file" env:"OTEL_TRACES_FILE" flag:"traces-file" usage:"file the spans are written to by the file exporter"`
+}
+
+func (t Telemetry) Validate() error {
+	switch t.TracesExporter {
+	case "otlp", "stdout", "none":
+	case "file":
+		if t.TracesFile == "" {
+			return errors.New("telemetry.traces_file must be set for the file exporter")
+		}
+	default:
+		return fmt.Errorf("unknown telemetry.traces_exporter %q", t.TracesExporter)
+	}
+	return nil
+}
+
+// ValidatePort checks that port can be listened on.
+func ValidatePort(name string, port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%s %d is out of range", name, port)
+	}
+	return nil
+}
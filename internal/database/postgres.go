@@ -1,19 +1,81 @@
 package database
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"ecommerce-platform/internal/config"
 	"log"
+	"strings"
+
+	"github.com/XSAM/otelsql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
 )
 
-func InitDb(host, port, user, pass, dbName string) *sql.DB {
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable", user, pass, host, port, dbName)
-	log.Println(connStr)
+func InitDb(cfg config.Database) *sql.DB {
+	log.Printf("Connecting to postgres at %s:%d/%s as %s", cfg.Host, cfg.Port, cfg.Name, cfg.User)
 
-	db, err := sql.Open("postgres", connStr)
+	// Every query gets a span named after its statement, see statementName.
+	db, err := otelsql.Open("postgres", cfg.DSN(),
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL, semconv.DBNamespace(cfg.Name)),
+		otelsql.WithSpanNameFormatter(spanName),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+		}),
+	)
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	// Connection pool stats from db.Stats() on /metrics.
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, cfg.Name))
+
 	return db
 }
+
+func spanName(_ context.Context, method otelsql.Method, query string) string {
+	if name := statementName(query); name != "" {
+		return name
+	}
+	return string(method)
+}
+
+// statementName names a query by its operation and table, e.g.
+// "SELECT orders" or "INSERT order_items". Statements it can't name, like
+// BEGIN, get an empty name.
+func statementName(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) < 2 {
+		return ""
+	}
+
+	operation := strings.ToUpper(fields[0])
+
+	var keyword string
+	switch operation {
+	case "SELECT", "DELETE":
+		keyword = "FROM"
+	case "INSERT":
+		keyword = "INTO"
+	case "UPDATE":
+		return operation + " " + tableName(fields[1])
+	default:
+		return ""
+	}
+
+	for i := 1; i < len(fields)-1; i++ {
+		if strings.EqualFold(fields[i], keyword) {
+			return operation + " " + tableName(fields[i+1])
+		}
+	}
+
+	return operation
+}
+
+func tableName(field string) string {
+	name, _, _ := strings.Cut(field, "(")
+	return strings.ToLower(strings.TrimRight(name, ",;)"))
+}
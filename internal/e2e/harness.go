@@ -0,0 +1,380 @@
+// Package e2e runs the order and inventory services in-process for end-to-end
+// tests without docker-compose. The inventory gRPC server listens on bufconn,
+// both HTTP APIs are served by httptest servers with the production routes
+// and policies, and Postgres and RabbitMQ are replaced by the in-memory
+// repositories and broker.
+package e2e
+
+import (
+	"bytes"
+	"context"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/config"
+	"ecommerce-platform/internal/interceptor"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/internal/openapi"
+	"ecommerce-platform/internal/resilience"
+	"ecommerce-platform/services/user/token"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	pb "ecommerce-platform/pkg/grpc/inventory"
+	inventory_handler "ecommerce-platform/services/inventory/api/handler"
+	inventory_grpc "ecommerce-platform/services/inventory/grpc"
+	inventory_model "ecommerce-platform/services/inventory/model"
+	inventory_memory "ecommerce-platform/services/inventory/repository/memory"
+	inventory_service "ecommerce-platform/services/inventory/service"
+	order_consumer "ecommerce-platform/services/order/api/consumer"
+	order_handler "ecommerce-platform/services/order/api/handler"
+	order_client "ecommerce-platform/services/order/client"
+	order_model "ecommerce-platform/services/order/model"
+	order_memory "ecommerce-platform/services/order/repository/memory"
+	order_service "ecommerce-platform/services/order/service"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// settleTimeout is how long Settle waits for the events to be handled.
+const settleTimeout = 5 * time.Second
+
+// productQueue is the queue the product cache of the order service is
+// invalidated from.
+const productQueue = "order-service.products"
+
+// Harness is a running order and inventory service. Everything is stopped
+// when the test ends.
+type Harness struct {
+	// OrderURL and InventoryURL are the base URLs of the HTTP APIs.
+	OrderURL     string
+	InventoryURL string
+
+	// Inventory is a client of the inventory gRPC server. Unlike the order
+	// service's client it doesn't forward credentials; calls need the
+	// metadata of Token.
+	Inventory pb.InventoryServiceClient
+
+	Orders   *order_memory.OrderMemoryRepository
+	Products *inventory_memory.InventoryMemoryRepository
+	Broker   *messaging.Memory
+	Payments *Payments
+
+	orderService order_service.OrderService
+	signer       *token.Signer
+	t            testing.TB
+}
+
+// New starts the services. Tokens for their APIs are issued with Token.
+//
+// Unlike in production the gRPC connection is not protected by mutual TLS,
+// so the check that only the order service may adjust stock is skipped.
+func New(t testing.TB) *Harness {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	key, err := token.GenerateKey()
+	if err != nil {
+		t.Fatalf("generate signing key: %v", err)
+	}
+	signer := token.NewSigner(key, time.Hour, time.Hour)
+	verifier := auth.NewVerifier(auth.StaticKeys{"": &key.PublicKey})
+
+	h := &Harness{
+		Orders:   order_memory.NewOrderMemoryRepository(),
+		Products: inventory_memory.NewInventoryMemoryRepository(),
+		Broker:   messaging.NewMemory(logger),
+		signer:   signer,
+		t:        t,
+	}
+	h.Payments = NewPayments(h.Broker)
+
+	// Inventory service
+	inventoryService := inventory_service.NewInventoryService(h.Products, h.Broker, logger)
+
+	lis := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer(
+		interceptor.Server(logger, 10*time.Second,
+			auth.UnaryServerInterceptor(verifier, inventory_grpc.Permissions(), logger),
+		),
+		interceptor.Stream(logger, auth.StreamServerInterceptor(verifier, inventory_grpc.Permissions(), logger)),
+	)
+	pb.RegisterInventoryServiceServer(grpcServer, inventory_grpc.NewInventoryGRPCServer(inventoryService))
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	inventoryRouter := chi.NewRouter()
+	inventoryRouter.Use(middleware.RequestID)
+	inventoryRouter.Use(middleware.Recoverer)
+	inventoryRouter.Use(auth.OptionalMiddleware(verifier, logger))
+	inventoryRouter.Use(inventory_handler.Policy(logger).Middleware(inventoryRouter))
+	inventoryRouter.Use(validator(t, inventory_handler.OpenAPI, logger).Middleware(inventoryRouter))
+	inventory_handler.Routes(inventoryRouter, inventory_handler.NewInventoryHandler(inventoryService, logger))
+	checkSpec(t, inventory_handler.OpenAPI, inventoryRouter)
+
+	inventoryServer := httptest.NewServer(inventoryRouter)
+	t.Cleanup(inventoryServer.Close)
+	h.InventoryURL = inventoryServer.URL
+
+	// Order service
+	inventoryResilience := resilience.New("inventory", config.GRPCClient{
+		CallTimeout:        2 * time.Second,
+		RetryAttempts:      3,
+		RetryBaseDelay:     10 * time.Millisecond,
+		RetryMaxDelay:      100 * time.Millisecond,
+		BreakerFailures:    5,
+		BreakerOpenTimeout: time.Second,
+	}, logger, pb.InventoryService_GetProductInfo_FullMethodName, pb.InventoryService_AdjustStock_FullMethodName)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		interceptor.Client(logger, 5*time.Second, inventoryResilience.UnaryClientInterceptor(), auth.UnaryClientInterceptor()),
+	)
+	if err != nil {
+		t.Fatalf("connect to inventory service: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	inventoryClient := pb.NewInventoryServiceClient(conn)
+	h.Inventory = inventoryClient
+
+	shipmentRepo := order_memory.NewShipmentMemoryRepository(h.Orders)
+	returnRepo := order_memory.NewReturnMemoryRepository(shipmentRepo)
+
+	productCache := order_client.NewProductCache(inventoryClient, order_client.ProductCacheConfig{
+		Size:   100,
+		TTL:    time.Minute,
+		MaxAge: time.Minute,
+	}, logger)
+	h.orderService = order_service.NewOrderService(h.Orders, logger, productCache, h.Broker)
+	shipmentService := order_service.NewShipmentService(shipmentRepo, h.Orders, h.Broker, logger)
+	returnService := order_service.NewReturnService(returnRepo, h.Orders, inventoryClient, h.Payments, logger)
+
+	// Bound before the consumer starts, so no event published in between is lost.
+	h.Broker.Bind(order_consumer.QueueName, order_consumer.EventTypes)
+	eventConsumer := order_consumer.NewEventConsumer(h.orderService, logger)
+	// Production uses a temporary queue per instance; a named one is bound
+	// here so no price change is missed before the consumer starts.
+	h.Broker.Bind(productQueue, order_consumer.ProductEventTypes)
+	productConsumer := order_consumer.NewProductConsumer(productCache, logger)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		h.Broker.Subscribe(ctx, order_consumer.QueueName, order_consumer.EventTypes, eventConsumer.Handle)
+	}()
+	go func() {
+		defer wg.Done()
+		h.Broker.Subscribe(ctx, productQueue, order_consumer.ProductEventTypes, productConsumer.Handle)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	orderRouter := chi.NewRouter()
+	orderRouter.Use(middleware.RequestID)
+	orderRouter.Use(middleware.Recoverer)
+	orderRouter.Use(auth.Middleware(verifier, logger))
+	orderRouter.Use(order_handler.Policy(logger).Middleware(orderRouter))
+	orderRouter.Use(validator(t, order_handler.OpenAPI, logger).Middleware(orderRouter))
+	order_handler.Routes(orderRouter,
+		order_handler.NewOrderHandler(h.orderService, logger),
+		order_handler.NewShipmentHandler(shipmentService, logger),
+		order_handler.NewReturnHandler(returnService, logger),
+	)
+	checkSpec(t, order_handler.OpenAPI, orderRouter)
+
+	orderServer := httptest.NewServer(orderRouter)
+	t.Cleanup(orderServer.Close)
+	h.OrderURL = orderServer.URL
+
+	return h
+}
+
+// validator validates requests against the OpenAPI document of a service.
+func validator(t testing.TB, spec []byte, logger *slog.Logger) *openapi.Validator {
+	t.Helper()
+
+	doc, err := openapi.Load(spec)
+	if err != nil {
+		t.Fatalf("load OpenAPI document: %v", err)
+	}
+	return openapi.NewValidator(doc, logger)
+}
+
+// checkSpec fails the test if the OpenAPI document of a service doesn't
+// describe exactly the routes of router.
+func checkSpec(t testing.TB, spec []byte, router chi.Routes) {
+	t.Helper()
+
+	doc, err := openapi.Load(spec)
+	if err != nil {
+		t.Fatalf("load OpenAPI document: %v", err)
+	}
+	if err := doc.Check(router); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Token returns an access token of a new user with the given roles.
+func (h *Harness) Token(roles ...auth.Role) string {
+	h.t.Helper()
+
+	names := make([]string, 0, len(roles))
+	for _, role := range roles {
+		names = append(names, string(role))
+	}
+
+	userID := uuid.NewString()
+	signed, err := h.signer.IssueAccessToken(userID, userID+"@example.com", names, time.Now())
+	if err != nil {
+		h.t.Fatalf("issue access token: %v", err)
+	}
+	return signed
+}
+
+// Request sends body as JSON to url and decodes a successful response into
+// out, if not nil. It returns the status code.
+func (h *Harness) Request(method, url, accessToken string, body, out any) int {
+	h.t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatalf("encode request: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, url, reader)
+	if err != nil {
+		h.t.Fatalf("create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		h.t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer res.Body.Close()
+
+	if out != nil && res.StatusCode < 300 {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			h.t.Fatalf("decode response of %s %s: %v", method, url, err)
+		}
+	}
+
+	return res.StatusCode
+}
+
+// SeedProduct stores a product directly in the inventory.
+func (h *Harness) SeedProduct(name string, price float64, stock int) *inventory_model.Product {
+	h.t.Helper()
+
+	product := &inventory_model.Product{Name: name, Price: price, StockQuantity: stock}
+	if err := h.Products.Create(context.Background(), product); err != nil {
+		h.t.Fatalf("seed product %s: %v", name, err)
+	}
+	return product
+}
+
+// CreateOrder places an order through the order API as the user of accessToken.
+func (h *Harness) CreateOrder(accessToken string, items ...order_model.OrderItem) *order_model.Order {
+	h.t.Helper()
+
+	var order order_model.Order
+	body := order_handler.CreateOrderRequest{Items: items}
+	if status := h.Request(http.MethodPost, h.OrderURL+"/orders", accessToken, body, &order); status != http.StatusAccepted {
+		h.t.Fatalf("create order: status %d, want %d", status, http.StatusAccepted)
+	}
+	return &order
+}
+
+// PayOrder announces the payment of the order and waits until the order
+// service marked it as paid.
+func (h *Harness) PayOrder(orderID string) {
+	h.t.Helper()
+
+	order, err := h.Orders.FindByID(context.Background(), orderID)
+	if err != nil {
+		h.t.Fatalf("find order %s: %v", orderID, err)
+	}
+	if err := h.Payments.Capture(context.Background(), orderID, order.TotalPrice); err != nil {
+		h.t.Fatalf("capture payment of order %s: %v", orderID, err)
+	}
+	h.AssertOrderStatus(orderID, order_model.OrderStatusPaid)
+}
+
+// Settle waits until every published event was handled, including the
+// events published while handling them.
+func (h *Harness) Settle() {
+	h.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+	defer cancel()
+	if err := h.Broker.Drain(ctx); err != nil {
+		h.t.Fatalf("events were not handled within %s", settleTimeout)
+	}
+}
+
+// Order returns the stored order once all events were handled.
+func (h *Harness) Order(orderID string) *order_model.Order {
+	h.t.Helper()
+
+	h.Settle()
+	order, err := h.Orders.FindByID(context.Background(), orderID)
+	if err != nil {
+		h.t.Fatalf("find order %s: %v", orderID, err)
+	}
+	return order
+}
+
+// AssertOrderStatus fails the test unless the order has the status once all
+// events were handled.
+func (h *Harness) AssertOrderStatus(orderID, want string) {
+	h.t.Helper()
+
+	if got := h.Order(orderID).Status; got != want {
+		h.t.Errorf("order %s has status %s, want %s", orderID, got, want)
+	}
+}
+
+// AssertStock fails the test unless the product has the stock quantity.
+func (h *Harness) AssertStock(productID string, want int) {
+	h.t.Helper()
+
+	product, err := h.Products.FindByID(context.Background(), productID)
+	if err != nil {
+		h.t.Fatalf("find product %s: %v", productID, err)
+	}
+	if product.StockQuantity != want {
+		h.t.Errorf("product %s has %d in stock, want %d", productID, product.StockQuantity, want)
+	}
+}
+
+// Events returns the published events of the given type, oldest first.
+func (h *Harness) Events(eventType string) []messaging.Event {
+	var events []messaging.Event
+	for _, event := range h.Broker.Published() {
+		if event.Type == eventType {
+			events = append(events, event)
+		}
+	}
+	return events
+}
@@ -0,0 +1,34 @@
+package e2e_test
+
+import (
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/e2e"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUnknownAndMalformedIDs(t *testing.T) {
+	h := e2e.New(t)
+
+	customer := h.Token(auth.RoleCustomer)
+
+	tests := []struct {
+		name string
+		url  string
+		want int
+	}{
+		{name: "unknown product", url: h.InventoryURL + "/products/" + uuid.NewString(), want: http.StatusNotFound},
+		{name: "malformed product ID", url: h.InventoryURL + "/products/not-a-uuid", want: http.StatusBadRequest},
+		{name: "unknown order", url: h.OrderURL + "/orders/" + uuid.NewString(), want: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := h.Request(http.MethodGet, tt.url, customer, nil, nil); status != tt.want {
+				t.Errorf("status %d, want %d", status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,316 @@
+package e2e_test
+
+import (
+	"context"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/e2e"
+	"ecommerce-platform/pkg/events"
+	"ecommerce-platform/services/order/api/handler"
+	"ecommerce-platform/services/order/model"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestOrderSaga(t *testing.T) {
+	h := e2e.New(t)
+
+	customer := h.Token(auth.RoleCustomer)
+	warehouse := h.Token(auth.RoleWarehouseStaff)
+	support := h.Token(auth.RoleSupport)
+
+	shirt := h.SeedProduct("Gopher T-Shirt", 12.5, 10)
+	mug := h.SeedProduct("Gopher Mug", 7.25, 3)
+
+	// The prices come from the inventory service over gRPC.
+	order := h.CreateOrder(customer,
+		model.OrderItem{ProductID: shirt.ID, Quantity: 2},
+		model.OrderItem{ProductID: mug.ID, Quantity: 1},
+	)
+	if order.Status != model.OrderStatusPending || order.TotalPrice != 32.25 {
+		t.Fatalf("created order has status %s and total %v, want %s and 32.25", order.Status, order.TotalPrice, model.OrderStatusPending)
+	}
+	if created := h.Events(events.OrderCreated); len(created) != 1 {
+		t.Errorf("%d order.created events published, want 1", len(created))
+	}
+
+	h.PayOrder(order.ID)
+
+	// Fulfillment
+	var shipment model.Shipment
+	status := h.Request(http.MethodPost, h.OrderURL+"/orders/"+order.ID+"/shipments", warehouse, handler.CreateShipmentRequest{
+		Warehouse: "berlin",
+		Items:     []model.ShipmentItem{{ProductID: shirt.ID, Quantity: 2}, {ProductID: mug.ID, Quantity: 1}},
+	}, &shipment)
+	if status != http.StatusCreated {
+		t.Fatalf("create shipment: status %d", status)
+	}
+	if status := h.Request(http.MethodPut, h.OrderURL+"/shipments/"+shipment.ID+"/tracking", warehouse, handler.SetTrackingRequest{
+		Carrier:        "DHL",
+		TrackingNumber: "00340434161094042557",
+	}, nil); status != http.StatusOK {
+		t.Fatalf("set tracking: status %d", status)
+	}
+
+	recordShipmentEvent(t, h, warehouse, shipment.ID, model.ShipmentStatusShipped)
+	h.AssertOrderStatus(order.ID, model.OrderStatusShipped)
+
+	recordShipmentEvent(t, h, warehouse, shipment.ID, model.ShipmentStatusDelivered)
+	h.AssertOrderStatus(order.ID, model.OrderStatusDelivered)
+
+	// Return of one shirt, which goes back into stock and is refunded.
+	var ret model.Return
+	status = h.Request(http.MethodPost, h.OrderURL+"/orders/"+order.ID+"/returns", customer, handler.RequestReturnRequest{
+		Lines: []handler.ReturnLineRequest{{ProductID: shirt.ID, Quantity: 1}},
+	}, &ret)
+	if status != http.StatusCreated {
+		t.Fatalf("request return: status %d", status)
+	}
+	if status := h.Request(http.MethodPost, h.OrderURL+"/returns/"+ret.ID+"/approve", support, handler.ReturnDecisionRequest{}, nil); status != http.StatusOK {
+		t.Fatalf("approve return: status %d", status)
+	}
+
+	status = h.Request(http.MethodPost, h.OrderURL+"/returns/"+ret.ID+"/inspection", warehouse, handler.InspectReturnRequest{
+		Lines: []model.ReturnInspection{{LineID: ret.Lines[0].ID, Disposition: model.ReturnDispositionRestock}},
+	}, &ret)
+	if status != http.StatusOK {
+		t.Fatalf("inspect return: status %d", status)
+	}
+	if ret.Status != model.ReturnStatusRefunded {
+		t.Errorf("return has status %s, want %s", ret.Status, model.ReturnStatusRefunded)
+	}
+
+	h.AssertStock(shirt.ID, 11)
+	h.AssertStock(mug.ID, 3)
+	// The refund doesn't hide that the order was delivered.
+	h.AssertOrderStatus(order.ID, model.OrderStatusDelivered)
+	if refunded := h.Order(order.ID); refunded.RefundedTotal != 12.5 || refunded.RefundStatus != model.RefundStatusPartiallyRefunded {
+		t.Errorf("order has %v refunded and refund status %s, want 12.5 and %s", refunded.RefundedTotal, refunded.RefundStatus, model.RefundStatusPartiallyRefunded)
+	}
+}
+
+func TestOrderSagaRefundRetry(t *testing.T) {
+	h := e2e.New(t)
+
+	customer := h.Token(auth.RoleCustomer)
+	warehouse := h.Token(auth.RoleWarehouseStaff)
+	support := h.Token(auth.RoleSupport)
+
+	shirt := h.SeedProduct("Gopher T-Shirt", 12.5, 10)
+	order := h.CreateOrder(customer, model.OrderItem{ProductID: shirt.ID, Quantity: 1})
+	h.PayOrder(order.ID)
+
+	var shipment model.Shipment
+	h.Request(http.MethodPost, h.OrderURL+"/orders/"+order.ID+"/shipments", warehouse, handler.CreateShipmentRequest{
+		Warehouse: "berlin",
+		Items:     []model.ShipmentItem{{ProductID: shirt.ID, Quantity: 1}},
+	}, &shipment)
+	h.Request(http.MethodPut, h.OrderURL+"/shipments/"+shipment.ID+"/tracking", warehouse, handler.SetTrackingRequest{Carrier: "DHL", TrackingNumber: "1"}, nil)
+	recordShipmentEvent(t, h, warehouse, shipment.ID, model.ShipmentStatusDelivered)
+
+	var ret model.Return
+	h.Request(http.MethodPost, h.OrderURL+"/orders/"+order.ID+"/returns", customer, handler.RequestReturnRequest{
+		Lines: []handler.ReturnLineRequest{{ProductID: shirt.ID, Quantity: 1}},
+	}, &ret)
+	h.Request(http.MethodPost, h.OrderURL+"/returns/"+ret.ID+"/approve", support, handler.ReturnDecisionRequest{}, nil)
+
+	// The stock is put back even though the refund fails, and only once when
+	// the refund is retried.
+	h.Payments.Fail(errors.New("payment provider unavailable"))
+	status := h.Request(http.MethodPost, h.OrderURL+"/returns/"+ret.ID+"/inspection", warehouse, handler.InspectReturnRequest{
+		Lines: []model.ReturnInspection{{LineID: ret.Lines[0].ID, Disposition: model.ReturnDispositionRestock}},
+	}, nil)
+	if status == http.StatusOK {
+		t.Fatal("inspection succeeded although the refund failed")
+	}
+	h.AssertStock(shirt.ID, 11)
+	h.AssertOrderStatus(order.ID, model.OrderStatusDelivered)
+
+	// A refund the provider hasn't completed yet leaves the return waiting
+	// for it.
+	h.Payments.Fail(nil)
+	h.Payments.Pend(true)
+	if status := h.Request(http.MethodPost, h.OrderURL+"/returns/"+ret.ID+"/refund", support, nil, nil); status == http.StatusOK {
+		t.Fatal("refund succeeded although it is still pending")
+	}
+	h.Request(http.MethodGet, h.OrderURL+"/returns/"+ret.ID, support, nil, &ret)
+	if ret.Status != model.ReturnStatusReceived {
+		t.Errorf("return is %s, want %s while the refund is pending", ret.Status, model.ReturnStatusReceived)
+	}
+
+	h.Payments.Pend(false)
+	if status := h.Request(http.MethodPost, h.OrderURL+"/returns/"+ret.ID+"/refund", support, nil, nil); status != http.StatusOK {
+		t.Fatalf("retry refund: status %d", status)
+	}
+	h.AssertStock(shirt.ID, 11)
+	h.AssertOrderStatus(order.ID, model.OrderStatusDelivered)
+	if refundStatus := h.Order(order.ID).RefundStatus; refundStatus != model.RefundStatusRefunded {
+		t.Errorf("order has refund status %s, want %s", refundStatus, model.RefundStatusRefunded)
+	}
+}
+
+func TestPaymentSucceededRedelivered(t *testing.T) {
+	h := e2e.New(t)
+
+	customer := h.Token(auth.RoleCustomer)
+	warehouse := h.Token(auth.RoleWarehouseStaff)
+
+	shirt := h.SeedProduct("Gopher T-Shirt", 12.5, 10)
+	order := h.CreateOrder(customer, model.OrderItem{ProductID: shirt.ID, Quantity: 1})
+	h.PayOrder(order.ID)
+
+	var shipment model.Shipment
+	h.Request(http.MethodPost, h.OrderURL+"/orders/"+order.ID+"/shipments", warehouse, handler.CreateShipmentRequest{
+		Warehouse: "berlin",
+		Items:     []model.ShipmentItem{{ProductID: shirt.ID, Quantity: 1}},
+	}, &shipment)
+	h.Request(http.MethodPut, h.OrderURL+"/shipments/"+shipment.ID+"/tracking", warehouse, handler.SetTrackingRequest{Carrier: "DHL", TrackingNumber: "1"}, nil)
+	recordShipmentEvent(t, h, warehouse, shipment.ID, model.ShipmentStatusDelivered)
+
+	// The payment event arrives again, the order stays delivered.
+	if err := h.Payments.Capture(context.Background(), order.ID, order.TotalPrice); err != nil {
+		t.Fatal(err)
+	}
+	h.AssertOrderStatus(order.ID, model.OrderStatusDelivered)
+}
+
+func TestReturnOnlyShippedUnits(t *testing.T) {
+	h := e2e.New(t)
+
+	customer := h.Token(auth.RoleCustomer)
+	warehouse := h.Token(auth.RoleWarehouseStaff)
+
+	shirt := h.SeedProduct("Gopher T-Shirt", 12.5, 10)
+	order := h.CreateOrder(customer, model.OrderItem{ProductID: shirt.ID, Quantity: 3})
+	h.PayOrder(order.ID)
+
+	// One shirt has left the warehouse, one is still being picked.
+	createShipment := func() string {
+		var shipment model.Shipment
+		status := h.Request(http.MethodPost, h.OrderURL+"/orders/"+order.ID+"/shipments", warehouse, handler.CreateShipmentRequest{
+			Warehouse: "berlin",
+			Items:     []model.ShipmentItem{{ProductID: shirt.ID, Quantity: 1}},
+		}, &shipment)
+		if status != http.StatusCreated {
+			t.Fatalf("create shipment: status %d", status)
+		}
+		h.Request(http.MethodPut, h.OrderURL+"/shipments/"+shipment.ID+"/tracking", warehouse, handler.SetTrackingRequest{Carrier: "DHL", TrackingNumber: shipment.ID}, nil)
+		return shipment.ID
+	}
+	recordShipmentEvent(t, h, warehouse, createShipment(), model.ShipmentStatusShipped)
+	createShipment()
+	h.AssertOrderStatus(order.ID, model.OrderStatusPartiallyShipped)
+
+	request := func(quantity int) int {
+		return h.Request(http.MethodPost, h.OrderURL+"/orders/"+order.ID+"/returns", customer, handler.RequestReturnRequest{
+			Lines: []handler.ReturnLineRequest{{ProductID: shirt.ID, Quantity: quantity}},
+		}, nil)
+	}
+	if status := request(2); status != http.StatusBadRequest {
+		t.Errorf("return of an unshipped shirt: status %d, want %d", status, http.StatusBadRequest)
+	}
+	if status := request(1); status != http.StatusCreated {
+		t.Fatalf("return of the shipped shirt: status %d", status)
+	}
+	if status := request(1); status != http.StatusBadRequest {
+		t.Errorf("second return of the shipped shirt: status %d, want %d", status, http.StatusBadRequest)
+	}
+}
+
+func TestConcurrentShipments(t *testing.T) {
+	h := e2e.New(t)
+
+	customer := h.Token(auth.RoleCustomer)
+	warehouse := h.Token(auth.RoleWarehouseStaff)
+
+	shirt := h.SeedProduct("Gopher T-Shirt", 12.5, 10)
+	order := h.CreateOrder(customer, model.OrderItem{ProductID: shirt.ID, Quantity: 2})
+	h.PayOrder(order.ID)
+
+	// Every request asks for all units, only one of them may get them.
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := h.Request(http.MethodPost, h.OrderURL+"/orders/"+order.ID+"/shipments", warehouse, handler.CreateShipmentRequest{
+				Warehouse: "berlin",
+				Items:     []model.ShipmentItem{{ProductID: shirt.ID, Quantity: 2}},
+			}, nil)
+			if status == http.StatusCreated {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := created.Load(); n != 1 {
+		t.Errorf("%d shipments created for the same units, want 1", n)
+	}
+}
+
+func TestConcurrentReturns(t *testing.T) {
+	h := e2e.New(t)
+
+	customer := h.Token(auth.RoleCustomer)
+	warehouse := h.Token(auth.RoleWarehouseStaff)
+
+	shirt := h.SeedProduct("Gopher T-Shirt", 12.5, 10)
+	order := h.CreateOrder(customer, model.OrderItem{ProductID: shirt.ID, Quantity: 2})
+	h.PayOrder(order.ID)
+
+	var shipment model.Shipment
+	h.Request(http.MethodPost, h.OrderURL+"/orders/"+order.ID+"/shipments", warehouse, handler.CreateShipmentRequest{
+		Warehouse: "berlin",
+		Items:     []model.ShipmentItem{{ProductID: shirt.ID, Quantity: 2}},
+	}, &shipment)
+	h.Request(http.MethodPut, h.OrderURL+"/shipments/"+shipment.ID+"/tracking", warehouse, handler.SetTrackingRequest{Carrier: "DHL", TrackingNumber: "1"}, nil)
+	recordShipmentEvent(t, h, warehouse, shipment.ID, model.ShipmentStatusShipped)
+
+	// Every request asks for both shipped units, only one of them may get them.
+	var wg sync.WaitGroup
+	var created atomic.Int32
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := h.Request(http.MethodPost, h.OrderURL+"/orders/"+order.ID+"/returns", customer, handler.RequestReturnRequest{
+				Lines: []handler.ReturnLineRequest{{ProductID: shirt.ID, Quantity: 2}},
+			}, nil)
+			if status == http.StatusCreated {
+				created.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := created.Load(); n != 1 {
+		t.Errorf("%d returns created for the same units, want 1", n)
+	}
+}
+
+func TestCreateOrderRequiresPermission(t *testing.T) {
+	h := e2e.New(t)
+
+	shirt := h.SeedProduct("Gopher T-Shirt", 12.5, 10)
+	body := handler.CreateOrderRequest{Items: []model.OrderItem{{ProductID: shirt.ID, Quantity: 1}}}
+
+	if status := h.Request(http.MethodPost, h.OrderURL+"/orders", "", body, nil); status != http.StatusUnauthorized {
+		t.Errorf("without token: status %d, want %d", status, http.StatusUnauthorized)
+	}
+	if status := h.Request(http.MethodPost, h.OrderURL+"/orders", h.Token(auth.RoleCatalogAdmin), body, nil); status != http.StatusForbidden {
+		t.Errorf("as catalog admin: status %d, want %d", status, http.StatusForbidden)
+	}
+}
+
+func recordShipmentEvent(t *testing.T, h *e2e.Harness, accessToken, shipmentID, status string) {
+	t.Helper()
+
+	code := h.Request(http.MethodPost, h.OrderURL+"/shipments/"+shipmentID+"/events", accessToken, handler.RecordShipmentEventRequest{Status: status}, nil)
+	if code != http.StatusOK {
+		t.Fatalf("record %s event: status %d", status, code)
+	}
+}
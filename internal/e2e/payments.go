@@ -0,0 +1,104 @@
+package e2e
+
+import (
+	"context"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
+	order_service "ecommerce-platform/services/order/service"
+	"errors"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// Payments stands in for the payment service. It issues refunds for the
+// order service and announces them like the payment service does.
+type Payments struct {
+	publisher messaging.Publisher
+
+	mu       sync.Mutex
+	refunds  map[string]string
+	refunded map[string]float64
+	fail     error
+	pending  bool
+}
+
+func NewPayments(publisher messaging.Publisher) *Payments {
+	return &Payments{
+		publisher: publisher,
+		refunds:   make(map[string]string),
+		refunded:  make(map[string]float64),
+	}
+}
+
+// Fail makes refunds fail with err until it is called with nil.
+func (p *Payments) Fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.fail = err
+}
+
+// Capture announces that the order was paid, like the payment service does
+// once the payment was captured.
+func (p *Payments) Capture(ctx context.Context, orderID string, amount float64) error {
+	return p.publisher.Publish(ctx, events.PaymentSucceeded, events.PaymentSucceededPayload{
+		PaymentID: uuid.NewString(),
+		OrderID:   orderID,
+		Amount:    amount,
+	})
+}
+
+// Pend makes refunds stay pending at the provider until it is called with
+// false.
+func (p *Payments) Pend(pending bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.pending = pending
+}
+
+// IssueRefund implements service.RefundIssuer. Like the payment service it
+// doesn't refund twice for the same reference, but announces the refund
+// again.
+func (p *Payments) IssueRefund(ctx context.Context, orderID string, amount float64, reason, reference string) (string, string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fail != nil {
+		return "", "", p.fail
+	}
+	if amount <= 0 {
+		return "", "", errors.New("refund amount must be positive")
+	}
+	if p.pending {
+		return uuid.NewString(), "PENDING", nil
+	}
+	id, ok := p.refunds[reference]
+	if !ok {
+		id = uuid.NewString()
+		p.refunds[reference] = id
+		p.refunded[orderID] += amount
+	}
+
+	err := p.publisher.Publish(ctx, events.PaymentRefunded, events.PaymentRefundedPayload{
+		OrderID:       orderID,
+		RefundID:      id,
+		Amount:        amount,
+		Reason:        reason,
+		RefundedTotal: p.refunded[orderID],
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return id, order_service.RefundSucceeded, nil
+}
+
+// Refunded returns the total refunded for the order.
+func (p *Payments) Refunded(orderID string) float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.refunded[orderID]
+}
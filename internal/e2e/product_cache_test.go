@@ -0,0 +1,42 @@
+package e2e_test
+
+import (
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/e2e"
+	"ecommerce-platform/pkg/events"
+	inventory_handler "ecommerce-platform/services/inventory/api/handler"
+	"ecommerce-platform/services/order/model"
+	"net/http"
+	"testing"
+)
+
+func TestPriceChangeInvalidatesProductCache(t *testing.T) {
+	h := e2e.New(t)
+
+	customer := h.Token(auth.RoleCustomer)
+	catalogAdmin := h.Token(auth.RoleCatalogAdmin)
+
+	shirt := h.SeedProduct("Gopher T-Shirt", 12.5, 10)
+
+	// The first order caches the price.
+	if order := h.CreateOrder(customer, model.OrderItem{ProductID: shirt.ID, Quantity: 1}); order.TotalPrice != 12.5 {
+		t.Fatalf("first order has total %v, want 12.5", order.TotalPrice)
+	}
+
+	status := h.Request(http.MethodPut, h.InventoryURL+"/products/"+shirt.ID+"/price", catalogAdmin, inventory_handler.UpdatePriceRequest{Price: 15}, nil)
+	if status != http.StatusOK {
+		t.Fatalf("update price: status %d", status)
+	}
+	if changed := h.Events(events.ProductPriceChanged); len(changed) != 1 {
+		t.Fatalf("%d product.price_changed events published, want 1", len(changed))
+	}
+	h.Settle()
+
+	if order := h.CreateOrder(customer, model.OrderItem{ProductID: shirt.ID, Quantity: 1}); order.TotalPrice != 15 {
+		t.Errorf("order after the price change has total %v, want 15", order.TotalPrice)
+	}
+
+	if status := h.Request(http.MethodPut, h.InventoryURL+"/products/"+shirt.ID+"/price", customer, inventory_handler.UpdatePriceRequest{Price: 1}, nil); status != http.StatusForbidden {
+		t.Errorf("update price as customer: status %d, want %d", status, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,90 @@
+package e2e_test
+
+import (
+	"context"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/e2e"
+	"net/http"
+	"testing"
+	"time"
+
+	pb "ecommerce-platform/pkg/grpc/inventory"
+	inventory_handler "ecommerce-platform/services/inventory/api/handler"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestWatchProducts(t *testing.T) {
+	h := e2e.New(t)
+
+	warehouse := h.Token(auth.RoleWarehouseStaff)
+	catalogAdmin := h.Token(auth.RoleCatalogAdmin)
+
+	shirt := h.SeedProduct("Gopher T-Shirt", 12.5, 10)
+	h.SeedProduct("Gopher Mug", 7.25, 3)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watch := func(ctx context.Context, accessToken string, req *pb.WatchProductsRequest) grpc.ServerStreamingClient[pb.ProductChange] {
+		t.Helper()
+		stream, err := h.Inventory.WatchProducts(metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+accessToken), req)
+		if err != nil {
+			t.Fatalf("WatchProducts: %v", err)
+		}
+		return stream
+	}
+	recv := func(stream grpc.ServerStreamingClient[pb.ProductChange], want pb.ProductChange_Type) *pb.ProductChange {
+		t.Helper()
+		change, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("receive %s change: %v", want, err)
+		}
+		if change.Type != want || change.Product.GetId() != shirt.ID {
+			t.Fatalf("received %s change of %s, want %s of %s", change.Type, change.Product.GetId(), want, shirt.ID)
+		}
+		return change
+	}
+
+	// The history is sent first, then changes as they happen.
+	streamCtx, cancelStream := context.WithCancel(ctx)
+	stream := watch(streamCtx, warehouse, &pb.WatchProductsRequest{ProductIds: []string{shirt.ID}})
+	created := recv(stream, pb.ProductChange_CREATED)
+	if created.StockQuantity != 10 || created.Product.Price != 12.5 {
+		t.Errorf("created product has stock %d and price %v, want 10 and 12.5", created.StockQuantity, created.Product.Price)
+	}
+
+	if status := h.Request(http.MethodPut, h.InventoryURL+"/products/"+shirt.ID+"/price", catalogAdmin, inventory_handler.UpdatePriceRequest{Price: 15}, nil); status != http.StatusOK {
+		t.Fatalf("update price: status %d", status)
+	}
+	updated := recv(stream, pb.ProductChange_UPDATED)
+	if updated.Product.Price != 15 || updated.StockQuantity != 10 {
+		t.Errorf("updated product has price %v and stock %d, want 15 and 10", updated.Product.Price, updated.StockQuantity)
+	}
+
+	if status := h.Request(http.MethodPost, h.InventoryURL+"/products/"+shirt.ID+"/stock-movements", warehouse, inventory_handler.AdjustStockRequest{QuantityChange: -2}, nil); status != http.StatusCreated {
+		t.Fatalf("adjust stock: status %d", status)
+	}
+	stockChanged := recv(stream, pb.ProductChange_STOCK_CHANGED)
+	if stockChanged.QuantityChange != -2 || stockChanged.StockQuantity != 8 {
+		t.Errorf("stock change of %d to %d, want -2 to 8", stockChanged.QuantityChange, stockChanged.StockQuantity)
+	}
+	cancelStream()
+
+	// A client that lost the stream continues after the last change it handled.
+	resumed := watch(ctx, warehouse, &pb.WatchProductsRequest{Cursor: created.Cursor, ProductIds: []string{shirt.ID}})
+	if change := recv(resumed, pb.ProductChange_UPDATED); change.Cursor != updated.Cursor {
+		t.Errorf("resumed with cursor %s, want %s", change.Cursor, updated.Cursor)
+	}
+	recv(resumed, pb.ProductChange_STOCK_CHANGED)
+
+	if _, err := watch(ctx, warehouse, &pb.WatchProductsRequest{Cursor: "not-a-cursor"}).Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("watch with invalid cursor: %v, want InvalidArgument", err)
+	}
+	if _, err := watch(ctx, h.Token(auth.RoleCustomer), &pb.WatchProductsRequest{}).Recv(); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("watch as customer: %v, want PermissionDenied", err)
+	}
+}
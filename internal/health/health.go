@@ -0,0 +1,167 @@
+// Package health serves the liveness and readiness endpoints of the services.
+//
+// /healthz only tells that the process serves HTTP, so an orchestrator
+// restarts it when it hangs. /readyz runs the checks registered for the
+// dependencies of the service, like the database or the broker, and fails
+// while one of them does, so no traffic is sent to an instance that can't
+// handle it.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Check reports whether a dependency is usable.
+type Check func(ctx context.Context) error
+
+type Status struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+type Registry struct {
+	mu      sync.RWMutex
+	checks  map[string]Check
+	timeout time.Duration
+	logger  *slog.Logger
+}
+
+// NewRegistry creates a registry whose checks fail when they take longer
+// than timeout.
+func NewRegistry(timeout time.Duration, logger *slog.Logger) *Registry {
+	return &Registry{
+		checks:  make(map[string]Check),
+		timeout: timeout,
+		logger:  logger.With("file", "health.go"),
+	}
+}
+
+// Register adds the check under name, replacing an earlier one.
+func (r *Registry) Register(name string, check Check) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.checks[name] = check
+}
+
+// Check runs all checks concurrently and returns their errors by name. The
+// error of a passing check is nil.
+func (r *Registry) Check(ctx context.Context) map[string]error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]error, len(r.checks))
+	for name, check := range r.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := check(ctx)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// Live answers every request with 200.
+func (r *Registry) Live(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(Status{Status: "ok"})
+}
+
+// Ready answers with 200 if all checks pass and 503 otherwise, listing the
+// result of every check.
+func (r *Registry) Ready(w http.ResponseWriter, req *http.Request) {
+	results := r.Check(req.Context())
+
+	status := Status{Status: "ok", Checks: make(map[string]string, len(results))}
+	for name, err := range results {
+		if err != nil {
+			r.logger.Error("Readiness check failed", "request_id", middleware.GetReqID(req.Context()), "check", name, "error", err)
+			status.Status = "unavailable"
+			status.Checks[name] = err.Error()
+			continue
+		}
+		status.Checks[name] = "ok"
+	}
+
+	code := http.StatusOK
+	if status.Status != "ok" {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(status)
+}
+
+// UpdateGRPC runs the checks every interval until ctx is done and sets the
+// serving status of the services on the gRPC health server accordingly. Once
+// ctx is done, all services are reported as not serving.
+func (r *Registry) UpdateGRPC(ctx context.Context, server *grpchealth.Server, interval time.Duration, services ...string) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status := healthpb.HealthCheckResponse_SERVING
+		for name, err := range r.Check(ctx) {
+			if err != nil {
+				r.logger.Error("Health check failed", "check", name, "error", err)
+				status = healthpb.HealthCheckResponse_NOT_SERVING
+			}
+		}
+		for _, service := range services {
+			server.SetServingStatus(service, status)
+		}
+
+		select {
+		case <-ctx.Done():
+			server.Shutdown()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DB checks that the database answers.
+func DB(db *sql.DB) Check {
+	return db.PingContext
+}
+
+// GRPC checks that the service behind conn reports itself as serving through
+// the standard gRPC health service.
+func GRPC(conn *grpc.ClientConn, service string) Check {
+	client := healthpb.NewHealthClient(conn)
+
+	return func(ctx context.Context) error {
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			return err
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			return fmt.Errorf("%s is %s", conn.Target(), resp.GetStatus())
+		}
+		return nil
+	}
+}
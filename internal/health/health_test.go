@@ -0,0 +1,76 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReady(t *testing.T) {
+	pass := func(ctx context.Context) error { return nil }
+	fail := func(ctx context.Context) error { return errors.New("connection refused") }
+	hang := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	tests := []struct {
+		name       string
+		checks     map[string]Check
+		wantStatus int
+		wantChecks map[string]string
+	}{
+		{name: "no checks", wantStatus: http.StatusOK, wantChecks: map[string]string{}},
+		{
+			name:       "all checks pass",
+			checks:     map[string]Check{"database": pass, "broker": pass},
+			wantStatus: http.StatusOK,
+			wantChecks: map[string]string{"database": "ok", "broker": "ok"},
+		},
+		{
+			name:       "a check fails",
+			checks:     map[string]Check{"database": pass, "broker": fail},
+			wantStatus: http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"database": "ok", "broker": "connection refused"},
+		},
+		{
+			name:       "a check times out",
+			checks:     map[string]Check{"database": hang},
+			wantStatus: http.StatusServiceUnavailable,
+			wantChecks: map[string]string{"database": context.DeadlineExceeded.Error()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(10*time.Millisecond, slog.New(slog.DiscardHandler))
+			for name, check := range tt.checks {
+				registry.Register(name, check)
+			}
+
+			w := httptest.NewRecorder()
+			registry.Ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			var status Status
+			if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+				t.Fatal(err)
+			}
+			if len(status.Checks) != len(tt.wantChecks) {
+				t.Errorf("checks %v, want %v", status.Checks, tt.wantChecks)
+			}
+			for name, want := range tt.wantChecks {
+				if got := status.Checks[name]; got != want {
+					t.Errorf("check %s: %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
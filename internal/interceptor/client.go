@@ -0,0 +1,70 @@
+package interceptor
+
+import (
+	"context"
+	"ecommerce-platform/internal/metrics"
+	"log/slog"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Client returns the interceptor chain of a gRPC client connection, followed
+// by the extra interceptors, e.g. for forwarding credentials.
+func Client(logger *slog.Logger, timeout time.Duration, extra ...grpc.UnaryClientInterceptor) grpc.DialOption {
+	interceptors := []grpc.UnaryClientInterceptor{
+		UnaryClientRequestID(),
+		UnaryClientDeadline(timeout),
+		UnaryClientLogging(logger),
+		metrics.UnaryClientInterceptor(),
+	}
+	return grpc.WithChainUnaryInterceptor(append(interceptors, extra...)...)
+}
+
+// UnaryClientRequestID sends the request ID of the context along with the
+// call.
+func UnaryClientRequestID() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if reqID := middleware.GetReqID(ctx); reqID != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, RequestIDMetadata, reqID)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryClientDeadline gives calls without deadline one of timeout, so a hanging
+// server doesn't block the caller forever.
+func UnaryClientDeadline(timeout time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// UnaryClientLogging logs every call with its status code and duration.
+func UnaryClientLogging(logger *slog.Logger) grpc.UnaryClientInterceptor {
+	logger = logger.With("file", "client.go")
+
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		callLogger := logger.With("request_id", middleware.GetReqID(ctx), "method", method, "target", cc.Target(),
+			"code", status.Code(err).String(), "duration_ms", time.Since(start).Milliseconds())
+		if err != nil {
+			callLogger.Error("gRPC call failed", "error", err)
+		} else {
+			callLogger.Info("gRPC call completed")
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,125 @@
+package interceptor
+
+import (
+	"context"
+	"ecommerce-platform/internal/apperr"
+	"log/slog"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// serve starts a gRPC health server with the shared interceptors followed by
+// handler, which sees every call, and returns a client connection to it.
+func serve(t *testing.T, handler grpc.UnaryServerInterceptor) *grpc.ClientConn {
+	t.Helper()
+	logger := slog.New(slog.DiscardHandler)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(Server(logger, time.Second, handler))
+	healthpb.RegisterHealthServer(srv, grpchealth.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		Client(logger, time.Second),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func check(ctx context.Context, conn *grpc.ClientConn) error {
+	_, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return err
+}
+
+func TestRequestID(t *testing.T) {
+	var got string
+	conn := serve(t, func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		got = middleware.GetReqID(ctx)
+		return handler(ctx, req)
+	})
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-1")
+	if err := check(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+	if got != "req-1" {
+		t.Errorf("request ID %q on the server, want the caller's", got)
+	}
+
+	if err := check(context.Background(), conn); err != nil {
+		t.Fatal(err)
+	}
+	if got == "" || got == "req-1" {
+		t.Errorf("request ID %q for a call without one, want a new one", got)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler grpc.UnaryHandler
+		want    codes.Code
+	}{
+		{
+			name: "domain error",
+			handler: func(ctx context.Context, req any) (any, error) {
+				return nil, apperr.New(apperr.Conflict, "conflict", "Conflict")
+			},
+			want: codes.FailedPrecondition,
+		},
+		{
+			name: "status is kept",
+			handler: func(ctx context.Context, req any) (any, error) {
+				return nil, status.Error(codes.ResourceExhausted, "slow down")
+			},
+			want: codes.ResourceExhausted,
+		},
+		{
+			name:    "panic",
+			handler: func(ctx context.Context, req any) (any, error) { panic("boom") },
+			want:    codes.Internal,
+		},
+		{
+			name: "deadline is set",
+			handler: func(ctx context.Context, req any) (any, error) {
+				if _, ok := ctx.Deadline(); !ok {
+					return nil, status.Error(codes.Unknown, "no deadline")
+				}
+				return nil, nil
+			},
+			want: codes.OK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := serve(t, func(ctx context.Context, req any, info *grpc.UnaryServerInfo, _ grpc.UnaryHandler) (any, error) {
+				resp, err := tt.handler(ctx, req)
+				if err == nil {
+					resp = &healthpb.HealthCheckResponse{}
+				}
+				return resp, err
+			})
+
+			if code := status.Code(check(context.Background(), conn)); code != tt.want {
+				t.Errorf("code %s, want %s", code, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+// Package interceptor holds the gRPC interceptors every service uses: request
+// IDs are propagated in metadata like on the HTTP side, calls are logged and
+// measured, domain errors get the status code of their kind, panics become
+// codes.Internal and calls get a deadline if they have none.
+package interceptor
+
+import (
+	"context"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/metrics"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDMetadata carries the request ID between services.
+const RequestIDMetadata = "x-request-id"
+
+// Server returns the interceptor chain of a gRPC server. The extra
+// interceptors, e.g. authentication, run after the shared ones, so their
+// calls are logged and their panics recovered too.
+func Server(logger *slog.Logger, timeout time.Duration, extra ...grpc.UnaryServerInterceptor) grpc.ServerOption {
+	interceptors := []grpc.UnaryServerInterceptor{
+		UnaryServerRequestID(),
+		UnaryServerLogging(logger),
+		metrics.UnaryServerInterceptor(),
+		UnaryServerErrors(),
+		UnaryServerRecovery(logger),
+		UnaryServerDeadline(timeout),
+	}
+	return grpc.ChainUnaryInterceptor(append(interceptors, extra...)...)
+}
+
+// Stream returns the interceptor chain of the streams of a gRPC server, like
+// Server does for unary calls. Streams get no deadline, they may stay open
+// as long as the client wants.
+func Stream(logger *slog.Logger, extra ...grpc.StreamServerInterceptor) grpc.ServerOption {
+	interceptors := []grpc.StreamServerInterceptor{
+		StreamServerRequestID(),
+		StreamServerLogging(logger),
+		metrics.StreamServerInterceptor(),
+		StreamServerErrors(),
+		StreamServerRecovery(logger),
+	}
+	return grpc.ChainStreamInterceptor(append(interceptors, extra...)...)
+}
+
+// UnaryServerRequestID puts the caller's request ID into the context, where
+// middleware.GetReqID finds it. Calls without one get a new ID.
+func UnaryServerRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(withRequestID(ctx), req)
+	}
+}
+
+// StreamServerRequestID is UnaryServerRequestID for streams.
+func StreamServerRequestID() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{ServerStream: ss, ctx: withRequestID(ss.Context())})
+	}
+}
+
+func withRequestID(ctx context.Context) context.Context {
+	var reqID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDMetadata); len(values) > 0 {
+			reqID = values[0]
+		}
+	}
+	if reqID == "" {
+		reqID = uuid.NewString()
+	}
+
+	return context.WithValue(ctx, middleware.RequestIDKey, reqID)
+}
+
+// UnaryServerLogging logs every call with its status code and duration.
+func UnaryServerLogging(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	logger = logger.With("file", "server.go")
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		callLogger := logger.With("request_id", middleware.GetReqID(ctx), "method", info.FullMethod,
+			"code", status.Code(err).String(), "duration_ms", time.Since(start).Milliseconds())
+		if err != nil {
+			callLogger.Error("gRPC call failed", "error", err)
+		} else {
+			callLogger.Info("gRPC call completed")
+		}
+
+		return resp, err
+	}
+}
+
+// StreamServerLogging logs every stream when it ends.
+func StreamServerLogging(logger *slog.Logger) grpc.StreamServerInterceptor {
+	logger = logger.With("file", "server.go")
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, ss)
+
+		streamLogger := logger.With("request_id", middleware.GetReqID(ss.Context()), "method", info.FullMethod,
+			"code", status.Code(err).String(), "duration_ms", time.Since(start).Milliseconds())
+		if err != nil && status.Code(err) != codes.Canceled {
+			streamLogger.Error("gRPC stream failed", "error", err)
+		} else {
+			streamLogger.Info("gRPC stream ended")
+		}
+
+		return err
+	}
+}
+
+// UnaryServerErrors reports the errors of handlers with the gRPC code of
+// their apperr kind, e.g. missing rows as codes.NotFound instead of
+// codes.Unknown.
+func UnaryServerErrors() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		return resp, apperr.GRPCError(err)
+	}
+}
+
+// StreamServerErrors is UnaryServerErrors for streams.
+func StreamServerErrors() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return apperr.GRPCError(handler(srv, ss))
+	}
+}
+
+// UnaryServerRecovery turns panics in the handler into codes.Internal, so a
+// bad request can't take the server down.
+func UnaryServerRecovery(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	logger = logger.With("file", "server.go")
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				logger.Error("Recovered from panic in gRPC handler", "request_id", middleware.GetReqID(ctx),
+					"method", info.FullMethod, "panic", p, "stack", string(debug.Stack()))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerRecovery is UnaryServerRecovery for streams.
+func StreamServerRecovery(logger *slog.Logger) grpc.StreamServerInterceptor {
+	logger = logger.With("file", "server.go")
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				logger.Error("Recovered from panic in gRPC handler", "request_id", middleware.GetReqID(ss.Context()),
+					"method", info.FullMethod, "panic", p, "stack", string(debug.Stack()))
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+
+		return handler(srv, ss)
+	}
+}
+
+// UnaryServerDeadline limits calls that arrive without deadline to timeout.
+// Deadlines set by the caller are kept.
+func UnaryServerDeadline(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// serverStream replaces the context of a stream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
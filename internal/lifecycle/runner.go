@@ -0,0 +1,187 @@
+// Package lifecycle runs the servers and background workers of a service
+// and shuts them down gracefully on SIGINT or SIGTERM.
+//
+// On shutdown the servers stop accepting new requests and the workers'
+// contexts are cancelled. In-flight requests and events are given the
+// shutdown timeout to finish, then the connections registered with Close
+// are closed in reverse order, like deferred calls.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/signal"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+type task struct {
+	name string
+	// run blocks until the task is done. Returning before shutdown is
+	// treated as a failure and shuts the service down.
+	run func(ctx context.Context) error
+	// stop makes run return, waiting for in-flight work until ctx is done.
+	stop func(ctx context.Context) error
+}
+
+type closer struct {
+	name  string
+	close func() error
+}
+
+type Runner struct {
+	tasks   []task
+	closers []closer
+	timeout time.Duration
+	logger  *slog.Logger
+}
+
+// NewRunner creates a runner that gives in-flight work timeout to finish on
+// shutdown.
+func NewRunner(timeout time.Duration, logger *slog.Logger) *Runner {
+	return &Runner{
+		timeout: timeout,
+		logger:  logger.With("file", "runner.go"),
+	}
+}
+
+// HTTP serves srv on srv.Addr.
+func (r *Runner) HTTP(name string, srv *http.Server) {
+	r.tasks = append(r.tasks, task{
+		name: name,
+		run: func(ctx context.Context) error {
+			if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				return err
+			}
+			return nil
+		},
+		stop: srv.Shutdown,
+	})
+}
+
+// GRPC serves srv on lis. If the in-flight calls don't finish in time, they
+// are cancelled.
+func (r *Runner) GRPC(name string, srv *grpc.Server, lis net.Listener) {
+	r.tasks = append(r.tasks, task{
+		name: name,
+		run: func(ctx context.Context) error {
+			return srv.Serve(lis)
+		},
+		stop: func(ctx context.Context) error {
+			stopped := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				srv.Stop()
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// Go runs fn until shutdown, when its context is cancelled. fn should return
+// once the work it is doing is done, e.g. the event it is handling.
+func (r *Runner) Go(name string, fn func(ctx context.Context) error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	r.tasks = append(r.tasks, task{
+		name: name,
+		run: func(context.Context) error {
+			defer close(done)
+			return fn(ctx)
+		},
+		stop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
+
+// Close registers a connection that is closed after all servers and workers
+// stopped. Like deferred calls, connections are closed in reverse order, so
+// register them right after opening them.
+func (r *Runner) Close(name string, close func() error) {
+	r.closers = append(r.closers, closer{name: name, close: close})
+}
+
+// Run starts all servers and workers and blocks until the process receives
+// SIGINT or SIGTERM or one of them fails. It then shuts everything down and
+// returns the error of the failed server or worker, if any.
+func (r *Runner) Run() error {
+	ctx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	failed := make(chan error, len(r.tasks))
+	for _, t := range r.tasks {
+		go func() {
+			r.logger.Info("Starting", "component", t.name)
+			if err := t.run(ctx); err != nil {
+				failed <- fmt.Errorf("%s: %w", t.name, err)
+				return
+			}
+			if ctx.Err() == nil {
+				failed <- fmt.Errorf("%s stopped unexpectedly", t.name)
+			}
+		}()
+	}
+
+	var runErr error
+	select {
+	case <-ctx.Done():
+		r.logger.Info("Shutdown signal received, shutting down", "timeout", r.timeout)
+	case runErr = <-failed:
+		r.logger.Error("Component failed, shutting down", "error", runErr)
+	}
+	stopSignals()
+
+	r.shutdown()
+
+	return runErr
+}
+
+func (r *Runner) shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, t := range r.tasks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := t.stop(ctx); err != nil {
+				r.logger.Error("Could not stop gracefully", "component", t.name, "error", err)
+				return
+			}
+			r.logger.Info("Stopped", "component", t.name)
+		}()
+	}
+	wg.Wait()
+
+	for _, c := range slices.Backward(r.closers) {
+		if err := c.close(); err != nil {
+			r.logger.Error("Could not close", "component", c.name, "error", err)
+			continue
+		}
+		r.logger.Info("Closed", "component", c.name)
+	}
+}
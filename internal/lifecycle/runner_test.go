@@ -0,0 +1,89 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"slices"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// recorder keeps the order in which workers stopped and connections closed.
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+// worker runs until its context is cancelled and then takes a moment to
+// finish its work.
+func (r *recorder) worker(name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		r.record("stopped " + name)
+		return nil
+	}
+}
+
+func (r *recorder) closer(name string) func() error {
+	return func() error {
+		r.record("closed " + name)
+		return nil
+	}
+}
+
+func TestRunStopsOnFailure(t *testing.T) {
+	rec := &recorder{}
+	runner := NewRunner(time.Second, slog.New(slog.DiscardHandler))
+	runner.Close("database", rec.closer("database"))
+	runner.Close("broker", rec.closer("broker"))
+	runner.Go("consumer", rec.worker("consumer"))
+	failure := errors.New("listen: address in use")
+	runner.Go("server", func(ctx context.Context) error { return failure })
+
+	if err := runner.Run(); !errors.Is(err, failure) {
+		t.Fatalf("Run: %v, want the error of the failed worker", err)
+	}
+
+	want := []string{"stopped consumer", "closed broker", "closed database"}
+	if !slices.Equal(rec.events, want) {
+		t.Errorf("events %v, want %v", rec.events, want)
+	}
+}
+
+func TestRunStopsOnSignal(t *testing.T) {
+	rec := &recorder{}
+	runner := NewRunner(time.Second, slog.New(slog.DiscardHandler))
+	runner.Close("database", rec.closer("database"))
+	runner.Go("consumer", rec.worker("consumer"))
+	started := make(chan struct{})
+	runner.Go("signal", func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	})
+
+	go func() {
+		<-started
+		syscall.Kill(os.Getpid(), syscall.SIGTERM)
+	}()
+
+	if err := runner.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := []string{"stopped consumer", "closed database"}
+	if !slices.Equal(rec.events, want) {
+		t.Errorf("events %v, want %v", rec.events, want)
+	}
+}
@@ -0,0 +1,208 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Memory is an in-process broker for tests and local runs without RabbitMQ.
+// Like the topic exchange it delivers every event to each queue bound to the
+// event's type, and like RabbitMQ it redelivers an event once if the handler
+// fails. Events published before any queue is bound to their type are lost.
+type Memory struct {
+	mu        sync.Mutex
+	queues    map[string]*memoryQueue
+	published []Event
+	// pending counts the events queued or being handled, see Drain.
+	pending int
+	logger  *slog.Logger
+}
+
+type memoryQueue struct {
+	eventTypes []string
+	deliveries []memoryDelivery
+	// notify wakes up the subscriber when deliveries were added.
+	notify chan struct{}
+}
+
+type memoryDelivery struct {
+	event       Event
+	redelivered bool
+}
+
+func NewMemory(logger *slog.Logger) *Memory {
+	return &Memory{
+		queues: make(map[string]*memoryQueue),
+		logger: logger.With("file", "memory.go"),
+	}
+}
+
+func (m *Memory) Publish(ctx context.Context, eventType string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	event := Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Payload:    body,
+		Headers:    headersFromContext(ctx),
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.published = append(m.published, event)
+	for _, queue := range m.queues {
+		if slices.Contains(queue.eventTypes, eventType) {
+			m.enqueue(queue, memoryDelivery{event: event})
+		}
+	}
+
+	m.logger.Info("Event published", "request_id", event.Headers[HeaderRequestID], "event_id", event.ID, "event_type", eventType)
+
+	return nil
+}
+
+// enqueue has to be called with m.mu held.
+func (m *Memory) enqueue(queue *memoryQueue, delivery memoryDelivery) {
+	queue.deliveries = append(queue.deliveries, delivery)
+	m.pending++
+
+	select {
+	case queue.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Bind declares queue and binds it to the event types like Subscribe does.
+// Events are then kept in the queue until a subscriber handles them, so
+// binding before starting Subscribe in a goroutine loses no events.
+func (m *Memory) Bind(queue string, eventTypes []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.bind(queue, eventTypes)
+}
+
+// bind has to be called with m.mu held.
+func (m *Memory) bind(queue string, eventTypes []string) *memoryQueue {
+	q, ok := m.queues[queue]
+	if !ok {
+		q = &memoryQueue{notify: make(chan struct{}, 1)}
+		m.queues[queue] = q
+	}
+	for _, eventType := range eventTypes {
+		if !slices.Contains(q.eventTypes, eventType) {
+			q.eventTypes = append(q.eventTypes, eventType)
+		}
+	}
+	return q
+}
+
+func (m *Memory) Subscribe(ctx context.Context, queue string, eventTypes []string, handler Handler) error {
+	m.mu.Lock()
+	if queue == "" {
+		queue = "temporary." + uuid.NewString()
+		defer m.remove(queue)
+	}
+	q := m.bind(queue, eventTypes)
+	m.mu.Unlock()
+
+	subLogger := m.logger.With("queue", queue)
+	subLogger.Info("Subscribed to events", "event_types", eventTypes)
+
+	handlerCtx := context.WithoutCancel(ctx)
+
+	for {
+		// Events left over from an earlier subscription are handled first.
+		for {
+			m.mu.Lock()
+			if len(q.deliveries) == 0 || ctx.Err() != nil {
+				m.mu.Unlock()
+				break
+			}
+			delivery := q.deliveries[0]
+			q.deliveries = q.deliveries[1:]
+			m.mu.Unlock()
+
+			event := delivery.event
+			eventLogger := subLogger.With("request_id", event.Headers[HeaderRequestID], "event_id", event.ID, "event_type", event.Type)
+
+			err := handler(contextFromHeaders(handlerCtx, event.Headers), event)
+
+			m.mu.Lock()
+			if err != nil {
+				// Give the event one more chance before dropping it, like
+				// the RabbitMQ subscription does.
+				eventLogger.Error("Event handler failed", "redelivered", delivery.redelivered, "error", err)
+				if !delivery.redelivered {
+					m.enqueue(q, memoryDelivery{event: event, redelivered: true})
+				}
+			}
+			m.pending--
+			m.mu.Unlock()
+		}
+
+		select {
+		case <-ctx.Done():
+			subLogger.Info("Subscription stopped")
+			return nil
+		case <-q.notify:
+		}
+	}
+}
+
+// remove deletes queue with the events left in it.
+func (m *Memory) remove(queue string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if q, ok := m.queues[queue]; ok {
+		m.pending -= len(q.deliveries)
+		delete(m.queues, queue)
+	}
+}
+
+// Published returns every event published so far, oldest first.
+func (m *Memory) Published() []Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return slices.Clone(m.published)
+}
+
+// Drain waits until every published event was handled by the subscribers of
+// its queues, including the events published by these handlers.
+func (m *Memory) Drain(ctx context.Context) error {
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		m.mu.Lock()
+		pending := m.pending
+		m.mu.Unlock()
+		if pending == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Ping always succeeds, the broker lives as long as the process.
+func (m *Memory) Ping(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+const (
+	// HeaderRequestID carries the ID of the request that caused the event, so
+	// consumers can log with the same request_id as the producer.
+	HeaderRequestID = "request_id"
+)
+
+// Event is the envelope every message on the bus is wrapped in.
+type Event struct {
+	ID         string            `json:"id"`
+	Type       string            `json:"type"`
+	OccurredAt time.Time         `json:"occurredAt"`
+	Payload    json.RawMessage   `json:"payload"`
+	Headers    map[string]string `json:"-"`
+}
+
+// Decode unmarshals the event payload into v.
+func (e Event) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Handler processes a single event. Returning an error makes the broker
+// redeliver the event once before it is dropped.
+type Handler func(ctx context.Context, event Event) error
+
+type Publisher interface {
+	Publish(ctx context.Context, eventType string, payload any) error
+}
+
+type Subscriber interface {
+	// Subscribe binds queue to the given event types and calls handler for
+	// every delivered event until ctx is cancelled. It blocks until then and
+	// returns once the event being handled is done, so no event is cut off.
+	//
+	// Instances subscribed to the same queue share its events. With an empty
+	// queue the instance gets a queue of its own that is deleted when the
+	// subscription ends, so every instance gets every event, e.g. to
+	// invalidate its cache. Events published while it is not subscribed are
+	// lost.
+	Subscribe(ctx context.Context, queue string, eventTypes []string, handler Handler) error
+}
+
+// headersFromContext returns the headers that are propagated with every event
+// published in ctx.
+func headersFromContext(ctx context.Context) map[string]string {
+	headers := make(map[string]string)
+	if reqID := middleware.GetReqID(ctx); reqID != "" {
+		headers[HeaderRequestID] = reqID
+	}
+	// The trace context, so the consumer's spans join the producer's trace.
+	otel.GetTextMapPropagator().Inject(ctx, propagation.MapCarrier(headers))
+	return headers
+}
+
+// contextFromHeaders restores the values propagated by headersFromContext.
+func contextFromHeaders(ctx context.Context, headers map[string]string) context.Context {
+	if reqID := headers[HeaderRequestID]; reqID != "" {
+		ctx = context.WithValue(ctx, middleware.RequestIDKey, reqID)
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(headers))
+}
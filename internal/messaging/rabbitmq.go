@@ -0,0 +1,226 @@
+package messaging
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExchangeName is the topic exchange all services publish their events to.
+// Events are routed by their type, e.g. "payment.refunded".
+const ExchangeName = "ecommerce.events"
+
+var tracer = otel.Tracer("ecommerce-platform/internal/messaging")
+
+type RabbitMQ struct {
+	conn   *amqp.Connection
+	mu     sync.Mutex
+	ch     *amqp.Channel
+	logger *slog.Logger
+}
+
+func NewRabbitMQ(url string, logger *slog.Logger) (*RabbitMQ, error) {
+	conn, err := amqp.Dial(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to rabbitmq: %w", err)
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open rabbitmq channel: %w", err)
+	}
+
+	err = ch.ExchangeDeclare(ExchangeName, amqp.ExchangeTopic, true, false, false, false, nil)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to declare exchange: %w", err)
+	}
+
+	return &RabbitMQ{
+		conn:   conn,
+		ch:     ch,
+		logger: logger.With("file", "rabbitmq.go"),
+	}, nil
+}
+
+func (r *RabbitMQ) Publish(ctx context.Context, eventType string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	ctx, span := tracer.Start(ctx, eventType+" publish", trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(semconv.MessagingSystemRabbitMQ, semconv.MessagingDestinationName(ExchangeName)))
+	defer span.End()
+
+	event := Event{
+		ID:         uuid.NewString(),
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		Payload:    body,
+		Headers:    headersFromContext(ctx),
+	}
+
+	msg, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	headers := amqp.Table{}
+	for k, v := range event.Headers {
+		headers[k] = v
+	}
+
+	pubLogger := r.logger.With("request_id", event.Headers[HeaderRequestID], "event_id", event.ID, "event_type", eventType)
+
+	// amqp channels are not safe for concurrent publishing.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err = r.ch.PublishWithContext(ctx, ExchangeName, eventType, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    event.ID,
+		Type:         eventType,
+		Timestamp:    event.OccurredAt,
+		Headers:      headers,
+		Body:         msg,
+	})
+	if err != nil {
+		pubLogger.Error("Could not publish event", "error", err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	span.SetAttributes(semconv.MessagingMessageID(event.ID))
+
+	pubLogger.Info("Event published")
+
+	return nil
+}
+
+func (r *RabbitMQ) Subscribe(ctx context.Context, queue string, eventTypes []string, handler Handler) error {
+	ch, err := r.conn.Channel()
+	if err != nil {
+		return fmt.Errorf("failed to open rabbitmq channel: %w", err)
+	}
+
+	// An empty queue gets a server-named queue that only lives as long as
+	// this subscription.
+	temporary := queue == ""
+	q, err := ch.QueueDeclare(queue, !temporary, temporary, temporary, false, nil)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to declare queue %s: %w", queue, err)
+	}
+	queue = q.Name
+
+	for _, eventType := range eventTypes {
+		if err := ch.QueueBind(queue, eventType, ExchangeName, false, nil); err != nil {
+			ch.Close()
+			return fmt.Errorf("failed to bind queue %s to %s: %w", queue, eventType, err)
+		}
+	}
+
+	if err := ch.Qos(10, 0, false); err != nil {
+		ch.Close()
+		return err
+	}
+
+	// Cancelling ctx only stops the delivery of new events, events already
+	// being handled may finish.
+	deliveries, err := ch.ConsumeWithContext(ctx, queue, "", false, false, false, false, nil)
+	if err != nil {
+		ch.Close()
+		return fmt.Errorf("failed to consume from %s: %w", queue, err)
+	}
+	defer ch.Close()
+
+	subLogger := r.logger.With("queue", queue)
+	subLogger.Info("Subscribed to events", "event_types", eventTypes)
+
+	handlerCtx := context.WithoutCancel(ctx)
+
+	for d := range deliveries {
+		if ctx.Err() != nil {
+			// Prefetched but not handled, leave it to another consumer.
+			d.Nack(false, true)
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(d.Body, &event); err != nil {
+			subLogger.Error("Could not decode event, dropping it", "message_id", d.MessageId, "error", err)
+			d.Nack(false, false)
+			continue
+		}
+
+		event.Headers = make(map[string]string)
+		for k, v := range d.Headers {
+			if s, ok := v.(string); ok {
+				event.Headers[k] = s
+			}
+		}
+
+		eventCtx := contextFromHeaders(handlerCtx, event.Headers)
+		eventLogger := subLogger.With("request_id", event.Headers[HeaderRequestID], "event_id", event.ID, "event_type", event.Type)
+
+		eventCtx, span := tracer.Start(eventCtx, event.Type+" process", trace.WithSpanKind(trace.SpanKindConsumer),
+			trace.WithAttributes(semconv.MessagingSystemRabbitMQ, semconv.MessagingDestinationName(queue), semconv.MessagingMessageID(event.ID)))
+
+		if err := handler(eventCtx, event); err != nil {
+			// Give the event one more chance before dropping it, so a
+			// poison message can't block the queue forever.
+			eventLogger.Error("Event handler failed", "redelivered", d.Redelivered, "error", err)
+			span.SetStatus(codes.Error, err.Error())
+			span.End()
+			d.Nack(false, !d.Redelivered)
+			continue
+		}
+
+		span.End()
+		d.Ack(false)
+	}
+
+	if ctx.Err() == nil {
+		return fmt.Errorf("subscription to %s closed by rabbitmq", queue)
+	}
+
+	subLogger.Info("Subscription stopped")
+
+	return nil
+}
+
+// Ping reports whether the connection and the publishing channel are open.
+func (r *RabbitMQ) Ping(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn.IsClosed() {
+		return errors.New("rabbitmq connection is closed")
+	}
+	if r.ch.IsClosed() {
+		return errors.New("rabbitmq channel is closed")
+	}
+	return nil
+}
+
+func (r *RabbitMQ) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ch.Close()
+	return r.conn.Close()
+}
@@ -0,0 +1,154 @@
+// Package metrics exposes Prometheus metrics: RED metrics for HTTP routes and
+// gRPC methods, served on /metrics. Services define their business metrics
+// next to the code that records them.
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// unmatchedRoute is the route label of requests no route matched, so
+// arbitrary paths can't blow up the number of series.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by route template, method and status code.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Duration of HTTP requests by route template and method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	grpcServerHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_handled_total",
+		Help: "gRPC calls handled by the server by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcServerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_server_handling_seconds",
+		Help:    "Duration of gRPC calls handled by the server by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	grpcServerStreams = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_server_streams_open",
+		Help: "Open server streams by method.",
+	}, []string{"method"})
+
+	grpcServerStreamMsgsSent = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_server_stream_msgs_sent_total",
+		Help: "Messages sent on server streams by method.",
+	}, []string{"method"})
+
+	grpcClientHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_handled_total",
+		Help: "gRPC calls made by the client by method and status code.",
+	}, []string{"method", "code"})
+
+	grpcClientDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_client_handling_seconds",
+		Help:    "Duration of gRPC calls made by the client by method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+)
+
+// Handler serves the metrics in the Prometheus format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Middleware records the requests per chi route template, e.g.
+// "/orders/{id}", not the raw path.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(ww, r)
+
+		route := unmatchedRoute
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		code := ww.Status()
+		if code == 0 {
+			code = http.StatusOK
+		}
+
+		httpRequests.WithLabelValues(route, r.Method, strconv.Itoa(code)).Inc()
+		httpDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+	})
+}
+
+// UnaryServerInterceptor records the calls handled by a gRPC server.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		grpcServerHandled.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+		grpcServerDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor records the streams handled by a gRPC server.
+// Streams may stay open for hours, so their duration is not recorded.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		streams := grpcServerStreams.WithLabelValues(info.FullMethod)
+		streams.Inc()
+		defer streams.Dec()
+
+		err := handler(srv, &countingStream{ServerStream: ss, sent: grpcServerStreamMsgsSent.WithLabelValues(info.FullMethod)})
+
+		grpcServerHandled.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+
+		return err
+	}
+}
+
+type countingStream struct {
+	grpc.ServerStream
+	sent prometheus.Counter
+}
+
+func (cs *countingStream) SendMsg(m any) error {
+	err := cs.ServerStream.SendMsg(m)
+	if err == nil {
+		cs.sent.Inc()
+	}
+	return err
+}
+
+// UnaryClientInterceptor records the calls made by a gRPC client.
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		grpcClientHandled.WithLabelValues(method, status.Code(err).String()).Inc()
+		grpcClientDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
@@ -0,0 +1,361 @@
+// Package migrate applies the SQL migrations embedded in the service
+// binaries, see package migrations.
+//
+// The applied version is kept in schema_migrations like the migrate CLI
+// does, so databases migrated with the CLI can be taken over. Migrations run
+// while holding a Postgres advisory lock, so replicas starting at the same
+// time apply them once, and every migration runs in its own transaction.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+)
+
+// lockID identifies the advisory lock held while migrating.
+const lockID int64 = 7_318_446_201
+
+var (
+	// ErrSchemaAhead means the database was migrated by a newer binary,
+	// which this one may not be compatible with.
+	ErrSchemaAhead = errors.New("database schema is newer than this binary")
+	// ErrDirty means a migration failed halfway, which has to be fixed by
+	// hand. Only migrations applied outside of transactions can leave the
+	// schema dirty, e.g. by the migrate CLI.
+	ErrDirty = errors.New("database schema is dirty")
+)
+
+var fileName = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// Migration is a schema change read from <version>_<name>.up.sql and
+// <version>_<name>.down.sql.
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	// Current is the applied version, 0 if none is.
+	Current uint64
+	Dirty   bool
+	// Latest is the version of the newest migration of this binary.
+	Latest  uint64
+	Pending []Migration
+}
+
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	logger     *slog.Logger
+}
+
+// New reads the migrations in the root of fsys.
+func New(db *sql.DB, fsys fs.FS, logger *slog.Logger) (*Migrator, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration version in %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		logger:     logger.With("file", "migrate.go"),
+	}, nil
+}
+
+// Startup applies the pending migrations if apply is set. Either way it
+// fails if the schema is ahead of the binary or dirty.
+func (m *Migrator) Startup(ctx context.Context, apply bool) error {
+	if apply {
+		return m.Up(ctx)
+	}
+
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if err := m.check(status.Current, status.Dirty); err != nil {
+		return err
+	}
+	if len(status.Pending) > 0 {
+		m.logger.Warn("Database schema is behind, pending migrations are not applied", "version", status.Current, "latest", status.Latest)
+	}
+	return nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.To(ctx, m.latest())
+}
+
+// Down reverts the last applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.check(current, dirty); err != nil {
+			return err
+		}
+		if current == 0 {
+			return nil
+		}
+		return m.migrate(ctx, conn, current, m.previous(current))
+	})
+}
+
+// To migrates up or down to version, 0 reverts all migrations.
+func (m *Migrator) To(ctx context.Context, version uint64) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.locked(ctx, func(conn *sql.Conn) error {
+		current, dirty, err := m.current(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if err := m.check(current, dirty); err != nil {
+			return err
+		}
+		return m.migrate(ctx, conn, current, version)
+	})
+}
+
+func (m *Migrator) Status(ctx context.Context) (Status, error) {
+	var status Status
+	err := m.locked(ctx, func(conn *sql.Conn) error {
+		var err error
+		status.Current, status.Dirty, err = m.current(ctx, conn)
+		return err
+	})
+	if err != nil {
+		return Status{}, err
+	}
+
+	status.Latest = m.latest()
+	for _, migration := range m.migrations {
+		if migration.Version > status.Current {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Command runs the migrate subcommand of the services:
+//
+//	migrate up       apply all pending migrations
+//	migrate down     revert the last migration
+//	migrate status   print the applied and the pending migrations
+//	migrate to N     migrate up or down to version N
+func (m *Migrator) Command(ctx context.Context, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down|status|to N")
+	}
+
+	switch args[0] {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		return m.Down(ctx)
+	case "to":
+		if len(args) != 2 {
+			return errors.New("usage: migrate to N")
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		return m.To(ctx, version)
+	case "status":
+		status, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "version %d of %d", status.Current, status.Latest)
+		if status.Dirty {
+			fmt.Fprint(out, " (dirty)")
+		}
+		if status.Current > status.Latest {
+			fmt.Fprint(out, " (ahead of this binary)")
+		}
+		fmt.Fprintln(out)
+		for _, migration := range status.Pending {
+			fmt.Fprintf(out, "pending %d_%s\n", migration.Version, migration.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+// migrate applies the up migrations after from up to to, or the down
+// migrations from from down to the one after to.
+func (m *Migrator) migrate(ctx context.Context, conn *sql.Conn, from, to uint64) error {
+	for _, migration := range m.migrations {
+		if migration.Version <= from || migration.Version > to {
+			continue
+		}
+		if err := m.apply(ctx, conn, migration.Up, migration.Version); err != nil {
+			return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+		}
+		m.logger.Info("Migration applied", "version", migration.Version, "name", migration.Name)
+	}
+
+	for _, migration := range slices.Backward(m.migrations) {
+		if migration.Version > from || migration.Version <= to {
+			continue
+		}
+		if migration.Down == "" {
+			return fmt.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+		if err := m.apply(ctx, conn, migration.Down, m.previous(migration.Version)); err != nil {
+			return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+		}
+		m.logger.Info("Migration reverted", "version", migration.Version, "name", migration.Name)
+	}
+
+	return nil
+}
+
+// apply runs the statements and records version in one transaction.
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, statements string, version uint64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, statements); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations`); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)`, version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// locked runs fn on a connection that holds the migration lock.
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, lockID); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock($1)`, lockID)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL)`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func (m *Migrator) current(ctx context.Context, conn *sql.Conn) (uint64, bool, error) {
+	var version uint64
+	var dirty bool
+	err := conn.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	return version, dirty, err
+}
+
+// check refuses to migrate from a dirty schema or one this binary doesn't
+// know.
+func (m *Migrator) check(current uint64, dirty bool) error {
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirty, current)
+	}
+	if current > m.latest() {
+		return fmt.Errorf("%w: version %d, latest known %d", ErrSchemaAhead, current, m.latest())
+	}
+	if current != 0 && m.index(current) < 0 {
+		return fmt.Errorf("database schema has unknown version %d", current)
+	}
+	return nil
+}
+
+func (m *Migrator) latest() uint64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// previous returns the version before version, 0 for the first one.
+func (m *Migrator) previous(version uint64) uint64 {
+	if i := m.index(version); i > 0 {
+		return m.migrations[i-1].Version
+	}
+	return 0
+}
+
+func (m *Migrator) index(version uint64) int {
+	return slices.IndexFunc(m.migrations, func(migration Migration) bool {
+		return migration.Version == version
+	})
+}
@@ -0,0 +1,142 @@
+package migrate
+
+import (
+	"ecommerce-platform/migrations"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestNew(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	tests := []struct {
+		name         string
+		fsys         fstest.MapFS
+		wantErr      string
+		wantVersions []uint64
+	}{
+		{
+			name: "sorted by version",
+			fsys: fstest.MapFS{
+				"000010_add_returns.up.sql":   file("CREATE TABLE returns ()"),
+				"000002_add_orders.up.sql":    file("CREATE TABLE orders ()"),
+				"000002_add_orders.down.sql":  file("DROP TABLE orders"),
+				"000001_init.up.sql":          file("CREATE TABLE products ()"),
+				"README.md":                   file("not a migration"),
+				"000003_no_direction.sql":     file("ignored"),
+				"000010_add_returns.down.sql": file("DROP TABLE returns"),
+			},
+			wantVersions: []uint64{1, 2, 10},
+		},
+		{
+			name:    "down without up",
+			fsys:    fstest.MapFS{"000001_init.down.sql": file("DROP TABLE products")},
+			wantErr: "has no up file",
+		},
+		{
+			name: "two names for a version",
+			fsys: fstest.MapFS{
+				"000001_init.up.sql":    file("CREATE TABLE products ()"),
+				"000001_initial.up.sql": file("CREATE TABLE products ()"),
+			},
+			wantErr: "has two names",
+		},
+		{
+			name:    "version 0",
+			fsys:    fstest.MapFS{"000000_init.up.sql": file("CREATE TABLE products ()")},
+			wantErr: "invalid migration version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(nil, tt.fsys, slog.New(slog.DiscardHandler))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+
+			var versions []uint64
+			for _, migration := range m.migrations {
+				versions = append(versions, migration.Version)
+			}
+			if len(versions) != len(tt.wantVersions) {
+				t.Fatalf("versions %v, want %v", versions, tt.wantVersions)
+			}
+			for i := range versions {
+				if versions[i] != tt.wantVersions[i] {
+					t.Errorf("versions %v, want %v", versions, tt.wantVersions)
+				}
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	m, err := New(nil, fstest.MapFS{
+		"000001_init.up.sql":     {Data: []byte("CREATE TABLE products ()")},
+		"000003_orders.up.sql":   {Data: []byte("CREATE TABLE orders ()")},
+		"000003_orders.down.sql": {Data: []byte("DROP TABLE orders")},
+	}, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		current uint64
+		dirty   bool
+		wantErr error
+		wantMsg string
+	}{
+		{name: "empty database", current: 0},
+		{name: "known version", current: 1},
+		{name: "latest version", current: 3},
+		{name: "dirty", current: 3, dirty: true, wantErr: ErrDirty},
+		{name: "ahead of the binary", current: 4, wantErr: ErrSchemaAhead},
+		{name: "unknown version", current: 2, wantMsg: "unknown version 2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := m.check(tt.current, tt.dirty)
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("error %v, want %v", err, tt.wantErr)
+				}
+			case tt.wantMsg != "":
+				if err == nil || !strings.Contains(err.Error(), tt.wantMsg) {
+					t.Errorf("error %v, want %q", err, tt.wantMsg)
+				}
+			case err != nil:
+				t.Errorf("error %v, want none", err)
+			}
+		})
+	}
+}
+
+// The embedded migrations have to be readable, and every one of them
+// revertible.
+func TestEmbeddedMigrations(t *testing.T) {
+	m, err := New(nil, migrations.FS, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if len(m.migrations) == 0 {
+		t.Fatal("no migrations embedded")
+	}
+	for _, migration := range m.migrations {
+		if strings.TrimSpace(migration.Down) == "" {
+			t.Errorf("migration %d_%s has no down file", migration.Version, migration.Name)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+// Package mtls provides the mutual TLS configuration for service-to-service
+// calls. Certificates are reloaded when their files change, so they can be
+// rotated without restarting the services.
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"ecommerce-platform/internal/config"
+)
+
+// Reloader keeps the current certificate and CA pool and replaces them when
+// the files change.
+type Reloader struct {
+	cfg    config.TLS
+	logger *slog.Logger
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	roots   *x509.CertPool
+	modTime time.Time
+}
+
+// NewReloader loads the certificates. They are only reloaded while Watch
+// runs.
+func NewReloader(cfg config.TLS, logger *slog.Logger) (*Reloader, error) {
+	r := &Reloader{cfg: cfg, logger: logger.With("file", "mtls.go")}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Watch checks the files for changes every interval until ctx is done.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !r.changed() {
+			continue
+		}
+		// Keep the old certificates if the new ones are broken, e.g.
+		// because only one of the files was written yet.
+		if err := r.reload(); err != nil {
+			r.logger.Error("Could not reload certificates", "error", err)
+			continue
+		}
+		r.logger.Info("Reloaded certificates", "cert_file", r.cfg.CertFile)
+	}
+}
+
+func (r *Reloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("loading key pair: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(r.cfg.CAFile)
+	if err != nil {
+		return err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return fmt.Errorf("no certificates in %s", r.cfg.CAFile)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.roots = roots
+	r.modTime = modTime
+
+	return nil
+}
+
+func (r *Reloader) changed() bool {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return modTime.After(r.modTime)
+}
+
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.CAFile} {
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *Reloader) current() (*tls.Certificate, *x509.CertPool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, r.roots
+}
+
+// ServerConfig returns a TLS config that requires clients to present a
+// certificate signed by the CA.
+func (r *Reloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		// Called for every handshake, so new connections pick up reloaded certificates.
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cert, roots := r.current()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS13,
+				Certificates: []tls.Certificate{*cert},
+				ClientCAs:    roots,
+				ClientAuth:   tls.RequireAndVerifyClientCert,
+			}, nil
+		},
+	}
+}
+
+// ClientConfig returns a TLS config that presents the service certificate and
+// verifies that the server's certificate is signed by the CA and valid for
+// serverName.
+func (r *Reloader) ClientConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		ServerName: serverName,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := r.current()
+			return cert, nil
+		},
+		// The default verification would use the CA pool from when the config
+		// was created, so verification is done in VerifyConnection with the
+		// current pool instead.
+		InsecureSkipVerify: true,
+		VerifyConnection: func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("server presented no certificate")
+			}
+
+			_, roots := r.current()
+			intermediates := x509.NewCertPool()
+			for _, cert := range cs.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+
+			_, err := cs.PeerCertificates[0].Verify(x509.VerifyOptions{
+				DNSName:       serverName,
+				Roots:         roots,
+				Intermediates: intermediates,
+			})
+			return err
+		},
+	}
+}
@@ -0,0 +1,182 @@
+package mtls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ecommerce-platform/internal/config"
+)
+
+// writeCerts creates a CA and certificates for the services in dir, like
+// cmd/devcerts, and returns the configuration of each service.
+func writeCerts(t *testing.T, dir string, services ...string) map[string]config.TLS {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(dir, "ca.pem")
+	writePEM(t, caFile, "CERTIFICATE", caDER)
+
+	configs := make(map[string]config.TLS)
+	for i, name := range services {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 2)),
+			Subject:      pkix.Name{CommonName: name},
+			DNSNames:     []string{name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cfg := config.TLS{
+			CertFile: filepath.Join(dir, name+".pem"),
+			KeyFile:  filepath.Join(dir, name+"-key.pem"),
+			CAFile:   caFile,
+		}
+		writePEM(t, cfg.CertFile, "CERTIFICATE", der)
+		writePEM(t, cfg.KeyFile, "EC PRIVATE KEY", keyDER)
+		configs[name] = cfg
+	}
+	return configs
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newReloader(t *testing.T, cfg config.TLS) *Reloader {
+	t.Helper()
+	r, err := NewReloader(cfg, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// handshake connects client to server over an in-memory connection and
+// returns the error of the server side.
+func handshake(server, client *tls.Config) error {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	go func() {
+		tls.Client(clientConn, client).Handshake()
+		clientConn.Close()
+	}()
+	return tls.Server(serverConn, server).Handshake()
+}
+
+func TestHandshake(t *testing.T) {
+	certs := writeCerts(t, t.TempDir(), "inventory-service", "order-service")
+	otherCerts := writeCerts(t, t.TempDir(), "order-service")
+
+	server := newReloader(t, certs["inventory-service"])
+
+	tests := []struct {
+		name    string
+		client  *tls.Config
+		wantErr bool
+	}{
+		{name: "certificate of the CA", client: newReloader(t, certs["order-service"]).ClientConfig("inventory-service")},
+		{name: "certificate of another CA", client: newReloader(t, otherCerts["order-service"]).ClientConfig("inventory-service"), wantErr: true},
+		{name: "no certificate", client: &tls.Config{InsecureSkipVerify: true, MinVersion: tls.VersionTLS13}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := handshake(server.ServerConfig(), tt.client); (err != nil) != tt.wantErr {
+				t.Errorf("handshake error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReloaderWatch(t *testing.T) {
+	dir := t.TempDir()
+	cfg := writeCerts(t, dir, "order-service")["order-service"]
+	r := newReloader(t, cfg)
+	before, _ := r.current()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		r.Watch(ctx, 10*time.Millisecond)
+		close(stopped)
+	}()
+
+	// Rotated files have a later modification time.
+	writeCerts(t, dir, "order-service")
+	later := time.Now().Add(time.Second)
+	for _, path := range []string{cfg.CertFile, cfg.KeyFile, cfg.CAFile} {
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if after, _ := r.current(); after != before {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("certificates were not reloaded")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Watch did not stop when its context was cancelled")
+	}
+}
@@ -0,0 +1,70 @@
+package mtls
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/go-chi/chi/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// PeerIdentity returns the name of the calling service, which is the common
+// name of its verified client certificate.
+func PeerIdentity(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+
+	return tlsInfo.State.VerifiedChains[0][0].Subject.CommonName, true
+}
+
+// UnaryPeerInterceptor restricts methods to the listed services. Methods that
+// are not listed may be called by every service with a valid certificate.
+func UnaryPeerInterceptor(allowed map[string][]string, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	logger = logger.With("file", "peer.go")
+
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := checkPeer(ctx, info.FullMethod, allowed, logger); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamPeerInterceptor is UnaryPeerInterceptor for streaming methods.
+func StreamPeerInterceptor(allowed map[string][]string, logger *slog.Logger) grpc.StreamServerInterceptor {
+	logger = logger.With("file", "peer.go")
+
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkPeer(ss.Context(), info.FullMethod, allowed, logger); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func checkPeer(ctx context.Context, method string, allowed map[string][]string, logger *slog.Logger) error {
+	identity, ok := PeerIdentity(ctx)
+	if !ok {
+		logger.With("request_id", middleware.GetReqID(ctx)).Error("Call without verified client certificate", "method", method)
+		return status.Error(codes.Unauthenticated, "client certificate required")
+	}
+
+	if services, restricted := allowed[method]; restricted && !slices.Contains(services, identity) {
+		logger.With("request_id", middleware.GetReqID(ctx)).Error("Service may not call method", "method", method, "peer", identity)
+		return status.Error(codes.PermissionDenied, "service may not call this method")
+	}
+
+	return nil
+}
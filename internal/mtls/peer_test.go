@@ -0,0 +1,79 @@
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"log/slog"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	restrictedMethod = "/test.Service/Adjust"
+	openMethod       = "/test.Service/Get"
+)
+
+// peerContext returns the context of a call from a client with a verified
+// certificate for name, or without certificate if name is empty.
+func peerContext(name string) context.Context {
+	var state tls.ConnectionState
+	if name != "" {
+		state.VerifiedChains = [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: name}}}}
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{State: state}})
+}
+
+type stream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s stream) Context() context.Context {
+	return s.ctx
+}
+
+func TestPeerInterceptors(t *testing.T) {
+	allowed := map[string][]string{restrictedMethod: {"order-service"}}
+	logger := slog.New(slog.DiscardHandler)
+	unary := UnaryPeerInterceptor(allowed, logger)
+	streaming := StreamPeerInterceptor(allowed, logger)
+
+	tests := []struct {
+		name     string
+		peer     string
+		method   string
+		wantCode codes.Code
+	}{
+		{name: "allowed service", peer: "order-service", method: restrictedMethod, wantCode: codes.OK},
+		{name: "other service", peer: "notification-service", method: restrictedMethod, wantCode: codes.PermissionDenied},
+		{name: "unrestricted method", peer: "notification-service", method: openMethod, wantCode: codes.OK},
+		{name: "no client certificate", method: openMethod, wantCode: codes.Unauthenticated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peerContext(tt.peer)
+
+			_, err := unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, func(context.Context, any) (any, error) {
+				return nil, nil
+			})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("unary: code %s, want %s", code, tt.wantCode)
+			}
+
+			err = streaming(nil, stream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: tt.method}, func(any, grpc.ServerStream) error {
+				return nil
+			})
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("stream: code %s, want %s", code, tt.wantCode)
+			}
+		})
+	}
+}
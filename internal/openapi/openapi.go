@@ -0,0 +1,265 @@
+// Package openapi serves the OpenAPI documents of the HTTP APIs and validates
+// requests against them.
+//
+// Only the subset of OpenAPI 3.0 the services use is understood: path
+// parameters, JSON request bodies, and schemas with type, format, enum,
+// nullable, required, properties, additionalProperties, items, the minimum,
+// maximum and length constraints. References may point to components/schemas
+// and components/parameters.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components struct {
+		Parameters map[string]*Parameter `json:"parameters"`
+		Schemas    map[string]*Schema    `json:"schemas"`
+	} `json:"components"`
+
+	// raw is served as is.
+	raw []byte
+}
+
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters"`
+	Get        *Operation   `json:"get"`
+	Put        *Operation   `json:"put"`
+	Post       *Operation   `json:"post"`
+	Patch      *Operation   `json:"patch"`
+	Delete     *Operation   `json:"delete"`
+}
+
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []*Parameter `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Enum                 []any              `json:"enum"`
+	Nullable             bool               `json:"nullable"`
+	ReadOnly             bool               `json:"readOnly"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*Schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *Schema            `json:"items"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	MinItems             *int               `json:"minItems"`
+	MaxItems             *int               `json:"maxItems"`
+}
+
+// Load parses an OpenAPI document and resolves its references.
+func Load(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse OpenAPI document: %w", err)
+	}
+	doc.raw = data
+
+	r := resolver{doc: &doc, seen: make(map[*Schema]bool)}
+	for _, schema := range doc.Components.Schemas {
+		r.walk(schema)
+	}
+	for _, param := range doc.Components.Parameters {
+		param.Schema = r.resolve(param.Schema)
+	}
+	for pattern, item := range doc.Paths {
+		r.resolveParameters(item.Parameters)
+		for method, op := range item.operations() {
+			r.resolveParameters(op.Parameters)
+			if op.RequestBody == nil {
+				continue
+			}
+			media, ok := op.RequestBody.Content["application/json"]
+			if !ok || media.Schema == nil {
+				return nil, fmt.Errorf("%s %s: request body without application/json schema", method, pattern)
+			}
+			media.Schema = r.resolve(media.Schema)
+		}
+	}
+	if len(r.missing) > 0 {
+		return nil, fmt.Errorf("unknown references: %s", strings.Join(r.missing, ", "))
+	}
+
+	return &doc, nil
+}
+
+// ServeHTTP serves the document as it was loaded.
+func (d *Document) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(d.raw)
+}
+
+// operation returns the operation for the method on the chi route pattern.
+// Path parameters declared on the path item are merged into its parameters.
+func (d *Document) operation(method, pattern string) (*Operation, []*Parameter) {
+	item, ok := d.Paths[normalize(pattern)]
+	if !ok {
+		return nil, nil
+	}
+	op := item.operations()[strings.ToUpper(method)]
+	if op == nil {
+		return nil, nil
+	}
+	return op, append(slices.Clip(item.Parameters), op.Parameters...)
+}
+
+// Check compares the document with the routes of router. It reports routes
+// without an operation and operations without a route. Routes below the skip
+// patterns, e.g. "/metrics", are left out of the comparison.
+func (d *Document) Check(router chi.Routes, skip ...string) error {
+	skipped := func(pattern string) bool {
+		for _, prefix := range skip {
+			if pattern == prefix || strings.HasPrefix(pattern, strings.TrimSuffix(prefix, "/")+"/") {
+				return true
+			}
+		}
+		return false
+	}
+
+	routes := make(map[string]bool)
+	var problems []string
+	err := chi.Walk(router, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		route = normalize(route)
+		if skipped(route) {
+			return nil
+		}
+		routes[method+" "+route] = true
+		if op, _ := d.operation(method, route); op == nil {
+			problems = append(problems, "undocumented route "+method+" "+route)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for pattern, item := range d.Paths {
+		if skipped(pattern) {
+			continue
+		}
+		for method := range item.operations() {
+			if !routes[method+" "+pattern] {
+				problems = append(problems, "no route for operation "+method+" "+pattern)
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return fmt.Errorf("OpenAPI document does not match the routes: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (p *PathItem) operations() map[string]*Operation {
+	ops := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		http.MethodGet:    p.Get,
+		http.MethodPut:    p.Put,
+		http.MethodPost:   p.Post,
+		http.MethodPatch:  p.Patch,
+		http.MethodDelete: p.Delete,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+// normalize strips the trailing slash chi keeps for "/" routes in a
+// sub-router, e.g. "/orders/".
+func normalize(pattern string) string {
+	if len(pattern) > 1 {
+		return strings.TrimSuffix(pattern, "/")
+	}
+	return pattern
+}
+
+// resolver replaces $ref schemas and parameters with the ones they point to.
+type resolver struct {
+	doc     *Document
+	seen    map[*Schema]bool
+	missing []string
+}
+
+func (r *resolver) resolve(s *Schema) *Schema {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/")
+		target, found := r.doc.Components.Schemas[name]
+		if !ok || !found {
+			r.missing = append(r.missing, s.Ref)
+			return s
+		}
+		s = target
+	}
+	r.walk(s)
+	return s
+}
+
+func (r *resolver) resolveParameters(params []*Parameter) {
+	for i, param := range params {
+		if param.Ref != "" {
+			name, ok := strings.CutPrefix(param.Ref, "#/components/parameters/")
+			target, found := r.doc.Components.Parameters[name]
+			if !ok || !found {
+				r.missing = append(r.missing, param.Ref)
+				continue
+			}
+			params[i] = target
+			continue
+		}
+		param.Schema = r.resolve(param.Schema)
+	}
+}
+
+func (r *resolver) walk(s *Schema) {
+	if s == nil || r.seen[s] {
+		return
+	}
+	r.seen[s] = true
+	for name, property := range s.Properties {
+		s.Properties[name] = r.resolve(property)
+	}
+	s.Items = r.resolve(s.Items)
+}
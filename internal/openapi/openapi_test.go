@@ -0,0 +1,182 @@
+package openapi
+
+import (
+	"ecommerce-platform/internal/apperr"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+const document = `{
+	"openapi": "3.0.3",
+	"paths": {
+		"/orders": {
+			"post": {
+				"operationId": "createOrder",
+				"requestBody": {
+					"required": true,
+					"content": {"application/json": {"schema": {"$ref": "#/components/schemas/CreateOrder"}}}
+				}
+			}
+		},
+		"/orders/{id}": {
+			"parameters": [{"$ref": "#/components/parameters/ID"}],
+			"get": {"operationId": "getOrder"}
+		}
+	},
+	"components": {
+		"parameters": {
+			"ID": {"name": "id", "in": "path", "required": true, "schema": {"type": "string", "format": "uuid"}}
+		},
+		"schemas": {
+			"CreateOrder": {
+				"type": "object",
+				"required": ["items"],
+				"additionalProperties": false,
+				"properties": {
+					"id": {"type": "string", "readOnly": true},
+					"items": {"type": "array", "minItems": 1, "items": {"$ref": "#/components/schemas/Item"}},
+					"note": {"type": "string", "nullable": true, "maxLength": 5}
+				}
+			},
+			"Item": {
+				"type": "object",
+				"required": ["productId", "quantity"],
+				"properties": {
+					"productId": {"type": "string", "format": "uuid"},
+					"quantity": {"type": "integer", "minimum": 0, "exclusiveMinimum": true},
+					"gift": {"type": "boolean"},
+					"speed": {"type": "string", "enum": ["standard", "express"]}
+				}
+			}
+		}
+	}
+}`
+
+const productID = "0b6e3a4c-5f8e-4a51-9c1e-2a3b4c5d6e7f"
+
+func TestValidator(t *testing.T) {
+	doc, err := Load([]byte(document))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	r := chi.NewRouter()
+	r.Group(func(r chi.Router) {
+		r.Use(NewValidator(doc, slog.New(slog.DiscardHandler)).Middleware(r))
+		r.Post("/orders", func(w http.ResponseWriter, r *http.Request) {
+			// The handler gets the body the validator read.
+			io.Copy(w, r.Body)
+		})
+		r.Get("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {})
+		r.Get("/undocumented", func(w http.ResponseWriter, r *http.Request) {})
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantFields []apperr.FieldError
+	}{
+		{name: "valid body", method: http.MethodPost, path: "/orders", body: `{"items": [{"productId": "` + productID + `", "quantity": 2, "speed": "express"}], "note": null}`},
+		{name: "read-only field is ignored", method: http.MethodPost, path: "/orders", body: `{"id": 1, "items": [{"productId": "` + productID + `", "quantity": 1}]}`},
+		{name: "missing body", method: http.MethodPost, path: "/orders", wantFields: []apperr.FieldError{{Message: "request body is required"}}},
+		{name: "malformed body", method: http.MethodPost, path: "/orders", body: `{"items": [`, wantFields: []apperr.FieldError{{Message: "request body is not valid JSON"}}},
+		{
+			name:   "invalid fields",
+			method: http.MethodPost,
+			path:   "/orders",
+			body:   `{"items": [{"productId": "p-1", "quantity": 1.5, "gift": "yes", "speed": "fast"}, {"quantity": 0}], "note": "too long", "coupon": "X"}`,
+			wantFields: []apperr.FieldError{
+				{Field: "coupon", Message: "is not allowed"},
+				{Field: "items[0].gift", Message: "must be a boolean"},
+				{Field: "items[0].productId", Message: "must be a UUID"},
+				{Field: "items[0].quantity", Message: "must be an integer"},
+				{Field: "items[0].speed", Message: "must be one of standard, express"},
+				{Field: "items[1].productId", Message: "is required"},
+				{Field: "items[1].quantity", Message: "must be greater than 0"},
+				{Field: "note", Message: "must be at most 5 characters long"},
+			},
+		},
+		{name: "empty array", method: http.MethodPost, path: "/orders", body: `{"items": []}`, wantFields: []apperr.FieldError{{Field: "items", Message: "must have at least 1 item"}}},
+		{name: "valid path parameter", method: http.MethodGet, path: "/orders/" + productID},
+		{name: "invalid path parameter", method: http.MethodGet, path: "/orders/42", wantFields: []apperr.FieldError{{Field: "id", Message: "must be a UUID"}}},
+		{name: "undocumented route", method: http.MethodGet, path: "/undocumented"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+
+			if len(tt.wantFields) == 0 {
+				if w.Code != http.StatusOK {
+					t.Fatalf("status %d, want 200: %s", w.Code, w.Body)
+				}
+				if w.Body.String() != tt.body {
+					t.Errorf("handler got body %q, want %q", w.Body, tt.body)
+				}
+				return
+			}
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status %d, want 400", w.Code)
+			}
+			var problem apperr.Problem
+			if err := json.NewDecoder(w.Body).Decode(&problem); err != nil {
+				t.Fatal(err)
+			}
+			// Required fields are reported before the others of their object.
+			slices.SortFunc(problem.Errors, func(a, b apperr.FieldError) int { return strings.Compare(a.Field, b.Field) })
+			if !slices.Equal(problem.Errors, tt.wantFields) {
+				t.Errorf("fields\n%v\nwant\n%v", problem.Errors, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	doc, err := Load([]byte(document))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	r := chi.NewRouter()
+	r.Route("/orders", func(r chi.Router) {
+		r.Post("/", handler)
+		r.Get("/{id}", handler)
+	})
+	r.Get("/metrics", handler)
+	if err := doc.Check(r, "/metrics"); err != nil {
+		t.Errorf("Check: %v", err)
+	}
+
+	r.Delete("/orders/{id}", handler)
+	err = doc.Check(r, "/metrics")
+	if err == nil || !strings.Contains(err.Error(), "undocumented route DELETE /orders/{id}") {
+		t.Errorf("Check: %v, want the undocumented route", err)
+	}
+
+	missing := chi.NewRouter()
+	missing.Post("/orders", handler)
+	err = doc.Check(missing)
+	if err == nil || !strings.Contains(err.Error(), "no route for operation GET /orders/{id}") {
+		t.Errorf("Check: %v, want the operation without route", err)
+	}
+}
+
+func TestLoadUnknownReference(t *testing.T) {
+	_, err := Load([]byte(`{"paths": {"/orders/{id}": {"parameters": [{"$ref": "#/components/parameters/Missing"}], "get": {}}}}`))
+	if err == nil || !strings.Contains(err.Error(), "#/components/parameters/Missing") {
+		t.Errorf("Load: %v, want the unknown reference", err)
+	}
+}
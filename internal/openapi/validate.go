@@ -0,0 +1,312 @@
+package openapi
+
+import (
+	"bytes"
+	"ecommerce-platform/internal/apperr"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+)
+
+// maxBodyBytes limits the request bodies read for validation.
+const maxBodyBytes = 1 << 20
+
+// Validator rejects requests that don't match the operation of their route
+// with 400 Bad Request and the invalid fields.
+type Validator struct {
+	doc    *Document
+	logger *slog.Logger
+}
+
+func NewValidator(doc *Document, logger *slog.Logger) *Validator {
+	return &Validator{
+		doc:    doc,
+		logger: logger.With("file", "validate.go"),
+	}
+}
+
+// Middleware validates the path parameters and JSON bodies of requests to
+// the routes of router. Requests to routes the document doesn't describe are
+// passed through; Document.Check finds those at startup.
+func (v *Validator) Middleware(router chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Routing has not happened yet, so look up the pattern the
+			// request is going to match, like auth.Policy does.
+			rctx := chi.NewRouteContext()
+			if !router.Match(rctx, r.Method, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			op, params := v.doc.operation(r.Method, rctx.RoutePattern())
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var errs []apperr.FieldError
+			for _, param := range params {
+				if param.In != "path" || param.Schema == nil {
+					continue
+				}
+				param.Schema.validate(param.Name, rctx.URLParam(param.Name), &errs)
+			}
+
+			if op.RequestBody != nil {
+				body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+				r.Body.Close()
+				if err != nil {
+					v.reject(w, r, []apperr.FieldError{{Message: "request body could not be read"}})
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+
+				switch value, err := decode(body); {
+				case err != nil:
+					errs = append(errs, apperr.FieldError{Message: err.Error()})
+				case value == nil && len(bytes.TrimSpace(body)) == 0:
+					if op.RequestBody.Required {
+						errs = append(errs, apperr.FieldError{Message: "request body is required"})
+					}
+				default:
+					op.RequestBody.Content["application/json"].Schema.validate("", value, &errs)
+				}
+			}
+
+			if len(errs) > 0 {
+				v.reject(w, r, errs)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (v *Validator) reject(w http.ResponseWriter, r *http.Request, errs []apperr.FieldError) {
+	v.logger.Info("Rejected invalid request", "request_id", middleware.GetReqID(r.Context()),
+		"method", r.Method, "path", r.URL.Path, "fields", errs)
+
+	apperr.Write(w, r, apperr.ErrInvalidRequest.WithFields(errs...))
+}
+
+// decode parses a JSON body. Numbers are kept as json.Number, so integers
+// can be told apart from fractions.
+func decode(body []byte) (any, error) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil, errors.New("request body is not valid JSON")
+	}
+	if dec.More() {
+		return nil, errors.New("request body has data after the JSON value")
+	}
+	return value, nil
+}
+
+// validate appends an error for every part of value that doesn't match the
+// schema. Path parameters are validated as strings against their schema.
+func (s *Schema) validate(field string, value any, errs *[]apperr.FieldError) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, apperr.FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if value == nil {
+		if !s.Nullable {
+			fail("must not be null")
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			fail("must be an object")
+			return
+		}
+		s.validateObject(field, object, errs)
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			fail("must be an array")
+			return
+		}
+		if s.MinItems != nil && len(array) < *s.MinItems {
+			fail("must have at least %d %s", *s.MinItems, plural(*s.MinItems, "item"))
+		}
+		if s.MaxItems != nil && len(array) > *s.MaxItems {
+			fail("must have at most %d %s", *s.MaxItems, plural(*s.MaxItems, "item"))
+		}
+		if s.Items != nil {
+			for i, item := range array {
+				s.Items.validate(fmt.Sprintf("%s[%d]", field, i), item, errs)
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			fail("must be a string")
+			return
+		}
+		s.validateString(str, fail)
+	case "integer", "number":
+		number, ok := value.(json.Number)
+		if !ok {
+			// Path parameters arrive as strings.
+			str, isString := value.(string)
+			if !isString {
+				fail("must be a number")
+				return
+			}
+			number = json.Number(str)
+		}
+		f, err := number.Float64()
+		if err != nil {
+			fail("must be a number")
+			return
+		}
+		if s.Type == "integer" {
+			if _, err := strconv.ParseInt(number.String(), 10, 64); err != nil {
+				fail("must be an integer")
+				return
+			}
+		}
+		s.validateNumber(f, fail)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			fail("must be a boolean")
+			return
+		}
+	}
+
+	if len(s.Enum) > 0 && !slices.ContainsFunc(s.Enum, func(e any) bool { return equal(e, value) }) {
+		values := make([]string, 0, len(s.Enum))
+		for _, e := range s.Enum {
+			values = append(values, fmt.Sprint(e))
+		}
+		fail("must be one of %s", strings.Join(values, ", "))
+	}
+}
+
+func (s *Schema) validateObject(field string, object map[string]any, errs *[]apperr.FieldError) {
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			*errs = append(*errs, apperr.FieldError{Field: join(field, name), Message: "is required"})
+		}
+	}
+
+	// Sorted, so the errors come in a stable order.
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		property, ok := s.Properties[name]
+		switch {
+		case !ok:
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				*errs = append(*errs, apperr.FieldError{Field: join(field, name), Message: "is not allowed"})
+			}
+		case property.ReadOnly:
+			// Set by the service; whatever the client sends is ignored.
+		default:
+			property.validate(join(field, name), object[name], errs)
+		}
+	}
+}
+
+func (s *Schema) validateString(str string, fail func(format string, args ...any)) {
+	length := utf8.RuneCountInString(str)
+	if s.MinLength != nil && length < *s.MinLength {
+		if *s.MinLength == 1 {
+			fail("must not be empty")
+		} else {
+			fail("must be at least %d characters long", *s.MinLength)
+		}
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		fail("must be at most %d %s long", *s.MaxLength, plural(*s.MaxLength, "character"))
+	}
+
+	switch s.Format {
+	case "uuid":
+		if len(str) != 36 || uuid.Validate(str) != nil {
+			fail("must be a UUID")
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			fail("must be an RFC 3339 date-time")
+		}
+	}
+}
+
+func (s *Schema) validateNumber(f float64, fail func(format string, args ...any)) {
+	if s.Minimum != nil {
+		switch {
+		case s.ExclusiveMinimum && f <= *s.Minimum:
+			fail("must be greater than %s", formatNumber(*s.Minimum))
+		case f < *s.Minimum:
+			fail("must be at least %s", formatNumber(*s.Minimum))
+		}
+	}
+	if s.Maximum != nil {
+		switch {
+		case s.ExclusiveMaximum && f >= *s.Maximum:
+			fail("must be less than %s", formatNumber(*s.Maximum))
+		case f > *s.Maximum:
+			fail("must be at most %s", formatNumber(*s.Maximum))
+		}
+	}
+}
+
+// equal compares an enum value of the document with a request value.
+func equal(enum, value any) bool {
+	if number, ok := value.(json.Number); ok {
+		f, err := number.Float64()
+		e, isNumber := enum.(float64)
+		return err == nil && isNumber && e == f
+	}
+	return enum == value
+}
+
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+func formatNumber(f float64) string {
+	if f == math.Trunc(f) {
+		return strconv.FormatFloat(f, 'f', 0, 64)
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
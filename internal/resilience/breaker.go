@@ -0,0 +1,154 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the state of a circuit breaker.
+type State int
+
+const (
+	// Closed lets every call through.
+	Closed State = iota
+	// HalfOpen lets a single probe call through to find out whether the
+	// server is back.
+	HalfOpen
+	// Open fails calls without trying them.
+	Open
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half-open"
+	case Open:
+		return "open"
+	}
+	return "unknown"
+}
+
+// Outcome is what a call tells the breaker about the server.
+type Outcome int
+
+const (
+	// Success means the server answered, possibly with an error about the
+	// request.
+	Success Outcome = iota
+	// Failure means the server failed or did not answer in time.
+	Failure
+	// Canceled means the caller gave up, which says nothing about the
+	// server.
+	Canceled
+)
+
+// Breaker is a circuit breaker. It opens after a number of consecutive
+// failures, lets a probe call through once it has been open for a while and
+// closes again when the probe succeeds.
+type Breaker struct {
+	failureThreshold int
+	openTimeout      time.Duration
+	onChange         func(State)
+	now              func() time.Time
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// NewBreaker returns a closed breaker. onChange, if not nil, is called with
+// every new state while the breaker's lock is held, so it must not call the
+// breaker.
+func NewBreaker(failureThreshold int, openTimeout time.Duration, onChange func(State)) *Breaker {
+	return &Breaker{
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		onChange:         onChange,
+		now:              time.Now,
+	}
+}
+
+// State returns the current state. An open breaker whose timeout has passed
+// is still reported open until the next call probes the server.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a call may be made. If it may, done has to be called
+// with the outcome of the call. A canceled probe lets the next call probe.
+func (b *Breaker) Allow() (done func(Outcome), ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.openTimeout {
+			return nil, false
+		}
+		b.setState(HalfOpen)
+		b.probing = true
+		return b.probeDone, true
+	case HalfOpen:
+		if b.probing {
+			return nil, false
+		}
+		b.probing = true
+		return b.probeDone, true
+	}
+	return b.callDone, true
+}
+
+func (b *Breaker) callDone(outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state != Closed {
+		// A call from before the breaker opened; the probe decides.
+		return
+	}
+	switch outcome {
+	case Success:
+		b.failures = 0
+	case Failure:
+		b.failures++
+		if b.failures >= b.failureThreshold {
+			b.open()
+		}
+	}
+}
+
+func (b *Breaker) probeDone(outcome Outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	switch outcome {
+	case Success:
+		b.failures = 0
+		b.setState(Closed)
+	case Failure:
+		b.open()
+	}
+}
+
+func (b *Breaker) open() {
+	b.openedAt = b.now()
+	b.failures = 0
+	b.setState(Open)
+}
+
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+	b.state = state
+	if b.onChange != nil {
+		b.onChange(state)
+	}
+}
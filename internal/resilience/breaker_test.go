@@ -0,0 +1,137 @@
+package resilience
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+// call is a call through the breaker after advancing the clock.
+type call struct {
+	after    time.Duration
+	outcome  Outcome
+	rejected bool
+}
+
+func TestBreaker(t *testing.T) {
+	const openTimeout = 10 * time.Second
+
+	tests := []struct {
+		name        string
+		calls       []call
+		wantState   State
+		wantChanges []State
+	}{
+		{
+			name:      "successes keep it closed",
+			calls:     []call{{outcome: Success}, {outcome: Success}, {outcome: Success}},
+			wantState: Closed,
+		},
+		{
+			name:        "consecutive failures open it",
+			calls:       []call{{outcome: Failure}, {outcome: Failure}, {outcome: Failure}},
+			wantState:   Open,
+			wantChanges: []State{Open},
+		},
+		{
+			name:      "a success resets the failures",
+			calls:     []call{{outcome: Failure}, {outcome: Failure}, {outcome: Success}, {outcome: Failure}, {outcome: Failure}},
+			wantState: Closed,
+		},
+		{
+			name:        "canceled calls neither count nor reset the failures",
+			calls:       []call{{outcome: Failure}, {outcome: Failure}, {outcome: Canceled}, {outcome: Canceled}, {outcome: Failure}},
+			wantState:   Open,
+			wantChanges: []State{Open},
+		},
+		{
+			name:        "open rejects calls until the timeout passed",
+			calls:       []call{{outcome: Failure}, {outcome: Failure}, {outcome: Failure}, {after: openTimeout - time.Second, rejected: true}},
+			wantState:   Open,
+			wantChanges: []State{Open},
+		},
+		{
+			name:        "a successful probe closes it",
+			calls:       []call{{outcome: Failure}, {outcome: Failure}, {outcome: Failure}, {after: openTimeout, outcome: Success}},
+			wantState:   Closed,
+			wantChanges: []State{Open, HalfOpen, Closed},
+		},
+		{
+			name:        "a failed probe opens it again",
+			calls:       []call{{outcome: Failure}, {outcome: Failure}, {outcome: Failure}, {after: openTimeout, outcome: Failure}, {after: openTimeout - time.Second, rejected: true}},
+			wantState:   Open,
+			wantChanges: []State{Open, HalfOpen, Open},
+		},
+		{
+			name:        "a canceled probe lets the next call probe",
+			calls:       []call{{outcome: Failure}, {outcome: Failure}, {outcome: Failure}, {after: openTimeout, outcome: Canceled}, {outcome: Success}},
+			wantState:   Closed,
+			wantChanges: []State{Open, HalfOpen, Closed},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var changes []State
+			b := NewBreaker(3, openTimeout, func(state State) { changes = append(changes, state) })
+			now := time.Unix(0, 0)
+			b.now = func() time.Time { return now }
+
+			for i, c := range tt.calls {
+				now = now.Add(c.after)
+				done, ok := b.Allow()
+				if ok == c.rejected {
+					t.Fatalf("call %d: allowed %v, want %v", i, ok, !c.rejected)
+				}
+				if ok {
+					done(c.outcome)
+				}
+			}
+
+			if state := b.State(); state != tt.wantState {
+				t.Errorf("state %s, want %s", state, tt.wantState)
+			}
+			if !slices.Equal(changes, tt.wantChanges) {
+				t.Errorf("state changes %v, want %v", changes, tt.wantChanges)
+			}
+		})
+	}
+}
+
+func TestBreakerSingleProbe(t *testing.T) {
+	b := NewBreaker(1, time.Second, nil)
+	now := time.Unix(0, 0)
+	b.now = func() time.Time { return now }
+
+	done, _ := b.Allow()
+	done(Failure)
+
+	now = now.Add(time.Second)
+	probeDone, ok := b.Allow()
+	if !ok {
+		t.Fatal("probe rejected")
+	}
+	if _, ok := b.Allow(); ok {
+		t.Error("second call allowed while the probe is running")
+	}
+
+	probeDone(Success)
+	if _, ok := b.Allow(); !ok {
+		t.Error("call rejected after the probe succeeded")
+	}
+}
+
+func TestBreakerIgnoresCallsFromBeforeOpening(t *testing.T) {
+	b := NewBreaker(1, time.Second, nil)
+	now := time.Unix(0, 0)
+	b.now = func() time.Time { return now }
+
+	slowDone, _ := b.Allow()
+	done, _ := b.Allow()
+	done(Failure)
+
+	slowDone(Success)
+	if state := b.State(); state != Open {
+		t.Errorf("state %s after a late success, want %s", state, Open)
+	}
+}
@@ -0,0 +1,227 @@
+// Package resilience protects a service from a slow or failing gRPC server
+// it depends on. Every attempt of a call gets its own deadline, idempotent
+// calls are retried with jittered exponential backoff and can be hedged, and
+// a circuit breaker fails calls fast while the server keeps failing, so
+// callers don't queue up behind it.
+//
+// Only calls to methods named idempotent are retried or hedged; the server
+// may have applied a call whose answer got lost.
+package resilience
+
+import (
+	"context"
+	"ecommerce-platform/internal/config"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	breakerState = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "grpc_client_circuit_breaker_state",
+		Help: "State of the circuit breaker of a gRPC client by target: 0 closed, 1 half-open, 2 open.",
+	}, []string{"target"})
+
+	breakerRejected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_circuit_breaker_rejected_total",
+		Help: "gRPC calls failed by an open circuit breaker by target and method.",
+	}, []string{"target", "method"})
+
+	retries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_retries_total",
+		Help: "Retried gRPC calls by target and method.",
+	}, []string{"target", "method"})
+
+	hedges = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_client_hedges_total",
+		Help: "Hedged second attempts of gRPC calls by target and method.",
+	}, []string{"target", "method"})
+)
+
+// Client makes the calls of a gRPC client connection to target resilient.
+type Client struct {
+	target     string
+	cfg        config.GRPCClient
+	idempotent map[string]bool
+	breaker    *Breaker
+	logger     *slog.Logger
+}
+
+// New returns the resilience layer of the connection to target, e.g.
+// "inventory". idempotent are the full names of the methods that may be
+// retried and hedged.
+func New(target string, cfg config.GRPCClient, logger *slog.Logger, idempotent ...string) *Client {
+	c := &Client{
+		target:     target,
+		cfg:        cfg,
+		idempotent: make(map[string]bool),
+		logger:     logger.With("file", "resilience.go", "target", target),
+	}
+	for _, method := range idempotent {
+		c.idempotent[method] = true
+	}
+
+	c.breaker = NewBreaker(cfg.BreakerFailures, cfg.BreakerOpenTimeout, func(state State) {
+		breakerState.WithLabelValues(target).Set(float64(state))
+		c.logger.Warn("Circuit breaker changed state", "state", state.String())
+	})
+	breakerState.WithLabelValues(target).Set(float64(Closed))
+
+	return c
+}
+
+// Breaker returns the circuit breaker of the connection.
+func (c *Client) Breaker() *Breaker {
+	return c.breaker
+}
+
+// UnaryClientInterceptor applies the resilience layer to the calls of a
+// connection. It belongs after interceptor.UnaryClientDeadline, whose
+// deadline bounds all attempts of a call together.
+func (c *Client) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		attempts := 1
+		if c.idempotent[method] {
+			attempts = c.cfg.RetryAttempts
+		}
+
+		for attempt := 1; ; attempt++ {
+			done, ok := c.breaker.Allow()
+			if !ok {
+				breakerRejected.WithLabelValues(c.target, method).Inc()
+				return status.Errorf(codes.Unavailable, "circuit breaker of %s is open", c.target)
+			}
+
+			err := c.try(ctx, method, req, reply, cc, invoker, opts)
+			done(outcome(ctx, err))
+
+			if err == nil || attempt >= attempts || !retryable(err) || ctx.Err() != nil {
+				return err
+			}
+
+			delay := c.backoff(attempt)
+			c.logger.Warn("Retrying gRPC call", "request_id", middleware.GetReqID(ctx), "method", method,
+				"attempt", attempt, "delay_ms", delay.Milliseconds(), "error", err)
+			retries.WithLabelValues(c.target, method).Inc()
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// try makes one attempt of a call, hedged if enabled for the method.
+func (c *Client) try(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts []grpc.CallOption) error {
+	msg, ok := reply.(proto.Message)
+	if c.cfg.HedgeDelay <= 0 || !c.idempotent[method] || !ok {
+		return c.invoke(ctx, method, req, reply, cc, invoker, opts)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		reply proto.Message
+		err   error
+	}
+	// Every attempt decodes into its own message, as the loser may still be
+	// running when the winner is copied into reply.
+	results := make(chan result, 2)
+	send := func() {
+		attemptReply := proto.Clone(msg)
+		go func() {
+			results <- result{attemptReply, c.invoke(ctx, method, req, attemptReply, cc, invoker, opts)}
+		}()
+	}
+
+	send()
+	pending := 1
+	timer := time.NewTimer(c.cfg.HedgeDelay)
+	defer timer.Stop()
+	hedge := timer.C
+
+	for {
+		select {
+		case <-hedge:
+			hedge = nil
+			hedges.WithLabelValues(c.target, method).Inc()
+			send()
+			pending++
+		case r := <-results:
+			pending--
+			// A retryable failure waits for the other attempt, if there is one.
+			if r.err != nil && retryable(r.err) && pending > 0 {
+				continue
+			}
+			if r.err == nil {
+				proto.Reset(msg)
+				proto.Merge(msg, r.reply)
+			}
+			return r.err
+		}
+	}
+}
+
+// invoke makes a single attempt with the per-attempt deadline.
+func (c *Client) invoke(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts []grpc.CallOption) error {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.CallTimeout)
+	defer cancel()
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// backoff returns the delay before the next attempt, with full jitter.
+func (c *Client) backoff(attempt int) time.Duration {
+	delay := c.cfg.RetryBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > c.cfg.RetryMaxDelay {
+		delay = c.cfg.RetryMaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay)
+}
+
+// retryable reports whether another attempt of a failed call may succeed.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+// outcome classifies an attempt for the circuit breaker.
+func outcome(ctx context.Context, err error) Outcome {
+	switch {
+	case err == nil:
+		return Success
+	case ctx.Err() != nil:
+		return Canceled
+	case serverFailure(err):
+		return Failure
+	}
+	return Success
+}
+
+// serverFailure reports whether err means the server is unhealthy, as
+// opposed to rejecting the request.
+func serverFailure(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	}
+	return false
+}
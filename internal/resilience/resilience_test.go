@@ -0,0 +1,167 @@
+package resilience
+
+import (
+	"context"
+	"ecommerce-platform/internal/config"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	idempotentMethod = "/test.Service/Get"
+	otherMethod      = "/test.Service/Reserve"
+)
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	tests := []struct {
+		name   string
+		method string
+		// errs are the results of the attempts, the last one repeats.
+		errs []error
+		// cancel makes the caller give up during the first attempt.
+		cancel    bool
+		wantCalls int
+		wantCode  codes.Code
+		wantState State
+	}{
+		{
+			name:      "success",
+			method:    idempotentMethod,
+			errs:      []error{nil},
+			wantCalls: 1,
+			wantCode:  codes.OK,
+			wantState: Closed,
+		},
+		{
+			name:      "idempotent calls are retried",
+			method:    idempotentMethod,
+			errs:      []error{unavailable, nil},
+			wantCalls: 2,
+			wantCode:  codes.OK,
+			wantState: Closed,
+		},
+		{
+			name:      "retries stop after the configured attempts",
+			method:    idempotentMethod,
+			errs:      []error{unavailable},
+			wantCalls: 3,
+			wantCode:  codes.Unavailable,
+			wantState: Open,
+		},
+		{
+			name:      "other calls are not retried",
+			method:    otherMethod,
+			errs:      []error{unavailable},
+			wantCalls: 1,
+			wantCode:  codes.Unavailable,
+			wantState: Closed,
+		},
+		{
+			name:      "rejected requests are not retried",
+			method:    idempotentMethod,
+			errs:      []error{status.Error(codes.NotFound, "not found")},
+			wantCalls: 1,
+			wantCode:  codes.NotFound,
+			wantState: Closed,
+		},
+		{
+			name:      "internal errors are not retried",
+			method:    idempotentMethod,
+			errs:      []error{status.Error(codes.Internal, "internal")},
+			wantCalls: 1,
+			wantCode:  codes.Internal,
+			wantState: Closed,
+		},
+		{
+			name:      "canceled calls are neither retried nor counted",
+			method:    idempotentMethod,
+			errs:      []error{status.Error(codes.Canceled, "canceled")},
+			cancel:    true,
+			wantCalls: 1,
+			wantCode:  codes.Canceled,
+			wantState: Closed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := New("test", config.GRPCClient{
+				CallTimeout:        time.Second,
+				RetryAttempts:      3,
+				BreakerFailures:    3,
+				BreakerOpenTimeout: time.Minute,
+			}, slog.New(slog.DiscardHandler), idempotentMethod)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			calls := 0
+			invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				err := tt.errs[min(calls, len(tt.errs)-1)]
+				calls++
+				if tt.cancel {
+					cancel()
+				}
+				return err
+			}
+
+			err := c.UnaryClientInterceptor()(ctx, tt.method, nil, nil, nil, invoker)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("code %s, want %s", code, tt.wantCode)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("%d attempts, want %d", calls, tt.wantCalls)
+			}
+			if state := c.Breaker().State(); state != tt.wantState {
+				t.Errorf("breaker %s, want %s", state, tt.wantState)
+			}
+		})
+	}
+}
+
+func TestUnaryClientInterceptorFailsFastWhenOpen(t *testing.T) {
+	c := New("test", config.GRPCClient{
+		CallTimeout:        time.Second,
+		RetryAttempts:      1,
+		BreakerFailures:    1,
+		BreakerOpenTimeout: time.Minute,
+	}, slog.New(slog.DiscardHandler))
+	now := time.Unix(0, 0)
+	c.Breaker().now = func() time.Time { return now }
+
+	calls := 0
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		return status.Error(codes.Unavailable, "unavailable")
+	}
+	interceptor := c.UnaryClientInterceptor()
+
+	interceptor(context.Background(), otherMethod, nil, nil, nil, invoker)
+	if err := interceptor(context.Background(), otherMethod, nil, nil, nil, invoker); status.Code(err) != codes.Unavailable || calls != 1 {
+		t.Errorf("open breaker: error %v after %d attempts, want Unavailable after 1", err, calls)
+	}
+
+	// A probe whose caller gave up neither closes nor opens the breaker.
+	now = now.Add(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	interceptor(ctx, otherMethod, nil, nil, nil, func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		calls++
+		cancel()
+		return status.Error(codes.Canceled, "canceled")
+	})
+	if state := c.Breaker().State(); calls != 2 || state != HalfOpen {
+		t.Errorf("breaker %s after %d attempts, want %s after a canceled probe", state, calls, HalfOpen)
+	}
+
+	interceptor(context.Background(), otherMethod, nil, nil, nil, invoker)
+	if calls != 3 {
+		t.Errorf("%d attempts, want another probe", calls)
+	}
+}
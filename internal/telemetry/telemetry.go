@@ -0,0 +1,127 @@
+// Package telemetry sets up OpenTelemetry tracing for the services.
+//
+// The traces exporter of config.Telemetry selects where spans go:
+//
+//	otlp   OTLP over gRPC, configured with the standard OTEL_EXPORTER_OTLP_* variables
+//	stdout pretty printed JSON on stdout
+//	file   JSON to the traces file
+//	none   nowhere, the default; trace context is still propagated
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"ecommerce-platform/internal/config"
+
+	"github.com/go-chi/chi"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup installs the global tracer provider and propagator for the service.
+// The returned function flushes the spans that weren't exported yet and has
+// to be called before the service exits.
+func Setup(ctx context.Context, serviceName string, cfg config.Telemetry) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	exporter, closer, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName(serviceName)),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
+
+func newExporter(ctx context.Context, cfg config.Telemetry) (sdktrace.SpanExporter, io.Closer, error) {
+	switch cfg.TracesExporter {
+	case "", "none":
+		return nil, nil, nil
+	case "otlp":
+		e, err := otlptracegrpc.New(ctx)
+		return e, nil, err
+	case "stdout":
+		e, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
+		return e, nil, err
+	case "file":
+		if cfg.TracesFile == "" {
+			return nil, nil, errors.New("no traces file configured for the file exporter")
+		}
+		f, err := os.OpenFile(cfg.TracesFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		e, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return e, f, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown traces exporter %q", cfg.TracesExporter)
+	}
+}
+
+// Middleware creates a server span for every request. Spans are named after
+// the chi route pattern, e.g. "GET /orders/{id}", not the raw path.
+func Middleware(serviceName string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			// The pattern is only known once the router matched the request.
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				if pattern := rctx.RoutePattern(); pattern != "" {
+					span := trace.SpanFromContext(r.Context())
+					span.SetName(r.Method + " " + pattern)
+					span.SetAttributes(semconv.HTTPRoute(pattern))
+				}
+			}
+		})
+
+		return otelhttp.NewHandler(named, serviceName, otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			return r.Method
+		}))
+	}
+}
+
+// Transport wraps base so outgoing requests get a client span and carry the
+// trace context.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(base)
+}
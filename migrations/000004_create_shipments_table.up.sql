@@ -0,0 +1,58 @@
+CREATE TABLE shipments (
+    id UUID PRIMARY KEY,
+
+    -- The order this shipment fulfils. An order can be split across several
+    -- shipments (partial fulfillment), so this is not unique.
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+
+    -- The warehouse the items are picked from. Pick lists are grouped by this value.
+    warehouse VARCHAR(100) NOT NULL,
+
+    -- The order lines (product ID and quantity) packed into this shipment.
+    items JSONB NOT NULL,
+
+    -- Carrier and tracking number are recorded once the parcel is handed over.
+    carrier VARCHAR(100),
+    tracking_number VARCHAR(255),
+
+    -- The current status of the shipment (PENDING, SHIPPED, IN_TRANSIT, DELIVERED).
+    status VARCHAR(50) NOT NULL,
+
+    shipped_at TIMESTAMPTZ,
+    delivered_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index on order_id to quickly find all shipments of an order for the status roll-up.
+CREATE INDEX idx_shipments_order_id ON shipments (order_id);
+
+-- Index on status to quickly build pick lists from the PENDING shipments.
+CREATE INDEX idx_shipments_status ON shipments (status);
+
+CREATE TRIGGER update_shipments_updated_at
+BEFORE UPDATE ON shipments
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE shipment_events (
+    id UUID PRIMARY KEY,
+
+    shipment_id UUID NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+
+    -- The status the shipment moved to with this event.
+    status VARCHAR(50) NOT NULL,
+
+    -- Optional free-form details reported by the carrier or warehouse staff.
+    location VARCHAR(255),
+    description TEXT,
+
+    -- When the event happened according to the reporter, which can be earlier
+    -- than the time we recorded it.
+    occurred_at TIMESTAMPTZ NOT NULL,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_shipment_events_shipment_id ON shipment_events (shipment_id);
@@ -0,0 +1,24 @@
+CREATE TABLE stock_movements (
+    id UUID PRIMARY KEY,
+
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+
+    -- The signed change applied to the product's stock_quantity.
+    quantity_change INTEGER NOT NULL CHECK (quantity_change <> 0),
+
+    -- The product's stock_quantity right after this movement was applied.
+    stock_after INTEGER NOT NULL,
+
+    -- Why the stock changed, e.g. 'RETURN_RESTOCK' or 'MANUAL_ADJUSTMENT'.
+    reason VARCHAR(50) NOT NULL,
+
+    -- An optional caller-supplied identifier of the business event behind the
+    -- movement (e.g. a return line). It is unique so that retried requests are
+    -- only applied once.
+    reference VARCHAR(255) UNIQUE,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index on product_id to quickly list the movement history of a product.
+CREATE INDEX idx_stock_movements_product_id ON stock_movements (product_id, created_at);
@@ -0,0 +1,55 @@
+CREATE TABLE returns (
+    id UUID PRIMARY KEY,
+
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+
+    -- The customer who requested the return. Copied from the order.
+    user_id UUID NOT NULL,
+
+    -- The RMA lifecycle: REQUESTED -> APPROVED / REJECTED -> RECEIVED -> REFUNDED / CLOSED.
+    status VARCHAR(50) NOT NULL,
+
+    -- Why the customer wants to return the items.
+    reason TEXT,
+
+    -- The note staff left when approving or rejecting the request.
+    decision_note TEXT,
+
+    -- The amount refunded for the accepted lines, and the refund ID returned by
+    -- the payment service once the refund was issued.
+    refund_amount NUMERIC(10, 2),
+    refund_id VARCHAR(255),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_returns_order_id ON returns (order_id);
+
+CREATE INDEX idx_returns_status ON returns (status);
+
+CREATE TRIGGER update_returns_updated_at
+BEFORE UPDATE ON returns
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+CREATE TABLE return_lines (
+    id UUID PRIMARY KEY,
+
+    return_id UUID NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+
+    product_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+
+    -- The price paid per unit, copied from the order so refunds don't depend on
+    -- the current product price.
+    unit_price NUMERIC(10, 2) NOT NULL,
+
+    reason TEXT,
+
+    -- Set during inspection: RESTOCK, WRITE_OFF or REJECTED.
+    disposition VARCHAR(50),
+    inspection_note TEXT
+);
+
+CREATE INDEX idx_return_lines_return_id ON return_lines (return_id);
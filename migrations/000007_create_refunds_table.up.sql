@@ -0,0 +1,39 @@
+-- The ID the payment provider assigned to the captured payment; refunds are
+-- issued against it.
+ALTER TABLE payments ADD COLUMN provider_payment_id VARCHAR(255);
+
+-- The sum of all succeeded refunds. It can never exceed the captured amount.
+ALTER TABLE payments ADD COLUMN refunded_amount NUMERIC(10, 2) NOT NULL DEFAULT 0 CHECK (refunded_amount >= 0 AND refunded_amount <= amount);
+
+CREATE TABLE refunds (
+    id UUID PRIMARY KEY,
+
+    -- A payment can be refunded several times (partial refunds), so this is not unique.
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+
+    amount NUMERIC(10, 2) NOT NULL CHECK (amount > 0),
+
+    -- Why the money was returned, e.g. 'RETURN' or 'GOODWILL', plus optional details.
+    reason VARCHAR(50) NOT NULL,
+    note TEXT,
+
+    -- PENDING while the provider is processing it, then SUCCEEDED or FAILED.
+    status VARCHAR(50) NOT NULL,
+
+    -- The refund ID assigned by the payment provider.
+    provider_refund_id VARCHAR(255),
+
+    -- An optional caller-supplied identifier (e.g. the RMA) so that retried
+    -- requests don't refund twice.
+    reference VARCHAR(255) UNIQUE,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refunds_payment_id ON refunds (payment_id);
+
+CREATE TRIGGER update_refunds_updated_at
+BEFORE UPDATE ON refunds
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,2 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS refund_status;
+ALTER TABLE orders DROP COLUMN IF EXISTS refunded_total;
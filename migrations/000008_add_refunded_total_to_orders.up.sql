@@ -0,0 +1,6 @@
+-- The total refunded for the order, kept in sync from the payment service's refund events.
+ALTER TABLE orders ADD COLUMN refunded_total NUMERIC(10, 2) NOT NULL DEFAULT 0;
+
+-- The refund state of an order, kept apart from its status so refunds don't
+-- hide the fulfillment progress: NONE, PARTIALLY_REFUNDED or REFUNDED.
+ALTER TABLE orders ADD COLUMN refund_status VARCHAR(50) NOT NULL DEFAULT 'NONE';
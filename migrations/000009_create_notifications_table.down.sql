@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS notification_attempts;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS notification_order_customers;
+DROP TABLE IF EXISTS notification_recipients;
@@ -0,0 +1,74 @@
+-- Contact details of the users the notification service can send messages to.
+CREATE TABLE notification_recipients (
+    user_id UUID PRIMARY KEY,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(255),
+
+    -- The preferred locale for templates, e.g. 'en' or 'de-DE'.
+    locale VARCHAR(35) NOT NULL DEFAULT 'en',
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_notification_recipients_updated_at
+BEFORE UPDATE ON notification_recipients
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Which user placed which order, learned from order.created events. Payment
+-- events only carry the order ID, so this is how their recipient is found.
+CREATE TABLE notification_order_customers (
+    order_id UUID PRIMARY KEY,
+    user_id UUID NOT NULL
+);
+
+CREATE TABLE notifications (
+    id UUID PRIMARY KEY,
+
+    -- The event that triggered the message. It is unique so redelivered
+    -- events don't send the same message twice.
+    event_id VARCHAR(255) NOT NULL UNIQUE,
+    event_type VARCHAR(100) NOT NULL,
+
+    user_id UUID,
+    recipient VARCHAR(255),
+    locale VARCHAR(35),
+
+    -- The rendered message, kept so retries send exactly the same content.
+    -- The bodies stay empty for messages holding secrets like reset tokens.
+    subject TEXT,
+    text_body TEXT,
+    html_body TEXT,
+
+    -- PENDING until sent, SENT, FAILED after the last retry, or SKIPPED when
+    -- there is no recipient to send to.
+    status VARCHAR(50) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMPTZ,
+    sent_at TIMESTAMPTZ,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Index for the retry worker picking up due messages.
+CREATE INDEX idx_notifications_due ON notifications (next_attempt_at) WHERE status = 'PENDING';
+
+CREATE TRIGGER update_notifications_updated_at
+BEFORE UPDATE ON notifications
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- One row per delivery attempt, successful or not.
+CREATE TABLE notification_attempts (
+    id UUID PRIMARY KEY,
+    notification_id UUID NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+    attempt INTEGER NOT NULL,
+    success BOOLEAN NOT NULL,
+    error TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_notification_attempts_notification_id ON notification_attempts (notification_id);
@@ -0,0 +1,45 @@
+CREATE TABLE users (
+    id UUID PRIMARY KEY,
+
+    -- Email addresses are stored lower-cased so the unique constraint is case-insensitive.
+    email VARCHAR(255) NOT NULL UNIQUE,
+
+    -- An encoded argon2id hash including its parameters and salt.
+    password_hash VARCHAR(255) NOT NULL,
+
+    name VARCHAR(255),
+    locale VARCHAR(35) NOT NULL DEFAULT 'en',
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TRIGGER update_users_updated_at
+BEFORE UPDATE ON users
+FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column();
+
+-- Every issued refresh token, identified by its 'jti' claim. A refresh token
+-- can be used once: using it revokes it and issues a new one.
+CREATE TABLE refresh_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+
+-- Password reset tokens are only stored as SHA-256 hash, so a leaked table
+-- can't be used to reset passwords.
+CREATE TABLE password_reset_tokens (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_password_reset_tokens_user_id ON password_reset_tokens (user_id);
@@ -0,0 +1,14 @@
+-- Role assignments. The roles are copied into the access tokens, so changes
+-- take effect when the user's next token is issued.
+--
+-- The first superadmin has to be assigned directly in the database:
+--   INSERT INTO user_roles (user_id, role) SELECT id, 'superadmin' FROM users WHERE email = '...';
+CREATE TABLE user_roles (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role VARCHAR(50) NOT NULL CHECK (role IN ('customer', 'catalog-admin', 'warehouse-staff', 'support', 'superadmin')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (user_id, role)
+);
+
+-- Users registered before roles existed are customers.
+INSERT INTO user_roles (user_id, role) SELECT id, 'customer' FROM users;
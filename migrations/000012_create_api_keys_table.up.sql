@@ -0,0 +1,24 @@
+-- API keys let partners call the APIs without a user login. A key acts as
+-- its owner, limited to its scopes. Only the SHA-256 hash of a key is
+-- stored; the prefix is part of the key and used to look it up.
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(32) NOT NULL UNIQUE,
+    key_hash VARCHAR(64) NOT NULL,
+
+    -- Permissions the key may use, e.g. 'orders:create'.
+    scopes TEXT[] NOT NULL,
+
+    -- Allowed requests per minute.
+    rate_limit INT NOT NULL CHECK (rate_limit > 0),
+
+    last_used_at TIMESTAMPTZ,
+    -- Set on the old key when a key is rotated, so clients have time to switch.
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
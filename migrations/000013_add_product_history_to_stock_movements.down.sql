@@ -0,0 +1,4 @@
+DELETE FROM stock_movements WHERE change_type <> 'STOCK_CHANGED';
+ALTER TABLE stock_movements DROP CONSTRAINT stock_movements_quantity_change_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_quantity_change_check CHECK (quantity_change <> 0);
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS seq, DROP COLUMN IF EXISTS price_after, DROP COLUMN IF EXISTS change_type;
@@ -0,0 +1,41 @@
+-- Stock movements become the change history of products, which the product
+-- feed of the gRPC API is read from. Besides the movements of stock they
+-- record the creation of products and price changes. Every entry keeps the
+-- price and stock right after it, and seq orders the entries for the cursors
+-- of the feed.
+ALTER TABLE stock_movements
+    ADD COLUMN change_type VARCHAR(20) NOT NULL DEFAULT 'STOCK_CHANGED' CHECK (change_type IN ('CREATED', 'UPDATED', 'STOCK_CHANGED')),
+    ADD COLUMN price_after NUMERIC(10, 2),
+    ADD COLUMN seq BIGINT;
+
+-- Products may be created without stock, and price changes leave it alone.
+ALTER TABLE stock_movements DROP CONSTRAINT stock_movements_quantity_change_check;
+ALTER TABLE stock_movements ADD CONSTRAINT stock_movements_quantity_change_check CHECK (quantity_change <> 0 OR change_type <> 'STOCK_CHANGED');
+
+-- Existing products get their creation recorded, with the stock they had
+-- before their movements. Past prices are unknown, so every entry gets the
+-- current price.
+INSERT INTO stock_movements (id, product_id, change_type, quantity_change, stock_after, reason, created_at)
+SELECT gen_random_uuid(), p.id, 'CREATED', initial.stock, initial.stock, 'PRODUCT_CREATED', p.created_at
+FROM products p
+CROSS JOIN LATERAL (
+    SELECT p.stock_quantity - COALESCE(SUM(m.quantity_change), 0) AS stock FROM stock_movements m WHERE m.product_id = p.id
+) initial;
+
+UPDATE stock_movements m SET price_after = p.price FROM products p WHERE p.id = m.product_id;
+
+-- The entries so far are numbered in the order they happened.
+CREATE SEQUENCE stock_movements_seq_seq OWNED BY stock_movements.seq;
+UPDATE stock_movements m SET seq = ordered.n
+FROM (
+    SELECT id, row_number() OVER (ORDER BY created_at, change_type <> 'CREATED', id) AS n FROM stock_movements
+) ordered
+WHERE m.id = ordered.id;
+SELECT setval('stock_movements_seq_seq', COALESCE(MAX(seq), 0) + 1, false) FROM stock_movements;
+
+ALTER TABLE stock_movements
+    ALTER COLUMN price_after SET NOT NULL,
+    ALTER COLUMN seq SET DEFAULT nextval('stock_movements_seq_seq'),
+    ALTER COLUMN seq SET NOT NULL;
+
+CREATE UNIQUE INDEX idx_stock_movements_seq ON stock_movements (seq);
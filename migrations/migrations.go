@@ -0,0 +1,8 @@
+// Package migrations embeds the SQL migrations, so every service binary can
+// apply them, see internal/migrate.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,249 @@
+// Package client is a typed Go client for the HTTP APIs of the order and
+// inventory services.
+//
+// Every method takes a context, which bounds all attempts of the call.
+// Idempotent calls (GET and PUT) are retried with exponential backoff when
+// the service can't be reached or answers 429, 502, 503 or 504. Writes carry
+// an Idempotency-Key header that stays the same across the attempts of a
+// call; POST calls are only retried when the service rejected them with 429,
+// or when the endpoint deduplicates them like stock movements do.
+//
+// Failed calls return an *Error, which matches one of the Err* values with
+// errors.Is, e.g. errors.Is(err, client.ErrNotFound).
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyHeader is the header writes carry their idempotency key in.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Option configures an OrderClient or InventoryClient.
+type Option func(*transport)
+
+// WithHTTPClient sends the requests with c instead of a client with a 30
+// second timeout.
+func WithHTTPClient(c *http.Client) Option {
+	return func(t *transport) { t.httpClient = c }
+}
+
+// WithToken authenticates every request with the access token.
+func WithToken(token string) Option {
+	return WithTokenSource(func(ctx context.Context) (string, error) { return token, nil })
+}
+
+// WithTokenSource authenticates every request with the access token returned
+// by source, e.g. to refresh expired tokens.
+func WithTokenSource(source func(ctx context.Context) (string, error)) Option {
+	return func(t *transport) { t.token = source }
+}
+
+// WithAPIKey authenticates every request with an API key instead of an
+// access token.
+func WithAPIKey(key string) Option {
+	return func(t *transport) { t.apiKey = key }
+}
+
+// WithRetries changes how often calls are attempted and how long the client
+// waits between attempts: a random duration up to baseDelay doubled for every
+// attempt, but at most maxDelay. One attempt disables retries.
+func WithRetries(attempts int, baseDelay, maxDelay time.Duration) Option {
+	return func(t *transport) {
+		t.attempts = max(attempts, 1)
+		t.baseDelay = baseDelay
+		t.maxDelay = maxDelay
+	}
+}
+
+// WithIdempotencyKeys generates the idempotency keys of writes with newKey
+// instead of random UUIDs.
+func WithIdempotencyKeys(newKey func() string) Option {
+	return func(t *transport) { t.newKey = newKey }
+}
+
+// transport sends the requests of both clients.
+type transport struct {
+	baseURL    string
+	httpClient *http.Client
+	token      func(ctx context.Context) (string, error)
+	apiKey     string
+	attempts   int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	newKey     func() string
+}
+
+func newTransport(baseURL string, opts []Option) *transport {
+	t := &transport{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		attempts:   4,
+		baseDelay:  100 * time.Millisecond,
+		maxDelay:   2 * time.Second,
+		newKey:     uuid.NewString,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// call describes a single API call.
+type call struct {
+	method string
+	path   string
+	body   any
+	// deduplicated marks POST calls the service applies only once, so they
+	// may be retried like idempotent ones.
+	deduplicated bool
+	// idempotencyKey is generated for writes unless set.
+	idempotencyKey string
+}
+
+func (c call) retryable() bool {
+	switch c.method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return c.deduplicated
+}
+
+// do sends the call and decodes the response into out, if not nil. It
+// returns the response headers, e.g. for pagination.
+func (t *transport) do(ctx context.Context, c call, out any) (http.Header, error) {
+	var body []byte
+	if c.body != nil {
+		var err error
+		if body, err = json.Marshal(c.body); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.method != http.MethodGet && c.method != http.MethodHead && c.idempotencyKey == "" {
+		c.idempotencyKey = t.newKey()
+	}
+
+	url := t.url(c.path)
+
+	for attempt := 1; ; attempt++ {
+		res, err := t.send(ctx, c, url, body)
+
+		var retryAfter time.Duration
+		switch {
+		case err != nil:
+			// The request may have reached the service, so only idempotent
+			// calls are sent again.
+			if ctx.Err() != nil || !c.retryable() || attempt >= t.attempts {
+				return nil, err
+			}
+		case res.StatusCode >= 200 && res.StatusCode < 300:
+			defer res.Body.Close()
+			if out != nil && res.StatusCode != http.StatusNoContent {
+				if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+					return nil, fmt.Errorf("decode response of %s %s: %w", c.method, c.path, err)
+				}
+			}
+			return res.Header, nil
+		default:
+			apiErr := newError(c.method, url, res)
+			res.Body.Close()
+
+			// Rate limited calls were not processed, so they are safe to
+			// retry even if they aren't idempotent.
+			retry := apiErr.StatusCode == http.StatusTooManyRequests ||
+				(c.retryable() && (apiErr.StatusCode == http.StatusBadGateway ||
+					apiErr.StatusCode == http.StatusServiceUnavailable ||
+					apiErr.StatusCode == http.StatusGatewayTimeout))
+			if !retry || attempt >= t.attempts {
+				return nil, apiErr
+			}
+			retryAfter = apiErr.RetryAfter
+		}
+
+		timer := time.NewTimer(max(t.backoff(attempt), retryAfter))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// url returns the URL of path, which may already be absolute, e.g. the link
+// to the next page.
+func (t *transport) url(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	return t.baseURL + path
+}
+
+func (t *transport) send(ctx context.Context, c call, url string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, c.method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.idempotencyKey != "" {
+		req.Header.Set(IdempotencyKeyHeader, c.idempotencyKey)
+	}
+	if reqID := middleware.GetReqID(ctx); reqID != "" {
+		req.Header.Set(middleware.RequestIDHeader, reqID)
+	}
+
+	switch {
+	case t.apiKey != "":
+		req.Header.Set("X-API-Key", t.apiKey)
+	case t.token != nil:
+		token, err := t.token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("get access token: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return t.httpClient.Do(req)
+}
+
+// backoff returns the delay before the next attempt, with full jitter.
+func (t *transport) backoff(attempt int) time.Duration {
+	delay := t.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > t.maxDelay {
+		delay = t.maxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return rand.N(delay)
+}
+
+// retryAfter parses the Retry-After header given in seconds.
+func retryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,120 @@
+package client
+
+import (
+	"context"
+	"ecommerce-platform/internal/apperr"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetries(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		// statuses are answered in turn, the last one for all further attempts.
+		statuses     []int
+		deduplicated bool
+		wantAttempts int
+		wantErr      error
+	}{
+		{name: "GET is retried", method: http.MethodGet, statuses: []int{503, 502, 200}, wantAttempts: 3},
+		{name: "GET gives up", method: http.MethodGet, statuses: []int{503}, wantAttempts: 4, wantErr: ErrUnavailable},
+		{name: "GET is not retried on client errors", method: http.MethodGet, statuses: []int{404}, wantAttempts: 1, wantErr: ErrNotFound},
+		{name: "POST is not retried", method: http.MethodPost, statuses: []int{503, 200}, wantAttempts: 1, wantErr: ErrUnavailable},
+		{name: "POST is retried when rate limited", method: http.MethodPost, statuses: []int{429, 200}, wantAttempts: 2},
+		{name: "deduplicated POST is retried", method: http.MethodPost, statuses: []int{503, 200}, deduplicated: true, wantAttempts: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var keys []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				attempt := len(keys)
+				keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+				mu.Unlock()
+
+				status := tt.statuses[min(attempt, len(tt.statuses)-1)]
+				w.WriteHeader(status)
+				if status == http.StatusOK {
+					w.Write([]byte("{}"))
+				}
+			}))
+			defer server.Close()
+
+			tr := newTransport(server.URL, []Option{WithRetries(4, time.Millisecond, time.Millisecond)})
+			var out map[string]any
+			_, err := tr.do(context.Background(), call{method: tt.method, path: "/orders", deduplicated: tt.deduplicated}, &out)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if len(keys) != tt.wantAttempts {
+				t.Errorf("%d attempts, want %d", len(keys), tt.wantAttempts)
+			}
+			for _, key := range keys {
+				if tt.method == http.MethodGet && key != "" {
+					t.Errorf("GET with idempotency key %q", key)
+				}
+				if tt.method != http.MethodGet && (key == "" || key != keys[0]) {
+					t.Errorf("idempotency keys %q, want the same key for every attempt", keys)
+				}
+			}
+		})
+	}
+}
+
+func TestError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		apperr.Write(w, r, apperr.New(apperr.Conflict, "order_not_shippable", "Order is not paid").
+			WithFields(apperr.FieldError{Field: "items", Message: "must not be empty"}))
+	}))
+	defer server.Close()
+
+	_, err := NewOrderClient(server.URL, WithRetries(1, 0, 0)).GetOrder(context.Background(), "1")
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error %v, want *Error", err)
+	}
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("error %v doesn't match ErrConflict", err)
+	}
+	if apiErr.Code != "order_not_shippable" || apiErr.Message != "Order is not paid" || apiErr.RetryAfter != 7*time.Second {
+		t.Errorf("error %+v", apiErr)
+	}
+	if len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != "items" {
+		t.Errorf("fields %+v", apiErr.Fields)
+	}
+}
+
+func TestList(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("page") {
+		case "":
+			w.Header().Add("Link", `</items?page=2>; rel="next"`)
+			json.NewEncoder(w).Encode([]int{1, 2})
+		case "2":
+			w.Header().Add("Link", `<`+server.URL+`/items>; rel="first", <`+server.URL+`/items?page=3>; rel="next"`)
+			json.NewEncoder(w).Encode([]int{3})
+		case "3":
+			json.NewEncoder(w).Encode([]int{4})
+		}
+	}))
+	defer server.Close()
+
+	items, err := Collect(list[int](context.Background(), newTransport(server.URL, nil), "/items"))
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	if len(items) != 4 || items[0] != 1 || items[3] != 4 {
+		t.Errorf("items %v, want [1 2 3 4]", items)
+	}
+}
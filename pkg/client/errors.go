@@ -0,0 +1,125 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// The errors an *Error matches depending on its status code.
+var (
+	ErrBadRequest   = errors.New("invalid request")
+	ErrUnauthorized = errors.New("missing or invalid credentials")
+	ErrForbidden    = errors.New("permission denied")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflicts with the current state")
+	ErrRateLimited  = errors.New("rate limit exceeded")
+	ErrUpstream     = errors.New("a service behind the API failed")
+	ErrUnavailable  = errors.New("service unavailable")
+	ErrServer       = errors.New("internal server error")
+)
+
+// Error is returned for responses with an error status code.
+type Error struct {
+	Method     string
+	URL        string
+	StatusCode int
+	// Code is the machine-readable error code sent by the service, e.g.
+	// order_not_shippable. It is empty if the response had no problem
+	// details body.
+	Code string
+	// Message is the error message sent by the service.
+	Message string
+	// RequestID identifies the request in the service logs.
+	RequestID string
+	// Fields are the invalid fields of a rejected request.
+	Fields []FieldError
+	// RetryAfter is how long the service asked to wait before calling again,
+	// if it did.
+	RetryAfter time.Duration
+}
+
+// FieldError describes why the value of a single field of a request is
+// invalid.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// problem is the problem details body of error responses.
+type problem struct {
+	Detail    string       `json:"detail"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"requestId"`
+	Errors    []FieldError `json:"errors"`
+}
+
+func newError(method, url string, res *http.Response) *Error {
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 64<<10))
+
+	e := &Error{
+		Method:     method,
+		URL:        url,
+		StatusCode: res.StatusCode,
+		RetryAfter: retryAfter(res.Header),
+	}
+
+	var p problem
+	if strings.HasPrefix(res.Header.Get("Content-Type"), "application/problem+json") && json.Unmarshal(body, &p) == nil {
+		e.Code = p.Code
+		e.Message = p.Detail
+		e.RequestID = p.RequestID
+		e.Fields = p.Errors
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	if message == "" {
+		message = http.StatusText(e.StatusCode)
+	}
+	if e.Code != "" {
+		message += " (" + e.Code + ")"
+	}
+	for _, f := range e.Fields {
+		if f.Field == "" {
+			message += "; " + f.Message
+		} else {
+			message += "; " + f.Field + " " + f.Message
+		}
+	}
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, message)
+}
+
+// Unwrap returns the Err* value of the status code, so errors.Is can be used
+// to tell errors apart.
+func (e *Error) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode == http.StatusBadGateway:
+		return ErrUpstream
+	case e.StatusCode == http.StatusServiceUnavailable, e.StatusCode == http.StatusGatewayTimeout:
+		return ErrUnavailable
+	case e.StatusCode >= 500:
+		return ErrServer
+	case e.StatusCode >= 400:
+		return ErrBadRequest
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+// InventoryClient calls the HTTP API of the inventory service.
+type InventoryClient struct {
+	t *transport
+}
+
+// NewInventoryClient returns a client of the inventory service at baseURL,
+// e.g. "http://localhost:8082".
+func NewInventoryClient(baseURL string, opts ...Option) *InventoryClient {
+	return &InventoryClient{t: newTransport(baseURL, opts)}
+}
+
+func (ic *InventoryClient) AddProduct(ctx context.Context, req AddProductRequest) (*Product, error) {
+	var product Product
+	if _, err := ic.t.do(ctx, call{method: http.MethodPost, path: "/products", body: req}, &product); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// GetPrice returns the current price of a product. It needs no credentials.
+func (ic *InventoryClient) GetPrice(ctx context.Context, productID string) (*Price, error) {
+	var price Price
+	if _, err := ic.t.do(ctx, call{method: http.MethodGet, path: "/products/" + url.PathEscape(productID)}, &price); err != nil {
+		return nil, err
+	}
+	return &price, nil
+}
+
+// UpdatePrice sets the price of a product. Orders placed afterwards are
+// charged the new price.
+func (ic *InventoryClient) UpdatePrice(ctx context.Context, productID string, req UpdatePriceRequest) (*Product, error) {
+	var product Product
+	if _, err := ic.t.do(ctx, call{method: http.MethodPut, path: "/products/" + url.PathEscape(productID) + "/price", body: req}, &product); err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+// AdjustStock records a stock movement. Without a reference in req the
+// idempotency key of the call is used, so the call is safe to retry.
+func (ic *InventoryClient) AdjustStock(ctx context.Context, productID string, req AdjustStockRequest) (*StockMovement, error) {
+	c := call{method: http.MethodPost, path: "/products/" + url.PathEscape(productID) + "/stock-movements", deduplicated: true}
+	if req.Reference == nil {
+		c.idempotencyKey = ic.t.newKey()
+		reference := c.idempotencyKey
+		req.Reference = &reference
+	} else {
+		c.idempotencyKey = *req.Reference
+	}
+	c.body = req
+
+	var movement StockMovement
+	if _, err := ic.t.do(ctx, c, &movement); err != nil {
+		return nil, err
+	}
+	return &movement, nil
+}
+
+func (ic *InventoryClient) ListStockMovements(ctx context.Context, productID string) iter.Seq2[*StockMovement, error] {
+	return list[*StockMovement](ctx, ic.t, "/products/"+url.PathEscape(productID)+"/stock-movements")
+}
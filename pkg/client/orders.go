@@ -0,0 +1,127 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+)
+
+// OrderClient calls the HTTP API of the order service.
+type OrderClient struct {
+	t *transport
+}
+
+// NewOrderClient returns a client of the order service at baseURL, e.g.
+// "http://localhost:8081".
+func NewOrderClient(baseURL string, opts ...Option) *OrderClient {
+	return &OrderClient{t: newTransport(baseURL, opts)}
+}
+
+// CreateOrder places an order of the caller. The prices are set by the
+// service.
+func (oc *OrderClient) CreateOrder(ctx context.Context, req CreateOrderRequest) (*Order, error) {
+	var order Order
+	if _, err := oc.t.do(ctx, call{method: http.MethodPost, path: "/orders", body: req}, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (oc *OrderClient) GetOrder(ctx context.Context, id string) (*Order, error) {
+	var order Order
+	if _, err := oc.t.do(ctx, call{method: http.MethodGet, path: "/orders/" + url.PathEscape(id)}, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (oc *OrderClient) CreateShipment(ctx context.Context, orderID string, req CreateShipmentRequest) (*Shipment, error) {
+	var shipment Shipment
+	if _, err := oc.t.do(ctx, call{method: http.MethodPost, path: "/orders/" + url.PathEscape(orderID) + "/shipments", body: req}, &shipment); err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+func (oc *OrderClient) ListShipments(ctx context.Context, orderID string) iter.Seq2[*Shipment, error] {
+	return list[*Shipment](ctx, oc.t, "/orders/"+url.PathEscape(orderID)+"/shipments")
+}
+
+func (oc *OrderClient) GetShipment(ctx context.Context, id string) (*Shipment, error) {
+	var shipment Shipment
+	if _, err := oc.t.do(ctx, call{method: http.MethodGet, path: "/shipments/" + url.PathEscape(id)}, &shipment); err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+func (oc *OrderClient) SetTracking(ctx context.Context, shipmentID string, req SetTrackingRequest) (*Shipment, error) {
+	var shipment Shipment
+	if _, err := oc.t.do(ctx, call{method: http.MethodPut, path: "/shipments/" + url.PathEscape(shipmentID) + "/tracking", body: req}, &shipment); err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+func (oc *OrderClient) RecordShipmentEvent(ctx context.Context, shipmentID string, req RecordShipmentEventRequest) (*Shipment, error) {
+	var shipment Shipment
+	if _, err := oc.t.do(ctx, call{method: http.MethodPost, path: "/shipments/" + url.PathEscape(shipmentID) + "/events", body: req}, &shipment); err != nil {
+		return nil, err
+	}
+	return &shipment, nil
+}
+
+// ListPickLists returns the pick list of every warehouse with work left.
+func (oc *OrderClient) ListPickLists(ctx context.Context) iter.Seq2[*PickList, error] {
+	return list[*PickList](ctx, oc.t, "/shipments/pick-lists")
+}
+
+// RequestReturn requests the return of items of an order of the caller.
+func (oc *OrderClient) RequestReturn(ctx context.Context, orderID string, req RequestReturnRequest) (*Return, error) {
+	var ret Return
+	if _, err := oc.t.do(ctx, call{method: http.MethodPost, path: "/orders/" + url.PathEscape(orderID) + "/returns", body: req}, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (oc *OrderClient) ListReturns(ctx context.Context, orderID string) iter.Seq2[*Return, error] {
+	return list[*Return](ctx, oc.t, "/orders/"+url.PathEscape(orderID)+"/returns")
+}
+
+func (oc *OrderClient) GetReturn(ctx context.Context, id string) (*Return, error) {
+	var ret Return
+	if _, err := oc.t.do(ctx, call{method: http.MethodGet, path: "/returns/" + url.PathEscape(id)}, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (oc *OrderClient) ApproveReturn(ctx context.Context, id string, req ReturnDecisionRequest) (*Return, error) {
+	return oc.returnAction(ctx, id, "approve", req)
+}
+
+func (oc *OrderClient) RejectReturn(ctx context.Context, id string, req ReturnDecisionRequest) (*Return, error) {
+	return oc.returnAction(ctx, id, "reject", req)
+}
+
+// InspectReturn records the inspection of every line of a received return.
+// Accepted lines are refunded right away.
+func (oc *OrderClient) InspectReturn(ctx context.Context, id string, req InspectReturnRequest) (*Return, error) {
+	return oc.returnAction(ctx, id, "inspection", req)
+}
+
+// RefundReturn retries the refund of an inspected return, e.g. after it
+// failed with ErrUpstream.
+func (oc *OrderClient) RefundReturn(ctx context.Context, id string) (*Return, error) {
+	return oc.returnAction(ctx, id, "refund", nil)
+}
+
+func (oc *OrderClient) returnAction(ctx context.Context, id, action string, body any) (*Return, error) {
+	var ret Return
+	if _, err := oc.t.do(ctx, call{method: http.MethodPost, path: "/returns/" + url.PathEscape(id) + "/" + action, body: body}, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
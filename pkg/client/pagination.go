@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"iter"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// list iterates over the items of a list endpoint. The endpoints return all
+// items at once today; the iterator also follows the rel="next" links of the
+// Link header (RFC 8288), so callers keep working once the endpoints are
+// paginated. Iteration stops at the first error.
+func list[T any](ctx context.Context, t *transport, path string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		next := path
+		for next != "" {
+			var page []T
+			header, err := t.do(ctx, call{method: http.MethodGet, path: next}, &page)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, item := range page {
+				if !yield(item, nil) {
+					return
+				}
+			}
+
+			next = nextLink(t.url(next), header)
+		}
+	}
+}
+
+// Collect returns all items of a list, or the first error.
+func Collect[T any](items iter.Seq2[T, error]) ([]T, error) {
+	var all []T
+	for item, err := range items {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, item)
+	}
+	return all, nil
+}
+
+// nextLink returns the path of the next page from the Link header, resolved
+// against the URL of the current page, or "" on the last page.
+func nextLink(current string, header http.Header) string {
+	for _, value := range header.Values("Link") {
+		for _, link := range strings.Split(value, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+
+			isNext := false
+			for _, param := range strings.Split(params, ";") {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.EqualFold(name, "rel") && strings.Contains(" "+strings.Trim(value, `"`)+" ", " next ") {
+					isNext = true
+				}
+			}
+			if !isNext {
+				continue
+			}
+
+			base, err := url.Parse(current)
+			if err != nil {
+				return ""
+			}
+			ref, err := url.Parse(strings.Trim(target, "<>"))
+			if err != nil {
+				return ""
+			}
+			return base.ResolveReference(ref).String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,229 @@
+package client
+
+import "time"
+
+// Order statuses
+const (
+	OrderStatusPending          = "PENDING"
+	OrderStatusPaid             = "PAID"
+	OrderStatusPartiallyShipped = "PARTIALLY_SHIPPED"
+	OrderStatusShipped          = "SHIPPED"
+	OrderStatusDelivered        = "DELIVERED"
+)
+
+// Order refund statuses
+const (
+	RefundStatusNone              = "NONE"
+	RefundStatusPartiallyRefunded = "PARTIALLY_REFUNDED"
+	RefundStatusRefunded          = "REFUNDED"
+)
+
+// Shipment statuses
+const (
+	ShipmentStatusPending   = "PENDING"
+	ShipmentStatusShipped   = "SHIPPED"
+	ShipmentStatusInTransit = "IN_TRANSIT"
+	ShipmentStatusDelivered = "DELIVERED"
+)
+
+// Return statuses
+const (
+	ReturnStatusRequested = "REQUESTED"
+	ReturnStatusApproved  = "APPROVED"
+	ReturnStatusRejected  = "REJECTED"
+	ReturnStatusReceived  = "RECEIVED"
+	ReturnStatusRefunded  = "REFUNDED"
+	ReturnStatusClosed    = "CLOSED"
+)
+
+// Dispositions of inspected return lines
+const (
+	ReturnDispositionRestock  = "RESTOCK"
+	ReturnDispositionWriteOff = "WRITE_OFF"
+	ReturnDispositionRejected = "REJECTED"
+)
+
+// Reasons of stock movements
+const (
+	StockMovementReasonReturnRestock    = "RETURN_RESTOCK"
+	StockMovementReasonManualAdjustment = "MANUAL_ADJUSTMENT"
+)
+
+type OrderItem struct {
+	ProductID string `json:"productId"`
+	Quantity  int    `json:"quantity"`
+	// Price is set by the order service when the order is created.
+	Price *float64 `json:"price,omitempty"`
+}
+
+type Order struct {
+	ID            string      `json:"id"`
+	UserID        string      `json:"userId"`
+	Items         []OrderItem `json:"items"`
+	TotalPrice    float64     `json:"totalPrice"`
+	RefundedTotal float64     `json:"refundedTotal"`
+	RefundStatus  string      `json:"refundStatus"`
+	Status        string      `json:"status"`
+	CreatedAt     time.Time   `json:"createdAt"`
+	UpdatedAt     time.Time   `json:"updatedAt"`
+}
+
+type CreateOrderRequest struct {
+	Items []OrderItem `json:"items"`
+}
+
+type ShipmentItem struct {
+	ProductID string `json:"productId"`
+	Quantity  int    `json:"quantity"`
+}
+
+type Shipment struct {
+	ID             string           `json:"id"`
+	OrderID        string           `json:"orderId"`
+	Warehouse      string           `json:"warehouse"`
+	Items          []ShipmentItem   `json:"items"`
+	Carrier        *string          `json:"carrier,omitempty"`
+	TrackingNumber *string          `json:"trackingNumber,omitempty"`
+	Status         string           `json:"status"`
+	ShippedAt      *time.Time       `json:"shippedAt,omitempty"`
+	DeliveredAt    *time.Time       `json:"deliveredAt,omitempty"`
+	Events         []*ShipmentEvent `json:"events,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+}
+
+type ShipmentEvent struct {
+	ID          string    `json:"id"`
+	ShipmentID  string    `json:"shipmentId"`
+	Status      string    `json:"status"`
+	Location    *string   `json:"location,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	OccurredAt  time.Time `json:"occurredAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+type CreateShipmentRequest struct {
+	Warehouse string         `json:"warehouse"`
+	Items     []ShipmentItem `json:"items"`
+}
+
+type SetTrackingRequest struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"trackingNumber"`
+}
+
+type RecordShipmentEventRequest struct {
+	Status      string  `json:"status"`
+	Location    *string `json:"location,omitempty"`
+	Description *string `json:"description,omitempty"`
+	// OccurredAt defaults to the time the event is recorded.
+	OccurredAt *time.Time `json:"occurredAt,omitempty"`
+}
+
+// PickList holds the lines a warehouse still has to pick.
+type PickList struct {
+	Warehouse string         `json:"warehouse"`
+	Lines     []PickListLine `json:"lines"`
+}
+
+type PickListLine struct {
+	ShipmentID string `json:"shipmentId"`
+	OrderID    string `json:"orderId"`
+	ProductID  string `json:"productId"`
+	Quantity   int    `json:"quantity"`
+}
+
+type Return struct {
+	ID           string        `json:"id"`
+	OrderID      string        `json:"orderId"`
+	UserID       string        `json:"userId"`
+	Status       string        `json:"status"`
+	Reason       *string       `json:"reason,omitempty"`
+	DecisionNote *string       `json:"decisionNote,omitempty"`
+	RefundAmount *float64      `json:"refundAmount,omitempty"`
+	RefundID     *string       `json:"refundId,omitempty"`
+	Lines        []*ReturnLine `json:"lines"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+type ReturnLine struct {
+	ID             string  `json:"id"`
+	ReturnID       string  `json:"returnId"`
+	ProductID      string  `json:"productId"`
+	Quantity       int     `json:"quantity"`
+	UnitPrice      float64 `json:"unitPrice"`
+	Reason         *string `json:"reason,omitempty"`
+	Disposition    *string `json:"disposition,omitempty"`
+	InspectionNote *string `json:"inspectionNote,omitempty"`
+}
+
+type RequestReturnRequest struct {
+	Reason *string             `json:"reason,omitempty"`
+	Lines  []ReturnLineRequest `json:"lines"`
+}
+
+type ReturnLineRequest struct {
+	ProductID string  `json:"productId"`
+	Quantity  int     `json:"quantity"`
+	Reason    *string `json:"reason,omitempty"`
+}
+
+type ReturnDecisionRequest struct {
+	Note *string `json:"note,omitempty"`
+}
+
+// ReturnInspection is the outcome of inspecting one return line.
+type ReturnInspection struct {
+	LineID      string  `json:"lineId"`
+	Disposition string  `json:"disposition"`
+	Note        *string `json:"note,omitempty"`
+}
+
+type InspectReturnRequest struct {
+	Lines []ReturnInspection `json:"lines"`
+}
+
+type Product struct {
+	ID            string    `json:"id"`
+	Name          string    `json:"name"`
+	Price         float64   `json:"price"`
+	StockQuantity int       `json:"stockQuantity"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+}
+
+type AddProductRequest struct {
+	Name          string  `json:"name"`
+	Price         float64 `json:"price"`
+	StockQuantity int     `json:"stockQuantity"`
+}
+
+type UpdatePriceRequest struct {
+	Price float64 `json:"price"`
+}
+
+type Price struct {
+	ProductID string  `json:"productId"`
+	Price     float64 `json:"price"`
+}
+
+type StockMovement struct {
+	ID             string    `json:"id"`
+	ProductID      string    `json:"productId"`
+	QuantityChange int       `json:"quantityChange"`
+	StockAfter     int       `json:"stockAfter"`
+	Reason         string    `json:"reason"`
+	Reference      *string   `json:"reference,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+type AdjustStockRequest struct {
+	QuantityChange int `json:"quantityChange"`
+	// Reason defaults to StockMovementReasonManualAdjustment.
+	Reason string `json:"reason,omitempty"`
+	// Reference makes the movement idempotent: the inventory service applies
+	// a movement with a known reference only once. The client uses the
+	// idempotency key of the call if it is empty.
+	Reference *string `json:"reference,omitempty"`
+}
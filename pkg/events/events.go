@@ -0,0 +1,99 @@
+// Package events defines the event types and payloads the services exchange
+// over the message bus.
+package events
+
+import "time"
+
+const (
+	OrderCreated = "order.created"
+
+	ShipmentShipped   = "shipment.shipped"
+	ShipmentInTransit = "shipment.in_transit"
+	ShipmentDelivered = "shipment.delivered"
+
+	PaymentSucceeded = "payment.succeeded"
+	PaymentRefunded  = "payment.refunded"
+
+	ProductPriceChanged = "product.price_changed"
+
+	UserRegistered             = "user.registered"
+	UserPasswordResetRequested = "user.password_reset_requested"
+)
+
+type OrderItem struct {
+	ProductID string  `json:"productId"`
+	Quantity  int     `json:"quantity"`
+	Price     float64 `json:"price"`
+}
+
+// OrderCreatedPayload is published by the order service when a new order was placed.
+type OrderCreatedPayload struct {
+	OrderID    string      `json:"orderId"`
+	UserID     string      `json:"userId"`
+	Items      []OrderItem `json:"items"`
+	TotalPrice float64     `json:"totalPrice"`
+	Status     string      `json:"status"`
+}
+
+// ShipmentStatusPayload is published by the order service with one of the
+// shipment.* event types whenever a shipment moves to a new status.
+type ShipmentStatusPayload struct {
+	ShipmentID     string    `json:"shipmentId"`
+	OrderID        string    `json:"orderId"`
+	UserID         string    `json:"userId"`
+	Status         string    `json:"status"`
+	Carrier        string    `json:"carrier"`
+	TrackingNumber string    `json:"trackingNumber"`
+	Location       string    `json:"location,omitempty"`
+	OccurredAt     time.Time `json:"occurredAt"`
+}
+
+// PaymentSucceededPayload is published when the payment of an order was
+// captured.
+type PaymentSucceededPayload struct {
+	PaymentID string  `json:"paymentId"`
+	OrderID   string  `json:"orderId"`
+	Amount    float64 `json:"amount"`
+}
+
+// PaymentRefundedPayload is published by the payment service every time a
+// refund against a payment succeeds.
+type PaymentRefundedPayload struct {
+	PaymentID      string  `json:"paymentId"`
+	OrderID        string  `json:"orderId"`
+	RefundID       string  `json:"refundId"`
+	Amount         float64 `json:"amount"`
+	Reason         string  `json:"reason"`
+	CapturedAmount float64 `json:"capturedAmount"`
+	RefundedTotal  float64 `json:"refundedTotal"`
+}
+
+// ProductPriceChangedPayload is published by the inventory service when the
+// price of a product was changed.
+type ProductPriceChangedPayload struct {
+	ProductID string    `json:"productId"`
+	OldPrice  float64   `json:"oldPrice"`
+	Price     float64   `json:"price"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
+// UserRegisteredPayload is published by the user service when an account was created.
+type UserRegisteredPayload struct {
+	UserID string  `json:"userId"`
+	Email  string  `json:"email"`
+	Name   *string `json:"name,omitempty"`
+	Locale string  `json:"locale"`
+}
+
+// UserPasswordResetRequestedPayload carries the reset token to the notification
+// service, which mails it to the user. The token is not stored anywhere in
+// plain text, so this event is the only way to get hold of it. The address is
+// the one of the user's account, the notification service sends nowhere else.
+type UserPasswordResetRequestedPayload struct {
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email"`
+	Name      *string   `json:"name,omitempty"`
+	Locale    string    `json:"locale"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
@@ -11,6 +11,7 @@ package inventory
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -23,6 +24,60 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type ProductChange_Type int32
+
+const (
+	ProductChange_TYPE_UNSPECIFIED ProductChange_Type = 0
+	ProductChange_CREATED          ProductChange_Type = 1
+	// The price changed.
+	ProductChange_UPDATED ProductChange_Type = 2
+	// A stock movement was recorded.
+	ProductChange_STOCK_CHANGED ProductChange_Type = 3
+)
+
+// Enum value maps for ProductChange_Type.
+var (
+	ProductChange_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "CREATED",
+		2: "UPDATED",
+		3: "STOCK_CHANGED",
+	}
+	ProductChange_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"CREATED":          1,
+		"UPDATED":          2,
+		"STOCK_CHANGED":    3,
+	}
+)
+
+func (x ProductChange_Type) Enum() *ProductChange_Type {
+	p := new(ProductChange_Type)
+	*p = x
+	return p
+}
+
+func (x ProductChange_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ProductChange_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_pkg_grpc_inventory_inventory_proto_enumTypes[0].Descriptor()
+}
+
+func (ProductChange_Type) Type() protoreflect.EnumType {
+	return &file_pkg_grpc_inventory_inventory_proto_enumTypes[0]
+}
+
+func (x ProductChange_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ProductChange_Type.Descriptor instead.
+func (ProductChange_Type) EnumDescriptor() ([]byte, []int) {
+	return file_pkg_grpc_inventory_inventory_proto_rawDescGZIP(), []int{6, 0}
+}
+
 // The request message containing a list of product IDs.
 type GetProductInfoRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -174,11 +229,288 @@ func (x *GetProductInfoResponse) GetProducts() []*ProductInfo {
 	return nil
 }
 
+// The request message describing a single stock movement.
+type AdjustStockRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProductId string                 `protobuf:"bytes,1,opt,name=product_id,json=productId,proto3" json:"product_id,omitempty"`
+	// The signed change to apply, e.g. 2 when two returned units are restocked.
+	QuantityChange int32  `protobuf:"varint,2,opt,name=quantity_change,json=quantityChange,proto3" json:"quantity_change,omitempty"`
+	Reason         string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Optional idempotency reference, e.g. the ID of the return line.
+	Reference     string `protobuf:"bytes,4,opt,name=reference,proto3" json:"reference,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdjustStockRequest) Reset() {
+	*x = AdjustStockRequest{}
+	mi := &file_pkg_grpc_inventory_inventory_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdjustStockRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdjustStockRequest) ProtoMessage() {}
+
+func (x *AdjustStockRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_inventory_inventory_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdjustStockRequest.ProtoReflect.Descriptor instead.
+func (*AdjustStockRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_inventory_inventory_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AdjustStockRequest) GetProductId() string {
+	if x != nil {
+		return x.ProductId
+	}
+	return ""
+}
+
+func (x *AdjustStockRequest) GetQuantityChange() int32 {
+	if x != nil {
+		return x.QuantityChange
+	}
+	return 0
+}
+
+func (x *AdjustStockRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *AdjustStockRequest) GetReference() string {
+	if x != nil {
+		return x.Reference
+	}
+	return ""
+}
+
+// The response message containing the recorded movement.
+type AdjustStockResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MovementId    string                 `protobuf:"bytes,1,opt,name=movement_id,json=movementId,proto3" json:"movement_id,omitempty"`
+	StockQuantity int32                  `protobuf:"varint,2,opt,name=stock_quantity,json=stockQuantity,proto3" json:"stock_quantity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdjustStockResponse) Reset() {
+	*x = AdjustStockResponse{}
+	mi := &file_pkg_grpc_inventory_inventory_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdjustStockResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdjustStockResponse) ProtoMessage() {}
+
+func (x *AdjustStockResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_inventory_inventory_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdjustStockResponse.ProtoReflect.Descriptor instead.
+func (*AdjustStockResponse) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_inventory_inventory_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *AdjustStockResponse) GetMovementId() string {
+	if x != nil {
+		return x.MovementId
+	}
+	return ""
+}
+
+func (x *AdjustStockResponse) GetStockQuantity() int32 {
+	if x != nil {
+		return x.StockQuantity
+	}
+	return 0
+}
+
+// The request message of a product change feed.
+type WatchProductsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The cursor of the last change the client handled. Empty starts at the
+	// beginning of the history.
+	Cursor string `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	// Only changes of these products are sent; empty means all products.
+	ProductIds    []string `protobuf:"bytes,2,rep,name=product_ids,json=productIds,proto3" json:"product_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchProductsRequest) Reset() {
+	*x = WatchProductsRequest{}
+	mi := &file_pkg_grpc_inventory_inventory_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchProductsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchProductsRequest) ProtoMessage() {}
+
+func (x *WatchProductsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_inventory_inventory_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchProductsRequest.ProtoReflect.Descriptor instead.
+func (*WatchProductsRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_inventory_inventory_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WatchProductsRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *WatchProductsRequest) GetProductIds() []string {
+	if x != nil {
+		return x.ProductIds
+	}
+	return nil
+}
+
+// A change of a product and the product's state right after it.
+type ProductChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Resumes the feed after this change. Cursors are opaque.
+	Cursor        string             `protobuf:"bytes,1,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	Type          ProductChange_Type `protobuf:"varint,2,opt,name=type,proto3,enum=inventory.ProductChange_Type" json:"type,omitempty"`
+	Product       *ProductInfo       `protobuf:"bytes,3,opt,name=product,proto3" json:"product,omitempty"`
+	StockQuantity int32              `protobuf:"varint,4,opt,name=stock_quantity,json=stockQuantity,proto3" json:"stock_quantity,omitempty"`
+	// The stock movement of STOCK_CHANGED changes and the initial stock of
+	// CREATED ones.
+	QuantityChange int32 `protobuf:"varint,5,opt,name=quantity_change,json=quantityChange,proto3" json:"quantity_change,omitempty"`
+	// Why the product changed, e.g. RETURN_RESTOCK or PRICE_CHANGE.
+	Reason        string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	ChangedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=changed_at,json=changedAt,proto3" json:"changed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProductChange) Reset() {
+	*x = ProductChange{}
+	mi := &file_pkg_grpc_inventory_inventory_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProductChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProductChange) ProtoMessage() {}
+
+func (x *ProductChange) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_grpc_inventory_inventory_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProductChange.ProtoReflect.Descriptor instead.
+func (*ProductChange) Descriptor() ([]byte, []int) {
+	return file_pkg_grpc_inventory_inventory_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ProductChange) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+func (x *ProductChange) GetType() ProductChange_Type {
+	if x != nil {
+		return x.Type
+	}
+	return ProductChange_TYPE_UNSPECIFIED
+}
+
+func (x *ProductChange) GetProduct() *ProductInfo {
+	if x != nil {
+		return x.Product
+	}
+	return nil
+}
+
+func (x *ProductChange) GetStockQuantity() int32 {
+	if x != nil {
+		return x.StockQuantity
+	}
+	return 0
+}
+
+func (x *ProductChange) GetQuantityChange() int32 {
+	if x != nil {
+		return x.QuantityChange
+	}
+	return 0
+}
+
+func (x *ProductChange) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *ProductChange) GetChangedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ChangedAt
+	}
+	return nil
+}
+
 var File_pkg_grpc_inventory_inventory_proto protoreflect.FileDescriptor
 
 const file_pkg_grpc_inventory_inventory_proto_rawDesc = "" +
 	"\n" +
-	"\"pkg/grpc/inventory/inventory.proto\x12\tinventory\"8\n" +
+	"\"pkg/grpc/inventory/inventory.proto\x12\tinventory\x1a\x1fgoogle/protobuf/timestamp.proto\"8\n" +
 	"\x15GetProductInfoRequest\x12\x1f\n" +
 	"\vproduct_ids\x18\x01 \x03(\tR\n" +
 	"productIds\"G\n" +
@@ -187,9 +519,39 @@ const file_pkg_grpc_inventory_inventory_proto_rawDesc = "" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
 	"\x05price\x18\x03 \x01(\x01R\x05price\"L\n" +
 	"\x16GetProductInfoResponse\x122\n" +
-	"\bproducts\x18\x01 \x03(\v2\x16.inventory.ProductInfoR\bproducts2k\n" +
+	"\bproducts\x18\x01 \x03(\v2\x16.inventory.ProductInfoR\bproducts\"\x92\x01\n" +
+	"\x12AdjustStockRequest\x12\x1d\n" +
+	"\n" +
+	"product_id\x18\x01 \x01(\tR\tproductId\x12'\n" +
+	"\x0fquantity_change\x18\x02 \x01(\x05R\x0equantityChange\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\x12\x1c\n" +
+	"\treference\x18\x04 \x01(\tR\treference\"]\n" +
+	"\x13AdjustStockResponse\x12\x1f\n" +
+	"\vmovement_id\x18\x01 \x01(\tR\n" +
+	"movementId\x12%\n" +
+	"\x0estock_quantity\x18\x02 \x01(\x05R\rstockQuantity\"O\n" +
+	"\x14WatchProductsRequest\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x12\x1f\n" +
+	"\vproduct_ids\x18\x02 \x03(\tR\n" +
+	"productIds\"\xfa\x02\n" +
+	"\rProductChange\x12\x16\n" +
+	"\x06cursor\x18\x01 \x01(\tR\x06cursor\x121\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x1d.inventory.ProductChange.TypeR\x04type\x120\n" +
+	"\aproduct\x18\x03 \x01(\v2\x16.inventory.ProductInfoR\aproduct\x12%\n" +
+	"\x0estock_quantity\x18\x04 \x01(\x05R\rstockQuantity\x12'\n" +
+	"\x0fquantity_change\x18\x05 \x01(\x05R\x0equantityChange\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\x129\n" +
+	"\n" +
+	"changed_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tchangedAt\"I\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\v\n" +
+	"\aCREATED\x10\x01\x12\v\n" +
+	"\aUPDATED\x10\x02\x12\x11\n" +
+	"\rSTOCK_CHANGED\x10\x032\x8b\x02\n" +
 	"\x10InventoryService\x12W\n" +
-	"\x0eGetProductInfo\x12 .inventory.GetProductInfoRequest\x1a!.inventory.GetProductInfoResponse\"\x00B\x14Z\x12pkg/grpc/inventoryb\x06proto3"
+	"\x0eGetProductInfo\x12 .inventory.GetProductInfoRequest\x1a!.inventory.GetProductInfoResponse\"\x00\x12N\n" +
+	"\vAdjustStock\x12\x1d.inventory.AdjustStockRequest\x1a\x1e.inventory.AdjustStockResponse\"\x00\x12N\n" +
+	"\rWatchProducts\x12\x1f.inventory.WatchProductsRequest\x1a\x18.inventory.ProductChange\"\x000\x01B\x14Z\x12pkg/grpc/inventoryb\x06proto3"
 
 var (
 	file_pkg_grpc_inventory_inventory_proto_rawDescOnce sync.Once
@@ -203,21 +565,35 @@ func file_pkg_grpc_inventory_inventory_proto_rawDescGZIP() []byte {
 	return file_pkg_grpc_inventory_inventory_proto_rawDescData
 }
 
-var file_pkg_grpc_inventory_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_pkg_grpc_inventory_inventory_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_pkg_grpc_inventory_inventory_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
 var file_pkg_grpc_inventory_inventory_proto_goTypes = []any{
-	(*GetProductInfoRequest)(nil),  // 0: inventory.GetProductInfoRequest
-	(*ProductInfo)(nil),            // 1: inventory.ProductInfo
-	(*GetProductInfoResponse)(nil), // 2: inventory.GetProductInfoResponse
+	(ProductChange_Type)(0),        // 0: inventory.ProductChange.Type
+	(*GetProductInfoRequest)(nil),  // 1: inventory.GetProductInfoRequest
+	(*ProductInfo)(nil),            // 2: inventory.ProductInfo
+	(*GetProductInfoResponse)(nil), // 3: inventory.GetProductInfoResponse
+	(*AdjustStockRequest)(nil),     // 4: inventory.AdjustStockRequest
+	(*AdjustStockResponse)(nil),    // 5: inventory.AdjustStockResponse
+	(*WatchProductsRequest)(nil),   // 6: inventory.WatchProductsRequest
+	(*ProductChange)(nil),          // 7: inventory.ProductChange
+	(*timestamppb.Timestamp)(nil),  // 8: google.protobuf.Timestamp
 }
 var file_pkg_grpc_inventory_inventory_proto_depIdxs = []int32{
-	1, // 0: inventory.GetProductInfoResponse.products:type_name -> inventory.ProductInfo
-	0, // 1: inventory.InventoryService.GetProductInfo:input_type -> inventory.GetProductInfoRequest
-	2, // 2: inventory.InventoryService.GetProductInfo:output_type -> inventory.GetProductInfoResponse
-	2, // [2:3] is the sub-list for method output_type
-	1, // [1:2] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	2, // 0: inventory.GetProductInfoResponse.products:type_name -> inventory.ProductInfo
+	0, // 1: inventory.ProductChange.type:type_name -> inventory.ProductChange.Type
+	2, // 2: inventory.ProductChange.product:type_name -> inventory.ProductInfo
+	8, // 3: inventory.ProductChange.changed_at:type_name -> google.protobuf.Timestamp
+	1, // 4: inventory.InventoryService.GetProductInfo:input_type -> inventory.GetProductInfoRequest
+	4, // 5: inventory.InventoryService.AdjustStock:input_type -> inventory.AdjustStockRequest
+	6, // 6: inventory.InventoryService.WatchProducts:input_type -> inventory.WatchProductsRequest
+	3, // 7: inventory.InventoryService.GetProductInfo:output_type -> inventory.GetProductInfoResponse
+	5, // 8: inventory.InventoryService.AdjustStock:output_type -> inventory.AdjustStockResponse
+	7, // 9: inventory.InventoryService.WatchProducts:output_type -> inventory.ProductChange
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
 }
 
 func init() { file_pkg_grpc_inventory_inventory_proto_init() }
@@ -230,13 +606,14 @@ func file_pkg_grpc_inventory_inventory_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_grpc_inventory_inventory_proto_rawDesc), len(file_pkg_grpc_inventory_inventory_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   3,
+			NumEnums:      1,
+			NumMessages:   7,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_pkg_grpc_inventory_inventory_proto_goTypes,
 		DependencyIndexes: file_pkg_grpc_inventory_inventory_proto_depIdxs,
+		EnumInfos:         file_pkg_grpc_inventory_inventory_proto_enumTypes,
 		MessageInfos:      file_pkg_grpc_inventory_inventory_proto_msgTypes,
 	}.Build()
 	File_pkg_grpc_inventory_inventory_proto = out.File
@@ -22,16 +22,28 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	InventoryService_GetProductInfo_FullMethodName = "/inventory.InventoryService/GetProductInfo"
+	InventoryService_AdjustStock_FullMethodName    = "/inventory.InventoryService/AdjustStock"
+	InventoryService_WatchProducts_FullMethodName  = "/inventory.InventoryService/WatchProducts"
 )
 
 // InventoryServiceClient is the client API for InventoryService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 //
-// The InventoryService provides methods for querying product information.
+// The InventoryService provides methods for querying product information,
+// recording stock movements and following the changes of products.
 type InventoryServiceClient interface {
 	// GetProductInfo takes a list of product IDs and returns their information.
 	GetProductInfo(ctx context.Context, in *GetProductInfoRequest, opts ...grpc.CallOption) (*GetProductInfoResponse, error)
+	// AdjustStock records a stock movement for a product and applies it to the
+	// available quantity. A movement whose reference was already recorded is
+	// not applied again; the original movement is returned instead.
+	AdjustStock(ctx context.Context, in *AdjustStockRequest, opts ...grpc.CallOption) (*AdjustStockResponse, error)
+	// WatchProducts streams the changes of products, oldest first: their
+	// creation, price changes and stock movements. The stream stays open and
+	// sends new changes as they happen. A client that lost the stream passes
+	// the cursor of the last change it handled and continues right after it.
+	WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProductChange], error)
 }
 
 type inventoryServiceClient struct {
@@ -52,14 +64,53 @@ func (c *inventoryServiceClient) GetProductInfo(ctx context.Context, in *GetProd
 	return out, nil
 }
 
+func (c *inventoryServiceClient) AdjustStock(ctx context.Context, in *AdjustStockRequest, opts ...grpc.CallOption) (*AdjustStockResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdjustStockResponse)
+	err := c.cc.Invoke(ctx, InventoryService_AdjustStock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *inventoryServiceClient) WatchProducts(ctx context.Context, in *WatchProductsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ProductChange], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &InventoryService_ServiceDesc.Streams[0], InventoryService_WatchProducts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchProductsRequest, ProductChange]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type InventoryService_WatchProductsClient = grpc.ServerStreamingClient[ProductChange]
+
 // InventoryServiceServer is the server API for InventoryService service.
 // All implementations must embed UnimplementedInventoryServiceServer
 // for forward compatibility.
 //
-// The InventoryService provides methods for querying product information.
+// The InventoryService provides methods for querying product information,
+// recording stock movements and following the changes of products.
 type InventoryServiceServer interface {
 	// GetProductInfo takes a list of product IDs and returns their information.
 	GetProductInfo(context.Context, *GetProductInfoRequest) (*GetProductInfoResponse, error)
+	// AdjustStock records a stock movement for a product and applies it to the
+	// available quantity. A movement whose reference was already recorded is
+	// not applied again; the original movement is returned instead.
+	AdjustStock(context.Context, *AdjustStockRequest) (*AdjustStockResponse, error)
+	// WatchProducts streams the changes of products, oldest first: their
+	// creation, price changes and stock movements. The stream stays open and
+	// sends new changes as they happen. A client that lost the stream passes
+	// the cursor of the last change it handled and continues right after it.
+	WatchProducts(*WatchProductsRequest, grpc.ServerStreamingServer[ProductChange]) error
 	mustEmbedUnimplementedInventoryServiceServer()
 }
 
@@ -73,6 +124,12 @@ type UnimplementedInventoryServiceServer struct{}
 func (UnimplementedInventoryServiceServer) GetProductInfo(context.Context, *GetProductInfoRequest) (*GetProductInfoResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetProductInfo not implemented")
 }
+func (UnimplementedInventoryServiceServer) AdjustStock(context.Context, *AdjustStockRequest) (*AdjustStockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AdjustStock not implemented")
+}
+func (UnimplementedInventoryServiceServer) WatchProducts(*WatchProductsRequest, grpc.ServerStreamingServer[ProductChange]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchProducts not implemented")
+}
 func (UnimplementedInventoryServiceServer) mustEmbedUnimplementedInventoryServiceServer() {}
 func (UnimplementedInventoryServiceServer) testEmbeddedByValue()                          {}
 
@@ -112,6 +169,35 @@ func _InventoryService_GetProductInfo_Handler(srv interface{}, ctx context.Conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _InventoryService_AdjustStock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AdjustStockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InventoryServiceServer).AdjustStock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InventoryService_AdjustStock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InventoryServiceServer).AdjustStock(ctx, req.(*AdjustStockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InventoryService_WatchProducts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchProductsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(InventoryServiceServer).WatchProducts(m, &grpc.GenericServerStream[WatchProductsRequest, ProductChange]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type InventoryService_WatchProductsServer = grpc.ServerStreamingServer[ProductChange]
+
 // InventoryService_ServiceDesc is the grpc.ServiceDesc for InventoryService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -123,7 +209,17 @@ var InventoryService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetProductInfo",
 			Handler:    _InventoryService_GetProductInfo_Handler,
 		},
+		{
+			MethodName: "AdjustStock",
+			Handler:    _InventoryService_AdjustStock_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchProducts",
+			Handler:       _InventoryService_WatchProducts_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "pkg/grpc/inventory/inventory.proto",
 }
@@ -1,10 +1,10 @@
 package handler
 
 import (
-	"database/sql"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/services/inventory/model"
 	"ecommerce-platform/services/inventory/service"
 	"encoding/json"
-	"errors"
 	"log/slog"
 	"net/http"
 
@@ -18,6 +18,16 @@ type AddProductRequest struct {
 	StockQuantity int     `json:"stockQuantity"`
 }
 
+type AdjustStockRequest struct {
+	QuantityChange int     `json:"quantityChange"`
+	Reason         string  `json:"reason"`
+	Reference      *string `json:"reference,omitempty"`
+}
+
+type UpdatePriceRequest struct {
+	Price float64 `json:"price"`
+}
+
 type InventoryHandler struct {
 	inventoryService service.InventoryService
 	logger           *slog.Logger
@@ -40,20 +50,21 @@ func (ih *InventoryHandler) AddProduct(w http.ResponseWriter, r *http.Request) {
 	var req AddProductRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		reqLogger.Error("Invalid request body")
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
 		return
 	}
 
-	if req.Name == "" || req.Price == 0.0 || req.StockQuantity == 0 {
+	// Products may be added before any stock arrives.
+	if req.Name == "" || req.Price <= 0 || req.StockQuantity < 0 {
 		reqLogger.Error("Invalid request body", "req", req)
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.ErrInvalidRequest)
 		return
 	}
 
 	createdProduct, err := ih.inventoryService.AddProduct(r.Context(), req.Name, req.Price, req.StockQuantity)
 	if err != nil {
 		reqLogger.Error("Error creating product", "error", err)
-		http.Error(w, "Error creating product", http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -73,13 +84,8 @@ func (ih *InventoryHandler) GetPrice(w http.ResponseWriter, r *http.Request) {
 
 	price, err := ih.inventoryService.GetPrice(r.Context(), productId)
 	if err != nil {
-		if errors.Is(sql.ErrNoRows, err) {
-			reqLogger.Error("Product with given id not found", "error", err)
-			http.Error(w, "Error creating product", http.StatusNotFound)
-			return
-		}
 		reqLogger.Error("Error fetching price", "error", err)
-		http.Error(w, "Error fetching price", http.StatusInternalServerError)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -97,3 +103,79 @@ func (ih *InventoryHandler) GetPrice(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(priceResponse)
 }
+
+func (ih *InventoryHandler) UpdatePrice(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ih.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	productId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Processing price update request", "product_id", productId)
+
+	var req UpdatePriceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	product, err := ih.inventoryService.UpdatePrice(r.Context(), productId, req.Price)
+	if err != nil {
+		reqLogger.Error("Error updating price", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Price updated successfully", "product", product)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(product)
+}
+
+func (ih *InventoryHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ih.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	productId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Processing stock movement request", "product_id", productId)
+
+	var req AdjustStockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	if req.Reason == "" {
+		req.Reason = model.StockMovementReasonManualAdjustment
+	}
+
+	movement, err := ih.inventoryService.AdjustStock(r.Context(), productId, req.QuantityChange, req.Reason, req.Reference)
+	if err != nil {
+		reqLogger.Error("Error adjusting stock", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Stock adjusted successfully", "movement", movement)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+func (ih *InventoryHandler) GetStockMovements(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ih.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	productId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Getting stock movements for given product id", "product_id", productId)
+
+	movements, err := ih.inventoryService.GetStockMovements(r.Context(), productId)
+	if err != nil {
+		reqLogger.Error("Error fetching stock movements", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(movements)
+}
@@ -0,0 +1,9 @@
+package handler
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI document of the routes registered by Routes. The
+// service serves it at /openapi.json and validates requests against it.
+//
+//go:embed openapi.json
+var OpenAPI []byte
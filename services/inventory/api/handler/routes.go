@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"ecommerce-platform/internal/auth"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// Policy returns the permissions of the routes registered by Routes.
+// Mutating routes missing here are denied. Product prices stay public.
+func Policy(logger *slog.Logger) *auth.Policy {
+	return auth.NewPolicy(logger,
+		auth.Rule{Method: http.MethodPost, Pattern: "/products", Permission: auth.PermProductsWrite},
+		auth.Rule{Method: http.MethodPut, Pattern: "/products/{id}/price", Permission: auth.PermProductsWrite},
+		auth.Rule{Method: http.MethodPost, Pattern: "/products/{id}/stock-movements", Permission: auth.PermStockAdjust},
+		auth.Rule{Method: http.MethodGet, Pattern: "/products/{id}/stock-movements", Permission: auth.PermStockRead},
+	)
+}
+
+// Routes registers the API of the inventory service on r, which has to
+// authenticate callers that sent credentials and enforce Policy.
+func Routes(r chi.Router, inventoryHandler *InventoryHandler) {
+	r.Route("/products", func(r chi.Router) {
+		r.Post("/", inventoryHandler.AddProduct)
+		r.Get("/{id}", inventoryHandler.GetPrice)
+		r.Put("/{id}/price", inventoryHandler.UpdatePrice)
+		r.Post("/{id}/stock-movements", inventoryHandler.AdjustStock)
+		r.Get("/{id}/stock-movements", inventoryHandler.GetStockMovements)
+	})
+}
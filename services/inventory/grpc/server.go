@@ -2,8 +2,14 @@ package grpc
 
 import (
 	"context"
+	"ecommerce-platform/internal/auth"
 	pb "ecommerce-platform/pkg/grpc/inventory"
+	"ecommerce-platform/services/inventory/model"
 	"ecommerce-platform/services/inventory/service"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 type Server struct {
@@ -11,12 +17,27 @@ type Server struct {
 	service service.InventoryService
 }
 
+// Permissions returns the permission every method of the inventory service
+// requires, for auth.UnaryServerInterceptor and auth.StreamServerInterceptor.
+func Permissions() map[string]auth.Permission {
+	return map[string]auth.Permission{
+		pb.InventoryService_GetProductInfo_FullMethodName: auth.Authenticated,
+		pb.InventoryService_AdjustStock_FullMethodName:    auth.PermStockAdjust,
+		pb.InventoryService_WatchProducts_FullMethodName:  auth.PermStockRead,
+	}
+}
+
 func NewInventoryGRPCServer(service service.InventoryService) *Server {
 	return &Server{
 		service: service,
 	}
 }
 
+// GetProductInfo returns the products in the order of their IDs. Unknown
+// products fail the call with codes.NotFound and the product_ids metadata of
+// the ErrorInfo detail listing them; IDs that are no UUIDs fail it with
+// codes.InvalidArgument and a BadRequest detail. interceptor.UnaryServerErrors
+// turns the service errors into these statuses.
 func (s *Server) GetProductInfo(ctx context.Context, req *pb.GetProductInfoRequest) (*pb.GetProductInfoResponse, error) {
 	// Use the existing inventory service to get data from the database.
 	products, err := s.service.GetProductsByIDs(ctx, req.ProductIds)
@@ -35,3 +56,60 @@ func (s *Server) GetProductInfo(ctx context.Context, req *pb.GetProductInfoReque
 
 	return &pb.GetProductInfoResponse{Products: productInfos}, nil
 }
+
+func (s *Server) AdjustStock(ctx context.Context, req *pb.AdjustStockRequest) (*pb.AdjustStockResponse, error) {
+	var reference *string
+	if req.Reference != "" {
+		reference = &req.Reference
+	}
+
+	movement, err := s.service.AdjustStock(ctx, req.ProductId, int(req.QuantityChange), req.Reason, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.AdjustStockResponse{
+		MovementId:    movement.ID,
+		StockQuantity: int32(movement.StockAfter),
+	}, nil
+}
+
+// WatchProducts sends the changes of products until the client cancels the
+// stream. An invalid cursor or product ID fails the call with
+// codes.InvalidArgument before anything is sent.
+func (s *Server) WatchProducts(req *pb.WatchProductsRequest, stream grpc.ServerStreamingServer[pb.ProductChange]) error {
+	ctx := stream.Context()
+
+	for change, err := range s.service.WatchProducts(ctx, req.Cursor, req.ProductIds) {
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(productChange(change)); err != nil {
+			return err
+		}
+	}
+
+	return status.FromContextError(ctx.Err()).Err()
+}
+
+var changeTypes = map[string]pb.ProductChange_Type{
+	model.ProductChangeCreated:      pb.ProductChange_CREATED,
+	model.ProductChangeUpdated:      pb.ProductChange_UPDATED,
+	model.ProductChangeStockChanged: pb.ProductChange_STOCK_CHANGED,
+}
+
+func productChange(change *model.ProductChange) *pb.ProductChange {
+	return &pb.ProductChange{
+		Cursor: service.Cursor(change.Seq),
+		Type:   changeTypes[change.Type],
+		Product: &pb.ProductInfo{
+			Id:    change.Product.ID,
+			Name:  change.Product.Name,
+			Price: change.Product.Price,
+		},
+		StockQuantity:  int32(change.Product.StockQuantity),
+		QuantityChange: int32(change.QuantityChange),
+		Reason:         change.Reason,
+		ChangedAt:      timestamppb.New(change.CreatedAt),
+	}
+}
@@ -2,6 +2,24 @@ package model
 
 import "time"
 
+const (
+	StockMovementReasonReturnRestock    = "RETURN_RESTOCK"
+	StockMovementReasonManualAdjustment = "MANUAL_ADJUSTMENT"
+)
+
+// Types of product changes.
+const (
+	ProductChangeCreated      = "CREATED"
+	ProductChangeUpdated      = "UPDATED"
+	ProductChangeStockChanged = "STOCK_CHANGED"
+)
+
+// Reasons of the changes that are no stock movements.
+const (
+	ProductChangeReasonCreated     = "PRODUCT_CREATED"
+	ProductChangeReasonPriceChange = "PRICE_CHANGE"
+)
+
 type Product struct {
 	ID            string    `json:"id"`
 	Name          string    `json:"name"`
@@ -10,3 +28,26 @@ type Product struct {
 	CreatedAt     time.Time `json:"createdAt"`
 	UpdatedAt     time.Time `json:"updatedAt"`
 }
+
+type StockMovement struct {
+	ID             string    `json:"id"`
+	ProductID      string    `json:"productId"`
+	QuantityChange int       `json:"quantityChange"`
+	StockAfter     int       `json:"stockAfter"`
+	Reason         string    `json:"reason"`
+	Reference      *string   `json:"reference,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// ProductChange is an entry of the change history of products: a stock
+// movement, the creation of a product or a change of its price.
+type ProductChange struct {
+	// Seq orders the history; later changes have higher numbers.
+	Seq  int64
+	Type string
+	// Product is the product right after the change.
+	Product        Product
+	QuantityChange int
+	Reason         string
+	CreatedAt      time.Time
+}
@@ -3,11 +3,45 @@ package repository
 import (
 	"context"
 	"ecommerce-platform/services/inventory/model"
+	"errors"
 )
 
+var (
+	ErrInsufficientStock = errors.New("stock quantity cannot go below zero")
+	ErrReferenceReused   = errors.New("reference was already used for a different movement")
+)
+
+// InventoryRepository stores products and the history of their changes. The
+// creation of a product, changes of its price and its stock movements are
+// recorded in the history.
 type InventoryRepository interface {
 	Create(ctx context.Context, product *model.Product) error
 	FindManyByIDs(ctx context.Context, ids []string) ([]*model.Product, error)
 	FindByID(ctx context.Context, id string) (*model.Product, error)
+	// UpdateStockQuantity changes the stock without recording a movement, so
+	// the change is missing from the history. Use RecordMovement instead.
 	UpdateStockQuantity(ctx context.Context, id string, change int) error
+	// UpdatePrice sets the price of the product and returns the price it had
+	// before. A change of the price is recorded in the history.
+	UpdatePrice(ctx context.Context, id string, price float64) (float64, error)
+	// RecordMovement applies the movement to the product's stock and stores it.
+	// When a movement with the same reference already exists it is loaded into
+	// movement instead and the stock is left untouched. If that movement is for
+	// another product or quantity, ErrReferenceReused is returned.
+	RecordMovement(ctx context.Context, movement *model.StockMovement) error
+	FindMovementsByProductID(ctx context.Context, productID string) ([]*model.StockMovement, error)
+	// FindChanges returns up to limit changes of the history with a Seq above
+	// after, ordered by Seq. With productIDs only the changes of these
+	// products are returned. Changes become visible in the order of their Seq,
+	// so a reader that continues after the last change it saw misses none.
+	FindChanges(ctx context.Context, after int64, productIDs []string, limit int) ([]*model.ProductChange, error)
+}
+
+// CheckReference returns ErrReferenceReused unless existing, the movement
+// stored with the reference of movement, is a retry of it.
+func CheckReference(existing, movement *model.StockMovement) error {
+	if existing.ProductID != movement.ProductID || existing.QuantityChange != movement.QuantityChange {
+		return ErrReferenceReused
+	}
+	return nil
 }
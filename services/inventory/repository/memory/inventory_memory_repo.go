@@ -0,0 +1,247 @@
+// Package memory holds in-memory repositories of the inventory service for
+// tests and local runs without Postgres. They behave like the Postgres ones:
+// missing rows give sql.ErrNoRows, prices are rounded to cents like NUMERIC
+// columns, stock can't go below zero and timestamps are set by the
+// repository.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/inventory/model"
+	"ecommerce-platform/services/inventory/repository"
+	"errors"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrZeroMovement is returned for movements that don't change the stock,
+// which the stock_movements table rejects.
+var ErrZeroMovement = errors.New("quantity change must not be zero")
+
+type InventoryMemoryRepository struct {
+	mu        sync.RWMutex
+	products  map[string]model.Product
+	movements []model.StockMovement
+	// changes is the history, ordered by Seq.
+	changes []model.ProductChange
+}
+
+func NewInventoryMemoryRepository() *InventoryMemoryRepository {
+	return &InventoryMemoryRepository{
+		products: make(map[string]model.Product),
+	}
+}
+
+func (in *InventoryMemoryRepository) Create(ctx context.Context, product *model.Product) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if product.StockQuantity < 0 {
+		return repository.ErrInsufficientStock
+	}
+
+	now := now()
+	product.ID = uuid.NewString()
+	product.CreatedAt = now
+	product.UpdatedAt = now
+
+	stored := *product
+	stored.Price = roundCents(product.Price)
+	in.products[product.ID] = stored
+	in.record(stored, model.ProductChangeCreated, stored.StockQuantity, model.ProductChangeReasonCreated)
+
+	return nil
+}
+
+func (in *InventoryMemoryRepository) FindByID(ctx context.Context, id string) (*model.Product, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+
+	product, ok := in.products[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return &product, nil
+}
+
+func (in *InventoryMemoryRepository) FindManyByIDs(ctx context.Context, ids []string) ([]*model.Product, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+
+	var products []*model.Product
+	for _, id := range ids {
+		product, ok := in.products[id]
+		if !ok {
+			return nil, sql.ErrNoRows
+		}
+		products = append(products, &product)
+	}
+
+	return products, nil
+}
+
+func (in *InventoryMemoryRepository) UpdatePrice(ctx context.Context, id string, price float64) (float64, error) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	product, ok := in.products[id]
+	if !ok {
+		return 0, sql.ErrNoRows
+	}
+
+	oldPrice := product.Price
+	product.Price = roundCents(price)
+	product.UpdatedAt = now()
+	in.products[id] = product
+	if product.Price != oldPrice {
+		in.record(product, model.ProductChangeUpdated, 0, model.ProductChangeReasonPriceChange)
+	}
+
+	return oldPrice, nil
+}
+
+func (in *InventoryMemoryRepository) UpdateStockQuantity(ctx context.Context, id string, change int) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	product, ok := in.products[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if product.StockQuantity+change < 0 {
+		return repository.ErrInsufficientStock
+	}
+
+	product.StockQuantity += change
+	product.UpdatedAt = now()
+	in.products[id] = product
+
+	return nil
+}
+
+func (in *InventoryMemoryRepository) RecordMovement(ctx context.Context, movement *model.StockMovement) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	if movement.Reference != nil {
+		for _, existing := range in.movements {
+			if existing.Reference != nil && *existing.Reference == *movement.Reference {
+				if err := repository.CheckReference(&existing, movement); err != nil {
+					return err
+				}
+				*movement = existing
+				movement.Reference = cloneString(existing.Reference)
+				return nil
+			}
+		}
+	}
+
+	product, ok := in.products[movement.ProductID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if product.StockQuantity+movement.QuantityChange < 0 {
+		return repository.ErrInsufficientStock
+	}
+	if movement.QuantityChange == 0 {
+		return ErrZeroMovement
+	}
+
+	now := now()
+	product.StockQuantity += movement.QuantityChange
+	product.UpdatedAt = now
+	in.products[product.ID] = product
+
+	movement.ID = uuid.NewString()
+	movement.StockAfter = product.StockQuantity
+	movement.CreatedAt = now
+
+	stored := *movement
+	stored.Reference = cloneString(movement.Reference)
+	in.movements = append(in.movements, stored)
+	in.record(product, model.ProductChangeStockChanged, movement.QuantityChange, movement.Reason)
+
+	return nil
+}
+
+func (in *InventoryMemoryRepository) FindMovementsByProductID(ctx context.Context, productID string) ([]*model.StockMovement, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+
+	var movements []*model.StockMovement
+	for _, movement := range in.movements {
+		if movement.ProductID == productID {
+			movement.Reference = cloneString(movement.Reference)
+			movements = append(movements, &movement)
+		}
+	}
+	slices.SortStableFunc(movements, func(a, b *model.StockMovement) int {
+		return cmp.Compare(a.CreatedAt.UnixNano(), b.CreatedAt.UnixNano())
+	})
+
+	return movements, nil
+}
+
+func (in *InventoryMemoryRepository) FindChanges(ctx context.Context, after int64, productIDs []string, limit int) ([]*model.ProductChange, error) {
+	in.mu.RLock()
+	defer in.mu.RUnlock()
+
+	start, _ := slices.BinarySearchFunc(in.changes, after+1, func(change model.ProductChange, seq int64) int {
+		return cmp.Compare(change.Seq, seq)
+	})
+
+	var changes []*model.ProductChange
+	for _, change := range in.changes[start:] {
+		if len(changes) == limit {
+			break
+		}
+		if len(productIDs) == 0 || slices.Contains(productIDs, change.Product.ID) {
+			changes = append(changes, &change)
+		}
+	}
+
+	return changes, nil
+}
+
+// record appends a change of product, as it is after the change, to the
+// history. in.mu must be held.
+func (in *InventoryMemoryRepository) record(product model.Product, changeType string, quantityChange int, reason string) {
+	var seq int64 = 1
+	if len(in.changes) > 0 {
+		seq = in.changes[len(in.changes)-1].Seq + 1
+	}
+
+	in.changes = append(in.changes, model.ProductChange{
+		Seq:            seq,
+		Type:           changeType,
+		Product:        product,
+		QuantityChange: quantityChange,
+		Reason:         reason,
+		CreatedAt:      product.UpdatedAt,
+	})
+}
+
+// now returns the time in the precision Postgres stores.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// roundCents rounds like a NUMERIC(10, 2) column.
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
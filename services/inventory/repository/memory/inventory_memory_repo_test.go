@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"ecommerce-platform/services/inventory/repository"
+	"ecommerce-platform/services/inventory/repository/memory"
+	"ecommerce-platform/services/inventory/repository/repositorytest"
+	"testing"
+)
+
+func TestInventoryMemoryRepository(t *testing.T) {
+	repositorytest.Inventory(t, func(t *testing.T) repository.InventoryRepository {
+		return memory.NewInventoryMemoryRepository()
+	})
+}
@@ -4,11 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"ecommerce-platform/services/inventory/model"
+	"ecommerce-platform/services/inventory/repository"
 	"errors"
 	"log/slog"
 
 	"github.com/go-chi/chi/middleware"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type InventoryPgRepository struct {
@@ -44,15 +46,33 @@ func (in *InventoryPgRepository) Create(ctx context.Context, product *model.Prod
 
 	repoLogger.Info("Create started", "input_product", product)
 
+	tx, err := in.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `INSERT INTO products (id, name, price, stock_quantity) VALUES ($1, $2, $3, $4) RETURNING id, created_at, updated_at`
-	row := in.db.QueryRowContext(ctx, query, uuid.NewString(), product.Name, product.Price, product.StockQuantity)
+	row := tx.QueryRowContext(ctx, query, uuid.NewString(), product.Name, product.Price, product.StockQuantity)
 
-	err := row.Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
+	err = row.Scan(&product.ID, &product.CreatedAt, &product.UpdatedAt)
 	if err != nil {
 		repoLogger.Error("Could not create product", "error", err)
 		return err
 	}
 
+	err = recordChange(ctx, tx, product.ID, model.ProductChangeCreated, product.StockQuantity, model.ProductChangeReasonCreated)
+	if err != nil {
+		repoLogger.Error("Could not record product creation", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
 	repoLogger.Info("Create successful", "output_product", product)
 
 	return nil
@@ -84,12 +104,57 @@ func (in *InventoryPgRepository) FindManyByIDs(ctx context.Context, ids []string
 	return products, nil
 }
 
+func (in *InventoryPgRepository) UpdatePrice(ctx context.Context, id string, price float64) (float64, error) {
+	repoLogger := in.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("UpdatePrice started", "product_id", id, "price", price)
+
+	tx, err := in.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// Lock the product row, so the old price is the one replaced.
+	var oldPrice float64
+	err = tx.QueryRowContext(ctx, `SELECT price FROM products WHERE id = $1 FOR UPDATE`, id).Scan(&oldPrice)
+	if err != nil {
+		repoLogger.Error("Error finding product", "error", err)
+		return 0, err
+	}
+
+	var newPrice float64
+	err = tx.QueryRowContext(ctx, `UPDATE products SET price = $1 WHERE id = $2 RETURNING price`, price, id).Scan(&newPrice)
+	if err != nil {
+		repoLogger.Error("Error updating price", "error", err)
+		return 0, err
+	}
+
+	if newPrice != oldPrice {
+		err = recordChange(ctx, tx, id, model.ProductChangeUpdated, 0, model.ProductChangeReasonPriceChange)
+		if err != nil {
+			repoLogger.Error("Could not record price change", "error", err)
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return 0, err
+	}
+
+	repoLogger.Info("UpdatePrice successful", "old_price", oldPrice)
+
+	return oldPrice, nil
+}
+
 func (in *InventoryPgRepository) UpdateStockQuantity(ctx context.Context, id string, change int) error {
 	repoLogger := in.logger.With("request_id", middleware.GetReqID(ctx))
 
 	repoLogger.Info("UpdateStockQuantity started", "product_id", id, "stock_change", change)
 
-	query := `UPDATE products SET stock = stock + $1 WHERE id = $2`
+	query := `UPDATE products SET stock_quantity = stock_quantity + $1 WHERE id = $2`
 
 	res, err := in.db.ExecContext(ctx, query, change, id)
 	if err != nil {
@@ -112,6 +177,220 @@ func (in *InventoryPgRepository) UpdateStockQuantity(ctx context.Context, id str
 	return nil
 }
 
+func (in *InventoryPgRepository) RecordMovement(ctx context.Context, movement *model.StockMovement) error {
+	repoLogger := in.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("RecordMovement started", "movement", movement)
+
+	tx, err := in.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if movement.Reference != nil {
+		existing, err := findMovementByReference(ctx, tx, *movement.Reference)
+		if err == nil {
+			if err := repository.CheckReference(existing, movement); err != nil {
+				repoLogger.Error("Reference used for a different movement", "existing", existing)
+				return err
+			}
+			repoLogger.Info("Movement with given reference already recorded", "movement", existing)
+			*movement = *existing
+			return nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Error("Error reading database", "error", err)
+			return err
+		}
+	}
+
+	// Lock the product row so concurrent movements are applied one after another.
+	var stock int
+	var price float64
+	err = tx.QueryRowContext(ctx, `SELECT stock_quantity, price FROM products WHERE id = $1 FOR UPDATE`, movement.ProductID).Scan(&stock, &price)
+	if err != nil {
+		repoLogger.Error("Error finding product", "product_id", movement.ProductID, "error", err)
+		return err
+	}
+
+	if stock+movement.QuantityChange < 0 {
+		repoLogger.Error("Movement would make stock negative", "stock_quantity", stock, "quantity_change", movement.QuantityChange)
+		return repository.ErrInsufficientStock
+	}
+
+	_, err = tx.ExecContext(ctx, `UPDATE products SET stock_quantity = $1 WHERE id = $2`, stock+movement.QuantityChange, movement.ProductID)
+	if err != nil {
+		repoLogger.Error("Error updating stock quantity", "error", err)
+		return err
+	}
+
+	if err := lockHistory(ctx, tx); err != nil {
+		repoLogger.Error("Could not lock history", "error", err)
+		return err
+	}
+
+	// A concurrent call with the same reference may have got past the check
+	// above. The insert then waits for it and inserts nothing.
+	exec := `INSERT INTO stock_movements (id, product_id, quantity_change, stock_after, price_after, reason, reference) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (reference) DO NOTHING RETURNING created_at`
+
+	movement.ID = uuid.NewString()
+	movement.StockAfter = stock + movement.QuantityChange
+
+	err = tx.QueryRowContext(ctx, exec, movement.ID, movement.ProductID, movement.QuantityChange, movement.StockAfter, price, movement.Reason, movement.Reference).Scan(&movement.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Undo the stock change, the other call's movement is the one that counts.
+		if err := tx.Rollback(); err != nil {
+			repoLogger.Error("Could not roll back transaction", "error", err)
+			return err
+		}
+		existing, err := findMovementByReference(ctx, in.db, *movement.Reference)
+		if err != nil {
+			repoLogger.Error("Error reading database", "error", err)
+			return err
+		}
+		if err := repository.CheckReference(existing, movement); err != nil {
+			repoLogger.Error("Reference used concurrently for a different movement", "existing", existing)
+			return err
+		}
+		repoLogger.Info("Movement with given reference recorded concurrently", "movement", existing)
+		*movement = *existing
+		return nil
+	}
+	if err != nil {
+		repoLogger.Error("Could not create stock movement", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
+	repoLogger.Info("RecordMovement successful", "movement", movement)
+
+	return nil
+}
+
+// rowQuerier is a *sql.DB or *sql.Tx.
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func findMovementByReference(ctx context.Context, db rowQuerier, reference string) (*model.StockMovement, error) {
+	query := `SELECT id, product_id, quantity_change, stock_after, reason, reference, created_at FROM stock_movements WHERE reference = $1`
+
+	var movement model.StockMovement
+	err := db.QueryRowContext(ctx, query, reference).Scan(&movement.ID, &movement.ProductID, &movement.QuantityChange,
+		&movement.StockAfter, &movement.Reason, &movement.Reference, &movement.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &movement, nil
+}
+
+func (in *InventoryPgRepository) FindMovementsByProductID(ctx context.Context, productID string) ([]*model.StockMovement, error) {
+	repoLogger := in.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindMovementsByProductID started", "product_id", productID)
+
+	query := `SELECT id, product_id, quantity_change, stock_after, reason, reference, created_at FROM stock_movements WHERE product_id = $1 AND change_type = 'STOCK_CHANGED' ORDER BY created_at`
+
+	rows, err := in.db.QueryContext(ctx, query, productID)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []*model.StockMovement
+	for rows.Next() {
+		var movement model.StockMovement
+		err := rows.Scan(&movement.ID, &movement.ProductID, &movement.QuantityChange, &movement.StockAfter, &movement.Reason, &movement.Reference, &movement.CreatedAt)
+		if err != nil {
+			repoLogger.Error("Error reading database", "error", err)
+			return nil, err
+		}
+		movements = append(movements, &movement)
+	}
+	if err := rows.Err(); err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindMovementsByProductID successful", "count", len(movements))
+
+	return movements, nil
+}
+
+func (in *InventoryPgRepository) FindChanges(ctx context.Context, after int64, productIDs []string, limit int) ([]*model.ProductChange, error) {
+	repoLogger := in.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindChanges started", "after", after, "product_ids", productIDs, "limit", limit)
+
+	query := `SELECT m.seq, m.change_type, m.quantity_change, m.reason, m.created_at,
+			p.id, p.name, m.price_after, m.stock_after, p.created_at
+		FROM stock_movements m JOIN products p ON p.id = m.product_id
+		WHERE m.seq > $1 AND (COALESCE(cardinality($2::uuid[]), 0) = 0 OR m.product_id = ANY($2::uuid[]))
+		ORDER BY m.seq
+		LIMIT $3`
+
+	rows, err := in.db.QueryContext(ctx, query, after, pq.Array(productIDs), limit)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []*model.ProductChange
+	for rows.Next() {
+		var change model.ProductChange
+		err := rows.Scan(&change.Seq, &change.Type, &change.QuantityChange, &change.Reason, &change.CreatedAt,
+			&change.Product.ID, &change.Product.Name, &change.Product.Price, &change.Product.StockQuantity, &change.Product.CreatedAt)
+		if err != nil {
+			repoLogger.Error("Error reading database", "error", err)
+			return nil, err
+		}
+		change.Product.UpdatedAt = change.CreatedAt
+		changes = append(changes, &change)
+	}
+	if err := rows.Err(); err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindChanges successful", "count", len(changes))
+
+	return changes, nil
+}
+
+// historyLockID identifies the advisory lock taken before an entry is added
+// to the history. It is held until commit, so entries commit in the order of
+// their seq and a reader of the history never sees an entry before one with
+// a lower seq that commits later. Take it last, after any row locks.
+const historyLockID int64 = 7_318_446_202
+
+func lockHistory(ctx context.Context, tx *sql.Tx) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1)`, historyLockID)
+	return err
+}
+
+// recordChange adds a change of the product, which is locked or new in tx,
+// to the history with the price and stock the product has in tx.
+func recordChange(ctx context.Context, tx *sql.Tx, productID, changeType string, quantityChange int, reason string) error {
+	if err := lockHistory(ctx, tx); err != nil {
+		return err
+	}
+
+	exec := `INSERT INTO stock_movements (id, product_id, change_type, quantity_change, stock_after, price_after, reason)
+		SELECT $1, id, $2, $3, stock_quantity, price, $4 FROM products WHERE id = $5`
+	_, err := tx.ExecContext(ctx, exec, uuid.NewString(), changeType, quantityChange, reason, productID)
+	return err
+}
+
 func NewInventoryPgRepository(db *sql.DB, logger *slog.Logger) (*InventoryPgRepository, error) {
 	if err := db.Ping(); err != nil {
 		return nil, errors.New("failed to connect to the database: " + err.Error())
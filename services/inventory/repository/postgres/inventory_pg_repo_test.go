@@ -0,0 +1,19 @@
+package postgres_test
+
+import (
+	"ecommerce-platform/internal/database/dbtest"
+	"ecommerce-platform/services/inventory/repository"
+	"ecommerce-platform/services/inventory/repository/postgres"
+	"ecommerce-platform/services/inventory/repository/repositorytest"
+	"testing"
+)
+
+func TestInventoryPgRepository(t *testing.T) {
+	repositorytest.Inventory(t, func(t *testing.T) repository.InventoryRepository {
+		repo, err := postgres.NewInventoryPgRepository(dbtest.Open(t), dbtest.Logger())
+		if err != nil {
+			t.Fatalf("NewInventoryPgRepository: %v", err)
+		}
+		return repo
+	})
+}
@@ -0,0 +1,409 @@
+// Package repositorytest holds the conformance tests every implementation of
+// the inventory repository has to pass, so the in-memory one can stand in
+// for Postgres.
+package repositorytest
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/inventory/model"
+	"ecommerce-platform/services/inventory/repository"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Inventory runs the conformance tests against the repositories created by
+// newRepo, a new one for every test.
+func Inventory(t *testing.T, newRepo func(t *testing.T) repository.InventoryRepository) {
+	ctx := context.Background()
+
+	t.Run("Create assigns ID and timestamps", func(t *testing.T) {
+		repo := newRepo(t)
+
+		product := &model.Product{Name: "Gopher T-Shirt", Price: 19.999, StockQuantity: 3}
+		if err := repo.Create(ctx, product); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if _, err := uuid.Parse(product.ID); err != nil {
+			t.Errorf("ID = %q, want a UUID", product.ID)
+		}
+		if product.CreatedAt.IsZero() || !product.UpdatedAt.Equal(product.CreatedAt) {
+			t.Errorf("CreatedAt = %v, UpdatedAt = %v, want both set and equal", product.CreatedAt, product.UpdatedAt)
+		}
+
+		found, err := repo.FindByID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.Name != product.Name || found.StockQuantity != 3 || !found.CreatedAt.Equal(product.CreatedAt) {
+			t.Errorf("FindByID = %+v, want %+v", found, product)
+		}
+		if found.Price != 20 {
+			t.Errorf("Price = %v, want it rounded to 20", found.Price)
+		}
+	})
+
+	t.Run("Create with negative stock", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.Create(ctx, &model.Product{Name: "Broken", Price: 1, StockQuantity: -1}); err == nil {
+			t.Error("Create succeeded, want an error")
+		}
+	})
+
+	t.Run("FindByID of missing product", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.FindByID(ctx, uuid.NewString()); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("FindByID error = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("FindManyByIDs", func(t *testing.T) {
+		repo := newRepo(t)
+		first := createProduct(t, repo, 1)
+		second := createProduct(t, repo, 2)
+
+		products, err := repo.FindManyByIDs(ctx, []string{second.ID, first.ID})
+		if err != nil {
+			t.Fatalf("FindManyByIDs: %v", err)
+		}
+		if len(products) != 2 || products[0].ID != second.ID || products[1].ID != first.ID {
+			t.Errorf("FindManyByIDs = %+v, want the products in the order of the IDs", products)
+		}
+
+		if _, err := repo.FindManyByIDs(ctx, []string{first.ID, uuid.NewString()}); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("FindManyByIDs with a missing product error = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("UpdatePrice", func(t *testing.T) {
+		repo := newRepo(t)
+		product := createProduct(t, repo, 5)
+
+		time.Sleep(5 * time.Millisecond)
+		oldPrice, err := repo.UpdatePrice(ctx, product.ID, 12.5)
+		if err != nil {
+			t.Fatalf("UpdatePrice: %v", err)
+		}
+		if oldPrice != product.Price {
+			t.Errorf("UpdatePrice old price = %v, want %v", oldPrice, product.Price)
+		}
+
+		found, err := repo.FindByID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.Price != 12.5 {
+			t.Errorf("Price = %v, want 12.5", found.Price)
+		}
+		if !found.UpdatedAt.After(found.CreatedAt) {
+			t.Errorf("UpdatedAt = %v, want after CreatedAt %v", found.UpdatedAt, found.CreatedAt)
+		}
+
+		if _, err := repo.UpdatePrice(ctx, uuid.NewString(), 1); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("UpdatePrice of missing product error = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("UpdateStockQuantity", func(t *testing.T) {
+		repo := newRepo(t)
+		product := createProduct(t, repo, 5)
+
+		time.Sleep(5 * time.Millisecond)
+		if err := repo.UpdateStockQuantity(ctx, product.ID, -2); err != nil {
+			t.Fatalf("UpdateStockQuantity: %v", err)
+		}
+		if err := repo.UpdateStockQuantity(ctx, product.ID, -4); err == nil {
+			t.Error("UpdateStockQuantity below zero succeeded, want an error")
+		}
+
+		found, err := repo.FindByID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.StockQuantity != 3 {
+			t.Errorf("StockQuantity = %d, want 3", found.StockQuantity)
+		}
+		if !found.UpdatedAt.After(found.CreatedAt) {
+			t.Errorf("UpdatedAt = %v, want after CreatedAt %v", found.UpdatedAt, found.CreatedAt)
+		}
+
+		if err := repo.UpdateStockQuantity(ctx, uuid.NewString(), 1); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("UpdateStockQuantity of missing product error = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("RecordMovement", func(t *testing.T) {
+		repo := newRepo(t)
+		product := createProduct(t, repo, 5)
+
+		movement := &model.StockMovement{ProductID: product.ID, QuantityChange: -2, Reason: model.StockMovementReasonManualAdjustment}
+		if err := repo.RecordMovement(ctx, movement); err != nil {
+			t.Fatalf("RecordMovement: %v", err)
+		}
+		if _, err := uuid.Parse(movement.ID); err != nil {
+			t.Errorf("ID = %q, want a UUID", movement.ID)
+		}
+		if movement.StockAfter != 3 || movement.CreatedAt.IsZero() {
+			t.Errorf("StockAfter = %d, CreatedAt = %v, want 3 and set", movement.StockAfter, movement.CreatedAt)
+		}
+
+		found, err := repo.FindByID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.StockQuantity != 3 {
+			t.Errorf("StockQuantity = %d, want 3", found.StockQuantity)
+		}
+	})
+
+	t.Run("RecordMovement below zero", func(t *testing.T) {
+		repo := newRepo(t)
+		product := createProduct(t, repo, 1)
+
+		movement := &model.StockMovement{ProductID: product.ID, QuantityChange: -2, Reason: model.StockMovementReasonManualAdjustment}
+		if err := repo.RecordMovement(ctx, movement); !errors.Is(err, repository.ErrInsufficientStock) {
+			t.Errorf("RecordMovement error = %v, want ErrInsufficientStock", err)
+		}
+
+		movements, err := repo.FindMovementsByProductID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("FindMovementsByProductID: %v", err)
+		}
+		if len(movements) != 0 {
+			t.Errorf("FindMovementsByProductID = %+v, want none", movements)
+		}
+	})
+
+	t.Run("RecordMovement of missing product", func(t *testing.T) {
+		repo := newRepo(t)
+
+		movement := &model.StockMovement{ProductID: uuid.NewString(), QuantityChange: 1, Reason: model.StockMovementReasonManualAdjustment}
+		if err := repo.RecordMovement(ctx, movement); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("RecordMovement error = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("RecordMovement with known reference", func(t *testing.T) {
+		repo := newRepo(t)
+		product := createProduct(t, repo, 0)
+		reference := "return-line-" + uuid.NewString()
+
+		first := &model.StockMovement{ProductID: product.ID, QuantityChange: 2, Reason: model.StockMovementReasonReturnRestock, Reference: &reference}
+		if err := repo.RecordMovement(ctx, first); err != nil {
+			t.Fatalf("RecordMovement: %v", err)
+		}
+		retry := &model.StockMovement{ProductID: product.ID, QuantityChange: 2, Reason: model.StockMovementReasonReturnRestock, Reference: &reference}
+		if err := repo.RecordMovement(ctx, retry); err != nil {
+			t.Fatalf("RecordMovement retry: %v", err)
+		}
+		if retry.ID != first.ID || retry.StockAfter != 2 || !retry.CreatedAt.Equal(first.CreatedAt) {
+			t.Errorf("retry = %+v, want the first movement %+v", retry, first)
+		}
+
+		found, err := repo.FindByID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.StockQuantity != 2 {
+			t.Errorf("StockQuantity = %d, want the movement applied once", found.StockQuantity)
+		}
+	})
+
+	t.Run("RecordMovement with reused reference", func(t *testing.T) {
+		repo := newRepo(t)
+		product := createProduct(t, repo, 5)
+		other := createProduct(t, repo, 5)
+		reference := "order-" + uuid.NewString()
+
+		first := &model.StockMovement{ProductID: product.ID, QuantityChange: -2, Reason: model.StockMovementReasonManualAdjustment, Reference: &reference}
+		if err := repo.RecordMovement(ctx, first); err != nil {
+			t.Fatalf("RecordMovement: %v", err)
+		}
+
+		reused := []*model.StockMovement{
+			{ProductID: product.ID, QuantityChange: -3, Reason: model.StockMovementReasonManualAdjustment, Reference: &reference},
+			{ProductID: other.ID, QuantityChange: -2, Reason: model.StockMovementReasonManualAdjustment, Reference: &reference},
+		}
+		for _, movement := range reused {
+			if err := repo.RecordMovement(ctx, movement); !errors.Is(err, repository.ErrReferenceReused) {
+				t.Errorf("RecordMovement(%+v) error = %v, want ErrReferenceReused", movement, err)
+			}
+		}
+
+		for _, p := range []*model.Product{product, other} {
+			found, err := repo.FindByID(ctx, p.ID)
+			if err != nil {
+				t.Fatalf("FindByID: %v", err)
+			}
+			want := 5
+			if p == product {
+				want = 3
+			}
+			if found.StockQuantity != want {
+				t.Errorf("StockQuantity of %s = %d, want %d", p.ID, found.StockQuantity, want)
+			}
+		}
+	})
+
+	t.Run("FindMovementsByProductID", func(t *testing.T) {
+		repo := newRepo(t)
+		product := createProduct(t, repo, 0)
+		other := createProduct(t, repo, 0)
+
+		for _, change := range []int{3, -1, 2} {
+			time.Sleep(time.Millisecond)
+			movement := &model.StockMovement{ProductID: product.ID, QuantityChange: change, Reason: model.StockMovementReasonManualAdjustment}
+			if err := repo.RecordMovement(ctx, movement); err != nil {
+				t.Fatalf("RecordMovement: %v", err)
+			}
+		}
+		otherMovement := &model.StockMovement{ProductID: other.ID, QuantityChange: 1, Reason: model.StockMovementReasonManualAdjustment}
+		if err := repo.RecordMovement(ctx, otherMovement); err != nil {
+			t.Fatalf("RecordMovement: %v", err)
+		}
+
+		movements, err := repo.FindMovementsByProductID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("FindMovementsByProductID: %v", err)
+		}
+		var stockAfter []int
+		for _, movement := range movements {
+			stockAfter = append(stockAfter, movement.StockAfter)
+		}
+		if len(stockAfter) != 3 || stockAfter[0] != 3 || stockAfter[1] != 2 || stockAfter[2] != 4 {
+			t.Errorf("stock after the movements = %v, want [3 2 4]", stockAfter)
+		}
+
+		movements, err = repo.FindMovementsByProductID(ctx, uuid.NewString())
+		if err != nil || len(movements) != 0 {
+			t.Errorf("FindMovementsByProductID of unknown product = %v, %v, want none", movements, err)
+		}
+	})
+
+	t.Run("FindChanges", func(t *testing.T) {
+		repo := newRepo(t)
+		product := createProduct(t, repo, 2)
+		other := createProduct(t, repo, 0)
+
+		if _, err := repo.UpdatePrice(ctx, product.ID, 12.5); err != nil {
+			t.Fatalf("UpdatePrice: %v", err)
+		}
+		// An unchanged price is no change.
+		if _, err := repo.UpdatePrice(ctx, product.ID, 12.5); err != nil {
+			t.Fatalf("UpdatePrice: %v", err)
+		}
+		movement := &model.StockMovement{ProductID: product.ID, QuantityChange: -1, Reason: model.StockMovementReasonManualAdjustment}
+		if err := repo.RecordMovement(ctx, movement); err != nil {
+			t.Fatalf("RecordMovement: %v", err)
+		}
+
+		changes, err := repo.FindChanges(ctx, 0, []string{product.ID}, 10)
+		if err != nil {
+			t.Fatalf("FindChanges: %v", err)
+		}
+		want := []model.ProductChange{
+			{Type: model.ProductChangeCreated, QuantityChange: 2, Reason: model.ProductChangeReasonCreated, Product: model.Product{Price: 9.5, StockQuantity: 2}},
+			{Type: model.ProductChangeUpdated, QuantityChange: 0, Reason: model.ProductChangeReasonPriceChange, Product: model.Product{Price: 12.5, StockQuantity: 2}},
+			{Type: model.ProductChangeStockChanged, QuantityChange: -1, Reason: model.StockMovementReasonManualAdjustment, Product: model.Product{Price: 12.5, StockQuantity: 1}},
+		}
+		if len(changes) != len(want) {
+			t.Fatalf("FindChanges = %d changes, want %d", len(changes), len(want))
+		}
+		for i, change := range changes {
+			w := want[i]
+			if change.Type != w.Type || change.QuantityChange != w.QuantityChange || change.Reason != w.Reason ||
+				change.Product.ID != product.ID || change.Product.Name != product.Name ||
+				change.Product.Price != w.Product.Price || change.Product.StockQuantity != w.Product.StockQuantity {
+				t.Errorf("change %d = %+v, want %+v of product %s", i, change, w, product.ID)
+			}
+			if i > 0 && change.Seq <= changes[i-1].Seq {
+				t.Errorf("change %d has Seq %d after %d, want increasing", i, change.Seq, changes[i-1].Seq)
+			}
+		}
+
+		after, err := repo.FindChanges(ctx, changes[0].Seq, []string{product.ID}, 1)
+		if err != nil {
+			t.Fatalf("FindChanges: %v", err)
+		}
+		if len(after) != 1 || after[0].Seq != changes[1].Seq {
+			t.Errorf("FindChanges after the first change with limit 1 = %+v, want the second change", after)
+		}
+
+		all, err := repo.FindChanges(ctx, changes[0].Seq-1, nil, 10)
+		if err != nil {
+			t.Fatalf("FindChanges: %v", err)
+		}
+		var ofProduct, ofOther int
+		for _, change := range all {
+			switch change.Product.ID {
+			case product.ID:
+				ofProduct++
+			case other.ID:
+				ofOther++
+			}
+		}
+		if ofProduct != 3 || ofOther != 1 {
+			t.Errorf("FindChanges of all products = %d changes of the product and %d of the other, want 3 and 1", ofProduct, ofOther)
+		}
+
+		movements, err := repo.FindMovementsByProductID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("FindMovementsByProductID: %v", err)
+		}
+		if len(movements) != 1 {
+			t.Errorf("FindMovementsByProductID = %d movements, want only the stock movement", len(movements))
+		}
+	})
+
+	t.Run("concurrent movements", func(t *testing.T) {
+		repo := newRepo(t)
+		product := createProduct(t, repo, 10)
+
+		var wg sync.WaitGroup
+		var succeeded, insufficient atomic.Int32
+		for range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				movement := &model.StockMovement{ProductID: product.ID, QuantityChange: -1, Reason: model.StockMovementReasonManualAdjustment}
+				switch err := repo.RecordMovement(ctx, movement); {
+				case err == nil:
+					succeeded.Add(1)
+				case errors.Is(err, repository.ErrInsufficientStock):
+					insufficient.Add(1)
+				default:
+					t.Errorf("RecordMovement: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if succeeded.Load() != 10 || insufficient.Load() != 10 {
+			t.Errorf("%d movements succeeded and %d had insufficient stock, want 10 each", succeeded.Load(), insufficient.Load())
+		}
+		found, err := repo.FindByID(ctx, product.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.StockQuantity != 0 {
+			t.Errorf("StockQuantity = %d, want 0", found.StockQuantity)
+		}
+	})
+}
+
+func createProduct(t *testing.T, repo repository.InventoryRepository, stock int) *model.Product {
+	t.Helper()
+
+	product := &model.Product{Name: "Product " + uuid.NewString(), Price: 9.5, StockQuantity: stock}
+	if err := repo.Create(context.Background(), product); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return product
+}
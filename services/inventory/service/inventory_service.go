@@ -3,23 +3,50 @@ package service
 import (
 	"context"
 	"database/sql"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
 	"ecommerce-platform/services/inventory/model"
 	"ecommerce-platform/services/inventory/repository"
 	"errors"
+	"fmt"
+	"iter"
 	"log/slog"
+	"math"
+	"strings"
 
 	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrProductNotFound      = apperr.New(apperr.NotFound, "product_not_found", "No product with given id")
+	ErrInvalidProductID     = apperr.New(apperr.Validation, "invalid_product_id", "Product IDs must be UUIDs")
+	ErrInvalidPrice         = apperr.New(apperr.Validation, "invalid_price", "Price must be positive")
+	ErrInvalidStockMovement = apperr.New(apperr.Validation, "invalid_stock_movement", "Stock movement needs a non-zero quantity change and a reason")
+	ErrInsufficientStock    = apperr.New(apperr.InsufficientStock, "insufficient_stock", "Not enough stock for the movement")
+	ErrReferenceReused      = apperr.New(apperr.Conflict, "stock_reference_reused", "Reference was already used for a different stock movement")
 )
 
 type InventoryService interface {
-	HandleReserveStock(ctx context.Context, orderID string, items []*model.Product) error
 	AddProduct(ctx context.Context, name string, price float64, quantity int) (*model.Product, error)
 	GetPrice(ctx context.Context, id string) (float64, error)
+	// UpdatePrice changes the price of a product and publishes
+	// product.price_changed, so caches of the price are invalidated.
+	UpdatePrice(ctx context.Context, id string, price float64) (*model.Product, error)
 	GetProductsByIDs(ctx context.Context, ids []string) ([]*model.Product, error)
+	AdjustStock(ctx context.Context, productID string, change int, reason string, reference *string) (*model.StockMovement, error)
+	GetStockMovements(ctx context.Context, productID string) ([]*model.StockMovement, error)
+	// WatchProducts returns the changes of products after cursor, or of all
+	// products if productIDs is empty, and waits for further ones until ctx
+	// is done. Cursor returns the cursor of a change.
+	WatchProducts(ctx context.Context, cursor string, productIDs []string) iter.Seq2[*model.ProductChange, error]
 }
 
 type inventoryServiceImpl struct {
 	inventoryRepo repository.InventoryRepository
+	publisher     messaging.Publisher
+	changes       *changeNotifier
 	logger        *slog.Logger
 }
 
@@ -28,7 +55,33 @@ func (in *inventoryServiceImpl) GetProductsByIDs(ctx context.Context, ids []stri
 
 	serviceLogger.Info("GetProductsByIDs started")
 
+	if len(ids) == 0 {
+		serviceLogger.Error("No product IDs")
+		return nil, ErrInvalidProductID.WithFields(apperr.FieldError{Field: "product_ids", Message: "must not be empty"})
+	}
+	var fields []apperr.FieldError
+	for i, id := range ids {
+		if !validID(id) {
+			fields = append(fields, apperr.FieldError{Field: fmt.Sprintf("product_ids[%d]", i), Message: "must be a UUID"})
+		}
+	}
+	if len(fields) > 0 {
+		serviceLogger.Error("Invalid product IDs", "fields", fields)
+		return nil, ErrInvalidProductID.WithFields(fields...)
+	}
+
 	products, err := in.inventoryRepo.FindManyByIDs(ctx, ids)
+	if errors.Is(err, sql.ErrNoRows) {
+		// The repository stops at the first missing product; the caller
+		// wants to know all of them.
+		missing, err := in.missingProducts(ctx, ids)
+		if err != nil {
+			serviceLogger.Error("Could not get product", "error", err)
+			return nil, err
+		}
+		serviceLogger.Error("Products not found", "missing", missing)
+		return nil, ErrProductNotFound.WithMetadata("product_ids", strings.Join(missing, ",")).Wrap(sql.ErrNoRows)
+	}
 	if err != nil {
 		serviceLogger.Error("Could not get product", "error", err)
 		return nil, err
@@ -44,11 +97,16 @@ func (in *inventoryServiceImpl) GetPrice(ctx context.Context, id string) (float6
 
 	serviceLogger.Info("GetPrice started")
 
+	if !validID(id) {
+		serviceLogger.Error("Invalid product ID")
+		return 0, ErrInvalidProductID.WithFields(apperr.FieldError{Field: "product_id", Message: "must be a UUID"})
+	}
+
 	product, err := in.inventoryRepo.FindByID(ctx, id)
 	if err != nil {
-		if errors.Is(sql.ErrNoRows, err) {
+		if errors.Is(err, sql.ErrNoRows) {
 			serviceLogger.Error("Product with given id could not be found")
-			return 0, err
+			return 0, ErrProductNotFound.Wrap(err)
 		}
 
 		serviceLogger.Error("Could not get product", "error", err)
@@ -62,8 +120,54 @@ func (in *inventoryServiceImpl) GetPrice(ctx context.Context, id string) (float6
 	return price, nil
 }
 
-func (in *inventoryServiceImpl) HandleReserveStock(ctx context.Context, orderID string, items []*model.Product) error {
-	panic("not implemented") // TODO: Implement
+func (in *inventoryServiceImpl) UpdatePrice(ctx context.Context, id string, price float64) (*model.Product, error) {
+	serviceLogger := in.logger.With("request_id", middleware.GetReqID(ctx), "product_id", id, "price", price)
+
+	serviceLogger.Info("UpdatePrice started")
+
+	if !validID(id) {
+		serviceLogger.Error("Invalid product ID")
+		return nil, ErrInvalidProductID.WithFields(apperr.FieldError{Field: "product_id", Message: "must be a UUID"})
+	}
+	price = math.Round(price*100) / 100
+	if price <= 0 {
+		serviceLogger.Error("Invalid price")
+		return nil, ErrInvalidPrice
+	}
+
+	oldPrice, err := in.inventoryRepo.UpdatePrice(ctx, id, price)
+	if err != nil {
+		serviceLogger.Error("Could not update price", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProductNotFound.Wrap(err)
+		}
+		return nil, err
+	}
+	in.changes.notify()
+
+	product, err := in.inventoryRepo.FindByID(ctx, id)
+	if err != nil {
+		serviceLogger.Error("Could not get product", "error", err)
+		return nil, err
+	}
+
+	if oldPrice != price {
+		err = in.publisher.Publish(ctx, events.ProductPriceChanged, events.ProductPriceChangedPayload{
+			ProductID: id,
+			OldPrice:  oldPrice,
+			Price:     price,
+			ChangedAt: product.UpdatedAt,
+		})
+		if err != nil {
+			// The price is changed; caches pick it up once their entries
+			// expire.
+			serviceLogger.Error("Could not publish price change event", "error", err)
+		}
+	}
+
+	serviceLogger.Info("UpdatePrice successful", "old_price", oldPrice)
+
+	return product, nil
 }
 
 func (in *inventoryServiceImpl) AddProduct(ctx context.Context, name string, price float64, quantity int) (*model.Product, error) {
@@ -81,15 +185,115 @@ func (in *inventoryServiceImpl) AddProduct(ctx context.Context, name string, pri
 	if err != nil {
 		return nil, err
 	}
+	in.changes.notify()
 
 	serviceLogger.Info("AddProduct completed successfully", "final_product", product)
 
 	return &product, nil
 }
 
-func NewInventoryService(inventoryRepo repository.InventoryRepository, logger *slog.Logger) *inventoryServiceImpl {
+func (in *inventoryServiceImpl) AdjustStock(ctx context.Context, productID string, change int, reason string, reference *string) (*model.StockMovement, error) {
+	serviceLogger := in.logger.With("request_id", middleware.GetReqID(ctx), "product_id", productID, "change", change, "reason", reason)
+
+	serviceLogger.Info("AdjustStock started")
+
+	if !validID(productID) {
+		serviceLogger.Error("Invalid product ID")
+		return nil, ErrInvalidProductID.WithFields(apperr.FieldError{Field: "product_id", Message: "must be a UUID"})
+	}
+	if change == 0 || reason == "" {
+		serviceLogger.Error("Invalid stock movement")
+		return nil, ErrInvalidStockMovement
+	}
+
+	movement := model.StockMovement{
+		ProductID:      productID,
+		QuantityChange: change,
+		Reason:         reason,
+		Reference:      reference,
+	}
+
+	err := in.inventoryRepo.RecordMovement(ctx, &movement)
+	if err != nil {
+		serviceLogger.Error("Could not record stock movement", "error", err)
+		if change < 0 {
+			reason := "error"
+			if errors.Is(err, repository.ErrInsufficientStock) {
+				reason = "insufficient_stock"
+			}
+			stockReservationsFailed.WithLabelValues(reason).Inc()
+		}
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrProductNotFound.WithMetadata("product_ids", productID).Wrap(err)
+		case errors.Is(err, repository.ErrInsufficientStock):
+			return nil, ErrInsufficientStock.WithViolations(apperr.Violation{
+				Type:        "STOCK",
+				Subject:     "products/" + productID,
+				Description: "Stock quantity would go below zero",
+			}).Wrap(err)
+		case errors.Is(err, repository.ErrReferenceReused):
+			return nil, ErrReferenceReused.Wrap(err)
+		}
+		return nil, err
+	}
+
+	in.changes.notify()
+
+	serviceLogger.Info("AdjustStock successful", "movement", movement)
+
+	return &movement, nil
+}
+
+func (in *inventoryServiceImpl) GetStockMovements(ctx context.Context, productID string) ([]*model.StockMovement, error) {
+	serviceLogger := in.logger.With("request_id", middleware.GetReqID(ctx), "product_id", productID)
+
+	serviceLogger.Info("GetStockMovements started")
+
+	if _, err := in.inventoryRepo.FindByID(ctx, productID); err != nil {
+		serviceLogger.Error("Could not get product", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrProductNotFound.Wrap(err)
+		}
+		return nil, err
+	}
+
+	movements, err := in.inventoryRepo.FindMovementsByProductID(ctx, productID)
+	if err != nil {
+		serviceLogger.Error("Could not get stock movements", "error", err)
+		return nil, err
+	}
+
+	serviceLogger.Info("GetStockMovements successful", "count", len(movements))
+
+	return movements, nil
+}
+
+// missingProducts returns the IDs of ids that have no product.
+func (in *inventoryServiceImpl) missingProducts(ctx context.Context, ids []string) ([]string, error) {
+	var missing []string
+	for _, id := range ids {
+		_, err := in.inventoryRepo.FindByID(ctx, id)
+		if errors.Is(err, sql.ErrNoRows) {
+			missing = append(missing, id)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return missing, nil
+}
+
+func validID(id string) bool {
+	return len(id) == 36 && uuid.Validate(id) == nil
+}
+
+func NewInventoryService(inventoryRepo repository.InventoryRepository, publisher messaging.Publisher, logger *slog.Logger) *inventoryServiceImpl {
 	return &inventoryServiceImpl{
 		inventoryRepo: inventoryRepo,
+		publisher:     publisher,
+		changes:       newChangeNotifier(),
 		logger:        logger.With("file", "inventory_service.go"),
 	}
 }
@@ -0,0 +1,13 @@
+package service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// stockReservationsFailed counts stock decreases that could not be applied,
+// by reason: "insufficient_stock" or "error".
+var stockReservationsFailed = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "ecommerce_stock_reservations_failed_total",
+	Help: "Stock decreases that failed, by reason.",
+}, []string{"reason"})
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/services/inventory/model"
+	"fmt"
+	"iter"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+var ErrInvalidCursor = apperr.New(apperr.Validation, "invalid_cursor", "Cursor is not one of a product change")
+
+const (
+	// feedBatchSize bounds the changes read from the history at once.
+	feedBatchSize = 100
+	// feedPollInterval is how often a watch looks for changes made by other
+	// instances; changes made by this one are sent right away.
+	feedPollInterval = time.Second
+)
+
+// changeNotifier wakes up the watches when this instance changed a product.
+type changeNotifier struct {
+	mu      sync.Mutex
+	changed chan struct{}
+}
+
+func newChangeNotifier() *changeNotifier {
+	return &changeNotifier{changed: make(chan struct{})}
+}
+
+// wait returns a channel that is closed by the next notify.
+func (cn *changeNotifier) wait() <-chan struct{} {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	return cn.changed
+}
+
+func (cn *changeNotifier) notify() {
+	cn.mu.Lock()
+	defer cn.mu.Unlock()
+	close(cn.changed)
+	cn.changed = make(chan struct{})
+}
+
+func (in *inventoryServiceImpl) WatchProducts(ctx context.Context, cursor string, productIDs []string) iter.Seq2[*model.ProductChange, error] {
+	return func(yield func(*model.ProductChange, error) bool) {
+		serviceLogger := in.logger.With("request_id", middleware.GetReqID(ctx), "cursor", cursor, "product_ids", productIDs)
+
+		serviceLogger.Info("WatchProducts started")
+
+		after, err := ParseCursor(cursor)
+		if err != nil {
+			serviceLogger.Error("Invalid cursor", "error", err)
+			yield(nil, err)
+			return
+		}
+		var fields []apperr.FieldError
+		for i, id := range productIDs {
+			if !validID(id) {
+				fields = append(fields, apperr.FieldError{Field: fmt.Sprintf("product_ids[%d]", i), Message: "must be a UUID"})
+			}
+		}
+		if len(fields) > 0 {
+			serviceLogger.Error("Invalid product IDs", "fields", fields)
+			yield(nil, ErrInvalidProductID.WithFields(fields...))
+			return
+		}
+
+		for {
+			// Taken before reading, so a change made in between is not
+			// waited for.
+			changed := in.changes.wait()
+
+			changes, err := in.inventoryRepo.FindChanges(ctx, after, productIDs, feedBatchSize)
+			if err != nil {
+				if ctx.Err() == nil {
+					serviceLogger.Error("Could not read product changes", "error", err)
+				}
+				yield(nil, err)
+				return
+			}
+			for _, change := range changes {
+				if !yield(change, nil) {
+					return
+				}
+				after = change.Seq
+			}
+			if len(changes) == feedBatchSize {
+				continue
+			}
+
+			timer := time.NewTimer(feedPollInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				serviceLogger.Info("WatchProducts ended", "cursor", Cursor(after))
+				return
+			case <-changed:
+				timer.Stop()
+			case <-timer.C:
+			}
+		}
+	}
+}
+
+// Cursor returns the cursor that resumes the product feed after the change
+// with seq.
+func Cursor(seq int64) string {
+	return strconv.FormatInt(seq, 10)
+}
+
+// ParseCursor returns the seq of the change a cursor points to. The empty
+// cursor points before the first change.
+func ParseCursor(cursor string) (int64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	seq, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil || seq < 0 {
+		return 0, ErrInvalidCursor.WithFields(apperr.FieldError{Field: "cursor", Message: "is not a cursor of a product change"})
+	}
+	return seq, nil
+}
@@ -0,0 +1,49 @@
+package consumer
+
+import (
+	"context"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
+	"ecommerce-platform/services/notification/service"
+	"log/slog"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// QueueName is the queue the notification service consumes its events from.
+const QueueName = "notification-service"
+
+// EventTypes lists the events the notification service subscribes to.
+var EventTypes = []string{
+	events.OrderCreated,
+	events.ShipmentShipped,
+	events.ShipmentDelivered,
+	events.PaymentRefunded,
+	events.UserRegistered,
+	events.UserPasswordResetRequested,
+}
+
+type EventConsumer struct {
+	notificationService service.NotificationService
+	logger              *slog.Logger
+}
+
+func NewEventConsumer(notificationService service.NotificationService, logger *slog.Logger) *EventConsumer {
+	return &EventConsumer{
+		notificationService: notificationService,
+		logger:              logger.With("file", "event_consumer.go"),
+	}
+}
+
+func (ec *EventConsumer) Handle(ctx context.Context, event messaging.Event) error {
+	eventLogger := ec.logger.With("request_id", middleware.GetReqID(ctx), "event_id", event.ID, "event_type", event.Type)
+
+	eventLogger.Info("Processing event")
+
+	if err := ec.notificationService.HandleEvent(ctx, event); err != nil {
+		eventLogger.Error("Could not process event", "error", err)
+		return err
+	}
+
+	return nil
+}
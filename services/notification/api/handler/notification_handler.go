@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/services/notification/service"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+type NotificationHandler struct {
+	notificationService service.NotificationService
+	logger              *slog.Logger
+}
+
+func NewNotificationHandler(notificationService service.NotificationService, logger *slog.Logger) *NotificationHandler {
+	return &NotificationHandler{
+		notificationService: notificationService,
+		logger:              logger.With("file", "notification_handler.go"),
+	}
+}
+
+func (nh *NotificationHandler) GetRecipient(w http.ResponseWriter, r *http.Request) {
+	reqLogger := nh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	userId := chi.URLParam(r, "userId")
+
+	reqLogger.Info("Retrieving recipient", "user_id", userId)
+
+	if !mayRead(r, userId) {
+		reqLogger.Error("Recipient belongs to another user", "user_id", userId)
+		apperr.Write(w, r, apperr.ErrNotFound)
+		return
+	}
+
+	recipient, err := nh.notificationService.GetRecipient(r.Context(), userId)
+	if err != nil {
+		reqLogger.Error("Error retrieving recipient", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(recipient)
+}
+
+func (nh *NotificationHandler) GetNotification(w http.ResponseWriter, r *http.Request) {
+	reqLogger := nh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	notificationId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Retrieving notification by id", "notification_id", notificationId)
+
+	notification, err := nh.notificationService.GetNotification(r.Context(), notificationId)
+	if err != nil {
+		reqLogger.Error("Error retrieving notification", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	owner := ""
+	if notification.UserID != nil {
+		owner = *notification.UserID
+	}
+	if !mayRead(r, owner) {
+		reqLogger.Error("Notification belongs to another user", "notification_id", notificationId)
+		apperr.Write(w, r, apperr.ErrNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(notification)
+}
+
+// mayRead reports whether the caller may see the messages of the user: users
+// see their own, support staff everybody's.
+func mayRead(r *http.Request, userID string) bool {
+	principal := auth.PrincipalFromContext(r.Context())
+	if principal == nil {
+		return false
+	}
+	return (userID != "" && userID == principal.UserID) || principal.Can(auth.PermNotificationsRead)
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"ecommerce-platform/internal/auth"
+	"log/slog"
+
+	"github.com/go-chi/chi"
+)
+
+// Policy returns the permissions of the routes registered by Routes. The
+// API only reads, the handlers check whose messages the caller may see.
+// Recipients are only written from user.registered events.
+func Policy(logger *slog.Logger) *auth.Policy {
+	return auth.NewPolicy(logger)
+}
+
+// Routes registers the API of the notification service on r, which has to
+// authenticate the caller and enforce Policy.
+func Routes(r chi.Router, notificationHandler *NotificationHandler) {
+	r.Get("/recipients/{userId}", notificationHandler.GetRecipient)
+	r.Get("/notifications/{id}", notificationHandler.GetNotification)
+}
@@ -0,0 +1,48 @@
+package model
+
+import "time"
+
+const (
+	NotificationStatusPending = "PENDING"
+	NotificationStatusSent    = "SENT"
+	NotificationStatusFailed  = "FAILED"
+	NotificationStatusSkipped = "SKIPPED"
+)
+
+type Recipient struct {
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email"`
+	Name      *string   `json:"name,omitempty"`
+	Locale    string    `json:"locale"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type Notification struct {
+	ID            string             `json:"id"`
+	EventID       string             `json:"eventId"`
+	EventType     string             `json:"eventType"`
+	UserID        *string            `json:"userId,omitempty"`
+	Recipient     *string            `json:"recipient,omitempty"`
+	Locale        *string            `json:"locale,omitempty"`
+	Subject       *string            `json:"subject,omitempty"`
+	TextBody      *string            `json:"textBody,omitempty"`
+	HTMLBody      *string            `json:"htmlBody,omitempty"`
+	Status        string             `json:"status"`
+	Attempts      int                `json:"attempts"`
+	LastError     *string            `json:"lastError,omitempty"`
+	NextAttemptAt *time.Time         `json:"nextAttemptAt,omitempty"`
+	SentAt        *time.Time         `json:"sentAt,omitempty"`
+	History       []*DeliveryAttempt `json:"history,omitempty"`
+	CreatedAt     time.Time          `json:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
+}
+
+type DeliveryAttempt struct {
+	ID             string    `json:"id"`
+	NotificationID string    `json:"notificationId"`
+	Attempt        int       `json:"attempt"`
+	Success        bool      `json:"success"`
+	Error          *string   `json:"error,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
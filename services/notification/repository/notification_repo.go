@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"ecommerce-platform/services/notification/model"
+	"time"
+)
+
+type NotificationRepository interface {
+	// Create stores the notification. It returns false without storing
+	// anything when a notification for the same event already exists.
+	Create(ctx context.Context, notification *model.Notification) (bool, error)
+	FindByID(ctx context.Context, id string) (*model.Notification, error)
+	// ClaimDue returns PENDING notifications whose next attempt is due and
+	// moves their next attempt lease into the future, so concurrent callers
+	// don't get the same notifications. If the caller doesn't record an
+	// attempt, they are due again after the lease.
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Notification, error)
+	// RecordAttempt stores the attempt and the notification's new delivery state.
+	RecordAttempt(ctx context.Context, notification *model.Notification, attempt *model.DeliveryAttempt) error
+	FindAttempts(ctx context.Context, notificationID string) ([]*model.DeliveryAttempt, error)
+}
+
+type RecipientRepository interface {
+	Upsert(ctx context.Context, recipient *model.Recipient) error
+	FindByUserID(ctx context.Context, userID string) (*model.Recipient, error)
+	SaveOrderCustomer(ctx context.Context, orderID, userID string) error
+	FindOrderCustomer(ctx context.Context, orderID string) (string, error)
+}
@@ -0,0 +1,193 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/notification/model"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+)
+
+const notificationColumns = `id, event_id, event_type, user_id, recipient, locale, subject, text_body, html_body, status, attempts, last_error, next_attempt_at, sent_at, created_at, updated_at`
+
+type NotificationPgRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanNotification(row rowScanner) (*model.Notification, error) {
+	var n model.Notification
+	err := row.Scan(&n.ID, &n.EventID, &n.EventType, &n.UserID, &n.Recipient, &n.Locale, &n.Subject, &n.TextBody, &n.HTMLBody,
+		&n.Status, &n.Attempts, &n.LastError, &n.NextAttemptAt, &n.SentAt, &n.CreatedAt, &n.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &n, nil
+}
+
+func (nr *NotificationPgRepository) Create(ctx context.Context, notification *model.Notification) (bool, error) {
+	repoLogger := nr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("Create started", "event_id", notification.EventID, "event_type", notification.EventType)
+
+	exec := `INSERT INTO notifications (id, event_id, event_type, user_id, recipient, locale, subject, text_body, html_body, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		ON CONFLICT (event_id) DO NOTHING
+		RETURNING created_at, updated_at`
+
+	notification.ID = uuid.NewString()
+
+	err := nr.db.QueryRowContext(ctx, exec, notification.ID, notification.EventID, notification.EventType, notification.UserID,
+		notification.Recipient, notification.Locale, notification.Subject, notification.TextBody, notification.HTMLBody,
+		notification.Status, notification.NextAttemptAt).Scan(&notification.CreatedAt, &notification.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Info("Notification for event already exists", "event_id", notification.EventID)
+			return false, nil
+		}
+
+		repoLogger.Error("Could not create record in database", "error", err)
+		return false, err
+	}
+
+	repoLogger.Info("Create successful", "notification_id", notification.ID)
+
+	return true, nil
+}
+
+func (nr *NotificationPgRepository) FindByID(ctx context.Context, id string) (*model.Notification, error) {
+	repoLogger := nr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByID started", "notification_id", id)
+
+	query := `SELECT ` + notificationColumns + ` FROM notifications WHERE id = $1`
+
+	notification, err := scanNotification(nr.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		repoLogger.Error("Error finding notification", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByID successful", "notification_id", id)
+
+	return notification, nil
+}
+
+func (nr *NotificationPgRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Notification, error) {
+	repoLogger := nr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	// Rows another replica is claiming are skipped instead of waited for.
+	query := `UPDATE notifications SET next_attempt_at = $1
+		WHERE id IN (
+			SELECT id FROM notifications WHERE status = $2 AND next_attempt_at <= $3
+			ORDER BY next_attempt_at LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + notificationColumns
+
+	rows, err := nr.db.QueryContext(ctx, query, now.Add(lease), model.NotificationStatusPending, now, limit)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notifications []*model.Notification
+	for rows.Next() {
+		notification, err := scanNotification(rows)
+		if err != nil {
+			repoLogger.Error("Error reading database", "error", err)
+			return nil, err
+		}
+		notifications = append(notifications, notification)
+	}
+
+	return notifications, rows.Err()
+}
+
+func (nr *NotificationPgRepository) RecordAttempt(ctx context.Context, notification *model.Notification, attempt *model.DeliveryAttempt) error {
+	repoLogger := nr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("RecordAttempt started", "notification_id", notification.ID, "attempt", attempt.Attempt, "success", attempt.Success)
+
+	tx, err := nr.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	exec := `INSERT INTO notification_attempts (id, notification_id, attempt, success, error) VALUES ($1, $2, $3, $4, $5) RETURNING created_at`
+
+	attempt.ID = uuid.NewString()
+	attempt.NotificationID = notification.ID
+
+	err = tx.QueryRowContext(ctx, exec, attempt.ID, attempt.NotificationID, attempt.Attempt, attempt.Success, attempt.Error).Scan(&attempt.CreatedAt)
+	if err != nil {
+		repoLogger.Error("Could not create delivery attempt", "error", err)
+		return err
+	}
+
+	query := `UPDATE notifications SET status = $1, attempts = $2, last_error = $3, next_attempt_at = $4, sent_at = $5 WHERE id = $6 RETURNING updated_at`
+
+	err = tx.QueryRowContext(ctx, query, notification.Status, notification.Attempts, notification.LastError, notification.NextAttemptAt,
+		notification.SentAt, notification.ID).Scan(&notification.UpdatedAt)
+	if err != nil {
+		repoLogger.Error("Could not update notification", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
+	repoLogger.Info("RecordAttempt successful", "notification_id", notification.ID, "status", notification.Status)
+
+	return nil
+}
+
+func (nr *NotificationPgRepository) FindAttempts(ctx context.Context, notificationID string) ([]*model.DeliveryAttempt, error) {
+	repoLogger := nr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	query := `SELECT id, notification_id, attempt, success, error, created_at FROM notification_attempts WHERE notification_id = $1 ORDER BY attempt`
+
+	rows, err := nr.db.QueryContext(ctx, query, notificationID)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*model.DeliveryAttempt
+	for rows.Next() {
+		var attempt model.DeliveryAttempt
+		if err := rows.Scan(&attempt.ID, &attempt.NotificationID, &attempt.Attempt, &attempt.Success, &attempt.Error, &attempt.CreatedAt); err != nil {
+			repoLogger.Error("Error reading database", "error", err)
+			return nil, err
+		}
+		attempts = append(attempts, &attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+func NewNotificationPgRepository(db *sql.DB, logger *slog.Logger) (*NotificationPgRepository, error) {
+	if err := db.Ping(); err != nil {
+		return nil, errors.New("failed to connect to the database: " + err.Error())
+	}
+
+	return &NotificationPgRepository{
+		db:     db,
+		logger: logger.With("file", "notification_pg_repo.go"),
+	}, nil
+}
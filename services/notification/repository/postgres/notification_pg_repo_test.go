@@ -0,0 +1,96 @@
+package postgres_test
+
+import (
+	"context"
+	"ecommerce-platform/internal/database/dbtest"
+	"ecommerce-platform/services/notification/model"
+	"ecommerce-platform/services/notification/repository/postgres"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestClaimDue(t *testing.T) {
+	repo, err := postgres.NewNotificationPgRepository(dbtest.Open(t), dbtest.Logger())
+	if err != nil {
+		t.Fatalf("NewNotificationPgRepository: %v", err)
+	}
+	ctx := context.Background()
+
+	// Far in the past, so the notifications are due before anything else in
+	// the database.
+	due := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	ours := make(map[string]bool)
+	for range 10 {
+		body := "body"
+		n := &model.Notification{
+			EventID:       uuid.NewString(),
+			EventType:     "test",
+			TextBody:      &body,
+			Status:        model.NotificationStatusPending,
+			NextAttemptAt: &due,
+		}
+		if _, err := repo.Create(ctx, n); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		ours[n.ID] = true
+	}
+
+	now := due.Add(time.Minute)
+	const lease = time.Hour
+
+	// Concurrent claimers never get the same notification.
+	var mu sync.Mutex
+	claims := make(map[string]int)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			claimed, err := repo.ClaimDue(ctx, now, lease, 3)
+			if err != nil {
+				t.Errorf("ClaimDue: %v", err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, n := range claimed {
+				claims[n.ID]++
+				if !n.NextAttemptAt.Equal(now.Add(lease)) {
+					t.Errorf("claimed notification is due at %s, want the end of the lease", n.NextAttemptAt)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for id, count := range claims {
+		if count > 1 {
+			t.Errorf("notification %s claimed %d times", id, count)
+		}
+	}
+
+	// Claimed notifications are due again only after the lease.
+	claimOurs := func(now time.Time) int {
+		claimed, err := repo.ClaimDue(ctx, now, lease, 1000)
+		if err != nil {
+			t.Fatalf("ClaimDue: %v", err)
+		}
+		count := 0
+		for _, n := range claimed {
+			if ours[n.ID] {
+				count++
+			}
+		}
+		return count
+	}
+	claimOurs(now)
+	if n := claimOurs(now.Add(time.Minute)); n != 0 {
+		t.Errorf("%d notifications claimed again during the lease", n)
+	}
+	if n := claimOurs(now.Add(lease)); n != len(ours) {
+		t.Errorf("%d notifications claimed after the lease, want %d", n, len(ours))
+	}
+}
@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/notification/model"
+	"errors"
+	"log/slog"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+type RecipientPgRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func (rr *RecipientPgRepository) Upsert(ctx context.Context, recipient *model.Recipient) error {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("Upsert started", "user_id", recipient.UserID)
+
+	exec := `INSERT INTO notification_recipients (user_id, email, name, locale) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE SET email = EXCLUDED.email, name = EXCLUDED.name, locale = EXCLUDED.locale
+		RETURNING created_at, updated_at`
+
+	err := rr.db.QueryRowContext(ctx, exec, recipient.UserID, recipient.Email, recipient.Name, recipient.Locale).
+		Scan(&recipient.CreatedAt, &recipient.UpdatedAt)
+	if err != nil {
+		repoLogger.Error("Could not upsert recipient", "error", err)
+		return err
+	}
+
+	repoLogger.Info("Upsert successful", "user_id", recipient.UserID)
+
+	return nil
+}
+
+func (rr *RecipientPgRepository) FindByUserID(ctx context.Context, userID string) (*model.Recipient, error) {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByUserID started", "user_id", userID)
+
+	query := `SELECT user_id, email, name, locale, created_at, updated_at FROM notification_recipients WHERE user_id = $1`
+
+	var recipient model.Recipient
+	err := rr.db.QueryRowContext(ctx, query, userID).Scan(&recipient.UserID, &recipient.Email, &recipient.Name, &recipient.Locale,
+		&recipient.CreatedAt, &recipient.UpdatedAt)
+	if err != nil {
+		repoLogger.Error("Error finding recipient", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByUserID successful", "user_id", userID)
+
+	return &recipient, nil
+}
+
+func (rr *RecipientPgRepository) SaveOrderCustomer(ctx context.Context, orderID, userID string) error {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	exec := `INSERT INTO notification_order_customers (order_id, user_id) VALUES ($1, $2) ON CONFLICT (order_id) DO NOTHING`
+
+	if _, err := rr.db.ExecContext(ctx, exec, orderID, userID); err != nil {
+		repoLogger.Error("Could not save order customer", "order_id", orderID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (rr *RecipientPgRepository) FindOrderCustomer(ctx context.Context, orderID string) (string, error) {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	var userID string
+	err := rr.db.QueryRowContext(ctx, `SELECT user_id FROM notification_order_customers WHERE order_id = $1`, orderID).Scan(&userID)
+	if err != nil {
+		repoLogger.Error("Error finding order customer", "order_id", orderID, "error", err)
+		return "", err
+	}
+
+	return userID, nil
+}
+
+func NewRecipientPgRepository(db *sql.DB, logger *slog.Logger) (*RecipientPgRepository, error) {
+	if err := db.Ping(); err != nil {
+		return nil, errors.New("failed to connect to the database: " + err.Error())
+	}
+
+	return &RecipientPgRepository{
+		db:     db,
+		logger: logger.With("file", "recipient_pg_repo.go"),
+	}, nil
+}
@@ -0,0 +1,352 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
+	"ecommerce-platform/services/notification/model"
+	"ecommerce-platform/services/notification/repository"
+	"ecommerce-platform/services/notification/sender"
+	"ecommerce-platform/services/notification/templates"
+	"errors"
+	"log/slog"
+	"net/mail"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+const (
+	// MaxAttempts is how often a message is tried before it is marked FAILED.
+	MaxAttempts = 5
+
+	retryBaseDelay = 30 * time.Second
+	retryMaxDelay  = time.Hour
+	retryBatchSize = 50
+	// deliveryLease is how long a claimed message isn't retried by others. It
+	// has to be longer than sending takes.
+	deliveryLease = 5 * time.Minute
+)
+
+var ErrInvalidRecipient = apperr.New(apperr.Validation, "invalid_recipient", "Recipient needs a valid email address")
+
+type NotificationService interface {
+	// HandleEvent renders and sends the message for an event. Events without a
+	// template are ignored, and an event never produces more than one message.
+	HandleEvent(ctx context.Context, event messaging.Event) error
+	// RetryDue sends the messages whose next attempt is due.
+	RetryDue(ctx context.Context) error
+	GetNotification(ctx context.Context, id string) (*model.Notification, error)
+	SaveRecipient(ctx context.Context, recipient *model.Recipient) (*model.Recipient, error)
+	GetRecipient(ctx context.Context, userID string) (*model.Recipient, error)
+}
+
+type notificationServiceImpl struct {
+	notificationRepo repository.NotificationRepository
+	recipientRepo    repository.RecipientRepository
+	renderer         *templates.Renderer
+	sender           sender.Sender
+	logger           *slog.Logger
+}
+
+// templateData is passed to the templates: the event payload plus the
+// recipient's name.
+type templateData[T any] struct {
+	Name    string
+	Payload T
+}
+
+func (ns *notificationServiceImpl) HandleEvent(ctx context.Context, event messaging.Event) error {
+	serviceLogger := ns.logger.With("request_id", middleware.GetReqID(ctx), "event_id", event.ID, "event_type", event.Type)
+
+	serviceLogger.Info("HandleEvent started")
+
+	// data builds the template data once the recipient's name is known.
+	var data func(name string) any
+	var userID string
+	// recipient is set by events that name the address to send to.
+	var recipient *model.Recipient
+	// secret is set for messages whose body must not be stored or logged.
+	var secret bool
+	switch event.Type {
+	case events.UserRegistered:
+		var payload events.UserRegisteredPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		_, err := ns.SaveRecipient(ctx, &model.Recipient{
+			UserID: payload.UserID,
+			Email:  payload.Email,
+			Name:   payload.Name,
+			Locale: payload.Locale,
+		})
+		return err
+	case events.UserPasswordResetRequested:
+		var payload events.UserPasswordResetRequestedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		userID = payload.UserID
+		data = func(name string) any { return templateData[events.UserPasswordResetRequestedPayload]{name, payload} }
+		// The token only goes to the address of the account as the user
+		// service knows it, never to one looked up here.
+		recipient = &model.Recipient{UserID: payload.UserID, Email: payload.Email, Name: payload.Name, Locale: payload.Locale}
+		secret = true
+	case events.OrderCreated:
+		var payload events.OrderCreatedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		userID = payload.UserID
+		data = func(name string) any { return templateData[events.OrderCreatedPayload]{name, payload} }
+		// Payment events don't carry the customer, so remember who placed the order.
+		if err := ns.recipientRepo.SaveOrderCustomer(ctx, payload.OrderID, userID); err != nil {
+			return err
+		}
+	case events.ShipmentShipped, events.ShipmentInTransit, events.ShipmentDelivered:
+		var payload events.ShipmentStatusPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		userID = payload.UserID
+		data = func(name string) any { return templateData[events.ShipmentStatusPayload]{name, payload} }
+	case events.PaymentRefunded:
+		var payload events.PaymentRefundedPayload
+		if err := event.Decode(&payload); err != nil {
+			return err
+		}
+		customer, err := ns.recipientRepo.FindOrderCustomer(ctx, payload.OrderID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		userID = customer
+		data = func(name string) any { return templateData[events.PaymentRefundedPayload]{name, payload} }
+	}
+
+	if data == nil || !ns.renderer.Supports(event.Type) {
+		serviceLogger.Info("No message for event type")
+		return nil
+	}
+
+	notification := model.Notification{
+		EventID:   event.ID,
+		EventType: event.Type,
+		Status:    model.NotificationStatusPending,
+	}
+	if userID != "" {
+		notification.UserID = &userID
+	}
+
+	if recipient == nil && userID != "" {
+		found, err := ns.recipientRepo.FindByUserID(ctx, userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		recipient = found
+	}
+
+	if recipient == nil || recipient.Email == "" {
+		serviceLogger.Info("No recipient for event, skipping", "user_id", userID)
+		notification.Status = model.NotificationStatusSkipped
+		_, err := ns.notificationRepo.Create(ctx, &notification)
+		return err
+	}
+
+	name := recipient.Email
+	if recipient.Name != nil && *recipient.Name != "" {
+		name = *recipient.Name
+	}
+
+	rendered, err := ns.renderer.Render(event.Type, recipient.Locale, data(name))
+	if err != nil {
+		serviceLogger.Error("Could not render message", "error", err)
+		return err
+	}
+
+	notification.Recipient = &recipient.Email
+	notification.Locale = &rendered.Locale
+	notification.Subject = &rendered.Subject
+	if !secret {
+		// The first attempt is made right away. The retry loop only picks the
+		// message up if that attempt is never recorded.
+		lease := time.Now().Add(deliveryLease)
+		notification.TextBody = &rendered.TextBody
+		notification.HTMLBody = &rendered.HTMLBody
+		notification.NextAttemptAt = &lease
+	}
+
+	created, err := ns.notificationRepo.Create(ctx, &notification)
+	if err != nil {
+		return err
+	}
+	if !created {
+		// The event was redelivered. The retry loop takes care of the message
+		// if the first attempt failed.
+		return nil
+	}
+
+	ns.deliver(ctx, &notification, sender.Message{
+		ID:       notification.ID,
+		To:       recipient.Email,
+		Subject:  rendered.Subject,
+		TextBody: rendered.TextBody,
+		HTMLBody: rendered.HTMLBody,
+	})
+
+	serviceLogger.Info("HandleEvent completed successfully", "notification_id", notification.ID, "status", notification.Status)
+
+	return nil
+}
+
+func (ns *notificationServiceImpl) RetryDue(ctx context.Context) error {
+	due, err := ns.notificationRepo.ClaimDue(ctx, time.Now(), deliveryLease, retryBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, notification := range due {
+		ns.deliver(ctx, notification, stored(notification))
+	}
+
+	return nil
+}
+
+// RunRetryLoop calls RetryDue every interval until ctx is cancelled.
+func (ns *notificationServiceImpl) RunRetryLoop(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ns.RetryDue(ctx); err != nil {
+				ns.logger.Error("Could not retry due notifications", "error", err)
+			}
+		}
+	}
+}
+
+// stored returns the message as it was rendered for the notification.
+func stored(notification *model.Notification) sender.Message {
+	msg := sender.Message{ID: notification.ID}
+	if notification.Recipient != nil {
+		msg.To = *notification.Recipient
+	}
+	if notification.Subject != nil {
+		msg.Subject = *notification.Subject
+	}
+	if notification.TextBody != nil {
+		msg.TextBody = *notification.TextBody
+	}
+	if notification.HTMLBody != nil {
+		msg.HTMLBody = *notification.HTMLBody
+	}
+	return msg
+}
+
+// deliver makes one delivery attempt and records its outcome. Failed attempts
+// are retried with exponential backoff until MaxAttempts is reached. Messages
+// whose body is not stored, like password resets, can't be sent again and
+// fail right away; the user asks for a new one instead.
+func (ns *notificationServiceImpl) deliver(ctx context.Context, notification *model.Notification, msg sender.Message) {
+	serviceLogger := ns.logger.With("request_id", middleware.GetReqID(ctx), "notification_id", notification.ID)
+
+	notification.Attempts++
+	attempt := model.DeliveryAttempt{Attempt: notification.Attempts}
+
+	sendErr := ns.sender.Send(ctx, msg)
+	now := time.Now()
+	if sendErr == nil {
+		attempt.Success = true
+		notification.Status = model.NotificationStatusSent
+		notification.LastError = nil
+		notification.NextAttemptAt = nil
+		notification.SentAt = &now
+	} else {
+		errMsg := sendErr.Error()
+		attempt.Error = &errMsg
+		notification.LastError = &errMsg
+		if notification.Attempts >= MaxAttempts || notification.TextBody == nil {
+			notification.Status = model.NotificationStatusFailed
+			notification.NextAttemptAt = nil
+		} else {
+			next := now.Add(backoff(notification.Attempts))
+			notification.NextAttemptAt = &next
+		}
+		serviceLogger.Error("Could not send message", "attempt", notification.Attempts, "error", sendErr)
+	}
+
+	if err := ns.notificationRepo.RecordAttempt(ctx, notification, &attempt); err != nil {
+		serviceLogger.Error("Could not record delivery attempt", "error", err)
+	}
+}
+
+// backoff returns the delay before the next attempt after the given number of
+// attempts: 30s, 1m, 2m, ... up to one hour.
+func backoff(attempts int) time.Duration {
+	delay := retryBaseDelay << (attempts - 1)
+	if delay <= 0 || delay > retryMaxDelay {
+		return retryMaxDelay
+	}
+	return delay
+}
+
+func (ns *notificationServiceImpl) GetNotification(ctx context.Context, id string) (*model.Notification, error) {
+	serviceLogger := ns.logger.With("request_id", middleware.GetReqID(ctx), "notification_id", id)
+
+	serviceLogger.Info("GetNotification started")
+
+	notification, err := ns.notificationRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	notification.History, err = ns.notificationRepo.FindAttempts(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("GetNotification completed successfully")
+
+	return notification, nil
+}
+
+func (ns *notificationServiceImpl) SaveRecipient(ctx context.Context, recipient *model.Recipient) (*model.Recipient, error) {
+	serviceLogger := ns.logger.With("request_id", middleware.GetReqID(ctx), "user_id", recipient.UserID)
+
+	serviceLogger.Info("SaveRecipient started")
+
+	if _, err := mail.ParseAddress(recipient.Email); err != nil {
+		serviceLogger.Error("Invalid email address", "error", err)
+		return nil, ErrInvalidRecipient
+	}
+	if recipient.Locale == "" {
+		recipient.Locale = templates.DefaultLocale
+	}
+
+	if err := ns.recipientRepo.Upsert(ctx, recipient); err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("SaveRecipient completed successfully")
+
+	return recipient, nil
+}
+
+func (ns *notificationServiceImpl) GetRecipient(ctx context.Context, userID string) (*model.Recipient, error) {
+	return ns.recipientRepo.FindByUserID(ctx, userID)
+}
+
+func NewNotificationService(notificationRepo repository.NotificationRepository, recipientRepo repository.RecipientRepository, renderer *templates.Renderer, sender sender.Sender, logger *slog.Logger) *notificationServiceImpl {
+	return &notificationServiceImpl{
+		notificationRepo: notificationRepo,
+		recipientRepo:    recipientRepo,
+		renderer:         renderer,
+		sender:           sender,
+		logger:           logger.With("file", "notification_service.go"),
+	}
+}
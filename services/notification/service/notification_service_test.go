@@ -0,0 +1,337 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
+	"ecommerce-platform/services/notification/model"
+	"ecommerce-platform/services/notification/sender"
+	"ecommerce-platform/services/notification/templates"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// store keeps notifications and recipients like the Postgres repositories do.
+type store struct {
+	mu            sync.Mutex
+	notifications map[string]*model.Notification
+	attempts      map[string][]*model.DeliveryAttempt
+	recipients    map[string]*model.Recipient
+	customers     map[string]string
+}
+
+func newStore() *store {
+	return &store{
+		notifications: make(map[string]*model.Notification),
+		attempts:      make(map[string][]*model.DeliveryAttempt),
+		recipients:    make(map[string]*model.Recipient),
+		customers:     make(map[string]string),
+	}
+}
+
+func (s *store) Create(ctx context.Context, notification *model.Notification) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.notifications {
+		if other.EventID == notification.EventID {
+			return false, nil
+		}
+	}
+	notification.ID = uuid.NewString()
+	stored := *notification
+	s.notifications[notification.ID] = &stored
+	return true, nil
+}
+
+func (s *store) FindByID(ctx context.Context, id string) (*model.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	notification, ok := s.notifications[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	n := *notification
+	return &n, nil
+}
+
+func (s *store) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*model.Notification, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*model.Notification
+	for _, notification := range s.notifications {
+		if len(due) == limit {
+			break
+		}
+		if notification.Status != model.NotificationStatusPending || notification.NextAttemptAt == nil || notification.NextAttemptAt.After(now) {
+			continue
+		}
+		next := now.Add(lease)
+		notification.NextAttemptAt = &next
+		n := *notification
+		due = append(due, &n)
+	}
+	return due, nil
+}
+
+func (s *store) RecordAttempt(ctx context.Context, notification *model.Notification, attempt *model.DeliveryAttempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *notification
+	s.notifications[notification.ID] = &stored
+	s.attempts[notification.ID] = append(s.attempts[notification.ID], attempt)
+	return nil
+}
+
+func (s *store) FindAttempts(ctx context.Context, notificationID string) ([]*model.DeliveryAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.attempts[notificationID], nil
+}
+
+// recipients is the RecipientRepository view of the store.
+type recipients struct{ *store }
+
+func (r recipients) Upsert(ctx context.Context, recipient *model.Recipient) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored := *recipient
+	r.recipients[recipient.UserID] = &stored
+	return nil
+}
+
+func (r recipients) FindByUserID(ctx context.Context, userID string) (*model.Recipient, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recipient, ok := r.recipients[userID]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	c := *recipient
+	return &c, nil
+}
+
+func (r recipients) SaveOrderCustomer(ctx context.Context, orderID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.customers[orderID] = userID
+	return nil
+}
+
+func (r recipients) FindOrderCustomer(ctx context.Context, orderID string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	userID, ok := r.customers[orderID]
+	if !ok {
+		return "", sql.ErrNoRows
+	}
+	return userID, nil
+}
+
+// outbox keeps the sent messages and fails while fail is set.
+type outbox struct {
+	mu   sync.Mutex
+	sent []sender.Message
+	fail error
+}
+
+func (o *outbox) Send(ctx context.Context, msg sender.Message) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.fail != nil {
+		return o.fail
+	}
+	o.sent = append(o.sent, msg)
+	return nil
+}
+
+func newNotificationService(t *testing.T) (*notificationServiceImpl, *store, *outbox) {
+	t.Helper()
+	renderer, err := templates.NewRenderer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newStore()
+	o := &outbox{}
+	return NewNotificationService(s, recipients{s}, renderer, o, slog.New(slog.DiscardHandler)), s, o
+}
+
+func event(t *testing.T, eventType string, payload any) messaging.Event {
+	t.Helper()
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return messaging.Event{ID: uuid.NewString(), Type: eventType, OccurredAt: time.Now(), Payload: data}
+}
+
+// only returns the single notification of the store.
+func only(t *testing.T, s *store) *model.Notification {
+	t.Helper()
+	if len(s.notifications) != 1 {
+		t.Fatalf("%d notifications, want 1", len(s.notifications))
+	}
+	for _, notification := range s.notifications {
+		return notification
+	}
+	return nil
+}
+
+func TestHandleEvent(t *testing.T) {
+	ns, s, o := newNotificationService(t)
+	ctx := context.Background()
+
+	registered := event(t, events.UserRegistered, events.UserRegisteredPayload{UserID: "user-1", Email: "jane@example.com", Locale: "de"})
+	if err := ns.HandleEvent(ctx, registered); err != nil {
+		t.Fatalf("user.registered: %v", err)
+	}
+
+	created := event(t, events.OrderCreated, events.OrderCreatedPayload{OrderID: "order-1", UserID: "user-1", TotalPrice: 20})
+	for range 2 {
+		// The second time is a redelivery.
+		if err := ns.HandleEvent(ctx, created); err != nil {
+			t.Fatalf("order.created: %v", err)
+		}
+	}
+
+	notification := only(t, s)
+	if notification.Status != model.NotificationStatusSent || notification.Attempts != 1 || *notification.Locale != "de" {
+		t.Errorf("notification is %s after %d attempts in %v", notification.Status, notification.Attempts, *notification.Locale)
+	}
+	if len(o.sent) != 1 || o.sent[0].To != "jane@example.com" || o.sent[0].ID != notification.ID {
+		t.Errorf("sent %+v, want one message to the recipient", o.sent)
+	}
+
+	// Refund events don't name the customer; it is known from the order.
+	refunded := event(t, events.PaymentRefunded, events.PaymentRefundedPayload{OrderID: "order-1", Amount: 5})
+	if err := ns.HandleEvent(ctx, refunded); err != nil {
+		t.Fatalf("payment.refunded: %v", err)
+	}
+	if len(o.sent) != 2 || o.sent[1].To != "jane@example.com" {
+		t.Errorf("sent %+v, want the refund to the customer of the order", o.sent)
+	}
+}
+
+func TestHandleEventWithoutRecipient(t *testing.T) {
+	ns, s, o := newNotificationService(t)
+
+	created := event(t, events.OrderCreated, events.OrderCreatedPayload{OrderID: "order-1", UserID: "unknown"})
+	if err := ns.HandleEvent(context.Background(), created); err != nil {
+		t.Fatalf("order.created: %v", err)
+	}
+
+	if notification := only(t, s); notification.Status != model.NotificationStatusSkipped {
+		t.Errorf("notification is %s, want %s", notification.Status, model.NotificationStatusSkipped)
+	}
+	if len(o.sent) != 0 {
+		t.Errorf("sent %+v, want nothing", o.sent)
+	}
+}
+
+func TestRetries(t *testing.T) {
+	ns, s, o := newNotificationService(t)
+	ctx := context.Background()
+
+	recipient := &model.Recipient{UserID: "user-1", Email: "jane@example.com"}
+	if _, err := ns.SaveRecipient(ctx, recipient); err != nil {
+		t.Fatal(err)
+	}
+
+	o.fail = errors.New("relay unavailable")
+	created := event(t, events.OrderCreated, events.OrderCreatedPayload{OrderID: "order-1", UserID: "user-1"})
+	start := time.Now()
+	if err := ns.HandleEvent(ctx, created); err != nil {
+		t.Fatalf("order.created: %v", err)
+	}
+
+	notification := only(t, s)
+	if notification.Status != model.NotificationStatusPending || notification.LastError == nil {
+		t.Fatalf("notification is %s after a failed attempt, want %s", notification.Status, model.NotificationStatusPending)
+	}
+	if wait := notification.NextAttemptAt.Sub(start); wait < retryBaseDelay || wait > retryBaseDelay+time.Minute {
+		t.Errorf("next attempt in %s, want %s", wait, retryBaseDelay)
+	}
+
+	// Not due yet.
+	if err := ns.RetryDue(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if notification := only(t, s); notification.Attempts != 1 {
+		t.Errorf("%d attempts before the retry is due, want 1", notification.Attempts)
+	}
+
+	for attempt := 2; attempt <= MaxAttempts; attempt++ {
+		past := time.Now().Add(-time.Second)
+		only(t, s).NextAttemptAt = &past
+		if err := ns.RetryDue(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	notification = only(t, s)
+	if notification.Status != model.NotificationStatusFailed || notification.Attempts != MaxAttempts || notification.NextAttemptAt != nil {
+		t.Errorf("notification is %s after %d attempts, want %s after %d", notification.Status, notification.Attempts, model.NotificationStatusFailed, MaxAttempts)
+	}
+	if attempts, _ := s.FindAttempts(ctx, notification.ID); len(attempts) != MaxAttempts {
+		t.Errorf("%d attempts recorded, want %d", len(attempts), MaxAttempts)
+	}
+}
+
+func TestPasswordResetIsNotStored(t *testing.T) {
+	ns, s, o := newNotificationService(t)
+
+	o.fail = errors.New("relay unavailable")
+	requested := event(t, events.UserPasswordResetRequested, events.UserPasswordResetRequestedPayload{
+		UserID:    "user-1",
+		Email:     "jane@example.com",
+		Token:     "secret-token",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err := ns.HandleEvent(context.Background(), requested); err != nil {
+		t.Fatalf("user.password_reset_requested: %v", err)
+	}
+
+	notification := only(t, s)
+	if notification.TextBody != nil || notification.HTMLBody != nil {
+		t.Error("body with the reset token was stored")
+	}
+	// It can't be sent again without the body.
+	if notification.Status != model.NotificationStatusFailed || notification.NextAttemptAt != nil {
+		t.Errorf("notification is %s, want %s without retries", notification.Status, model.NotificationStatusFailed)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{attempts: 1, want: 30 * time.Second},
+		{attempts: 2, want: time.Minute},
+		{attempts: 4, want: 4 * time.Minute},
+		{attempts: 8, want: time.Hour},
+		{attempts: 100, want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
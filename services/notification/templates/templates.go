@@ -0,0 +1,142 @@
+// Package templates renders the notification messages. Every event type has a
+// subject, a plain text and an HTML template per locale, stored as
+// <locale>/<event type>.{subject,txt,html}.tmpl.
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+// DefaultLocale is used when no template exists for the recipient's locale.
+const DefaultLocale = "en"
+
+var ErrTemplateNotFound = errors.New("No template for event type")
+
+//go:embed en de
+var files embed.FS
+
+var funcs = map[string]any{
+	"money": func(amount float64) string {
+		return fmt.Sprintf("%.2f", amount)
+	},
+	// shortID shortens UUIDs to something readable in a subject line.
+	"shortID": func(id string) string {
+		if len(id) > 8 {
+			return strings.ToUpper(id[:8])
+		}
+		return strings.ToUpper(id)
+	},
+}
+
+type Message struct {
+	Locale   string
+	Subject  string
+	TextBody string
+	HTMLBody string
+}
+
+type set struct {
+	subject *texttemplate.Template
+	text    *texttemplate.Template
+	html    *htmltemplate.Template
+}
+
+type Renderer struct {
+	// sets is keyed by locale and event type.
+	sets map[string]map[string]*set
+}
+
+// NewRenderer parses all embedded templates. It fails if a locale is missing
+// one of the three parts of an event's message.
+func NewRenderer() (*Renderer, error) {
+	r := &Renderer{sets: make(map[string]map[string]*set)}
+
+	locales, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, locale := range locales {
+		names, err := fs.Glob(files, locale.Name()+"/*.subject.tmpl")
+		if err != nil {
+			return nil, err
+		}
+
+		r.sets[locale.Name()] = make(map[string]*set)
+		for _, name := range names {
+			base := strings.TrimSuffix(name, ".subject.tmpl")
+			eventType := strings.TrimPrefix(base, locale.Name()+"/")
+
+			var s set
+			if s.subject, err = texttemplate.New("subject").Funcs(funcs).ParseFS(files, base+".subject.tmpl"); err != nil {
+				return nil, err
+			}
+			if s.text, err = texttemplate.New("text").Funcs(funcs).ParseFS(files, base+".txt.tmpl"); err != nil {
+				return nil, err
+			}
+			if s.html, err = htmltemplate.New("html").Funcs(funcs).ParseFS(files, base+".html.tmpl"); err != nil {
+				return nil, err
+			}
+
+			r.sets[locale.Name()][eventType] = &s
+		}
+	}
+
+	return r, nil
+}
+
+// Supports reports whether there is a template for the event type.
+func (r *Renderer) Supports(eventType string) bool {
+	_, ok := r.sets[DefaultLocale][eventType]
+	return ok
+}
+
+// Render renders the message of the event type for the locale. It falls back
+// from e.g. "de-AT" to "de" and finally to DefaultLocale.
+func (r *Renderer) Render(eventType, locale string, data any) (*Message, error) {
+	locale, s := r.lookup(eventType, locale)
+	if s == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTemplateNotFound, eventType)
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := s.subject.ExecuteTemplate(&subject, eventType+".subject.tmpl", data); err != nil {
+		return nil, err
+	}
+	if err := s.text.ExecuteTemplate(&text, eventType+".txt.tmpl", data); err != nil {
+		return nil, err
+	}
+	if err := s.html.ExecuteTemplate(&html, eventType+".html.tmpl", data); err != nil {
+		return nil, err
+	}
+
+	return &Message{
+		Locale:   locale,
+		Subject:  strings.TrimSpace(subject.String()),
+		TextBody: text.String(),
+		HTMLBody: html.String(),
+	}, nil
+}
+
+func (r *Renderer) lookup(eventType, locale string) (string, *set) {
+	candidates := []string{strings.ToLower(locale)}
+	if lang, _, found := strings.Cut(locale, "-"); found {
+		candidates = append(candidates, strings.ToLower(lang))
+	}
+	candidates = append(candidates, DefaultLocale)
+
+	for _, candidate := range candidates {
+		if s, ok := r.sets[candidate][eventType]; ok {
+			return candidate, s
+		}
+	}
+
+	return "", nil
+}
@@ -0,0 +1,67 @@
+package consumer
+
+import (
+	"context"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
+	"ecommerce-platform/services/order/service"
+	"log/slog"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// QueueName is the queue the order service consumes its events from.
+const QueueName = "order-service"
+
+// EventTypes lists the events the order service subscribes to.
+var EventTypes = []string{
+	events.PaymentSucceeded,
+	events.PaymentRefunded,
+}
+
+// EventConsumer translates events from the message bus into order service calls,
+// the way the handler package does for HTTP requests.
+type EventConsumer struct {
+	orderService service.OrderService
+	logger       *slog.Logger
+}
+
+func NewEventConsumer(orderService service.OrderService, logger *slog.Logger) *EventConsumer {
+	return &EventConsumer{
+		orderService: orderService,
+		logger:       logger.With("file", "event_consumer.go"),
+	}
+}
+
+func (ec *EventConsumer) Handle(ctx context.Context, event messaging.Event) error {
+	eventLogger := ec.logger.With("request_id", middleware.GetReqID(ctx), "event_id", event.ID, "event_type", event.Type)
+
+	eventLogger.Info("Processing event")
+
+	switch event.Type {
+	case events.PaymentSucceeded:
+		var payload events.PaymentSucceededPayload
+		if err := event.Decode(&payload); err != nil {
+			eventLogger.Error("Invalid event payload", "error", err)
+			return err
+		}
+		if err := ec.orderService.HandlePaymentSucceeded(ctx, payload.OrderID); err != nil {
+			eventLogger.Error("Could not process event", "error", err)
+			return err
+		}
+	case events.PaymentRefunded:
+		var payload events.PaymentRefundedPayload
+		if err := event.Decode(&payload); err != nil {
+			eventLogger.Error("Invalid event payload", "error", err)
+			return err
+		}
+		if err := ec.orderService.HandlePaymentRefunded(ctx, payload.OrderID, payload.RefundedTotal); err != nil {
+			eventLogger.Error("Could not process event", "error", err)
+			return err
+		}
+	default:
+		eventLogger.Info("Ignoring unknown event type")
+	}
+
+	return nil
+}
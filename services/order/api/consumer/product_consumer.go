@@ -0,0 +1,51 @@
+package consumer
+
+import (
+	"context"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
+	"ecommerce-platform/services/order/client"
+	"log/slog"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// ProductEventTypes lists the events that change cached product info. Every
+// instance of the order service has its own cache, so they are consumed
+// from a temporary queue per instance rather than from QueueName.
+var ProductEventTypes = []string{
+	events.ProductPriceChanged,
+}
+
+// ProductConsumer drops products from the product cache when the inventory
+// service reports that their price changed.
+type ProductConsumer struct {
+	productCache *client.ProductCache
+	logger       *slog.Logger
+}
+
+func NewProductConsumer(productCache *client.ProductCache, logger *slog.Logger) *ProductConsumer {
+	return &ProductConsumer{
+		productCache: productCache,
+		logger:       logger.With("file", "product_consumer.go"),
+	}
+}
+
+func (pc *ProductConsumer) Handle(ctx context.Context, event messaging.Event) error {
+	eventLogger := pc.logger.With("request_id", middleware.GetReqID(ctx), "event_id", event.ID, "event_type", event.Type)
+
+	switch event.Type {
+	case events.ProductPriceChanged:
+		var payload events.ProductPriceChangedPayload
+		if err := event.Decode(&payload); err != nil {
+			eventLogger.Error("Invalid event payload", "error", err)
+			return err
+		}
+		eventLogger.Info("Dropping cached product", "product_id", payload.ProductID, "price", payload.Price)
+		pc.productCache.Invalidate(payload.ProductID)
+	default:
+		eventLogger.Info("Ignoring unknown event type")
+	}
+
+	return nil
+}
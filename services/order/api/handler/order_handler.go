@@ -2,6 +2,8 @@ package handler
 
 import (
 	"database/sql"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/auth"
 	"ecommerce-platform/services/order/model"
 	"ecommerce-platform/services/order/service"
 	"encoding/json"
@@ -14,8 +16,7 @@ import (
 )
 
 type CreateOrderRequest struct {
-	UserID string            `json:"userId"`
-	Items  []model.OrderItem `json:"items"`
+	Items []model.OrderItem `json:"items"`
 }
 
 type OrderHandler struct {
@@ -40,14 +41,23 @@ func (oh *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	var req CreateOrderRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		reqLogger.Error("Invalid request body")
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
 		return
 	}
 
-	createdOrder, err := oh.orderService.CreateOrder(r.Context(), req.UserID, req.Items)
+	if !validItems(req.Items) {
+		reqLogger.Error("Invalid request body", "req", req)
+		apperr.Write(w, r, apperr.ErrInvalidRequest)
+		return
+	}
+
+	// The order always belongs to the authenticated caller.
+	principal := auth.PrincipalFromContext(r.Context())
+
+	createdOrder, err := oh.orderService.CreateOrder(r.Context(), principal.UserID, req.Items)
 	if err != nil {
-		reqLogger.Error("Error creating order")
-		http.Error(w, "Error creating order", http.StatusInternalServerError)
+		reqLogger.Error("Error creating order", "error", err)
+		apperr.Write(w, r, err)
 		return
 	}
 
@@ -64,17 +74,65 @@ func (oh *OrderHandler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
 
 	reqLogger.Info("Retrieving order by id", "order_id", orderId, "path", r.URL.Path)
 
+	order, ok := oh.ownedOrder(w, r, orderId)
+	if !ok {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(order)
+}
+
+// RequireOwner only lets requests for the order in the {id} URL parameter
+// through if the order belongs to the caller or the caller is staff.
+func (oh *OrderHandler) RequireOwner(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := oh.ownedOrder(w, r, chi.URLParam(r, "id")); !ok {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ownedOrder loads the order and checks that it belongs to the caller. Orders
+// of other users are reported as not found, so their IDs can't be probed. If
+// it returns false, the error response was already written.
+func (oh *OrderHandler) ownedOrder(w http.ResponseWriter, r *http.Request, orderId string) (*model.Order, bool) {
+	reqLogger := oh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
 	order, err := oh.orderService.GetOrderByID(r.Context(), orderId)
 	if err != nil {
-		if errors.Is(sql.ErrNoRows, err) {
-			http.Error(w, "No order with given id", http.StatusNotFound)
-			return
+		if errors.Is(err, sql.ErrNoRows) {
+			apperr.Write(w, r, service.ErrOrderNotFound.Wrap(err))
+			return nil, false
 		}
 
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
+		reqLogger.Error("Error retrieving order", "order_id", orderId, "error", err)
+		apperr.Write(w, r, err)
+		return nil, false
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(order)
+	// Staff may look at every order.
+	principal := auth.PrincipalFromContext(r.Context())
+	if principal == nil || (order.UserID != principal.UserID && !principal.Can(auth.PermOrdersReadAll)) {
+		reqLogger.Error("Order belongs to another user", "order_id", orderId)
+		apperr.Write(w, r, service.ErrOrderNotFound)
+		return nil, false
+	}
+
+	return order, true
+}
+
+// validItems reports whether the order has items and every item names a
+// product and a positive quantity.
+func validItems(items []model.OrderItem) bool {
+	if len(items) == 0 {
+		return false
+	}
+	for _, item := range items {
+		if item.ProductID == "" || item.Quantity <= 0 {
+			return false
+		}
+	}
+	return true
 }
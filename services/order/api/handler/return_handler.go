@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/services/order/model"
+	"ecommerce-platform/services/order/service"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+type ReturnLineRequest struct {
+	ProductID string  `json:"productId"`
+	Quantity  int     `json:"quantity"`
+	Reason    *string `json:"reason,omitempty"`
+}
+
+type RequestReturnRequest struct {
+	Reason *string             `json:"reason,omitempty"`
+	Lines  []ReturnLineRequest `json:"lines"`
+}
+
+type ReturnDecisionRequest struct {
+	Note *string `json:"note,omitempty"`
+}
+
+type InspectReturnRequest struct {
+	Lines []model.ReturnInspection `json:"lines"`
+}
+
+type ReturnHandler struct {
+	returnService service.ReturnService
+	logger        *slog.Logger
+}
+
+func NewReturnHandler(returnService service.ReturnService, logger *slog.Logger) *ReturnHandler {
+	return &ReturnHandler{
+		returnService: returnService,
+		logger:        logger.With("file", "return_handler.go"),
+	}
+}
+
+func (rh *ReturnHandler) RequestReturn(w http.ResponseWriter, r *http.Request) {
+	reqLogger := rh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	orderId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Processing new return request", "order_id", orderId)
+
+	var req RequestReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	if len(req.Lines) == 0 {
+		reqLogger.Error("Invalid request body", "req", req)
+		apperr.Write(w, r, apperr.ErrInvalidRequest)
+		return
+	}
+
+	lines := make([]*model.ReturnLine, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		lines = append(lines, &model.ReturnLine{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+			Reason:    line.Reason,
+		})
+	}
+
+	principal := auth.PrincipalFromContext(r.Context())
+
+	ret, err := rh.returnService.RequestReturn(r.Context(), orderId, principal.UserID, req.Reason, lines)
+	if err != nil {
+		reqLogger.Error("Error requesting return", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Return requested successfully", "return", ret)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(ret)
+}
+
+func (rh *ReturnHandler) ListReturns(w http.ResponseWriter, r *http.Request) {
+	reqLogger := rh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	orderId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Listing returns of order", "order_id", orderId)
+
+	returns, err := rh.returnService.ListReturns(r.Context(), orderId)
+	if err != nil {
+		reqLogger.Error("Error listing returns", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(returns)
+}
+
+func (rh *ReturnHandler) GetReturn(w http.ResponseWriter, r *http.Request) {
+	reqLogger := rh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	returnId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Retrieving return by id", "return_id", returnId)
+
+	ret, err := rh.returnService.GetReturn(r.Context(), returnId)
+	if err != nil {
+		reqLogger.Error("Error retrieving return", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	principal := auth.PrincipalFromContext(r.Context())
+	if principal == nil || (ret.UserID != principal.UserID && !principal.Can(auth.PermOrdersReadAll)) {
+		reqLogger.Error("Return belongs to another user", "return_id", returnId)
+		apperr.Write(w, r, service.ErrReturnNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ret)
+}
+
+func (rh *ReturnHandler) ApproveReturn(w http.ResponseWriter, r *http.Request) {
+	rh.decide(w, r, rh.returnService.ApproveReturn)
+}
+
+func (rh *ReturnHandler) RejectReturn(w http.ResponseWriter, r *http.Request) {
+	rh.decide(w, r, rh.returnService.RejectReturn)
+}
+
+func (rh *ReturnHandler) decide(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, id string, note *string) (*model.Return, error)) {
+	reqLogger := rh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	returnId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Processing return decision", "return_id", returnId, "path", r.URL.Path)
+
+	var req ReturnDecisionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			reqLogger.Error("Invalid request body")
+			apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+			return
+		}
+	}
+
+	ret, err := decide(r.Context(), returnId, req.Note)
+	if err != nil {
+		reqLogger.Error("Error deciding return", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Return decided successfully", "return", ret)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ret)
+}
+
+func (rh *ReturnHandler) InspectReturn(w http.ResponseWriter, r *http.Request) {
+	reqLogger := rh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	returnId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Processing return inspection", "return_id", returnId)
+
+	var req InspectReturnRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	ret, err := rh.returnService.InspectReturn(r.Context(), returnId, req.Lines)
+	if err != nil {
+		reqLogger.Error("Error inspecting return", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Return inspected successfully", "return", ret)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ret)
+}
+
+func (rh *ReturnHandler) RefundReturn(w http.ResponseWriter, r *http.Request) {
+	reqLogger := rh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	returnId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Retrying refund of return", "return_id", returnId)
+
+	ret, err := rh.returnService.RefundReturn(r.Context(), returnId)
+	if err != nil {
+		reqLogger.Error("Error refunding return", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Return refunded successfully", "return", ret)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(ret)
+}
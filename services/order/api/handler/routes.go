@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"ecommerce-platform/internal/auth"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// Policy returns the permissions of the routes registered by Routes.
+// Mutating routes missing here are denied. Reading routes without a rule
+// check ownership in the handlers.
+func Policy(logger *slog.Logger) *auth.Policy {
+	return auth.NewPolicy(logger,
+		auth.Rule{Method: http.MethodPost, Pattern: "/orders", Permission: auth.PermOrdersCreate},
+		auth.Rule{Method: http.MethodPost, Pattern: "/orders/{id}/shipments", Permission: auth.PermShipmentsManage},
+		auth.Rule{Method: http.MethodPost, Pattern: "/orders/{id}/returns", Permission: auth.PermReturnsRequest},
+		auth.Rule{Method: http.MethodGet, Pattern: "/shipments/pick-lists", Permission: auth.PermShipmentsManage},
+		auth.Rule{Method: http.MethodGet, Pattern: "/shipments/{id}", Permission: auth.PermShipmentsManage},
+		auth.Rule{Method: http.MethodPut, Pattern: "/shipments/{id}/tracking", Permission: auth.PermShipmentsManage},
+		auth.Rule{Method: http.MethodPost, Pattern: "/shipments/{id}/events", Permission: auth.PermShipmentsManage},
+		auth.Rule{Method: http.MethodPost, Pattern: "/returns/{id}/approve", Permission: auth.PermReturnsDecide},
+		auth.Rule{Method: http.MethodPost, Pattern: "/returns/{id}/reject", Permission: auth.PermReturnsDecide},
+		auth.Rule{Method: http.MethodPost, Pattern: "/returns/{id}/inspection", Permission: auth.PermReturnsInspect},
+		auth.Rule{Method: http.MethodPost, Pattern: "/returns/{id}/refund", Permission: auth.PermReturnsRefund},
+	)
+}
+
+// Routes registers the API of the order service on r, which has to
+// authenticate the caller and enforce Policy.
+func Routes(r chi.Router, orderHandler *OrderHandler, shipmentHandler *ShipmentHandler, returnHandler *ReturnHandler) {
+	r.Route("/orders", func(r chi.Router) {
+		r.Post("/", orderHandler.CreateOrder)
+		r.Get("/{id}", orderHandler.GetOrderByID)
+		r.Post("/{id}/shipments", shipmentHandler.CreateShipment)
+		r.With(orderHandler.RequireOwner).Get("/{id}/shipments", shipmentHandler.ListShipments)
+		r.With(orderHandler.RequireOwner).Post("/{id}/returns", returnHandler.RequestReturn)
+		r.With(orderHandler.RequireOwner).Get("/{id}/returns", returnHandler.ListReturns)
+	})
+
+	r.Route("/shipments", func(r chi.Router) {
+		r.Get("/pick-lists", shipmentHandler.GetPickLists)
+		r.Get("/{id}", shipmentHandler.GetShipment)
+		r.Put("/{id}/tracking", shipmentHandler.SetTracking)
+		r.Post("/{id}/events", shipmentHandler.RecordEvent)
+	})
+
+	r.Route("/returns", func(r chi.Router) {
+		r.Get("/{id}", returnHandler.GetReturn)
+		r.Post("/{id}/approve", returnHandler.ApproveReturn)
+		r.Post("/{id}/reject", returnHandler.RejectReturn)
+		r.Post("/{id}/inspection", returnHandler.InspectReturn)
+		r.Post("/{id}/refund", returnHandler.RefundReturn)
+	})
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/services/order/model"
+	"ecommerce-platform/services/order/service"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+type CreateShipmentRequest struct {
+	Warehouse string               `json:"warehouse"`
+	Items     []model.ShipmentItem `json:"items"`
+}
+
+type SetTrackingRequest struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"trackingNumber"`
+}
+
+type RecordShipmentEventRequest struct {
+	Status      string     `json:"status"`
+	Location    *string    `json:"location,omitempty"`
+	Description *string    `json:"description,omitempty"`
+	OccurredAt  *time.Time `json:"occurredAt,omitempty"`
+}
+
+type ShipmentHandler struct {
+	shipmentService service.ShipmentService
+	logger          *slog.Logger
+}
+
+func NewShipmentHandler(shipmentService service.ShipmentService, logger *slog.Logger) *ShipmentHandler {
+	return &ShipmentHandler{
+		shipmentService: shipmentService,
+		logger:          logger.With("file", "shipment_handler.go"),
+	}
+}
+
+func (sh *ShipmentHandler) CreateShipment(w http.ResponseWriter, r *http.Request) {
+	reqLogger := sh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	orderId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Processing new shipment request", "order_id", orderId)
+
+	var req CreateShipmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	if req.Warehouse == "" || len(req.Items) == 0 {
+		reqLogger.Error("Invalid request body", "req", req)
+		apperr.Write(w, r, apperr.ErrInvalidRequest)
+		return
+	}
+
+	shipment, err := sh.shipmentService.CreateShipment(r.Context(), orderId, req.Warehouse, req.Items)
+	if err != nil {
+		reqLogger.Error("Error creating shipment", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Shipment created successfully", "shipment", shipment)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(shipment)
+}
+
+func (sh *ShipmentHandler) ListShipments(w http.ResponseWriter, r *http.Request) {
+	reqLogger := sh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	orderId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Listing shipments of order", "order_id", orderId)
+
+	shipments, err := sh.shipmentService.ListShipments(r.Context(), orderId)
+	if err != nil {
+		reqLogger.Error("Error listing shipments", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(shipments)
+}
+
+func (sh *ShipmentHandler) GetShipment(w http.ResponseWriter, r *http.Request) {
+	reqLogger := sh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	shipmentId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Retrieving shipment by id", "shipment_id", shipmentId)
+
+	shipment, err := sh.shipmentService.GetShipment(r.Context(), shipmentId)
+	if err != nil {
+		reqLogger.Error("Error retrieving shipment", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(shipment)
+}
+
+func (sh *ShipmentHandler) SetTracking(w http.ResponseWriter, r *http.Request) {
+	reqLogger := sh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	shipmentId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Recording tracking information", "shipment_id", shipmentId)
+
+	var req SetTrackingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	if req.Carrier == "" || req.TrackingNumber == "" {
+		reqLogger.Error("Invalid request body", "req", req)
+		apperr.Write(w, r, apperr.ErrInvalidRequest)
+		return
+	}
+
+	shipment, err := sh.shipmentService.SetTracking(r.Context(), shipmentId, req.Carrier, req.TrackingNumber)
+	if err != nil {
+		reqLogger.Error("Error recording tracking information", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(shipment)
+}
+
+func (sh *ShipmentHandler) RecordEvent(w http.ResponseWriter, r *http.Request) {
+	reqLogger := sh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	shipmentId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Recording shipment event", "shipment_id", shipmentId)
+
+	var req RecordShipmentEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	event := model.ShipmentEvent{
+		Status:      req.Status,
+		Location:    req.Location,
+		Description: req.Description,
+	}
+	if req.OccurredAt != nil {
+		event.OccurredAt = *req.OccurredAt
+	}
+
+	shipment, err := sh.shipmentService.RecordEvent(r.Context(), shipmentId, event)
+	if err != nil {
+		reqLogger.Error("Error recording shipment event", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Shipment event recorded successfully", "shipment", shipment)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(shipment)
+}
+
+func (sh *ShipmentHandler) GetPickLists(w http.ResponseWriter, r *http.Request) {
+	reqLogger := sh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	reqLogger.Info("Building pick lists")
+
+	pickLists, err := sh.shipmentService.GetPickLists(r.Context())
+	if err != nil {
+		reqLogger.Error("Error building pick lists", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(pickLists)
+}
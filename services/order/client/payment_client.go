@@ -0,0 +1,103 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/config"
+	"ecommerce-platform/internal/telemetry"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+type refundRequest struct {
+	OrderID   string  `json:"orderId"`
+	Amount    float64 `json:"amount"`
+	Reason    string  `json:"reason"`
+	Reference string  `json:"reference"`
+}
+
+type refundResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+// PaymentClient calls the payment service's HTTP API. It authenticates with
+// its API key if it has one, otherwise with the credentials of the request
+// it is called for.
+type PaymentClient struct {
+	baseURL    string
+	apiKey     config.Secret
+	httpClient *http.Client
+	logger     *slog.Logger
+}
+
+func NewPaymentClient(baseURL string, apiKey config.Secret, logger *slog.Logger) *PaymentClient {
+	return &PaymentClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: auth.Transport(telemetry.Transport(http.DefaultTransport))},
+		logger:     logger.With("file", "payment_client.go"),
+	}
+}
+
+// IssueRefund asks the payment service to refund amount of the order's payment
+// and returns the ID and status of the refund. The payment service ignores
+// repeated requests with the same reference, so failed calls can be retried.
+func (pc *PaymentClient) IssueRefund(ctx context.Context, orderID string, amount float64, reason, reference string) (string, string, error) {
+	clientLogger := pc.logger.With("request_id", middleware.GetReqID(ctx), "order_id", orderID, "amount", amount, "reference", reference)
+
+	clientLogger.Info("IssueRefund started")
+
+	body, err := json.Marshal(refundRequest{
+		OrderID:   orderID,
+		Amount:    amount,
+		Reason:    reason,
+		Reference: reference,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pc.baseURL+"/refunds", bytes.NewReader(body))
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if pc.apiKey != "" {
+		req.Header.Set(auth.APIKeyHeader, pc.apiKey.Value())
+	}
+	if reqID := middleware.GetReqID(ctx); reqID != "" {
+		req.Header.Set(middleware.RequestIDHeader, reqID)
+	}
+
+	res, err := pc.httpClient.Do(req)
+	if err != nil {
+		clientLogger.Error("Could not reach payment service", "error", err)
+		return "", "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		clientLogger.Error("Payment service rejected refund", "status", res.StatusCode, "body", string(msg))
+		return "", "", fmt.Errorf("payment service returned %d: %s", res.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var refund refundResponse
+	if err := json.NewDecoder(res.Body).Decode(&refund); err != nil {
+		clientLogger.Error("Could not decode refund response", "error", err)
+		return "", "", err
+	}
+
+	clientLogger.Info("IssueRefund successful", "refund_id", refund.ID, "status", refund.Status)
+
+	return refund.ID, refund.Status, nil
+}
@@ -0,0 +1,248 @@
+package client
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pb "ecommerce-platform/pkg/grpc/inventory"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+var (
+	productCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ecommerce_product_cache_lookups_total",
+		Help: "Product info lookups of the order service by result: hit, stale (served while refreshed) or miss.",
+	}, []string{"result"})
+
+	productCacheInvalidations = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ecommerce_product_cache_invalidations_total",
+		Help: "Cached products dropped because their price changed.",
+	})
+
+	productCacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ecommerce_product_cache_evictions_total",
+		Help: "Cached products dropped to make room for others.",
+	})
+
+	productCacheEntries = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "ecommerce_product_cache_entries",
+		Help: "Products in the product info cache.",
+	})
+)
+
+// ProductCacheConfig configures the product info cache of the order service.
+type ProductCacheConfig struct {
+	Size int           `yaml:"size" env:"PRODUCT_CACHE_SIZE" flag:"product-cache-size" default:"10000" usage:"products kept in the product info cache, 0 disables the cache"`
+	TTL  time.Duration `yaml:"ttl" env:"PRODUCT_CACHE_TTL" flag:"product-cache-ttl" default:"30s" usage:"how long cached product info is used without asking the inventory service"`
+	// StaleWhileRevalidate serves product info older than TTL, but younger
+	// than MaxAge, while it is fetched again in the background.
+	StaleWhileRevalidate bool `yaml:"stale_while_revalidate" env:"PRODUCT_CACHE_STALE_WHILE_REVALIDATE" flag:"product-cache-stale-while-revalidate" default:"false" usage:"serve expired product info while it is refreshed"`
+	// MaxAge bounds how old a price an order may be charged. Price change
+	// events normally drop changed products long before, but may be lost.
+	MaxAge time.Duration `yaml:"max_age" env:"PRODUCT_CACHE_MAX_AGE" flag:"product-cache-max-age" default:"5m" usage:"age after which cached product info is never used"`
+}
+
+func (c ProductCacheConfig) Validate() error {
+	if c.Size < 0 {
+		return errors.New("product cache size must not be negative")
+	}
+	if c.Size > 0 && (c.TTL <= 0 || c.MaxAge < c.TTL) {
+		return errors.New("product cache ttl must be positive and max_age not below ttl")
+	}
+	return nil
+}
+
+type productCacheEntry struct {
+	product   *pb.ProductInfo
+	fetchedAt time.Time
+}
+
+// ProductCache is an inventory client that keeps the product info of the
+// most recently ordered products. Products are dropped by Invalidate when
+// their price changes and are never used once older than MaxAge. Concurrent
+// lookups of the same products share one call to the inventory service.
+//
+// Only GetProductInfo is cached, all other calls go to the inventory
+// service.
+type ProductCache struct {
+	pb.InventoryServiceClient
+	cfg    ProductCacheConfig
+	now    func() time.Time
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	// lru holds the entries, most recently used first.
+	lru *list.List
+	// epoch counts the invalidations. Product info fetched while one
+	// happened may predate the price change and is not cached.
+	epoch uint64
+
+	flights singleflight.Group
+}
+
+func NewProductCache(inventoryClient pb.InventoryServiceClient, cfg ProductCacheConfig, logger *slog.Logger) *ProductCache {
+	return &ProductCache{
+		InventoryServiceClient: inventoryClient,
+		cfg:                    cfg,
+		now:                    time.Now,
+		logger:                 logger.With("file", "product_cache.go"),
+		entries:                make(map[string]*list.Element),
+		lru:                    list.New(),
+	}
+}
+
+// GetProductInfo returns the cached products and fetches the others. Like
+// the inventory service it fails if any product is unknown.
+func (pc *ProductCache) GetProductInfo(ctx context.Context, in *pb.GetProductInfoRequest, opts ...grpc.CallOption) (*pb.GetProductInfoResponse, error) {
+	if pc.cfg.Size <= 0 {
+		return pc.InventoryServiceClient.GetProductInfo(ctx, in, opts...)
+	}
+
+	ids := in.GetProductIds()
+	products := make(map[string]*pb.ProductInfo, len(ids))
+	var missing, stale []string
+
+	pc.mu.Lock()
+	epoch := pc.epoch
+	now := pc.now()
+	for _, id := range ids {
+		if _, ok := products[id]; ok || slices.Contains(missing, id) {
+			continue
+		}
+		entry, ok := pc.get(id)
+		switch age := now.Sub(entry.fetchedAt); {
+		case ok && age < pc.cfg.TTL:
+			products[id] = proto.CloneOf(entry.product)
+			productCacheLookups.WithLabelValues("hit").Inc()
+		case ok && pc.cfg.StaleWhileRevalidate && age < pc.cfg.MaxAge:
+			products[id] = proto.CloneOf(entry.product)
+			stale = append(stale, id)
+			productCacheLookups.WithLabelValues("stale").Inc()
+		default:
+			missing = append(missing, id)
+			productCacheLookups.WithLabelValues("miss").Inc()
+		}
+	}
+	pc.mu.Unlock()
+
+	if len(stale) > 0 {
+		go func() {
+			if _, err := pc.fetch(context.WithoutCancel(ctx), stale, epoch, opts); err != nil {
+				pc.logger.Warn("Failed to refresh cached product info", "request_id", middleware.GetReqID(ctx), "product_ids", stale, "error", err)
+			}
+		}()
+	}
+
+	if len(missing) > 0 {
+		fetched, err := pc.fetch(ctx, missing, epoch, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, product := range fetched {
+			products[product.GetId()] = proto.CloneOf(product)
+		}
+	}
+
+	res := &pb.GetProductInfoResponse{}
+	for _, id := range ids {
+		if product, ok := products[id]; ok {
+			res.Products = append(res.Products, product)
+			delete(products, id)
+		}
+	}
+	return res, nil
+}
+
+// Invalidate drops the product from the cache.
+func (pc *ProductCache) Invalidate(productID string) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.epoch++
+	if el, ok := pc.entries[productID]; ok {
+		pc.lru.Remove(el)
+		delete(pc.entries, productID)
+		productCacheEntries.Set(float64(pc.lru.Len()))
+	}
+	productCacheInvalidations.Inc()
+}
+
+// fetch gets the products from the inventory service and caches them,
+// unless an invalidation happened since epoch. Lookups of the same products
+// in the same epoch share the call, which outlives callers that give up.
+func (pc *ProductCache) fetch(ctx context.Context, ids []string, epoch uint64, opts []grpc.CallOption) ([]*pb.ProductInfo, error) {
+	ids = slices.Sorted(slices.Values(ids))
+	key := strconv.FormatUint(epoch, 10) + ":" + strings.Join(ids, ",")
+
+	results := pc.flights.DoChan(key, func() (any, error) {
+		res, err := pc.InventoryServiceClient.GetProductInfo(context.WithoutCancel(ctx), &pb.GetProductInfoRequest{ProductIds: ids}, opts...)
+		if err != nil {
+			return nil, err
+		}
+		pc.store(res.GetProducts(), epoch)
+		return res.GetProducts(), nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, status.FromContextError(ctx.Err()).Err()
+	case r := <-results:
+		if r.Err != nil {
+			return nil, r.Err
+		}
+		return r.Val.([]*pb.ProductInfo), nil
+	}
+}
+
+// get returns the entry of the product and marks it as used. pc.mu must
+// be held.
+func (pc *ProductCache) get(id string) (productCacheEntry, bool) {
+	el, ok := pc.entries[id]
+	if !ok {
+		return productCacheEntry{}, false
+	}
+	pc.lru.MoveToFront(el)
+	return *el.Value.(*productCacheEntry), true
+}
+
+func (pc *ProductCache) store(products []*pb.ProductInfo, epoch uint64) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	if pc.epoch != epoch {
+		return
+	}
+
+	now := pc.now()
+	for _, product := range products {
+		entry := &productCacheEntry{product: proto.CloneOf(product), fetchedAt: now}
+		if el, ok := pc.entries[product.GetId()]; ok {
+			el.Value = entry
+			pc.lru.MoveToFront(el)
+			continue
+		}
+		pc.entries[product.GetId()] = pc.lru.PushFront(entry)
+		if pc.lru.Len() > pc.cfg.Size {
+			oldest := pc.lru.Back()
+			pc.lru.Remove(oldest)
+			delete(pc.entries, oldest.Value.(*productCacheEntry).product.GetId())
+			productCacheEvictions.Inc()
+		}
+	}
+	productCacheEntries.Set(float64(pc.lru.Len()))
+}
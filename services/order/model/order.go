@@ -5,6 +5,22 @@ import (
 	"time"
 )
 
+const (
+	OrderStatusPending          = "PENDING"
+	OrderStatusPaid             = "PAID"
+	OrderStatusPartiallyShipped = "PARTIALLY_SHIPPED"
+	OrderStatusShipped          = "SHIPPED"
+	OrderStatusDelivered        = "DELIVERED"
+)
+
+// Refund states of an order. They are kept apart from the status, which
+// follows payment and fulfillment only.
+const (
+	RefundStatusNone              = "NONE"
+	RefundStatusPartiallyRefunded = "PARTIALLY_REFUNDED"
+	RefundStatusRefunded          = "REFUNDED"
+)
+
 type OrderItem struct {
 	ProductID string   `json:"productId"`
 	Quantity  int      `json:"quantity"`
@@ -12,11 +28,13 @@ type OrderItem struct {
 }
 
 type Order struct {
-	ID         string          `json:"id"`
-	UserID     string          `json:"userId"`
-	Items      json.RawMessage `json:"items"`
-	TotalPrice float64         `json:"totalPrice"`
-	Status     string          `json:"status"`
-	CreatedAt  time.Time       `json:"createdAt"`
-	UpdatedAt  time.Time       `json:"updatedAt"`
+	ID            string          `json:"id"`
+	UserID        string          `json:"userId"`
+	Items         json.RawMessage `json:"items"`
+	TotalPrice    float64         `json:"totalPrice"`
+	RefundedTotal float64         `json:"refundedTotal"`
+	RefundStatus  string          `json:"refundStatus"`
+	Status        string          `json:"status"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	UpdatedAt     time.Time       `json:"updatedAt"`
 }
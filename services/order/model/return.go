@@ -0,0 +1,54 @@
+package model
+
+import "time"
+
+const (
+	ReturnStatusRequested = "REQUESTED"
+	ReturnStatusApproved  = "APPROVED"
+	ReturnStatusRejected  = "REJECTED"
+	ReturnStatusReceived  = "RECEIVED"
+	ReturnStatusRefunded  = "REFUNDED"
+	ReturnStatusClosed    = "CLOSED"
+)
+
+const (
+	// ReturnDispositionRestock puts the received units back into sellable stock.
+	ReturnDispositionRestock = "RESTOCK"
+	// ReturnDispositionWriteOff accepts the units but discards them, e.g. when damaged.
+	ReturnDispositionWriteOff = "WRITE_OFF"
+	// ReturnDispositionRejected refuses the units on inspection; they are not refunded.
+	ReturnDispositionRejected = "REJECTED"
+)
+
+// Return is a return merchandise authorization (RMA) for some lines of an order.
+type Return struct {
+	ID           string        `json:"id"`
+	OrderID      string        `json:"orderId"`
+	UserID       string        `json:"userId"`
+	Status       string        `json:"status"`
+	Reason       *string       `json:"reason,omitempty"`
+	DecisionNote *string       `json:"decisionNote,omitempty"`
+	RefundAmount *float64      `json:"refundAmount,omitempty"`
+	RefundID     *string       `json:"refundId,omitempty"`
+	Lines        []*ReturnLine `json:"lines"`
+	CreatedAt    time.Time     `json:"createdAt"`
+	UpdatedAt    time.Time     `json:"updatedAt"`
+}
+
+type ReturnLine struct {
+	ID             string  `json:"id"`
+	ReturnID       string  `json:"returnId"`
+	ProductID      string  `json:"productId"`
+	Quantity       int     `json:"quantity"`
+	UnitPrice      float64 `json:"unitPrice"`
+	Reason         *string `json:"reason,omitempty"`
+	Disposition    *string `json:"disposition,omitempty"`
+	InspectionNote *string `json:"inspectionNote,omitempty"`
+}
+
+// ReturnInspection is the outcome of inspecting one received return line.
+type ReturnInspection struct {
+	LineID      string  `json:"lineId"`
+	Disposition string  `json:"disposition"`
+	Note        *string `json:"note,omitempty"`
+}
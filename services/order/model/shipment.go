@@ -0,0 +1,66 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	ShipmentStatusPending   = "PENDING"
+	ShipmentStatusShipped   = "SHIPPED"
+	ShipmentStatusInTransit = "IN_TRANSIT"
+	ShipmentStatusDelivered = "DELIVERED"
+)
+
+// ShipmentStatusRank orders the shipment statuses, shipments only ever move
+// to a status of the same or a higher rank.
+var ShipmentStatusRank = map[string]int{
+	ShipmentStatusPending:   0,
+	ShipmentStatusShipped:   1,
+	ShipmentStatusInTransit: 2,
+	ShipmentStatusDelivered: 3,
+}
+
+type ShipmentItem struct {
+	ProductID string `json:"productId"`
+	Quantity  int    `json:"quantity"`
+}
+
+type Shipment struct {
+	ID             string           `json:"id"`
+	OrderID        string           `json:"orderId"`
+	Warehouse      string           `json:"warehouse"`
+	Items          json.RawMessage  `json:"items"`
+	Carrier        *string          `json:"carrier,omitempty"`
+	TrackingNumber *string          `json:"trackingNumber,omitempty"`
+	Status         string           `json:"status"`
+	ShippedAt      *time.Time       `json:"shippedAt,omitempty"`
+	DeliveredAt    *time.Time       `json:"deliveredAt,omitempty"`
+	Events         []*ShipmentEvent `json:"events,omitempty"`
+	CreatedAt      time.Time        `json:"createdAt"`
+	UpdatedAt      time.Time        `json:"updatedAt"`
+}
+
+type ShipmentEvent struct {
+	ID          string    `json:"id"`
+	ShipmentID  string    `json:"shipmentId"`
+	Status      string    `json:"status"`
+	Location    *string   `json:"location,omitempty"`
+	Description *string   `json:"description,omitempty"`
+	OccurredAt  time.Time `json:"occurredAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PickList is the work list of a single warehouse: every line that still has to
+// be picked for shipments which have not left the building yet.
+type PickList struct {
+	Warehouse string         `json:"warehouse"`
+	Lines     []PickListLine `json:"lines"`
+}
+
+type PickListLine struct {
+	ShipmentID string `json:"shipmentId"`
+	OrderID    string `json:"orderId"`
+	ProductID  string `json:"productId"`
+	Quantity   int    `json:"quantity"`
+}
@@ -0,0 +1,103 @@
+// Package memory holds in-memory repositories of the order service for tests
+// and local runs without Postgres. They behave like the Postgres ones:
+// missing rows give sql.ErrNoRows, amounts are rounded to cents like NUMERIC
+// columns and timestamps are set by the repository.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/order/model"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type OrderMemoryRepository struct {
+	mu     sync.RWMutex
+	orders map[string]model.Order
+}
+
+func NewOrderMemoryRepository() *OrderMemoryRepository {
+	return &OrderMemoryRepository{
+		orders: make(map[string]model.Order),
+	}
+}
+
+func (or *OrderMemoryRepository) Create(ctx context.Context, order *model.Order) error {
+	or.mu.Lock()
+	defer or.mu.Unlock()
+
+	now := now()
+	order.ID = uuid.NewString()
+	order.RefundStatus = model.RefundStatusNone
+	order.CreatedAt = now
+	order.UpdatedAt = now
+
+	stored := *order
+	stored.Items = slices.Clone(order.Items)
+	stored.TotalPrice = roundCents(order.TotalPrice)
+	stored.RefundedTotal = 0
+	or.orders[order.ID] = stored
+
+	return nil
+}
+
+func (or *OrderMemoryRepository) FindByID(ctx context.Context, id string) (*model.Order, error) {
+	or.mu.RLock()
+	defer or.mu.RUnlock()
+
+	order, ok := or.orders[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	order.Items = slices.Clone(order.Items)
+	return &order, nil
+}
+
+func (or *OrderMemoryRepository) UpdateStatus(ctx context.Context, id, newStatus string) error {
+	or.mu.Lock()
+	defer or.mu.Unlock()
+
+	order, ok := or.orders[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	order.Status = newStatus
+	order.UpdatedAt = now()
+	or.orders[id] = order
+
+	return nil
+}
+
+func (or *OrderMemoryRepository) UpdateRefund(ctx context.Context, id string, refundedTotal float64, refundStatus string) error {
+	or.mu.Lock()
+	defer or.mu.Unlock()
+
+	order, ok := or.orders[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	order.RefundedTotal = roundCents(refundedTotal)
+	order.RefundStatus = refundStatus
+	order.UpdatedAt = now()
+	or.orders[id] = order
+
+	return nil
+}
+
+// now returns the time in the precision Postgres stores.
+func now() time.Time {
+	return time.Now().Truncate(time.Microsecond)
+}
+
+// roundCents rounds like a NUMERIC(10, 2) column.
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"ecommerce-platform/services/order/repository"
+	"ecommerce-platform/services/order/repository/memory"
+	"ecommerce-platform/services/order/repository/repositorytest"
+	"testing"
+)
+
+func TestOrderMemoryRepository(t *testing.T) {
+	repositorytest.Order(t, func(t *testing.T) repository.OrderRepository {
+		return memory.NewOrderMemoryRepository()
+	})
+}
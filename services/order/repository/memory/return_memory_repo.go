@@ -0,0 +1,227 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/order/model"
+	"ecommerce-platform/services/order/repository"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReturnMemoryRepository struct {
+	// shipments holds the shipments the returns are checked against.
+	shipments *ShipmentMemoryRepository
+
+	mu      sync.RWMutex
+	returns map[string]model.Return
+}
+
+func NewReturnMemoryRepository(shipments *ShipmentMemoryRepository) *ReturnMemoryRepository {
+	return &ReturnMemoryRepository{
+		shipments: shipments,
+		returns:   make(map[string]model.Return),
+	}
+}
+
+func (rr *ReturnMemoryRepository) Create(ctx context.Context, ret *model.Return) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	if _, err := rr.shipments.orders.FindByID(ctx, ret.OrderID); err != nil {
+		return err
+	}
+	shipments, err := rr.shipments.FindByOrderID(ctx, ret.OrderID)
+	if err != nil {
+		return err
+	}
+	var returned []*model.ReturnLine
+	for _, other := range rr.returns {
+		if other.OrderID == ret.OrderID && other.Status != model.ReturnStatusRejected {
+			returned = append(returned, other.Lines...)
+		}
+	}
+	if err := repository.CheckReturnFits(shipments, returned, ret); err != nil {
+		return err
+	}
+
+	now := now()
+	ret.ID = uuid.NewString()
+	ret.CreatedAt = now
+	ret.UpdatedAt = now
+
+	// Like the INSERTs, only the columns set on creation are stored.
+	stored := model.Return{
+		ID:        ret.ID,
+		OrderID:   ret.OrderID,
+		UserID:    ret.UserID,
+		Status:    ret.Status,
+		Reason:    cloneString(ret.Reason),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, line := range ret.Lines {
+		line.ID = uuid.NewString()
+		line.ReturnID = ret.ID
+
+		stored.Lines = append(stored.Lines, &model.ReturnLine{
+			ID:        line.ID,
+			ReturnID:  line.ReturnID,
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+			UnitPrice: roundCents(line.UnitPrice),
+			Reason:    cloneString(line.Reason),
+		})
+	}
+	slices.SortFunc(stored.Lines, func(a, b *model.ReturnLine) int {
+		return cmp.Or(cmp.Compare(a.ProductID, b.ProductID), cmp.Compare(a.ID, b.ID))
+	})
+	rr.returns[ret.ID] = stored
+
+	return nil
+}
+
+func (rr *ReturnMemoryRepository) FindByID(ctx context.Context, id string) (*model.Return, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	ret, ok := rr.returns[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return cloneReturn(ret), nil
+}
+
+func (rr *ReturnMemoryRepository) FindByOrderID(ctx context.Context, orderID string) ([]*model.Return, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	var returns []*model.Return
+	for _, ret := range rr.returns {
+		if ret.OrderID == orderID {
+			returns = append(returns, cloneReturn(ret))
+		}
+	}
+	slices.SortFunc(returns, func(a, b *model.Return) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return returns, nil
+}
+
+func (rr *ReturnMemoryRepository) UpdateDecision(ctx context.Context, id, newStatus string, note *string) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	ret, ok := rr.returns[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	ret.Status = newStatus
+	ret.DecisionNote = cloneString(note)
+	ret.UpdatedAt = now()
+	rr.returns[id] = ret
+
+	return nil
+}
+
+func (rr *ReturnMemoryRepository) SaveInspection(ctx context.Context, ret *model.Return) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	stored, ok := rr.returns[ret.ID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	// Nothing is changed unless every line exists, like the rolled back
+	// transaction.
+	stored = *cloneReturn(stored)
+	for _, line := range ret.Lines {
+		i := slices.IndexFunc(stored.Lines, func(l *model.ReturnLine) bool { return l.ID == line.ID })
+		if i < 0 {
+			return sql.ErrNoRows
+		}
+		stored.Lines[i].Disposition = cloneString(line.Disposition)
+		stored.Lines[i].InspectionNote = cloneString(line.InspectionNote)
+	}
+
+	stored.Status = ret.Status
+	stored.RefundAmount = nil
+	if ret.RefundAmount != nil {
+		amount := roundCents(*ret.RefundAmount)
+		stored.RefundAmount = &amount
+	}
+	stored.UpdatedAt = now()
+	rr.returns[ret.ID] = stored
+
+	ret.UpdatedAt = stored.UpdatedAt
+
+	return nil
+}
+
+func (rr *ReturnMemoryRepository) MarkRefunded(ctx context.Context, id, refundID string) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	ret, ok := rr.returns[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	ret.Status = model.ReturnStatusRefunded
+	ret.RefundID = &refundID
+	ret.UpdatedAt = now()
+	rr.returns[id] = ret
+
+	return nil
+}
+
+func cloneReturn(ret model.Return) *model.Return {
+	ret.Reason = cloneString(ret.Reason)
+	ret.DecisionNote = cloneString(ret.DecisionNote)
+	ret.RefundAmount = cloneFloat(ret.RefundAmount)
+	ret.RefundID = cloneString(ret.RefundID)
+
+	var lines []*model.ReturnLine
+	for _, line := range ret.Lines {
+		clone := *line
+		clone.Reason = cloneString(line.Reason)
+		clone.Disposition = cloneString(line.Disposition)
+		clone.InspectionNote = cloneString(line.InspectionNote)
+		lines = append(lines, &clone)
+	}
+	ret.Lines = lines
+
+	return &ret
+}
+
+func cloneString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	return &c
+}
+
+func cloneFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	c := *f
+	return &c
+}
+
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
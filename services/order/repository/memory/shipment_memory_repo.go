@@ -0,0 +1,190 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/order/model"
+	"ecommerce-platform/services/order/repository"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+type ShipmentMemoryRepository struct {
+	// orders holds the orders the shipments are checked against.
+	orders *OrderMemoryRepository
+
+	mu        sync.RWMutex
+	shipments map[string]model.Shipment
+	events    []model.ShipmentEvent
+}
+
+func NewShipmentMemoryRepository(orders *OrderMemoryRepository) *ShipmentMemoryRepository {
+	return &ShipmentMemoryRepository{
+		orders:    orders,
+		shipments: make(map[string]model.Shipment),
+	}
+}
+
+func (sr *ShipmentMemoryRepository) Create(ctx context.Context, shipment *model.Shipment) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	order, err := sr.orders.FindByID(ctx, shipment.OrderID)
+	if err != nil {
+		return err
+	}
+	var others []*model.Shipment
+	for _, other := range sr.shipments {
+		if other.OrderID == shipment.OrderID {
+			others = append(others, &other)
+		}
+	}
+	if err := repository.CheckShipmentFits(order.Items, others, shipment); err != nil {
+		return err
+	}
+
+	now := now()
+	shipment.ID = uuid.NewString()
+	shipment.CreatedAt = now
+	shipment.UpdatedAt = now
+
+	// Like the INSERT, only the columns set on creation are stored.
+	sr.shipments[shipment.ID] = model.Shipment{
+		ID:        shipment.ID,
+		OrderID:   shipment.OrderID,
+		Warehouse: shipment.Warehouse,
+		Items:     slices.Clone(shipment.Items),
+		Status:    shipment.Status,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	return nil
+}
+
+func (sr *ShipmentMemoryRepository) FindByID(ctx context.Context, id string) (*model.Shipment, error) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	shipment, ok := sr.shipments[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+
+	return cloneShipment(shipment), nil
+}
+
+func (sr *ShipmentMemoryRepository) FindByOrderID(ctx context.Context, orderID string) ([]*model.Shipment, error) {
+	return sr.find(func(shipment model.Shipment) bool { return shipment.OrderID == orderID }), nil
+}
+
+func (sr *ShipmentMemoryRepository) FindByStatus(ctx context.Context, status string) ([]*model.Shipment, error) {
+	return sr.find(func(shipment model.Shipment) bool { return shipment.Status == status }), nil
+}
+
+// find returns the matching shipments ordered by creation time.
+func (sr *ShipmentMemoryRepository) find(match func(model.Shipment) bool) []*model.Shipment {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	var shipments []*model.Shipment
+	for _, shipment := range sr.shipments {
+		if match(shipment) {
+			shipments = append(shipments, cloneShipment(shipment))
+		}
+	}
+	slices.SortFunc(shipments, func(a, b *model.Shipment) int {
+		return cmp.Or(a.CreatedAt.Compare(b.CreatedAt), cmp.Compare(a.ID, b.ID))
+	})
+
+	return shipments
+}
+
+func (sr *ShipmentMemoryRepository) UpdateTracking(ctx context.Context, id, carrier, trackingNumber string) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	shipment, ok := sr.shipments[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	shipment.Carrier = &carrier
+	shipment.TrackingNumber = &trackingNumber
+	shipment.UpdatedAt = now()
+	sr.shipments[id] = shipment
+
+	return nil
+}
+
+func (sr *ShipmentMemoryRepository) AddEvent(ctx context.Context, event *model.ShipmentEvent) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	shipment, ok := sr.shipments[event.ShipmentID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	if model.ShipmentStatusRank[event.Status] < model.ShipmentStatusRank[shipment.Status] {
+		return repository.ErrShipmentStatusRegressed
+	}
+
+	// shipped_at and delivered_at keep the first time the status was reached.
+	occurredAt := event.OccurredAt
+	switch event.Status {
+	case model.ShipmentStatusShipped, model.ShipmentStatusInTransit, model.ShipmentStatusDelivered:
+		if shipment.ShippedAt == nil {
+			shipment.ShippedAt = &occurredAt
+		}
+	}
+	if event.Status == model.ShipmentStatusDelivered && shipment.DeliveredAt == nil {
+		shipment.DeliveredAt = &occurredAt
+	}
+
+	now := now()
+	shipment.Status = event.Status
+	shipment.UpdatedAt = now
+	sr.shipments[shipment.ID] = shipment
+
+	event.ID = uuid.NewString()
+	event.CreatedAt = now
+
+	stored := *event
+	stored.Location = cloneString(event.Location)
+	stored.Description = cloneString(event.Description)
+	sr.events = append(sr.events, stored)
+
+	return nil
+}
+
+func (sr *ShipmentMemoryRepository) FindEvents(ctx context.Context, shipmentID string) ([]*model.ShipmentEvent, error) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	var events []*model.ShipmentEvent
+	for _, event := range sr.events {
+		if event.ShipmentID == shipmentID {
+			event.Location = cloneString(event.Location)
+			event.Description = cloneString(event.Description)
+			events = append(events, &event)
+		}
+	}
+	slices.SortStableFunc(events, func(a, b *model.ShipmentEvent) int {
+		return cmp.Or(a.OccurredAt.Compare(b.OccurredAt), a.CreatedAt.Compare(b.CreatedAt))
+	})
+
+	return events, nil
+}
+
+func cloneShipment(shipment model.Shipment) *model.Shipment {
+	shipment.Items = slices.Clone(shipment.Items)
+	shipment.Carrier = cloneString(shipment.Carrier)
+	shipment.TrackingNumber = cloneString(shipment.TrackingNumber)
+	shipment.ShippedAt = cloneTime(shipment.ShippedAt)
+	shipment.DeliveredAt = cloneTime(shipment.DeliveredAt)
+	shipment.Events = nil
+	return &shipment
+}
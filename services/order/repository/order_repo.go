@@ -9,4 +9,7 @@ type OrderRepository interface {
 	Create(ctx context.Context, order *model.Order) error
 	FindByID(ctx context.Context, id string) (*model.Order, error)
 	UpdateStatus(ctx context.Context, id, newStatus string) error
+	// UpdateRefund sets the refunded total and refund status, the order status
+	// is left alone.
+	UpdateRefund(ctx context.Context, id string, refundedTotal float64, refundStatus string) error
 }
@@ -21,13 +21,13 @@ func (or *OrderPgRepository) Create(ctx context.Context, order *model.Order) err
 
 	repoLogger.Info("Create started", "order", order)
 
-	exec := `INSERT INTO orders (id, user_id, items, total_price, status) VALUES ($1, $2, $3, $4, $5) RETURNING created_at, updated_at`
+	exec := `INSERT INTO orders (id, user_id, items, total_price, status) VALUES ($1, $2, $3, $4, $5) RETURNING refund_status, created_at, updated_at`
 
 	order.ID = uuid.NewString()
 	repoLogger.Info("UUID generated for order", "order", order)
 
 	createdOrder := or.db.QueryRowContext(ctx, exec, order.ID, order.UserID, order.Items, order.TotalPrice, order.Status)
-	err := createdOrder.Scan(&order.CreatedAt, &order.UpdatedAt)
+	err := createdOrder.Scan(&order.RefundStatus, &order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
 		repoLogger.Error("Could not create record in database", "order", order, "error", err)
 		return err
@@ -43,14 +43,14 @@ func (or *OrderPgRepository) FindByID(ctx context.Context, id string) (*model.Or
 
 	repoLogger.Info("FindByID started", "order_id", id)
 
-	query := `SELECT id, user_id, items, total_price, status, created_at, updated_at FROM orders WHERE id = $1`
+	query := `SELECT id, user_id, items, total_price, refunded_total, refund_status, status, created_at, updated_at FROM orders WHERE id = $1`
 
 	row := or.db.QueryRowContext(ctx, query, id)
 
 	var order model.Order
-	err := row.Scan(&order.ID, &order.UserID, &order.Items, &order.TotalPrice, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+	err := row.Scan(&order.ID, &order.UserID, &order.Items, &order.TotalPrice, &order.RefundedTotal, &order.RefundStatus, &order.Status, &order.CreatedAt, &order.UpdatedAt)
 	if err != nil {
-		if errors.Is(sql.ErrNoRows, err) {
+		if errors.Is(err, sql.ErrNoRows) {
 			repoLogger.Error("No order with given id", "order_id", id, "error", err)
 			return nil, err
 		}
@@ -92,6 +92,33 @@ func (or *OrderPgRepository) UpdateStatus(ctx context.Context, id string, newSta
 	return nil
 }
 
+func (or *OrderPgRepository) UpdateRefund(ctx context.Context, id string, refundedTotal float64, refundStatus string) error {
+	repoLogger := or.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("UpdateRefund started", "order_id", id, "refunded_total", refundedTotal, "refund_status", refundStatus)
+
+	query := `UPDATE orders SET refunded_total = $1, refund_status = $2 WHERE id = $3`
+
+	res, err := or.db.ExecContext(ctx, query, refundedTotal, refundStatus, id)
+	if err != nil {
+		repoLogger.Error("Could not update database", "error", err)
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		repoLogger.Error("No order with given id found", "order_id", id)
+		return sql.ErrNoRows
+	}
+
+	repoLogger.Info("UpdateRefund successful", "order_id", id)
+
+	return nil
+}
+
 func NewOrderPgRepository(db *sql.DB, logger *slog.Logger) (*OrderPgRepository, error) {
 	if err := db.Ping(); err != nil {
 		return nil, errors.New("failed to connect to the database: " + err.Error())
@@ -0,0 +1,19 @@
+package postgres_test
+
+import (
+	"ecommerce-platform/internal/database/dbtest"
+	"ecommerce-platform/services/order/repository"
+	"ecommerce-platform/services/order/repository/postgres"
+	"ecommerce-platform/services/order/repository/repositorytest"
+	"testing"
+)
+
+func TestOrderPgRepository(t *testing.T) {
+	repositorytest.Order(t, func(t *testing.T) repository.OrderRepository {
+		repo, err := postgres.NewOrderPgRepository(dbtest.Open(t), dbtest.Logger())
+		if err != nil {
+			t.Fatalf("NewOrderPgRepository: %v", err)
+		}
+		return repo
+	})
+}
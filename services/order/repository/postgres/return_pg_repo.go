@@ -0,0 +1,332 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/order/model"
+	"ecommerce-platform/services/order/repository"
+	"errors"
+	"log/slog"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+)
+
+const returnColumns = `id, order_id, user_id, status, reason, decision_note, refund_amount, refund_id, created_at, updated_at`
+
+type ReturnPgRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func scanReturn(row rowScanner) (*model.Return, error) {
+	var ret model.Return
+	err := row.Scan(&ret.ID, &ret.OrderID, &ret.UserID, &ret.Status, &ret.Reason, &ret.DecisionNote, &ret.RefundAmount, &ret.RefundID,
+		&ret.CreatedAt, &ret.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (rr *ReturnPgRepository) Create(ctx context.Context, ret *model.Return) error {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("Create started", "return", ret)
+
+	tx, err := rr.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the order so concurrent returns can't take the same units. Shipments
+	// are created under the same lock.
+	var orderID string
+	err = tx.QueryRowContext(ctx, `SELECT id FROM orders WHERE id = $1 FOR UPDATE`, ret.OrderID).Scan(&orderID)
+	if err != nil {
+		repoLogger.Error("Error finding order", "order_id", ret.OrderID, "error", err)
+		return err
+	}
+
+	shipments, err := queryShipments(ctx, tx, `SELECT `+shipmentColumns+` FROM shipments WHERE order_id = $1`, ret.OrderID)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return err
+	}
+
+	returned, err := queryReturnedLines(ctx, tx, ret.OrderID)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return err
+	}
+
+	if err := repository.CheckReturnFits(shipments, returned, ret); err != nil {
+		repoLogger.Error("Return does not fit into the shipped units", "error", err)
+		return err
+	}
+
+	exec := `INSERT INTO returns (id, order_id, user_id, status, reason) VALUES ($1, $2, $3, $4, $5) RETURNING created_at, updated_at`
+
+	ret.ID = uuid.NewString()
+
+	err = tx.QueryRowContext(ctx, exec, ret.ID, ret.OrderID, ret.UserID, ret.Status, ret.Reason).Scan(&ret.CreatedAt, &ret.UpdatedAt)
+	if err != nil {
+		repoLogger.Error("Could not create record in database", "return", ret, "error", err)
+		return err
+	}
+
+	lineExec := `INSERT INTO return_lines (id, return_id, product_id, quantity, unit_price, reason) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	for _, line := range ret.Lines {
+		line.ID = uuid.NewString()
+		line.ReturnID = ret.ID
+
+		_, err := tx.ExecContext(ctx, lineExec, line.ID, line.ReturnID, line.ProductID, line.Quantity, line.UnitPrice, line.Reason)
+		if err != nil {
+			repoLogger.Error("Could not create return line", "line", line, "error", err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
+	repoLogger.Info("Create successful", "return", ret)
+
+	return nil
+}
+
+func (rr *ReturnPgRepository) FindByID(ctx context.Context, id string) (*model.Return, error) {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByID started", "return_id", id)
+
+	query := `SELECT ` + returnColumns + ` FROM returns WHERE id = $1`
+
+	ret, err := scanReturn(rr.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Error("No return with given id", "return_id", id, "error", err)
+			return nil, err
+		}
+
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	ret.Lines, err = rr.findLines(ctx, ret.ID)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByID successful", "return", ret)
+
+	return ret, nil
+}
+
+func (rr *ReturnPgRepository) FindByOrderID(ctx context.Context, orderID string) ([]*model.Return, error) {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByOrderID started", "order_id", orderID)
+
+	query := `SELECT ` + returnColumns + ` FROM returns WHERE order_id = $1 ORDER BY created_at`
+
+	rows, err := rr.db.QueryContext(ctx, query, orderID)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var returns []*model.Return
+	for rows.Next() {
+		ret, err := scanReturn(rows)
+		if err != nil {
+			repoLogger.Error("Error reading database", "error", err)
+			return nil, err
+		}
+		returns = append(returns, ret)
+	}
+	if err := rows.Err(); err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	for _, ret := range returns {
+		ret.Lines, err = rr.findLines(ctx, ret.ID)
+		if err != nil {
+			repoLogger.Error("Error reading database", "error", err)
+			return nil, err
+		}
+	}
+
+	repoLogger.Info("FindByOrderID successful", "count", len(returns))
+
+	return returns, nil
+}
+
+// queryReturnedLines returns the lines of the order's returns that were not
+// rejected.
+func queryReturnedLines(ctx context.Context, db querier, orderID string) ([]*model.ReturnLine, error) {
+	query := `SELECT l.product_id, l.quantity FROM return_lines l JOIN returns r ON r.id = l.return_id
+		WHERE r.order_id = $1 AND r.status <> $2`
+
+	rows, err := db.QueryContext(ctx, query, orderID, model.ReturnStatusRejected)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []*model.ReturnLine
+	for rows.Next() {
+		var line model.ReturnLine
+		if err := rows.Scan(&line.ProductID, &line.Quantity); err != nil {
+			return nil, err
+		}
+		lines = append(lines, &line)
+	}
+
+	return lines, rows.Err()
+}
+
+func (rr *ReturnPgRepository) findLines(ctx context.Context, returnID string) ([]*model.ReturnLine, error) {
+	query := `SELECT id, return_id, product_id, quantity, unit_price, reason, disposition, inspection_note FROM return_lines WHERE return_id = $1 ORDER BY product_id, id`
+
+	rows, err := rr.db.QueryContext(ctx, query, returnID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []*model.ReturnLine
+	for rows.Next() {
+		var line model.ReturnLine
+		err := rows.Scan(&line.ID, &line.ReturnID, &line.ProductID, &line.Quantity, &line.UnitPrice, &line.Reason, &line.Disposition, &line.InspectionNote)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, &line)
+	}
+
+	return lines, rows.Err()
+}
+
+func (rr *ReturnPgRepository) UpdateDecision(ctx context.Context, id, newStatus string, note *string) error {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("UpdateDecision started", "return_id", id, "new_status", newStatus)
+
+	query := `UPDATE returns SET status = $1, decision_note = $2 WHERE id = $3`
+
+	res, err := rr.db.ExecContext(ctx, query, newStatus, note, id)
+	if err != nil {
+		repoLogger.Error("Could not update database", "error", err)
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		repoLogger.Error("No return with given id found", "return_id", id)
+		return sql.ErrNoRows
+	}
+
+	repoLogger.Info("UpdateDecision successful", "return_id", id, "new_status", newStatus)
+
+	return nil
+}
+
+func (rr *ReturnPgRepository) SaveInspection(ctx context.Context, ret *model.Return) error {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("SaveInspection started", "return", ret)
+
+	tx, err := rr.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	lineQuery := `UPDATE return_lines SET disposition = $1, inspection_note = $2 WHERE id = $3 AND return_id = $4`
+
+	for _, line := range ret.Lines {
+		res, err := tx.ExecContext(ctx, lineQuery, line.Disposition, line.InspectionNote, line.ID, ret.ID)
+		if err != nil {
+			repoLogger.Error("Could not update return line", "line", line, "error", err)
+			return err
+		}
+
+		rowsAff, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rowsAff == 0 {
+			repoLogger.Error("No return line with given id found", "line_id", line.ID)
+			return sql.ErrNoRows
+		}
+	}
+
+	query := `UPDATE returns SET status = $1, refund_amount = $2 WHERE id = $3 RETURNING updated_at`
+
+	err = tx.QueryRowContext(ctx, query, ret.Status, ret.RefundAmount, ret.ID).Scan(&ret.UpdatedAt)
+	if err != nil {
+		repoLogger.Error("Could not update return", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
+	repoLogger.Info("SaveInspection successful", "return_id", ret.ID)
+
+	return nil
+}
+
+func (rr *ReturnPgRepository) MarkRefunded(ctx context.Context, id, refundID string) error {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("MarkRefunded started", "return_id", id, "refund_id", refundID)
+
+	query := `UPDATE returns SET status = $1, refund_id = $2 WHERE id = $3`
+
+	res, err := rr.db.ExecContext(ctx, query, model.ReturnStatusRefunded, refundID, id)
+	if err != nil {
+		repoLogger.Error("Could not update database", "error", err)
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		repoLogger.Error("No return with given id found", "return_id", id)
+		return sql.ErrNoRows
+	}
+
+	repoLogger.Info("MarkRefunded successful", "return_id", id)
+
+	return nil
+}
+
+func NewReturnPgRepository(db *sql.DB, logger *slog.Logger) (*ReturnPgRepository, error) {
+	if err := db.Ping(); err != nil {
+		return nil, errors.New("failed to connect to the database: " + err.Error())
+	}
+
+	return &ReturnPgRepository{
+		db:     db,
+		logger: logger.With("file", "return_pg_repo.go"),
+	}, nil
+}
@@ -0,0 +1,311 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/order/model"
+	"ecommerce-platform/services/order/repository"
+	"encoding/json"
+	"errors"
+	"log/slog"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+)
+
+const shipmentColumns = `id, order_id, warehouse, items, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at`
+
+// shipmentStatusOrder lists the shipment statuses by model.ShipmentStatusRank.
+const shipmentStatusOrder = `ARRAY['PENDING', 'SHIPPED', 'IN_TRANSIT', 'DELIVERED']::VARCHAR[]`
+
+type ShipmentPgRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanShipment(row rowScanner) (*model.Shipment, error) {
+	var shipment model.Shipment
+	err := row.Scan(&shipment.ID, &shipment.OrderID, &shipment.Warehouse, &shipment.Items, &shipment.Carrier, &shipment.TrackingNumber,
+		&shipment.Status, &shipment.ShippedAt, &shipment.DeliveredAt, &shipment.CreatedAt, &shipment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &shipment, nil
+}
+
+func (sr *ShipmentPgRepository) Create(ctx context.Context, shipment *model.Shipment) error {
+	repoLogger := sr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("Create started", "shipment", shipment)
+
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the order so concurrent shipments can't take the same units.
+	var orderItems json.RawMessage
+	err = tx.QueryRowContext(ctx, `SELECT items FROM orders WHERE id = $1 FOR UPDATE`, shipment.OrderID).Scan(&orderItems)
+	if err != nil {
+		repoLogger.Error("Error finding order", "order_id", shipment.OrderID, "error", err)
+		return err
+	}
+
+	others, err := queryShipments(ctx, tx, `SELECT `+shipmentColumns+` FROM shipments WHERE order_id = $1`, shipment.OrderID)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return err
+	}
+
+	if err := repository.CheckShipmentFits(orderItems, others, shipment); err != nil {
+		repoLogger.Error("Shipment does not fit into the order", "error", err)
+		return err
+	}
+
+	exec := `INSERT INTO shipments (id, order_id, warehouse, items, status) VALUES ($1, $2, $3, $4, $5) RETURNING created_at, updated_at`
+
+	shipment.ID = uuid.NewString()
+
+	row := tx.QueryRowContext(ctx, exec, shipment.ID, shipment.OrderID, shipment.Warehouse, shipment.Items, shipment.Status)
+	err = row.Scan(&shipment.CreatedAt, &shipment.UpdatedAt)
+	if err != nil {
+		repoLogger.Error("Could not create record in database", "shipment", shipment, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
+	repoLogger.Info("Create successful", "shipment", shipment)
+
+	return nil
+}
+
+func (sr *ShipmentPgRepository) FindByID(ctx context.Context, id string) (*model.Shipment, error) {
+	repoLogger := sr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByID started", "shipment_id", id)
+
+	query := `SELECT ` + shipmentColumns + ` FROM shipments WHERE id = $1`
+
+	shipment, err := scanShipment(sr.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Error("No shipment with given id", "shipment_id", id, "error", err)
+			return nil, err
+		}
+
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByID successful", "shipment", shipment)
+
+	return shipment, nil
+}
+
+func (sr *ShipmentPgRepository) FindByOrderID(ctx context.Context, orderID string) ([]*model.Shipment, error) {
+	repoLogger := sr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByOrderID started", "order_id", orderID)
+
+	query := `SELECT ` + shipmentColumns + ` FROM shipments WHERE order_id = $1 ORDER BY created_at`
+
+	shipments, err := queryShipments(ctx, sr.db, query, orderID)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByOrderID successful", "count", len(shipments))
+
+	return shipments, nil
+}
+
+func (sr *ShipmentPgRepository) FindByStatus(ctx context.Context, status string) ([]*model.Shipment, error) {
+	repoLogger := sr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByStatus started", "status", status)
+
+	query := `SELECT ` + shipmentColumns + ` FROM shipments WHERE status = $1 ORDER BY created_at`
+
+	shipments, err := queryShipments(ctx, sr.db, query, status)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByStatus successful", "count", len(shipments))
+
+	return shipments, nil
+}
+
+// querier is a *sql.DB or *sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func queryShipments(ctx context.Context, db querier, query string, args ...any) ([]*model.Shipment, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []*model.Shipment
+	for rows.Next() {
+		shipment, err := scanShipment(rows)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+
+	return shipments, rows.Err()
+}
+
+func (sr *ShipmentPgRepository) UpdateTracking(ctx context.Context, id, carrier, trackingNumber string) error {
+	repoLogger := sr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("UpdateTracking started", "shipment_id", id, "carrier", carrier, "tracking_number", trackingNumber)
+
+	query := `UPDATE shipments SET carrier = $1, tracking_number = $2 WHERE id = $3`
+
+	res, err := sr.db.ExecContext(ctx, query, carrier, trackingNumber, id)
+	if err != nil {
+		repoLogger.Error("Could not update database", "error", err)
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		repoLogger.Error("No shipment with given id found", "shipment_id", id)
+		return sql.ErrNoRows
+	}
+
+	repoLogger.Info("UpdateTracking successful", "shipment_id", id)
+
+	return nil
+}
+
+func (sr *ShipmentPgRepository) AddEvent(ctx context.Context, event *model.ShipmentEvent) error {
+	repoLogger := sr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("AddEvent started", "event", event)
+
+	tx, err := sr.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// shipped_at and delivered_at keep the first time the status was reached.
+	// The status only moves forward, even if a concurrent event got here
+	// first.
+	update := `UPDATE shipments SET status = $1::VARCHAR,
+		shipped_at = CASE WHEN $1::VARCHAR IN ('SHIPPED', 'IN_TRANSIT', 'DELIVERED') THEN COALESCE(shipped_at, $2) ELSE shipped_at END,
+		delivered_at = CASE WHEN $1::VARCHAR = 'DELIVERED' THEN COALESCE(delivered_at, $2) ELSE delivered_at END
+		WHERE id = $3 AND array_position(` + shipmentStatusOrder + `, status) <= array_position(` + shipmentStatusOrder + `, $1::VARCHAR)`
+
+	res, err := tx.ExecContext(ctx, update, event.Status, event.OccurredAt, event.ShipmentID)
+	if err != nil {
+		repoLogger.Error("Could not update shipment status", "error", err)
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		var status string
+		err := tx.QueryRowContext(ctx, `SELECT status FROM shipments WHERE id = $1`, event.ShipmentID).Scan(&status)
+		if errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Error("No shipment with given id found", "shipment_id", event.ShipmentID)
+			return err
+		}
+		if err != nil {
+			repoLogger.Error("Error reading database", "error", err)
+			return err
+		}
+		repoLogger.Error("Shipment already has a later status", "shipment_id", event.ShipmentID, "status", status)
+		return repository.ErrShipmentStatusRegressed
+	}
+
+	exec := `INSERT INTO shipment_events (id, shipment_id, status, location, description, occurred_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING created_at`
+
+	event.ID = uuid.NewString()
+
+	err = tx.QueryRowContext(ctx, exec, event.ID, event.ShipmentID, event.Status, event.Location, event.Description, event.OccurredAt).Scan(&event.CreatedAt)
+	if err != nil {
+		repoLogger.Error("Could not create shipment event", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
+	repoLogger.Info("AddEvent successful", "event", event)
+
+	return nil
+}
+
+func (sr *ShipmentPgRepository) FindEvents(ctx context.Context, shipmentID string) ([]*model.ShipmentEvent, error) {
+	repoLogger := sr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindEvents started", "shipment_id", shipmentID)
+
+	query := `SELECT id, shipment_id, status, location, description, occurred_at, created_at FROM shipment_events WHERE shipment_id = $1 ORDER BY occurred_at, created_at`
+
+	rows, err := sr.db.QueryContext(ctx, query, shipmentID)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*model.ShipmentEvent
+	for rows.Next() {
+		var event model.ShipmentEvent
+		err := rows.Scan(&event.ID, &event.ShipmentID, &event.Status, &event.Location, &event.Description, &event.OccurredAt, &event.CreatedAt)
+		if err != nil {
+			repoLogger.Error("Error reading database", "error", err)
+			return nil, err
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindEvents successful", "count", len(events))
+
+	return events, nil
+}
+
+func NewShipmentPgRepository(db *sql.DB, logger *slog.Logger) (*ShipmentPgRepository, error) {
+	if err := db.Ping(); err != nil {
+		return nil, errors.New("failed to connect to the database: " + err.Error())
+	}
+
+	return &ShipmentPgRepository{
+		db:     db,
+		logger: logger.With("file", "shipment_pg_repo.go"),
+	}, nil
+}
@@ -0,0 +1,226 @@
+// Package repositorytest holds the conformance tests every implementation of
+// the order repositories has to pass, so the in-memory ones can stand in for
+// Postgres.
+package repositorytest
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/order/model"
+	"ecommerce-platform/services/order/repository"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Order runs the conformance tests against the repositories created by
+// newRepo, a new one for every test.
+func Order(t *testing.T, newRepo func(t *testing.T) repository.OrderRepository) {
+	ctx := context.Background()
+
+	t.Run("Create assigns ID and timestamps", func(t *testing.T) {
+		repo := newRepo(t)
+
+		order := newOrder()
+		if err := repo.Create(ctx, order); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+		if _, err := uuid.Parse(order.ID); err != nil {
+			t.Errorf("ID = %q, want a UUID", order.ID)
+		}
+		if order.CreatedAt.IsZero() || !order.UpdatedAt.Equal(order.CreatedAt) {
+			t.Errorf("CreatedAt = %v, UpdatedAt = %v, want both set and equal", order.CreatedAt, order.UpdatedAt)
+		}
+
+		found, err := repo.FindByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.UserID != order.UserID || found.Status != order.Status || found.TotalPrice != order.TotalPrice {
+			t.Errorf("FindByID = %+v, want %+v", found, order)
+		}
+		if found.RefundedTotal != 0 || found.RefundStatus != model.RefundStatusNone {
+			t.Errorf("RefundedTotal, RefundStatus = %v, %q, want 0, %q", found.RefundedTotal, found.RefundStatus, model.RefundStatusNone)
+		}
+		if !found.CreatedAt.Equal(order.CreatedAt) || !found.UpdatedAt.Equal(order.UpdatedAt) {
+			t.Errorf("timestamps = %v, %v, want %v, %v", found.CreatedAt, found.UpdatedAt, order.CreatedAt, order.UpdatedAt)
+		}
+		assertSameJSON(t, found.Items, order.Items)
+	})
+
+	t.Run("Create rounds total price to cents", func(t *testing.T) {
+		repo := newRepo(t)
+
+		order := newOrder()
+		order.TotalPrice = 19.999
+		if err := repo.Create(ctx, order); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+
+		found, err := repo.FindByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.TotalPrice != 20 {
+			t.Errorf("TotalPrice = %v, want 20", found.TotalPrice)
+		}
+	})
+
+	t.Run("FindByID of missing order", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if _, err := repo.FindByID(ctx, uuid.NewString()); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("FindByID error = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("FindByID returns a copy", func(t *testing.T) {
+		repo := newRepo(t)
+		order := createOrder(t, repo)
+
+		found, err := repo.FindByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		found.Status = model.OrderStatusDelivered
+		found.Items[0] = ' '
+
+		again, err := repo.FindByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if again.Status != order.Status {
+			t.Errorf("Status = %q after changing the returned order, want %q", again.Status, order.Status)
+		}
+		assertSameJSON(t, again.Items, order.Items)
+	})
+
+	t.Run("UpdateStatus", func(t *testing.T) {
+		repo := newRepo(t)
+		order := createOrder(t, repo)
+
+		time.Sleep(5 * time.Millisecond)
+		if err := repo.UpdateStatus(ctx, order.ID, model.OrderStatusPaid); err != nil {
+			t.Fatalf("UpdateStatus: %v", err)
+		}
+
+		found, err := repo.FindByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.Status != model.OrderStatusPaid {
+			t.Errorf("Status = %q, want %q", found.Status, model.OrderStatusPaid)
+		}
+		if !found.UpdatedAt.After(found.CreatedAt) {
+			t.Errorf("UpdatedAt = %v, want after CreatedAt %v", found.UpdatedAt, found.CreatedAt)
+		}
+		if !found.CreatedAt.Equal(order.CreatedAt) {
+			t.Errorf("CreatedAt = %v, want unchanged %v", found.CreatedAt, order.CreatedAt)
+		}
+	})
+
+	t.Run("UpdateStatus of missing order", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.UpdateStatus(ctx, uuid.NewString(), model.OrderStatusPaid); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("UpdateStatus error = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("UpdateRefund", func(t *testing.T) {
+		repo := newRepo(t)
+		order := createOrder(t, repo)
+
+		time.Sleep(5 * time.Millisecond)
+		if err := repo.UpdateRefund(ctx, order.ID, 12.5, model.RefundStatusPartiallyRefunded); err != nil {
+			t.Fatalf("UpdateRefund: %v", err)
+		}
+
+		found, err := repo.FindByID(ctx, order.ID)
+		if err != nil {
+			t.Fatalf("FindByID: %v", err)
+		}
+		if found.RefundedTotal != 12.5 || found.RefundStatus != model.RefundStatusPartiallyRefunded {
+			t.Errorf("RefundedTotal, RefundStatus = %v, %q, want 12.5, %q", found.RefundedTotal, found.RefundStatus, model.RefundStatusPartiallyRefunded)
+		}
+		if found.Status != order.Status {
+			t.Errorf("Status = %q, want unchanged %q", found.Status, order.Status)
+		}
+		if !found.UpdatedAt.After(found.CreatedAt) {
+			t.Errorf("UpdatedAt = %v, want after CreatedAt %v", found.UpdatedAt, found.CreatedAt)
+		}
+	})
+
+	t.Run("UpdateRefund of missing order", func(t *testing.T) {
+		repo := newRepo(t)
+
+		if err := repo.UpdateRefund(ctx, uuid.NewString(), 1, model.RefundStatusRefunded); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("UpdateRefund error = %v, want sql.ErrNoRows", err)
+		}
+	})
+
+	t.Run("concurrent updates", func(t *testing.T) {
+		repo := newRepo(t)
+		order := createOrder(t, repo)
+
+		var wg sync.WaitGroup
+		for i := range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				status := model.OrderStatusPaid
+				if i%2 == 0 {
+					status = model.OrderStatusShipped
+				}
+				if err := repo.UpdateStatus(ctx, order.ID, status); err != nil {
+					t.Errorf("UpdateStatus: %v", err)
+				}
+				if _, err := repo.FindByID(ctx, order.ID); err != nil {
+					t.Errorf("FindByID: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+	})
+}
+
+func newOrder() *model.Order {
+	return &model.Order{
+		UserID:     uuid.NewString(),
+		Items:      json.RawMessage(`[{"productId":"` + uuid.NewString() + `","quantity":2,"price":4.5}]`),
+		TotalPrice: 9,
+		Status:     model.OrderStatusPending,
+	}
+}
+
+func createOrder(t *testing.T, repo repository.OrderRepository) *model.Order {
+	t.Helper()
+
+	order := newOrder()
+	if err := repo.Create(context.Background(), order); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	return order
+}
+
+// assertSameJSON compares JSON by value, Postgres doesn't keep the
+// formatting of JSONB columns.
+func assertSameJSON(t *testing.T, got, want json.RawMessage) {
+	t.Helper()
+
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid JSON %s: %v", got, err)
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		t.Fatalf("invalid JSON %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"ecommerce-platform/services/order/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+var ErrReturnExceedsShipped = errors.New("return exceeds the shipped quantities not in another return")
+
+type ReturnRepository interface {
+	// Create stores the return together with its lines after checking, under
+	// a lock on the order, that the lines fit into the shipped units not in
+	// another return. Otherwise it returns ErrReturnExceedsShipped.
+	Create(ctx context.Context, ret *model.Return) error
+	FindByID(ctx context.Context, id string) (*model.Return, error)
+	FindByOrderID(ctx context.Context, orderID string) ([]*model.Return, error)
+	UpdateDecision(ctx context.Context, id, newStatus string, note *string) error
+	// SaveInspection stores the dispositions of all lines, the refund amount and the status.
+	SaveInspection(ctx context.Context, ret *model.Return) error
+	MarkRefunded(ctx context.Context, id, refundID string) error
+}
+
+// CheckReturnFits returns ErrReturnExceedsShipped unless the lines of ret fit
+// into the units of the shipments that left the warehouse, less the returned
+// lines of the order's other returns that were not rejected.
+func CheckReturnFits(shipments []*model.Shipment, returned []*model.ReturnLine, ret *model.Return) error {
+	returnable := make(map[string]int)
+	for _, shipment := range shipments {
+		if shipment.Status == model.ShipmentStatusPending {
+			continue
+		}
+		var items []model.ShipmentItem
+		if err := json.Unmarshal(shipment.Items, &items); err != nil {
+			return err
+		}
+		for _, item := range items {
+			returnable[item.ProductID] += item.Quantity
+		}
+	}
+
+	for _, line := range returned {
+		returnable[line.ProductID] -= line.Quantity
+	}
+	for _, line := range ret.Lines {
+		returnable[line.ProductID] -= line.Quantity
+	}
+
+	for productID, quantity := range returnable {
+		if quantity < 0 {
+			return fmt.Errorf("%w: %d too many of product %s", ErrReturnExceedsShipped, -quantity, productID)
+		}
+	}
+
+	return nil
+}
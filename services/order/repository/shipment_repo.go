@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"ecommerce-platform/services/order/model"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var (
+	ErrShipmentExceedsOrder    = errors.New("shipment exceeds the order quantities not in another shipment")
+	ErrShipmentStatusRegressed = errors.New("shipment already has a later status")
+)
+
+type ShipmentRepository interface {
+	// Create stores the shipment after checking, under a lock on the order,
+	// that its items are part of the order and not in another shipment yet.
+	// Otherwise it returns ErrShipmentExceedsOrder.
+	Create(ctx context.Context, shipment *model.Shipment) error
+	FindByID(ctx context.Context, id string) (*model.Shipment, error)
+	FindByOrderID(ctx context.Context, orderID string) ([]*model.Shipment, error)
+	FindByStatus(ctx context.Context, status string) ([]*model.Shipment, error)
+	UpdateTracking(ctx context.Context, id, carrier, trackingNumber string) error
+	// AddEvent stores the event and moves the shipment to the event's status.
+	// It returns ErrShipmentStatusRegressed if the shipment already has a
+	// status of higher rank.
+	AddEvent(ctx context.Context, event *model.ShipmentEvent) error
+	FindEvents(ctx context.Context, shipmentID string) ([]*model.ShipmentEvent, error)
+}
+
+// CheckShipmentFits returns ErrShipmentExceedsOrder unless the items of
+// shipment fit into the units of the order items that none of the other
+// shipments holds.
+func CheckShipmentFits(orderItems json.RawMessage, others []*model.Shipment, shipment *model.Shipment) error {
+	var items []model.OrderItem
+	if err := json.Unmarshal(orderItems, &items); err != nil {
+		return err
+	}
+
+	remaining := make(map[string]int)
+	for _, item := range items {
+		remaining[item.ProductID] += item.Quantity
+	}
+
+	for _, s := range slices.Concat(others, []*model.Shipment{shipment}) {
+		var shipmentItems []model.ShipmentItem
+		if err := json.Unmarshal(s.Items, &shipmentItems); err != nil {
+			return err
+		}
+		for _, item := range shipmentItems {
+			remaining[item.ProductID] -= item.Quantity
+		}
+	}
+
+	for productID, quantity := range remaining {
+		if quantity < 0 {
+			return fmt.Errorf("%w: %d too many of product %s", ErrShipmentExceedsOrder, -quantity, productID)
+		}
+	}
+	return nil
+}
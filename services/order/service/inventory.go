@@ -0,0 +1,63 @@
+package service
+
+import (
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/services/order/model"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrInventoryUnavailable = apperr.New(apperr.Upstream, "inventory_unavailable", "Inventory service is unavailable")
+	ErrRestockFailed        = apperr.New(apperr.Conflict, "restock_failed", "Returned items could not be put back into stock")
+)
+
+// productInfoError converts an error of the inventory service's
+// GetProductInfo into an error of the order service. The products the
+// inventory service reports as missing or invalid are pointed out by the
+// index of their item, since GetProductInfo was called with the product IDs
+// of items in order.
+func productInfoError(err error, items []model.OrderItem) error {
+	e := apperr.From(err)
+
+	switch e.Kind {
+	case apperr.NotFound:
+		missing := strings.Split(e.Metadata["product_ids"], ",")
+		var fields []apperr.FieldError
+		for i, item := range items {
+			if slices.Contains(missing, item.ProductID) {
+				fields = append(fields, apperr.FieldError{Field: fmt.Sprintf("items[%d].productId", i), Message: "is not a known product"})
+			}
+		}
+		return ErrNoPrice.WithFields(fields...).Wrap(err)
+	case apperr.Validation:
+		fields := make([]apperr.FieldError, 0, len(e.Fields))
+		for _, f := range e.Fields {
+			if index, ok := strings.CutPrefix(f.Field, "product_ids"); ok && index != "" {
+				f.Field = "items" + index + ".productId"
+			} else if ok {
+				f.Field = "items"
+			}
+			fields = append(fields, f)
+		}
+		return apperr.ErrInvalidRequest.WithFields(fields...).Wrap(err)
+	case apperr.Upstream:
+		return ErrInventoryUnavailable.Wrap(err)
+	}
+	return err
+}
+
+// restockError converts an error of the inventory service's AdjustStock
+// for a returned product into an error of the order service.
+func restockError(err error, productID string) error {
+	e := apperr.From(err)
+
+	switch e.Kind {
+	case apperr.NotFound, apperr.Validation, apperr.Conflict:
+		return ErrRestockFailed.WithMetadata("product_id", productID).Wrap(err)
+	case apperr.Upstream:
+		return ErrInventoryUnavailable.Wrap(err)
+	}
+	return err
+}
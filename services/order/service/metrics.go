@@ -0,0 +1,24 @@
+package service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	ordersCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ecommerce_orders_created_total",
+		Help: "Orders created by their status at creation.",
+	}, []string{"status"})
+
+	orderRevenue = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ecommerce_order_revenue_total",
+		Help: "Sum of the total prices of created orders.",
+	})
+
+	orderCreationDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "ecommerce_order_creation_duration_seconds",
+		Help:    "Duration of order creation, including the price lookup in the inventory service.",
+		Buckets: []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	})
+)
@@ -2,11 +2,14 @@ package service
 
 import (
 	"context"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
 	"ecommerce-platform/services/order/model"
 	"ecommerce-platform/services/order/repository"
 	"encoding/json"
-	"errors"
 	"log/slog"
+	"time"
 
 	pb "ecommerce-platform/pkg/grpc/inventory"
 
@@ -14,22 +17,26 @@ import (
 )
 
 var (
-	ErrNoPrice = errors.New("Price not found for one of the items")
+	ErrOrderNotFound = apperr.New(apperr.NotFound, "order_not_found", "No order with given id")
+	ErrNoPrice       = apperr.New(apperr.Validation, "unknown_product", "Price not found for one of the items")
 )
 
 type OrderService interface {
 	CreateOrder(ctx context.Context, userID string, items []model.OrderItem) (*model.Order, error)
 	GetOrderByID(ctx context.Context, id string) (*model.Order, error)
-	HandlePaymentSucceeded(ctx context.Context, orderID string)
-	HandlePaymentFailed(ctx context.Context, orderID, reason string)
-	HandleStockReserved(ctx context.Context, orderID string)
-	HandleStockUnavailable(ctx context.Context, orderID string, unavailableItems *[]model.OrderItem)
+	// HandlePaymentSucceeded marks a pending order as paid. An error means the
+	// event has to be delivered again.
+	HandlePaymentSucceeded(ctx context.Context, orderID string) error
+	// HandlePaymentRefunded records the refunded total of the order. An error
+	// means the event has to be delivered again.
+	HandlePaymentRefunded(ctx context.Context, orderID string, refundedTotal float64) error
 }
 
 type orderServiceImpl struct {
 	orderRepo       repository.OrderRepository
 	logger          *slog.Logger
 	inventoryClient pb.InventoryServiceClient
+	publisher       messaging.Publisher
 }
 
 func (or *orderServiceImpl) CreateOrder(ctx context.Context, userID string, items []model.OrderItem) (*model.Order, error) {
@@ -37,6 +44,9 @@ func (or *orderServiceImpl) CreateOrder(ctx context.Context, userID string, item
 
 	serviceLogger.Info("CreateOrder started")
 
+	start := time.Now()
+	defer func() { orderCreationDuration.Observe(time.Since(start).Seconds()) }()
+
 	var productIDs []string
 	for _, item := range items {
 		productIDs = append(productIDs, item.ProductID)
@@ -45,7 +55,7 @@ func (or *orderServiceImpl) CreateOrder(ctx context.Context, userID string, item
 	products, err := or.inventoryClient.GetProductInfo(ctx, &pb.GetProductInfoRequest{ProductIds: productIDs})
 	if err != nil {
 		serviceLogger.Error("Failed to fetch product info from grpc server", "error", err)
-		return nil, err
+		return nil, productInfoError(err, items)
 	}
 
 	priceMap := make(map[string]float64)
@@ -74,7 +84,7 @@ func (or *orderServiceImpl) CreateOrder(ctx context.Context, userID string, item
 	serviceLogger.Info("Set items", "items", order.Items)
 
 	order.TotalPrice = totalPrice
-	order.Status = "PENDING"
+	order.Status = model.OrderStatusPending
 
 	serviceLogger.Info("Set total price and status", "total_price", order.TotalPrice, "status", order.Status)
 
@@ -84,6 +94,26 @@ func (or *orderServiceImpl) CreateOrder(ctx context.Context, userID string, item
 		return nil, err
 	}
 
+	ordersCreated.WithLabelValues(order.Status).Inc()
+	orderRevenue.Add(order.TotalPrice)
+
+	eventItems := make([]events.OrderItem, 0, len(items))
+	for _, item := range items {
+		eventItems = append(eventItems, events.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity, Price: *item.Price})
+	}
+
+	err = or.publisher.Publish(ctx, events.OrderCreated, events.OrderCreatedPayload{
+		OrderID:    order.ID,
+		UserID:     order.UserID,
+		Items:      eventItems,
+		TotalPrice: order.TotalPrice,
+		Status:     order.Status,
+	})
+	if err != nil {
+		// The order is stored; only the notification about it is lost.
+		serviceLogger.Error("Could not publish order created event", "error", err)
+	}
+
 	serviceLogger.Info("CreateOrder completed successfully", "order", order)
 	return &order, nil
 }
@@ -103,26 +133,73 @@ func (or *orderServiceImpl) GetOrderByID(ctx context.Context, id string) (*model
 	return order, nil
 }
 
-func (or *orderServiceImpl) HandlePaymentSucceeded(ctx context.Context, orderID string) {
-	panic("not implemented") // TODO: Implement
-}
+func (or *orderServiceImpl) HandlePaymentSucceeded(ctx context.Context, orderID string) error {
+	serviceLogger := or.logger.With("request_id", middleware.GetReqID(ctx), "order_id", orderID)
 
-func (or *orderServiceImpl) HandlePaymentFailed(ctx context.Context, orderID string, reason string) {
-	panic("not implemented") // TODO: Implement
-}
+	serviceLogger.Info("HandlePaymentSucceeded started")
+
+	order, err := or.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		serviceLogger.Error("Could not find paid order", "error", err)
+		return err
+	}
+
+	// A redelivered event must not take a shipped order back to PAID.
+	if order.Status != model.OrderStatusPending {
+		serviceLogger.Info("Payment already reflected on order", "status", order.Status)
+		return nil
+	}
 
-func (or *orderServiceImpl) HandleStockReserved(ctx context.Context, orderID string) {
-	panic("not implemented") // TODO: Implement
+	// Once paid, the order is ready for fulfillment.
+	err = or.orderRepo.UpdateStatus(ctx, orderID, model.OrderStatusPaid)
+	if err != nil {
+		serviceLogger.Error("Could not mark order as paid", "error", err)
+		return err
+	}
+
+	serviceLogger.Info("HandlePaymentSucceeded completed successfully")
+
+	return nil
 }
 
-func (or *orderServiceImpl) HandleStockUnavailable(ctx context.Context, orderID string, unavailableItems *[]model.OrderItem) {
-	panic("not implemented") // TODO: Implement
+func (or *orderServiceImpl) HandlePaymentRefunded(ctx context.Context, orderID string, refundedTotal float64) error {
+	serviceLogger := or.logger.With("request_id", middleware.GetReqID(ctx), "order_id", orderID, "refunded_total", refundedTotal)
+
+	serviceLogger.Info("HandlePaymentRefunded started")
+
+	order, err := or.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		serviceLogger.Error("Could not find refunded order", "error", err)
+		return err
+	}
+
+	// Refund events can arrive out of order; the refunded total only ever grows.
+	if refundedTotal <= order.RefundedTotal {
+		serviceLogger.Info("Refund already reflected on order", "current_refunded_total", order.RefundedTotal)
+		return nil
+	}
+
+	refundStatus := model.RefundStatusPartiallyRefunded
+	if refundedTotal >= order.TotalPrice {
+		refundStatus = model.RefundStatusRefunded
+	}
+
+	err = or.orderRepo.UpdateRefund(ctx, orderID, refundedTotal, refundStatus)
+	if err != nil {
+		serviceLogger.Error("Could not update refunded total", "error", err)
+		return err
+	}
+
+	serviceLogger.Info("HandlePaymentRefunded completed successfully", "refund_status", refundStatus)
+
+	return nil
 }
 
-func NewOrderService(orderRepo repository.OrderRepository, logger *slog.Logger, inventoryClient pb.InventoryServiceClient) *orderServiceImpl {
+func NewOrderService(orderRepo repository.OrderRepository, logger *slog.Logger, inventoryClient pb.InventoryServiceClient, publisher messaging.Publisher) *orderServiceImpl {
 	return &orderServiceImpl{
 		orderRepo:       orderRepo,
 		logger:          logger.With("file", "order_service.go"),
 		inventoryClient: inventoryClient,
+		publisher:       publisher,
 	}
 }
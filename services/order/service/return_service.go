@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/services/order/model"
+	"ecommerce-platform/services/order/repository"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+
+	pb "ecommerce-platform/pkg/grpc/inventory"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+var (
+	ErrReturnNotFound          = apperr.New(apperr.NotFound, "return_not_found", "No return with given id")
+	ErrOrderNotReturnable      = apperr.New(apperr.Conflict, "order_not_returnable", "Order has not been shipped yet")
+	ErrInvalidReturnLines      = apperr.New(apperr.Validation, "invalid_return_lines", "Return lines do not match the returnable order quantities")
+	ErrInvalidReturnTransition = apperr.New(apperr.Conflict, "invalid_return_transition", "Return is not in a state that allows this action")
+	ErrInvalidInspection       = apperr.New(apperr.Validation, "invalid_inspection", "Every return line needs exactly one valid disposition")
+	ErrRefundFailed            = apperr.New(apperr.Upstream, "refund_failed", "Refund could not be issued")
+)
+
+// RefundIssuer issues refunds against an order's payment. It returns the ID
+// and status of the refund, which is RefundSucceeded once the money was
+// returned.
+type RefundIssuer interface {
+	IssueRefund(ctx context.Context, orderID string, amount float64, reason, reference string) (string, string, error)
+}
+
+// RefundSucceeded is the status of refunds the payment provider completed.
+const RefundSucceeded = "SUCCEEDED"
+
+type ReturnService interface {
+	RequestReturn(ctx context.Context, orderID, userID string, reason *string, lines []*model.ReturnLine) (*model.Return, error)
+	GetReturn(ctx context.Context, id string) (*model.Return, error)
+	ListReturns(ctx context.Context, orderID string) ([]*model.Return, error)
+	ApproveReturn(ctx context.Context, id string, note *string) (*model.Return, error)
+	RejectReturn(ctx context.Context, id string, note *string) (*model.Return, error)
+	InspectReturn(ctx context.Context, id string, inspections []model.ReturnInspection) (*model.Return, error)
+	RefundReturn(ctx context.Context, id string) (*model.Return, error)
+}
+
+type returnServiceImpl struct {
+	returnRepo      repository.ReturnRepository
+	orderRepo       repository.OrderRepository
+	inventoryClient pb.InventoryServiceClient
+	refundIssuer    RefundIssuer
+	logger          *slog.Logger
+}
+
+func (rs *returnServiceImpl) RequestReturn(ctx context.Context, orderID, userID string, reason *string, lines []*model.ReturnLine) (*model.Return, error) {
+	serviceLogger := rs.logger.With("request_id", middleware.GetReqID(ctx), "order_id", orderID, "user_id", userID)
+
+	serviceLogger.Info("RequestReturn started")
+
+	order, err := rs.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if order.UserID != userID {
+		// Don't reveal that the order exists to other users.
+		serviceLogger.Error("Order belongs to another user")
+		return nil, ErrOrderNotReturnable
+	}
+
+	switch order.Status {
+	case model.OrderStatusPartiallyShipped, model.OrderStatusShipped, model.OrderStatusDelivered:
+	default:
+		serviceLogger.Error("Order cannot be returned", "status", order.Status)
+		return nil, ErrOrderNotReturnable
+	}
+
+	var orderItems []model.OrderItem
+	if err := json.Unmarshal(order.Items, &orderItems); err != nil {
+		return nil, err
+	}
+
+	prices := make(map[string]float64)
+	for _, item := range orderItems {
+		if item.Price != nil {
+			prices[item.ProductID] = *item.Price
+		}
+	}
+
+	if len(lines) == 0 {
+		serviceLogger.Error("Return has no lines")
+		return nil, ErrInvalidReturnLines
+	}
+
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			serviceLogger.Error("Return line quantity must be positive", "product_id", line.ProductID)
+			return nil, ErrInvalidReturnLines
+		}
+		line.UnitPrice = prices[line.ProductID]
+	}
+
+	ret := model.Return{
+		OrderID: orderID,
+		UserID:  userID,
+		Status:  model.ReturnStatusRequested,
+		Reason:  reason,
+		Lines:   lines,
+	}
+
+	// Only units that left the warehouse and are not in another return can
+	// come back. The repository checks that under a lock on the order.
+	err = rs.returnRepo.Create(ctx, &ret)
+	if errors.Is(err, repository.ErrReturnExceedsShipped) {
+		serviceLogger.Error("Return exceeds returnable quantity", "error", err)
+		return nil, ErrInvalidReturnLines.Wrap(err)
+	}
+	if err != nil {
+		serviceLogger.Error("RequestReturn failed")
+		return nil, err
+	}
+
+	serviceLogger.Info("RequestReturn completed successfully", "return", ret)
+
+	return &ret, nil
+}
+
+func (rs *returnServiceImpl) GetReturn(ctx context.Context, id string) (*model.Return, error) {
+	serviceLogger := rs.logger.With("request_id", middleware.GetReqID(ctx), "return_id", id)
+
+	serviceLogger.Info("GetReturn started")
+
+	ret, err := rs.returnRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("GetReturn completed successfully")
+
+	return ret, nil
+}
+
+func (rs *returnServiceImpl) ListReturns(ctx context.Context, orderID string) ([]*model.Return, error) {
+	serviceLogger := rs.logger.With("request_id", middleware.GetReqID(ctx), "order_id", orderID)
+
+	serviceLogger.Info("ListReturns started")
+
+	if _, err := rs.orderRepo.FindByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	returns, err := rs.returnRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("ListReturns completed successfully", "count", len(returns))
+
+	return returns, nil
+}
+
+func (rs *returnServiceImpl) ApproveReturn(ctx context.Context, id string, note *string) (*model.Return, error) {
+	return rs.decide(ctx, id, model.ReturnStatusApproved, note)
+}
+
+func (rs *returnServiceImpl) RejectReturn(ctx context.Context, id string, note *string) (*model.Return, error) {
+	return rs.decide(ctx, id, model.ReturnStatusRejected, note)
+}
+
+func (rs *returnServiceImpl) decide(ctx context.Context, id, newStatus string, note *string) (*model.Return, error) {
+	serviceLogger := rs.logger.With("request_id", middleware.GetReqID(ctx), "return_id", id, "new_status", newStatus)
+
+	serviceLogger.Info("Return decision started")
+
+	ret, err := rs.returnRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Status != model.ReturnStatusRequested {
+		serviceLogger.Error("Return was already decided", "status", ret.Status)
+		return nil, ErrInvalidReturnTransition
+	}
+
+	err = rs.returnRepo.UpdateDecision(ctx, id, newStatus, note)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("Return decision completed successfully")
+
+	return rs.returnRepo.FindByID(ctx, id)
+}
+
+// InspectReturn records what happened to every received line. Restocked lines
+// are put back into inventory, and the accepted (restocked or written off)
+// lines are refunded.
+func (rs *returnServiceImpl) InspectReturn(ctx context.Context, id string, inspections []model.ReturnInspection) (*model.Return, error) {
+	serviceLogger := rs.logger.With("request_id", middleware.GetReqID(ctx), "return_id", id)
+
+	serviceLogger.Info("InspectReturn started")
+
+	ret, err := rs.returnRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Status != model.ReturnStatusApproved {
+		serviceLogger.Error("Return is not awaiting inspection", "status", ret.Status)
+		return nil, ErrInvalidReturnTransition
+	}
+
+	byLine := make(map[string]model.ReturnInspection)
+	for _, inspection := range inspections {
+		switch inspection.Disposition {
+		case model.ReturnDispositionRestock, model.ReturnDispositionWriteOff, model.ReturnDispositionRejected:
+		default:
+			serviceLogger.Error("Unknown disposition", "line_id", inspection.LineID, "disposition", inspection.Disposition)
+			return nil, ErrInvalidInspection
+		}
+		if _, dup := byLine[inspection.LineID]; dup {
+			serviceLogger.Error("Line inspected twice", "line_id", inspection.LineID)
+			return nil, ErrInvalidInspection
+		}
+		byLine[inspection.LineID] = inspection
+	}
+
+	if len(byLine) != len(ret.Lines) {
+		serviceLogger.Error("Not every line was inspected", "inspected", len(byLine), "lines", len(ret.Lines))
+		return nil, ErrInvalidInspection
+	}
+
+	var refundAmount float64
+	for _, line := range ret.Lines {
+		inspection, ok := byLine[line.ID]
+		if !ok {
+			serviceLogger.Error("Line was not inspected", "line_id", line.ID)
+			return nil, ErrInvalidInspection
+		}
+
+		disposition := inspection.Disposition
+		line.Disposition = &disposition
+		line.InspectionNote = inspection.Note
+
+		if disposition == model.ReturnDispositionRejected {
+			continue
+		}
+		refundAmount += line.UnitPrice * float64(line.Quantity)
+
+		if disposition != model.ReturnDispositionRestock {
+			continue
+		}
+
+		// The line ID is used as reference so the inventory service applies the
+		// movement only once, even if the inspection is submitted again after a failure.
+		_, err := rs.inventoryClient.AdjustStock(ctx, &pb.AdjustStockRequest{
+			ProductId:      line.ProductID,
+			QuantityChange: int32(line.Quantity),
+			Reason:         "RETURN_RESTOCK",
+			Reference:      "return-line:" + line.ID,
+		})
+		if err != nil {
+			serviceLogger.Error("Could not restock returned items", "line_id", line.ID, "product_id", line.ProductID, "error", err)
+			return nil, restockError(err, line.ProductID)
+		}
+	}
+
+	refundAmount = math.Round(refundAmount*100) / 100
+	ret.RefundAmount = &refundAmount
+	ret.Status = model.ReturnStatusReceived
+	if refundAmount == 0 {
+		ret.Status = model.ReturnStatusClosed
+	}
+
+	err = rs.returnRepo.SaveInspection(ctx, ret)
+	if err != nil {
+		serviceLogger.Error("InspectReturn failed")
+		return nil, err
+	}
+
+	serviceLogger.Info("InspectReturn completed successfully", "refund_amount", refundAmount)
+
+	if ret.Status == model.ReturnStatusClosed {
+		return ret, nil
+	}
+
+	return rs.RefundReturn(ctx, id)
+}
+
+// RefundReturn issues the refund for a received return. It is called after the
+// inspection and can be called again if the payment service was unavailable.
+func (rs *returnServiceImpl) RefundReturn(ctx context.Context, id string) (*model.Return, error) {
+	serviceLogger := rs.logger.With("request_id", middleware.GetReqID(ctx), "return_id", id)
+
+	serviceLogger.Info("RefundReturn started")
+
+	ret, err := rs.returnRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if ret.Status != model.ReturnStatusReceived || ret.RefundAmount == nil {
+		serviceLogger.Error("Return is not awaiting a refund", "status", ret.Status)
+		return nil, ErrInvalidReturnTransition
+	}
+
+	refundID, status, err := rs.refundIssuer.IssueRefund(ctx, ret.OrderID, *ret.RefundAmount, "RETURN", "return:"+ret.ID)
+	if err != nil {
+		serviceLogger.Error("Could not issue refund", "error", err)
+		return nil, ErrRefundFailed.Wrap(err)
+	}
+	if status != RefundSucceeded {
+		// The return stays received, so the refund can be retried.
+		serviceLogger.Error("Refund did not succeed", "refund_id", refundID, "status", status)
+		return nil, ErrRefundFailed.WithMetadata("refund_status", status)
+	}
+
+	err = rs.returnRepo.MarkRefunded(ctx, id, refundID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("RefundReturn completed successfully", "refund_id", refundID)
+
+	return rs.returnRepo.FindByID(ctx, id)
+}
+
+func NewReturnService(returnRepo repository.ReturnRepository, orderRepo repository.OrderRepository, inventoryClient pb.InventoryServiceClient, refundIssuer RefundIssuer, logger *slog.Logger) *returnServiceImpl {
+	return &returnServiceImpl{
+		returnRepo:      returnRepo,
+		orderRepo:       orderRepo,
+		inventoryClient: inventoryClient,
+		refundIssuer:    refundIssuer,
+		logger:          logger.With("file", "return_service.go"),
+	}
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
+	"ecommerce-platform/services/order/model"
+	"ecommerce-platform/services/order/repository"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+var (
+	ErrOrderNotShippable         = apperr.New(apperr.Conflict, "order_not_shippable", "Order is not in a state that allows shipping")
+	ErrInvalidShipmentItems      = apperr.New(apperr.Validation, "invalid_shipment_items", "Shipment items do not match the remaining order quantities")
+	ErrInvalidShipmentTransition = apperr.New(apperr.Conflict, "invalid_shipment_transition", "Shipment cannot move to the requested status")
+	ErrMissingTracking           = apperr.New(apperr.Conflict, "missing_tracking", "Carrier and tracking number must be recorded before shipping")
+)
+
+var shipmentStatusEvents = map[string]string{
+	model.ShipmentStatusShipped:   events.ShipmentShipped,
+	model.ShipmentStatusInTransit: events.ShipmentInTransit,
+	model.ShipmentStatusDelivered: events.ShipmentDelivered,
+}
+
+type ShipmentService interface {
+	CreateShipment(ctx context.Context, orderID, warehouse string, items []model.ShipmentItem) (*model.Shipment, error)
+	GetShipment(ctx context.Context, id string) (*model.Shipment, error)
+	ListShipments(ctx context.Context, orderID string) ([]*model.Shipment, error)
+	SetTracking(ctx context.Context, id, carrier, trackingNumber string) (*model.Shipment, error)
+	RecordEvent(ctx context.Context, id string, event model.ShipmentEvent) (*model.Shipment, error)
+	GetPickLists(ctx context.Context) ([]*model.PickList, error)
+}
+
+type shipmentServiceImpl struct {
+	shipmentRepo repository.ShipmentRepository
+	orderRepo    repository.OrderRepository
+	publisher    messaging.Publisher
+	logger       *slog.Logger
+}
+
+func (ss *shipmentServiceImpl) CreateShipment(ctx context.Context, orderID, warehouse string, items []model.ShipmentItem) (*model.Shipment, error) {
+	serviceLogger := ss.logger.With("request_id", middleware.GetReqID(ctx), "order_id", orderID, "warehouse", warehouse, "items", items)
+
+	serviceLogger.Info("CreateShipment started")
+
+	order, err := ss.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch order.Status {
+	case model.OrderStatusPaid, model.OrderStatusPartiallyShipped:
+	default:
+		serviceLogger.Error("Order cannot be shipped", "status", order.Status)
+		return nil, ErrOrderNotShippable
+	}
+	if order.RefundStatus == model.RefundStatusRefunded {
+		serviceLogger.Error("Order was refunded in full")
+		return nil, ErrOrderNotShippable
+	}
+
+	if len(items) == 0 {
+		serviceLogger.Error("Shipment has no items")
+		return nil, ErrInvalidShipmentItems
+	}
+
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			serviceLogger.Error("Shipment item quantity must be positive", "product_id", item.ProductID)
+			return nil, ErrInvalidShipmentItems
+		}
+	}
+
+	itemsBytes, _ := json.Marshal(items)
+
+	shipment := model.Shipment{
+		OrderID:   orderID,
+		Warehouse: warehouse,
+		Items:     itemsBytes,
+		Status:    model.ShipmentStatusPending,
+	}
+
+	// The repository checks the remaining quantities under a lock on the
+	// order, so concurrent requests can't ship the same units twice.
+	err = ss.shipmentRepo.Create(ctx, &shipment)
+	if errors.Is(err, repository.ErrShipmentExceedsOrder) {
+		serviceLogger.Error("Shipment exceeds remaining quantity", "error", err)
+		return nil, ErrInvalidShipmentItems.Wrap(err)
+	}
+	if err != nil {
+		serviceLogger.Error("CreateShipment failed")
+		return nil, err
+	}
+
+	serviceLogger.Info("CreateShipment completed successfully", "shipment", shipment)
+
+	return &shipment, nil
+}
+
+func (ss *shipmentServiceImpl) GetShipment(ctx context.Context, id string) (*model.Shipment, error) {
+	serviceLogger := ss.logger.With("request_id", middleware.GetReqID(ctx), "shipment_id", id)
+
+	serviceLogger.Info("GetShipment started")
+
+	shipment, err := ss.shipmentRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := ss.shipmentRepo.FindEvents(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	shipment.Events = events
+
+	serviceLogger.Info("GetShipment completed successfully")
+
+	return shipment, nil
+}
+
+func (ss *shipmentServiceImpl) ListShipments(ctx context.Context, orderID string) ([]*model.Shipment, error) {
+	serviceLogger := ss.logger.With("request_id", middleware.GetReqID(ctx), "order_id", orderID)
+
+	serviceLogger.Info("ListShipments started")
+
+	if _, err := ss.orderRepo.FindByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	shipments, err := ss.shipmentRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("ListShipments completed successfully", "count", len(shipments))
+
+	return shipments, nil
+}
+
+func (ss *shipmentServiceImpl) SetTracking(ctx context.Context, id, carrier, trackingNumber string) (*model.Shipment, error) {
+	serviceLogger := ss.logger.With("request_id", middleware.GetReqID(ctx), "shipment_id", id)
+
+	serviceLogger.Info("SetTracking started", "carrier", carrier, "tracking_number", trackingNumber)
+
+	err := ss.shipmentRepo.UpdateTracking(ctx, id, carrier, trackingNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("SetTracking completed successfully")
+
+	return ss.GetShipment(ctx, id)
+}
+
+func (ss *shipmentServiceImpl) RecordEvent(ctx context.Context, id string, event model.ShipmentEvent) (*model.Shipment, error) {
+	serviceLogger := ss.logger.With("request_id", middleware.GetReqID(ctx), "shipment_id", id, "status", event.Status)
+
+	serviceLogger.Info("RecordEvent started")
+
+	shipment, err := ss.shipmentRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	newRank, ok := model.ShipmentStatusRank[event.Status]
+	if !ok || event.Status == model.ShipmentStatusPending || newRank < model.ShipmentStatusRank[shipment.Status] {
+		serviceLogger.Error("Invalid shipment status transition", "current_status", shipment.Status)
+		return nil, ErrInvalidShipmentTransition
+	}
+
+	if shipment.Carrier == nil || shipment.TrackingNumber == nil {
+		serviceLogger.Error("Shipment has no tracking information")
+		return nil, ErrMissingTracking
+	}
+
+	event.ShipmentID = id
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now().UTC()
+	}
+
+	err = ss.shipmentRepo.AddEvent(ctx, &event)
+	if errors.Is(err, repository.ErrShipmentStatusRegressed) {
+		// Another event moved the shipment on in the meantime.
+		serviceLogger.Error("Invalid shipment status transition", "error", err)
+		return nil, ErrInvalidShipmentTransition.Wrap(err)
+	}
+	if err != nil {
+		serviceLogger.Error("RecordEvent failed")
+		return nil, err
+	}
+
+	if err := ss.rollUpOrderStatus(ctx, shipment.OrderID); err != nil {
+		serviceLogger.Error("Could not roll up order status", "order_id", shipment.OrderID, "error", err)
+		return nil, err
+	}
+
+	// Only status changes are announced; repeated events of the same status
+	// (e.g. in transit scans) are just recorded.
+	if event.Status != shipment.Status {
+		ss.publishStatusChange(ctx, shipment, event)
+	}
+
+	serviceLogger.Info("RecordEvent completed successfully")
+
+	return ss.GetShipment(ctx, id)
+}
+
+func (ss *shipmentServiceImpl) GetPickLists(ctx context.Context) ([]*model.PickList, error) {
+	serviceLogger := ss.logger.With("request_id", middleware.GetReqID(ctx))
+
+	serviceLogger.Info("GetPickLists started")
+
+	shipments, err := ss.shipmentRepo.FindByStatus(ctx, model.ShipmentStatusPending)
+	if err != nil {
+		return nil, err
+	}
+
+	byWarehouse := make(map[string]*model.PickList)
+	for _, shipment := range shipments {
+		var items []model.ShipmentItem
+		if err := json.Unmarshal(shipment.Items, &items); err != nil {
+			serviceLogger.Error("Could not decode shipment items", "shipment_id", shipment.ID, "error", err)
+			return nil, err
+		}
+
+		pickList, ok := byWarehouse[shipment.Warehouse]
+		if !ok {
+			pickList = &model.PickList{Warehouse: shipment.Warehouse}
+			byWarehouse[shipment.Warehouse] = pickList
+		}
+
+		for _, item := range items {
+			pickList.Lines = append(pickList.Lines, model.PickListLine{
+				ShipmentID: shipment.ID,
+				OrderID:    shipment.OrderID,
+				ProductID:  item.ProductID,
+				Quantity:   item.Quantity,
+			})
+		}
+	}
+
+	pickLists := make([]*model.PickList, 0, len(byWarehouse))
+	for _, pickList := range byWarehouse {
+		pickLists = append(pickLists, pickList)
+	}
+	sort.Slice(pickLists, func(i, j int) bool { return pickLists[i].Warehouse < pickLists[j].Warehouse })
+
+	serviceLogger.Info("GetPickLists completed successfully", "warehouses", len(pickLists))
+
+	return pickLists, nil
+}
+
+func (ss *shipmentServiceImpl) publishStatusChange(ctx context.Context, shipment *model.Shipment, event model.ShipmentEvent) {
+	serviceLogger := ss.logger.With("request_id", middleware.GetReqID(ctx), "shipment_id", shipment.ID, "status", event.Status)
+
+	order, err := ss.orderRepo.FindByID(ctx, shipment.OrderID)
+	if err != nil {
+		serviceLogger.Error("Could not load order for shipment event", "error", err)
+		return
+	}
+
+	payload := events.ShipmentStatusPayload{
+		ShipmentID:     shipment.ID,
+		OrderID:        shipment.OrderID,
+		UserID:         order.UserID,
+		Status:         event.Status,
+		Carrier:        *shipment.Carrier,
+		TrackingNumber: *shipment.TrackingNumber,
+		OccurredAt:     event.OccurredAt,
+	}
+	if event.Location != nil {
+		payload.Location = *event.Location
+	}
+
+	if err := ss.publisher.Publish(ctx, shipmentStatusEvents[event.Status], payload); err != nil {
+		serviceLogger.Error("Could not publish shipment status event", "error", err)
+	}
+}
+
+// rollUpOrderStatus derives the order status from its shipments. The order is
+// SHIPPED once every unit is in a shipment that has left the warehouse,
+// DELIVERED once all of those shipments have arrived, and PARTIALLY_SHIPPED as
+// long as only some of the units are on their way.
+func (ss *shipmentServiceImpl) rollUpOrderStatus(ctx context.Context, orderID string) error {
+	order, err := ss.orderRepo.FindByID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	var orderItems []model.OrderItem
+	if err := json.Unmarshal(order.Items, &orderItems); err != nil {
+		return err
+	}
+
+	outstanding := make(map[string]int)
+	for _, item := range orderItems {
+		outstanding[item.ProductID] += item.Quantity
+	}
+
+	shipments, err := ss.shipmentRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return err
+	}
+
+	anyShipped, allDelivered := false, true
+	for _, shipment := range shipments {
+		if shipment.Status == model.ShipmentStatusPending {
+			continue
+		}
+		anyShipped = true
+		if shipment.Status != model.ShipmentStatusDelivered {
+			allDelivered = false
+		}
+
+		var items []model.ShipmentItem
+		if err := json.Unmarshal(shipment.Items, &items); err != nil {
+			return err
+		}
+		for _, item := range items {
+			outstanding[item.ProductID] -= item.Quantity
+		}
+	}
+
+	fullyShipped := true
+	for _, quantity := range outstanding {
+		if quantity > 0 {
+			fullyShipped = false
+			break
+		}
+	}
+
+	var newStatus string
+	switch {
+	case !anyShipped:
+		return nil
+	case fullyShipped && allDelivered:
+		newStatus = model.OrderStatusDelivered
+	case fullyShipped:
+		newStatus = model.OrderStatusShipped
+	default:
+		newStatus = model.OrderStatusPartiallyShipped
+	}
+
+	if newStatus == order.Status {
+		return nil
+	}
+
+	return ss.orderRepo.UpdateStatus(ctx, orderID, newStatus)
+}
+
+func NewShipmentService(shipmentRepo repository.ShipmentRepository, orderRepo repository.OrderRepository, publisher messaging.Publisher, logger *slog.Logger) *shipmentServiceImpl {
+	return &shipmentServiceImpl{
+		shipmentRepo: shipmentRepo,
+		orderRepo:    orderRepo,
+		publisher:    publisher,
+		logger:       logger.With("file", "shipment_service.go"),
+	}
+}
@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/services/payment/model"
+	"ecommerce-platform/services/payment/service"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+type CreateRefundRequest struct {
+	// OrderID selects the payment when the refund is created through /refunds.
+	OrderID   string  `json:"orderId,omitempty"`
+	Amount    float64 `json:"amount"`
+	Reason    string  `json:"reason"`
+	Note      *string `json:"note,omitempty"`
+	Reference *string `json:"reference,omitempty"`
+}
+
+type PaymentHandler struct {
+	paymentService service.PaymentService
+	logger         *slog.Logger
+}
+
+func NewPaymentHandler(paymentService service.PaymentService, logger *slog.Logger) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+		logger:         logger.With("file", "payment_handler.go"),
+	}
+}
+
+func (ph *PaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ph.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	paymentId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Retrieving payment by id", "payment_id", paymentId)
+
+	payment, ok := ph.ownedPayment(w, r, paymentId)
+	if !ok {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(payment)
+}
+
+func (ph *PaymentHandler) ListRefunds(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ph.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	paymentId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Listing refunds of payment", "payment_id", paymentId)
+
+	if _, ok := ph.ownedPayment(w, r, paymentId); !ok {
+		return
+	}
+
+	refunds, err := ph.paymentService.ListRefunds(r.Context(), paymentId)
+	if err != nil {
+		reqLogger.Error("Error listing refunds", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(refunds)
+}
+
+func (ph *PaymentHandler) RefundPayment(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ph.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	paymentId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Processing new refund request", "payment_id", paymentId)
+
+	var req CreateRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	refund, err := ph.paymentService.RefundPayment(r.Context(), paymentId, req.Amount, req.Reason, req.Note, req.Reference)
+	if err != nil {
+		reqLogger.Error("Error refunding payment", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Refund created successfully", "refund", refund)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
+
+func (ph *PaymentHandler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ph.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	reqLogger.Info("Processing new refund request by order")
+
+	var req CreateRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	if req.OrderID == "" {
+		reqLogger.Error("Invalid request body", "req", req)
+		apperr.Write(w, r, apperr.ErrInvalidRequest.WithFields(apperr.FieldError{Field: "orderId", Message: "is required"}))
+		return
+	}
+
+	refund, err := ph.paymentService.RefundOrder(r.Context(), req.OrderID, req.Amount, req.Reason, req.Note, req.Reference)
+	if err != nil {
+		reqLogger.Error("Error refunding order", "order_id", req.OrderID, "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Refund created successfully", "refund", refund)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
+
+func (ph *PaymentHandler) GetRefund(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ph.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	refundId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Retrieving refund by id", "refund_id", refundId)
+
+	refund, err := ph.paymentService.GetRefund(r.Context(), refundId)
+	if err != nil {
+		reqLogger.Error("Error retrieving refund", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	payment, err := ph.paymentService.GetPayment(r.Context(), refund.PaymentID)
+	if err != nil {
+		reqLogger.Error("Error retrieving payment of refund", "payment_id", refund.PaymentID, "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+	if !ownedBy(r, payment) {
+		reqLogger.Error("Refund belongs to another user", "refund_id", refundId)
+		apperr.Write(w, r, service.ErrRefundNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(refund)
+}
+
+// ownedPayment loads the payment and checks that it belongs to the caller.
+// Payments of other users are reported as not found, so their IDs can't be
+// probed. If it returns false, the error response was already written.
+func (ph *PaymentHandler) ownedPayment(w http.ResponseWriter, r *http.Request, paymentId string) (*model.Payment, bool) {
+	reqLogger := ph.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	payment, err := ph.paymentService.GetPayment(r.Context(), paymentId)
+	if err != nil {
+		reqLogger.Error("Error retrieving payment", "payment_id", paymentId, "error", err)
+		apperr.Write(w, r, err)
+		return nil, false
+	}
+
+	if !ownedBy(r, payment) {
+		reqLogger.Error("Payment belongs to another user", "payment_id", paymentId)
+		apperr.Write(w, r, service.ErrPaymentNotFound)
+		return nil, false
+	}
+
+	return payment, true
+}
+
+// ownedBy reports whether the caller owns the payment's order or may look at
+// every payment.
+func ownedBy(r *http.Request, payment *model.Payment) bool {
+	principal := auth.PrincipalFromContext(r.Context())
+	return principal != nil && (payment.UserID == principal.UserID || principal.Can(auth.PermPaymentsReadAll))
+}
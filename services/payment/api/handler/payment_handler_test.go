@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/services/payment/model"
+	"ecommerce-platform/services/payment/service"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+// payments serves one payment of user-1 with one refund.
+type payments struct {
+	service.PaymentService
+	refunded bool
+}
+
+var (
+	payment = &model.Payment{ID: "payment-1", OrderID: "order-1", UserID: "user-1", Amount: 10, Status: model.PaymentStatusSucceeded}
+	refund  = &model.Refund{ID: "refund-1", PaymentID: "payment-1", Amount: 5, Reason: model.RefundReasonGoodwill, Status: model.RefundStatusSucceeded}
+)
+
+func (p *payments) GetPayment(ctx context.Context, id string) (*model.Payment, error) {
+	if id != payment.ID {
+		return nil, service.ErrPaymentNotFound.Wrap(sql.ErrNoRows)
+	}
+	return payment, nil
+}
+
+func (p *payments) GetRefund(ctx context.Context, id string) (*model.Refund, error) {
+	if id != refund.ID {
+		return nil, service.ErrRefundNotFound.Wrap(sql.ErrNoRows)
+	}
+	return refund, nil
+}
+
+func (p *payments) ListRefunds(ctx context.Context, paymentID string) ([]*model.Refund, error) {
+	return []*model.Refund{refund}, nil
+}
+
+func (p *payments) RefundPayment(ctx context.Context, paymentID string, amount float64, reason string, note, reference *string) (*model.Refund, error) {
+	p.refunded = true
+	return refund, nil
+}
+
+func (p *payments) RefundOrder(ctx context.Context, orderID string, amount float64, reason string, note, reference *string) (*model.Refund, error) {
+	p.refunded = true
+	return refund, nil
+}
+
+func TestPaymentAccess(t *testing.T) {
+	customer := &auth.Principal{UserID: "user-1", Roles: []string{string(auth.RoleCustomer)}}
+	otherCustomer := &auth.Principal{UserID: "user-2", Roles: []string{string(auth.RoleCustomer)}}
+	support := &auth.Principal{UserID: "user-3", Roles: []string{string(auth.RoleSupport)}}
+	warehouse := &auth.Principal{UserID: "user-4", Roles: []string{string(auth.RoleWarehouseStaff)}}
+	refundBody := `{"amount": 5, "reason": "GOODWILL"}`
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		method    string
+		path      string
+		body      string
+		want      int
+	}{
+		{name: "owner reads payment", principal: customer, method: http.MethodGet, path: "/payments/payment-1", want: http.StatusOK},
+		{name: "other user reads payment", principal: otherCustomer, method: http.MethodGet, path: "/payments/payment-1", want: http.StatusNotFound},
+		{name: "support reads payment", principal: support, method: http.MethodGet, path: "/payments/payment-1", want: http.StatusOK},
+		{name: "other user lists refunds", principal: otherCustomer, method: http.MethodGet, path: "/payments/payment-1/refunds", want: http.StatusNotFound},
+		{name: "owner reads refund", principal: customer, method: http.MethodGet, path: "/refunds/refund-1", want: http.StatusOK},
+		{name: "other user reads refund", principal: otherCustomer, method: http.MethodGet, path: "/refunds/refund-1", want: http.StatusNotFound},
+		{name: "anonymous reads payment", method: http.MethodGet, path: "/payments/payment-1", want: http.StatusNotFound},
+		{name: "owner refunds payment", principal: customer, method: http.MethodPost, path: "/payments/payment-1/refunds", body: refundBody, want: http.StatusForbidden},
+		{name: "warehouse staff refunds order", principal: warehouse, method: http.MethodPost, path: "/refunds", body: `{"orderId": "order-1", "amount": 5, "reason": "GOODWILL"}`, want: http.StatusForbidden},
+		{name: "support refunds payment", principal: support, method: http.MethodPost, path: "/payments/payment-1/refunds", body: refundBody, want: http.StatusCreated},
+		{name: "support refunds order", principal: support, method: http.MethodPost, path: "/refunds", body: `{"orderId": "order-1", "amount": 5, "reason": "GOODWILL"}`, want: http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := slog.New(slog.DiscardHandler)
+			svc := &payments{}
+
+			r := chi.NewRouter()
+			r.Group(func(r chi.Router) {
+				// Stands in for the auth middleware.
+				r.Use(func(next http.Handler) http.Handler {
+					return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+						if tt.principal != nil {
+							req = req.WithContext(auth.WithPrincipal(req.Context(), tt.principal))
+						}
+						next.ServeHTTP(w, req)
+					})
+				})
+				r.Use(Policy(logger).Middleware(r))
+				Routes(r, NewPaymentHandler(svc, logger))
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if refunded := tt.want == http.StatusCreated; svc.refunded != refunded {
+				t.Errorf("refunded %v, want %v", svc.refunded, refunded)
+			}
+		})
+	}
+}
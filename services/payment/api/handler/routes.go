@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"ecommerce-platform/internal/auth"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi"
+)
+
+// Policy returns the permissions of the routes registered by Routes.
+// Mutating routes missing here are denied. Reading routes check ownership in
+// the handlers.
+func Policy(logger *slog.Logger) *auth.Policy {
+	return auth.NewPolicy(logger,
+		auth.Rule{Method: http.MethodPost, Pattern: "/payments/{id}/refunds", Permission: auth.PermPaymentsRefund},
+		auth.Rule{Method: http.MethodPost, Pattern: "/refunds", Permission: auth.PermPaymentsRefund},
+	)
+}
+
+// Routes registers the API of the payment service on r, which has to
+// authenticate the caller and enforce Policy.
+func Routes(r chi.Router, paymentHandler *PaymentHandler) {
+	r.Route("/payments", func(r chi.Router) {
+		r.Get("/{id}", paymentHandler.GetPayment)
+		r.Get("/{id}/refunds", paymentHandler.ListRefunds)
+		r.Post("/{id}/refunds", paymentHandler.RefundPayment)
+	})
+
+	r.Route("/refunds", func(r chi.Router) {
+		r.Post("/", paymentHandler.RefundOrder)
+		r.Get("/{id}", paymentHandler.GetRefund)
+	})
+}
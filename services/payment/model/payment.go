@@ -0,0 +1,52 @@
+package model
+
+import "time"
+
+const (
+	PaymentStatusPending           = "PENDING"
+	PaymentStatusSucceeded         = "SUCCEEDED"
+	PaymentStatusFailed            = "FAILED"
+	PaymentStatusPartiallyRefunded = "PARTIALLY_REFUNDED"
+	PaymentStatusRefunded          = "REFUNDED"
+)
+
+const (
+	RefundStatusPending   = "PENDING"
+	RefundStatusSucceeded = "SUCCEEDED"
+	RefundStatusFailed    = "FAILED"
+)
+
+const (
+	RefundReasonReturn      = "RETURN"
+	RefundReasonDamaged     = "DAMAGED"
+	RefundReasonNotReceived = "NOT_RECEIVED"
+	RefundReasonDuplicate   = "DUPLICATE"
+	RefundReasonGoodwill    = "GOODWILL"
+	RefundReasonOther       = "OTHER"
+)
+
+type Payment struct {
+	ID      string `json:"id"`
+	OrderID string `json:"orderId"`
+	// UserID is the owner of the order.
+	UserID            string    `json:"userId"`
+	Amount            float64   `json:"amount"`
+	RefundedAmount    float64   `json:"refundedAmount"`
+	Status            string    `json:"status"`
+	ProviderPaymentID *string   `json:"providerPaymentId,omitempty"`
+	CreatedAt         time.Time `json:"createdAt"`
+	UpdatedAt         time.Time `json:"updatedAt"`
+}
+
+type Refund struct {
+	ID               string    `json:"id"`
+	PaymentID        string    `json:"paymentId"`
+	Amount           float64   `json:"amount"`
+	Reason           string    `json:"reason"`
+	Note             *string   `json:"note,omitempty"`
+	Status           string    `json:"status"`
+	ProviderRefundID *string   `json:"providerRefundId,omitempty"`
+	Reference        *string   `json:"reference,omitempty"`
+	CreatedAt        time.Time `json:"createdAt"`
+	UpdatedAt        time.Time `json:"updatedAt"`
+}
@@ -0,0 +1,38 @@
+package provider
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+)
+
+// Provider is the payment provider (PSP) the captured payments live at.
+type Provider interface {
+	// Refund returns amount of the given provider payment to the customer and
+	// returns the provider's ID for the refund. idempotencyKey must be the same
+	// when the call is retried so the provider refunds only once.
+	Refund(ctx context.Context, providerPaymentID string, amount float64, idempotencyKey string) (string, error)
+}
+
+// SimulatedProvider accepts every refund without contacting a real PSP. It is
+// used until a provider integration is configured.
+type SimulatedProvider struct {
+	logger *slog.Logger
+}
+
+func NewSimulatedProvider(logger *slog.Logger) *SimulatedProvider {
+	return &SimulatedProvider{
+		logger: logger.With("file", "provider.go"),
+	}
+}
+
+func (sp *SimulatedProvider) Refund(ctx context.Context, providerPaymentID string, amount float64, idempotencyKey string) (string, error) {
+	refundID := "sim_re_" + uuid.NewSHA1(uuid.NameSpaceOID, []byte(idempotencyKey)).String()
+
+	sp.logger.Info("Simulated refund", "request_id", middleware.GetReqID(ctx), "provider_payment_id", providerPaymentID,
+		"amount", amount, "provider_refund_id", refundID)
+
+	return refundID, nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"ecommerce-platform/services/payment/model"
+	"errors"
+)
+
+var (
+	ErrRefundExceedsPayment = errors.New("refund exceeds the refundable amount of the payment")
+	ErrReferenceExists      = errors.New("a refund with the reference already exists")
+)
+
+type PaymentRepository interface {
+	FindByID(ctx context.Context, id string) (*model.Payment, error)
+	FindByOrderID(ctx context.Context, orderID string) (*model.Payment, error)
+}
+
+type RefundRepository interface {
+	// CreatePending stores a PENDING refund after checking, under a lock on the
+	// payment, that it fits into the not yet refunded amount. It returns
+	// ErrReferenceExists if another refund has the reference.
+	CreatePending(ctx context.Context, refund *model.Refund) error
+	// MarkSucceeded completes the pending refund and adds its amount to the
+	// payment's refunded amount, returning the updated payment. It returns
+	// sql.ErrNoRows if the refund is no longer pending.
+	MarkSucceeded(ctx context.Context, id, providerRefundID string) (*model.Payment, error)
+	// MarkFailed fails the refund and releases its reference for a retry.
+	MarkFailed(ctx context.Context, id string) error
+	FindByID(ctx context.Context, id string) (*model.Refund, error)
+	FindByReference(ctx context.Context, reference string) (*model.Refund, error)
+	FindByPaymentID(ctx context.Context, paymentID string) ([]*model.Refund, error)
+}
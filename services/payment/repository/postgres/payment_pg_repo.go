@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/payment/model"
+	"errors"
+	"log/slog"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+// paymentColumns include the owner of the paid order.
+const paymentColumns = `id, order_id, (SELECT user_id FROM orders WHERE orders.id = payments.order_id),
+	amount, refunded_amount, status, provider_payment_id, created_at, updated_at`
+
+type PaymentPgRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPayment(row rowScanner) (*model.Payment, error) {
+	var payment model.Payment
+	err := row.Scan(&payment.ID, &payment.OrderID, &payment.UserID, &payment.Amount, &payment.RefundedAmount, &payment.Status, &payment.ProviderPaymentID,
+		&payment.CreatedAt, &payment.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &payment, nil
+}
+
+func (pr *PaymentPgRepository) FindByID(ctx context.Context, id string) (*model.Payment, error) {
+	repoLogger := pr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByID started", "payment_id", id)
+
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE id = $1`
+
+	payment, err := scanPayment(pr.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Error("No payment with given id", "payment_id", id, "error", err)
+			return nil, err
+		}
+
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByID successful", "payment", payment)
+
+	return payment, nil
+}
+
+func (pr *PaymentPgRepository) FindByOrderID(ctx context.Context, orderID string) (*model.Payment, error) {
+	repoLogger := pr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByOrderID started", "order_id", orderID)
+
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE order_id = $1`
+
+	payment, err := scanPayment(pr.db.QueryRowContext(ctx, query, orderID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Error("No payment for given order", "order_id", orderID, "error", err)
+			return nil, err
+		}
+
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByOrderID successful", "payment", payment)
+
+	return payment, nil
+}
+
+func NewPaymentPgRepository(db *sql.DB, logger *slog.Logger) (*PaymentPgRepository, error) {
+	if err := db.Ping(); err != nil {
+		return nil, errors.New("failed to connect to the database: " + err.Error())
+	}
+
+	return &PaymentPgRepository{
+		db:     db,
+		logger: logger.With("file", "payment_pg_repo.go"),
+	}, nil
+}
@@ -0,0 +1,251 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/payment/model"
+	"ecommerce-platform/services/payment/repository"
+	"errors"
+	"log/slog"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const refundColumns = `id, payment_id, amount, reason, note, status, provider_refund_id, reference, created_at, updated_at`
+
+type RefundPgRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func scanRefund(row rowScanner) (*model.Refund, error) {
+	var refund model.Refund
+	err := row.Scan(&refund.ID, &refund.PaymentID, &refund.Amount, &refund.Reason, &refund.Note, &refund.Status, &refund.ProviderRefundID,
+		&refund.Reference, &refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &refund, nil
+}
+
+func (rr *RefundPgRepository) CreatePending(ctx context.Context, refund *model.Refund) error {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("CreatePending started", "refund", refund)
+
+	tx, err := rr.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the payment so concurrent refunds can't together exceed the captured amount.
+	var amount, refunded float64
+	err = tx.QueryRowContext(ctx, `SELECT amount, refunded_amount FROM payments WHERE id = $1 FOR UPDATE`, refund.PaymentID).Scan(&amount, &refunded)
+	if err != nil {
+		repoLogger.Error("Error finding payment", "payment_id", refund.PaymentID, "error", err)
+		return err
+	}
+
+	var pending float64
+	err = tx.QueryRowContext(ctx, `SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id = $1 AND status = $2`, refund.PaymentID, model.RefundStatusPending).Scan(&pending)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return err
+	}
+
+	// Compare in cents to avoid floating-point surprises.
+	if toCents(refunded)+toCents(pending)+toCents(refund.Amount) > toCents(amount) {
+		repoLogger.Error("Refund exceeds refundable amount", "amount", amount, "refunded", refunded, "pending", pending, "requested", refund.Amount)
+		return repository.ErrRefundExceedsPayment
+	}
+
+	exec := `INSERT INTO refunds (id, payment_id, amount, reason, note, status, reference) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING created_at, updated_at`
+
+	refund.ID = uuid.NewString()
+	refund.Status = model.RefundStatusPending
+
+	err = tx.QueryRowContext(ctx, exec, refund.ID, refund.PaymentID, refund.Amount, refund.Reason, refund.Note, refund.Status, refund.Reference).
+		Scan(&refund.CreatedAt, &refund.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			repoLogger.Error("Refund reference already used", "reference", *refund.Reference)
+			return repository.ErrReferenceExists
+		}
+
+		repoLogger.Error("Could not create record in database", "refund", refund, "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
+	repoLogger.Info("CreatePending successful", "refund", refund)
+
+	return nil
+}
+
+func (rr *RefundPgRepository) MarkSucceeded(ctx context.Context, id, providerRefundID string) (*model.Payment, error) {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("MarkSucceeded started", "refund_id", id, "provider_refund_id", providerRefundID)
+
+	tx, err := rr.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var paymentID string
+	var amount float64
+	query := `UPDATE refunds SET status = $1, provider_refund_id = $2 WHERE id = $3 AND status = $4 RETURNING payment_id, amount`
+	err = tx.QueryRowContext(ctx, query, model.RefundStatusSucceeded, providerRefundID, id, model.RefundStatusPending).Scan(&paymentID, &amount)
+	if err != nil {
+		repoLogger.Error("Could not complete pending refund", "error", err)
+		return nil, err
+	}
+
+	update := `UPDATE payments SET refunded_amount = refunded_amount + $1,
+		status = CASE WHEN refunded_amount + $1 >= amount THEN $2 ELSE $3 END
+		WHERE id = $4 RETURNING ` + paymentColumns
+
+	payment, err := scanPayment(tx.QueryRowContext(ctx, update, amount, model.PaymentStatusRefunded, model.PaymentStatusPartiallyRefunded, paymentID))
+	if err != nil {
+		repoLogger.Error("Could not update payment", "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("MarkSucceeded successful", "payment", payment)
+
+	return payment, nil
+}
+
+func (rr *RefundPgRepository) MarkFailed(ctx context.Context, id string) error {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("MarkFailed started", "refund_id", id)
+
+	// The reference is released so that the caller can retry the refund.
+	query := `UPDATE refunds SET status = $1, reference = NULL WHERE id = $2 AND status = $3`
+
+	res, err := rr.db.ExecContext(ctx, query, model.RefundStatusFailed, id, model.RefundStatusPending)
+	if err != nil {
+		repoLogger.Error("Could not update database", "error", err)
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		repoLogger.Error("No pending refund with given id found", "refund_id", id)
+		return sql.ErrNoRows
+	}
+
+	repoLogger.Info("MarkFailed successful", "refund_id", id)
+
+	return nil
+}
+
+func (rr *RefundPgRepository) FindByID(ctx context.Context, id string) (*model.Refund, error) {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByID started", "refund_id", id)
+
+	query := `SELECT ` + refundColumns + ` FROM refunds WHERE id = $1`
+
+	refund, err := scanRefund(rr.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		repoLogger.Error("Error finding refund", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByID successful", "refund", refund)
+
+	return refund, nil
+}
+
+func (rr *RefundPgRepository) FindByReference(ctx context.Context, reference string) (*model.Refund, error) {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByReference started", "reference", reference)
+
+	query := `SELECT ` + refundColumns + ` FROM refunds WHERE reference = $1`
+
+	refund, err := scanRefund(rr.db.QueryRowContext(ctx, query, reference))
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Error("Error reading database", "error", err)
+		}
+		return nil, err
+	}
+
+	repoLogger.Info("FindByReference successful", "refund", refund)
+
+	return refund, nil
+}
+
+func (rr *RefundPgRepository) FindByPaymentID(ctx context.Context, paymentID string) ([]*model.Refund, error) {
+	repoLogger := rr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByPaymentID started", "payment_id", paymentID)
+
+	query := `SELECT ` + refundColumns + ` FROM refunds WHERE payment_id = $1 ORDER BY created_at`
+
+	rows, err := rr.db.QueryContext(ctx, query, paymentID)
+	if err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var refunds []*model.Refund
+	for rows.Next() {
+		refund, err := scanRefund(rows)
+		if err != nil {
+			repoLogger.Error("Error reading database", "error", err)
+			return nil, err
+		}
+		refunds = append(refunds, refund)
+	}
+	if err := rows.Err(); err != nil {
+		repoLogger.Error("Error reading database", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByPaymentID successful", "count", len(refunds))
+
+	return refunds, nil
+}
+
+func toCents(amount float64) int64 {
+	if amount < 0 {
+		return int64(amount*100 - 0.5)
+	}
+	return int64(amount*100 + 0.5)
+}
+
+func NewRefundPgRepository(db *sql.DB, logger *slog.Logger) (*RefundPgRepository, error) {
+	if err := db.Ping(); err != nil {
+		return nil, errors.New("failed to connect to the database: " + err.Error())
+	}
+
+	return &RefundPgRepository{
+		db:     db,
+		logger: logger.With("file", "refund_pg_repo.go"),
+	}, nil
+}
@@ -0,0 +1,18 @@
+package service
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	paymentOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ecommerce_payment_operations_total",
+		Help: "Operations at the payment provider by operation and outcome.",
+	}, []string{"operation", "outcome"})
+
+	refundedAmount = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "ecommerce_refunded_amount_total",
+		Help: "Sum of successfully refunded amounts.",
+	})
+)
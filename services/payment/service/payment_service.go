@@ -0,0 +1,307 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
+	"ecommerce-platform/services/payment/model"
+	"ecommerce-platform/services/payment/provider"
+	"ecommerce-platform/services/payment/repository"
+	"errors"
+	"log/slog"
+	"math"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+var (
+	ErrPaymentNotFound      = apperr.New(apperr.NotFound, "payment_not_found", "Payment not found")
+	ErrRefundNotFound       = apperr.New(apperr.NotFound, "refund_not_found", "Refund not found")
+	ErrInvalidRefund        = apperr.New(apperr.Validation, "invalid_refund", "Refund needs a positive amount and a known reason")
+	ErrPaymentNotRefundable = apperr.New(apperr.Conflict, "payment_not_refundable", "Payment has not been captured")
+	ErrReferenceReused      = apperr.New(apperr.Conflict, "refund_reference_reused", "Refund reference was already used for a different refund")
+	ErrRefundExceedsPayment = apperr.New(apperr.Conflict, "refund_exceeds_payment", "Refund exceeds the refundable amount of the payment")
+	ErrRefundFailed         = apperr.New(apperr.Upstream, "refund_failed", "Payment provider rejected the refund")
+	ErrRefundPending        = apperr.New(apperr.Conflict, "refund_pending", "Refund with this reference is still being processed, retry later")
+	ErrRefundNotAnnounced   = apperr.New(apperr.Upstream, "refund_not_announced", "Refund was issued but could not be announced, retry with the same reference")
+)
+
+var refundReasons = map[string]bool{
+	model.RefundReasonReturn:      true,
+	model.RefundReasonDamaged:     true,
+	model.RefundReasonNotReceived: true,
+	model.RefundReasonDuplicate:   true,
+	model.RefundReasonGoodwill:    true,
+	model.RefundReasonOther:       true,
+}
+
+type PaymentService interface {
+	GetPayment(ctx context.Context, id string) (*model.Payment, error)
+	GetRefund(ctx context.Context, id string) (*model.Refund, error)
+	ListRefunds(ctx context.Context, paymentID string) ([]*model.Refund, error)
+	RefundPayment(ctx context.Context, paymentID string, amount float64, reason string, note, reference *string) (*model.Refund, error)
+	RefundOrder(ctx context.Context, orderID string, amount float64, reason string, note, reference *string) (*model.Refund, error)
+}
+
+type paymentServiceImpl struct {
+	paymentRepo repository.PaymentRepository
+	refundRepo  repository.RefundRepository
+	provider    provider.Provider
+	publisher   messaging.Publisher
+	logger      *slog.Logger
+}
+
+func (ps *paymentServiceImpl) GetPayment(ctx context.Context, id string) (*model.Payment, error) {
+	serviceLogger := ps.logger.With("request_id", middleware.GetReqID(ctx), "payment_id", id)
+
+	serviceLogger.Info("GetPayment started")
+
+	payment, err := ps.paymentRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, paymentNotFound(err)
+	}
+
+	serviceLogger.Info("GetPayment completed successfully")
+
+	return payment, nil
+}
+
+func (ps *paymentServiceImpl) GetRefund(ctx context.Context, id string) (*model.Refund, error) {
+	serviceLogger := ps.logger.With("request_id", middleware.GetReqID(ctx), "refund_id", id)
+
+	serviceLogger.Info("GetRefund started")
+
+	refund, err := ps.refundRepo.FindByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrRefundNotFound.Wrap(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("GetRefund completed successfully")
+
+	return refund, nil
+}
+
+func (ps *paymentServiceImpl) ListRefunds(ctx context.Context, paymentID string) ([]*model.Refund, error) {
+	serviceLogger := ps.logger.With("request_id", middleware.GetReqID(ctx), "payment_id", paymentID)
+
+	serviceLogger.Info("ListRefunds started")
+
+	if _, err := ps.paymentRepo.FindByID(ctx, paymentID); err != nil {
+		return nil, paymentNotFound(err)
+	}
+
+	refunds, err := ps.refundRepo.FindByPaymentID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("ListRefunds completed successfully", "count", len(refunds))
+
+	return refunds, nil
+}
+
+func (ps *paymentServiceImpl) RefundOrder(ctx context.Context, orderID string, amount float64, reason string, note, reference *string) (*model.Refund, error) {
+	payment, err := ps.paymentRepo.FindByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, paymentNotFound(err)
+	}
+
+	return ps.refund(ctx, payment, amount, reason, note, reference)
+}
+
+func (ps *paymentServiceImpl) RefundPayment(ctx context.Context, paymentID string, amount float64, reason string, note, reference *string) (*model.Refund, error) {
+	payment, err := ps.paymentRepo.FindByID(ctx, paymentID)
+	if err != nil {
+		return nil, paymentNotFound(err)
+	}
+
+	return ps.refund(ctx, payment, amount, reason, note, reference)
+}
+
+func (ps *paymentServiceImpl) refund(ctx context.Context, payment *model.Payment, amount float64, reason string, note, reference *string) (*model.Refund, error) {
+	serviceLogger := ps.logger.With("request_id", middleware.GetReqID(ctx), "payment_id", payment.ID, "amount", amount, "reason", reason)
+
+	serviceLogger.Info("Refund started")
+
+	amount = math.Round(amount*100) / 100
+	if amount <= 0 || !refundReasons[reason] {
+		serviceLogger.Error("Invalid refund")
+		return nil, ErrInvalidRefund
+	}
+
+	if reference != nil {
+		existing, err := ps.refundRepo.FindByReference(ctx, *reference)
+		if err == nil {
+			return ps.retry(ctx, payment, existing, amount)
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	switch payment.Status {
+	case model.PaymentStatusSucceeded, model.PaymentStatusPartiallyRefunded:
+	default:
+		serviceLogger.Error("Payment cannot be refunded", "status", payment.Status)
+		return nil, ErrPaymentNotRefundable
+	}
+
+	refund := model.Refund{
+		PaymentID: payment.ID,
+		Amount:    amount,
+		Reason:    reason,
+		Note:      note,
+		Reference: reference,
+	}
+
+	err := ps.refundRepo.CreatePending(ctx, &refund)
+	if errors.Is(err, repository.ErrReferenceExists) {
+		// A concurrent request with the same reference was first.
+		existing, err := ps.refundRepo.FindByReference(ctx, *reference)
+		if err != nil {
+			return nil, err
+		}
+		return ps.retry(ctx, payment, existing, amount)
+	}
+	if errors.Is(err, repository.ErrRefundExceedsPayment) {
+		serviceLogger.Error("Refund exceeds payment", "error", err)
+		return nil, ErrRefundExceedsPayment.Wrap(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ps.complete(ctx, payment, &refund)
+}
+
+// retry answers a refund request whose reference an existing refund has. A
+// succeeded refund is announced again, as the call that issued it may have
+// failed to. A pending one is completed, as the call that created it may
+// have failed before it finished.
+func (ps *paymentServiceImpl) retry(ctx context.Context, payment *model.Payment, existing *model.Refund, amount float64) (*model.Refund, error) {
+	serviceLogger := ps.logger.With("request_id", middleware.GetReqID(ctx), "payment_id", payment.ID, "refund_id", existing.ID)
+
+	if existing.PaymentID != payment.ID || existing.Amount != amount {
+		serviceLogger.Error("Refund reference reused", "reference", *existing.Reference, "existing_refund", existing)
+		return nil, ErrReferenceReused
+	}
+
+	serviceLogger.Info("Refund with given reference already exists", "status", existing.Status)
+
+	switch existing.Status {
+	case model.RefundStatusSucceeded:
+		return ps.announceAgain(ctx, existing)
+	case model.RefundStatusPending:
+		return ps.complete(ctx, payment, existing)
+	default:
+		// Failed refunds release their reference, so this can't happen.
+		return nil, ErrRefundFailed
+	}
+}
+
+// complete issues the pending refund at the provider and marks it as
+// succeeded. The provider refunds only once per refund, so completing a
+// refund twice is safe.
+func (ps *paymentServiceImpl) complete(ctx context.Context, payment *model.Payment, refund *model.Refund) (*model.Refund, error) {
+	serviceLogger := ps.logger.With("request_id", middleware.GetReqID(ctx), "payment_id", payment.ID, "refund_id", refund.ID)
+
+	var providerPaymentID string
+	if payment.ProviderPaymentID != nil {
+		providerPaymentID = *payment.ProviderPaymentID
+	}
+
+	providerRefundID, err := ps.provider.Refund(ctx, providerPaymentID, refund.Amount, refund.ID)
+	if err != nil {
+		serviceLogger.Error("Provider refund failed", "error", err)
+		paymentOperations.WithLabelValues("refund", "failed").Inc()
+		if markErr := ps.refundRepo.MarkFailed(ctx, refund.ID); markErr != nil {
+			serviceLogger.Error("Could not mark refund as failed", "error", markErr)
+		}
+		return nil, ErrRefundFailed.Wrap(err)
+	}
+
+	paymentOperations.WithLabelValues("refund", "succeeded").Inc()
+
+	updatedPayment, err := ps.refundRepo.MarkSucceeded(ctx, refund.ID, providerRefundID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// A concurrent retry completed or failed the refund first.
+		current, err := ps.refundRepo.FindByID(ctx, refund.ID)
+		if err != nil {
+			return nil, err
+		}
+		if current.Status != model.RefundStatusSucceeded {
+			serviceLogger.Error("Refund is no longer pending", "status", current.Status)
+			return nil, ErrRefundPending
+		}
+		return ps.announceAgain(ctx, current)
+	}
+	if err != nil {
+		serviceLogger.Error("Could not mark refund as succeeded", "provider_refund_id", providerRefundID, "error", err)
+		return nil, err
+	}
+
+	refundedAmount.Add(refund.Amount)
+
+	if err := ps.announce(ctx, updatedPayment, refund); err != nil {
+		// The money was already returned and the refund stays succeeded. The
+		// caller learns that the order doesn't know yet and retries with the
+		// same reference, which publishes the event again.
+		serviceLogger.Error("Could not publish refund event", "error", err)
+		return nil, ErrRefundNotAnnounced.Wrap(err)
+	}
+
+	serviceLogger.Info("Refund completed successfully", "provider_refund_id", providerRefundID)
+
+	return ps.refundRepo.FindByID(ctx, refund.ID)
+}
+
+// announceAgain publishes a succeeded refund again. Consumers ignore refunds
+// they already know.
+func (ps *paymentServiceImpl) announceAgain(ctx context.Context, refund *model.Refund) (*model.Refund, error) {
+	payment, err := ps.paymentRepo.FindByID(ctx, refund.PaymentID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ps.announce(ctx, payment, refund); err != nil {
+		ps.logger.Error("Could not publish refund event", "request_id", middleware.GetReqID(ctx), "refund_id", refund.ID, "error", err)
+		return nil, ErrRefundNotAnnounced.Wrap(err)
+	}
+	return refund, nil
+}
+
+// announce publishes the refund with the payment's refunded total after it.
+func (ps *paymentServiceImpl) announce(ctx context.Context, payment *model.Payment, refund *model.Refund) error {
+	return ps.publisher.Publish(ctx, events.PaymentRefunded, events.PaymentRefundedPayload{
+		PaymentID:      payment.ID,
+		OrderID:        payment.OrderID,
+		RefundID:       refund.ID,
+		Amount:         refund.Amount,
+		Reason:         refund.Reason,
+		CapturedAmount: payment.Amount,
+		RefundedTotal:  payment.RefundedAmount,
+	})
+}
+
+// paymentNotFound reports a payment lookup that found no row as
+// ErrPaymentNotFound.
+func paymentNotFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrPaymentNotFound.Wrap(err)
+	}
+	return err
+}
+
+func NewPaymentService(paymentRepo repository.PaymentRepository, refundRepo repository.RefundRepository, provider provider.Provider, publisher messaging.Publisher, logger *slog.Logger) *paymentServiceImpl {
+	return &paymentServiceImpl{
+		paymentRepo: paymentRepo,
+		refundRepo:  refundRepo,
+		provider:    provider,
+		publisher:   publisher,
+		logger:      logger.With("file", "payment_service.go"),
+	}
+}
@@ -0,0 +1,356 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/payment/model"
+	"ecommerce-platform/services/payment/repository"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// store keeps payments and refunds like the Postgres repositories do.
+type store struct {
+	mu       sync.Mutex
+	payments map[string]*model.Payment
+	refunds  map[string]*model.Refund
+	// lookedUp is called after a lookup by reference.
+	lookedUp func()
+}
+
+func newStore(payments ...*model.Payment) *store {
+	s := &store{payments: make(map[string]*model.Payment), refunds: make(map[string]*model.Refund)}
+	for _, payment := range payments {
+		s.payments[payment.ID] = payment
+	}
+	return s
+}
+
+func (s *store) FindByID(ctx context.Context, id string) (*model.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payment, ok := s.payments[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	p := *payment
+	return &p, nil
+}
+
+func (s *store) FindByOrderID(ctx context.Context, orderID string) (*model.Payment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, payment := range s.payments {
+		if payment.OrderID == orderID {
+			p := *payment
+			return &p, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// refunds is the RefundRepository view of the store.
+type refunds struct{ *store }
+
+func (r refunds) CreatePending(ctx context.Context, refund *model.Refund) error {
+	r.mu.Lock()
+	if refund.Reference != nil {
+		for _, other := range r.refunds {
+			if other.Reference != nil && *other.Reference == *refund.Reference {
+				r.mu.Unlock()
+				return repository.ErrReferenceExists
+			}
+		}
+	}
+	payment := r.payments[refund.PaymentID]
+	pending := 0.0
+	for _, other := range r.refunds {
+		if other.PaymentID == refund.PaymentID && other.Status == model.RefundStatusPending {
+			pending += other.Amount
+		}
+	}
+	if payment.RefundedAmount+pending+refund.Amount > payment.Amount {
+		r.mu.Unlock()
+		return repository.ErrRefundExceedsPayment
+	}
+
+	refund.ID = uuid.NewString()
+	refund.Status = model.RefundStatusPending
+	stored := *refund
+	r.refunds[refund.ID] = &stored
+	r.mu.Unlock()
+	return nil
+}
+
+func (r refunds) MarkSucceeded(ctx context.Context, id, providerRefundID string) (*model.Payment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refund, ok := r.refunds[id]
+	if !ok || refund.Status != model.RefundStatusPending {
+		return nil, sql.ErrNoRows
+	}
+	refund.Status = model.RefundStatusSucceeded
+	refund.ProviderRefundID = &providerRefundID
+
+	payment := r.payments[refund.PaymentID]
+	payment.RefundedAmount += refund.Amount
+	payment.Status = model.PaymentStatusPartiallyRefunded
+	if payment.RefundedAmount >= payment.Amount {
+		payment.Status = model.PaymentStatusRefunded
+	}
+	p := *payment
+	return &p, nil
+}
+
+func (r refunds) MarkFailed(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refund, ok := r.refunds[id]
+	if !ok || refund.Status != model.RefundStatusPending {
+		return sql.ErrNoRows
+	}
+	refund.Status = model.RefundStatusFailed
+	refund.Reference = nil
+	return nil
+}
+
+func (r refunds) FindByID(ctx context.Context, id string) (*model.Refund, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	refund, ok := r.refunds[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	c := *refund
+	return &c, nil
+}
+
+func (r refunds) FindByReference(ctx context.Context, reference string) (*model.Refund, error) {
+	if r.lookedUp != nil {
+		defer r.lookedUp()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, refund := range r.refunds {
+		if refund.Reference != nil && *refund.Reference == reference {
+			c := *refund
+			return &c, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r refunds) FindByPaymentID(ctx context.Context, paymentID string) ([]*model.Refund, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var found []*model.Refund
+	for _, refund := range r.refunds {
+		if refund.PaymentID == paymentID {
+			c := *refund
+			found = append(found, &c)
+		}
+	}
+	return found, nil
+}
+
+// psp refunds once per idempotency key and can be made to fail.
+type psp struct {
+	mu      sync.Mutex
+	calls   int
+	refunds map[string]bool
+	fail    error
+}
+
+func (p *psp) Refund(ctx context.Context, providerPaymentID string, amount float64, idempotencyKey string) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.calls++
+	if p.fail != nil {
+		return "", p.fail
+	}
+	if p.refunds == nil {
+		p.refunds = make(map[string]bool)
+	}
+	p.refunds[idempotencyKey] = true
+	return "re_" + idempotencyKey, nil
+}
+
+type publisher struct {
+	mu        sync.Mutex
+	published int
+}
+
+func (p *publisher) Publish(ctx context.Context, eventType string, payload any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.published++
+	return nil
+}
+
+func newPayment() *model.Payment {
+	return &model.Payment{ID: uuid.NewString(), OrderID: uuid.NewString(), Amount: 20, Status: model.PaymentStatusSucceeded}
+}
+
+func ref(s string) *string {
+	return &s
+}
+
+func TestRefundReference(t *testing.T) {
+	payment := newPayment()
+	s := newStore(payment)
+	p := &psp{}
+	pub := &publisher{}
+	ps := NewPaymentService(s, refunds{s}, p, pub, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+
+	first, err := ps.RefundPayment(ctx, payment.ID, 5, model.RefundReasonGoodwill, nil, ref("ticket-1"))
+	if err != nil {
+		t.Fatalf("first refund: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		amount    float64
+		reference string
+		wantErr   error
+	}{
+		{name: "same request", amount: 5, reference: "ticket-1"},
+		{name: "reference with another amount", amount: 6, reference: "ticket-1", wantErr: ErrReferenceReused},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refund, err := ps.RefundPayment(ctx, payment.ID, tt.amount, model.RefundReasonGoodwill, nil, ref(tt.reference))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && refund.ID != first.ID {
+				t.Errorf("refund %s, want the existing %s", refund.ID, first.ID)
+			}
+		})
+	}
+
+	if p.calls != 1 {
+		t.Errorf("%d provider calls, want 1", p.calls)
+	}
+	if stored, _ := s.FindByID(ctx, payment.ID); stored.RefundedAmount != 5 {
+		t.Errorf("refunded %v, want 5", stored.RefundedAmount)
+	}
+}
+
+func TestRefundPendingIsCompleted(t *testing.T) {
+	payment := newPayment()
+	s := newStore(payment)
+	p := &psp{}
+	pub := &publisher{}
+	ps := NewPaymentService(s, refunds{s}, p, pub, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+
+	// A refund left pending, e.g. by a crash before the provider was called.
+	pending := &model.Refund{PaymentID: payment.ID, Amount: 5, Reason: model.RefundReasonGoodwill, Reference: ref("ticket-1")}
+	if err := (refunds{s}).CreatePending(ctx, pending); err != nil {
+		t.Fatal(err)
+	}
+
+	refund, err := ps.RefundPayment(ctx, payment.ID, 5, model.RefundReasonGoodwill, nil, ref("ticket-1"))
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if refund.ID != pending.ID || refund.Status != model.RefundStatusSucceeded {
+		t.Errorf("refund %s is %s, want %s to be %s", refund.ID, refund.Status, pending.ID, model.RefundStatusSucceeded)
+	}
+	if !p.refunds[pending.ID] {
+		t.Error("provider was not asked for the pending refund")
+	}
+	if pub.published != 1 {
+		t.Errorf("%d events published, want 1", pub.published)
+	}
+}
+
+func TestRefundFailureReleasesReference(t *testing.T) {
+	payment := newPayment()
+	s := newStore(payment)
+	p := &psp{fail: errors.New("declined")}
+	ps := NewPaymentService(s, refunds{s}, p, &publisher{}, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+
+	if _, err := ps.RefundPayment(ctx, payment.ID, 5, model.RefundReasonGoodwill, nil, ref("ticket-1")); !errors.Is(err, ErrRefundFailed) {
+		t.Fatalf("error %v, want ErrRefundFailed", err)
+	}
+
+	p.fail = nil
+	refund, err := ps.RefundPayment(ctx, payment.ID, 5, model.RefundReasonGoodwill, nil, ref("ticket-1"))
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if refund.Status != model.RefundStatusSucceeded {
+		t.Errorf("refund is %s, want %s", refund.Status, model.RefundStatusSucceeded)
+	}
+}
+
+func TestConcurrentRefundsWithSameReference(t *testing.T) {
+	payment := newPayment()
+	s := newStore(payment)
+	p := &psp{}
+	ps := NewPaymentService(s, refunds{s}, p, &publisher{}, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+
+	// Both requests miss each other's refund in the lookup by reference, so
+	// one of them runs into the unique reference.
+	const requests = 2
+	var lookups sync.WaitGroup
+	lookups.Add(requests)
+	var calls sync.Mutex
+	first := 0
+	s.lookedUp = func() {
+		calls.Lock()
+		first++
+		waiting := first <= requests
+		calls.Unlock()
+		if waiting {
+			lookups.Done()
+			lookups.Wait()
+		}
+	}
+
+	type result struct {
+		refund *model.Refund
+		err    error
+	}
+	results := make(chan result, requests)
+	for range requests {
+		go func() {
+			refund, err := ps.RefundPayment(ctx, payment.ID, 5, model.RefundReasonGoodwill, nil, ref("ticket-1"))
+			results <- result{refund, err}
+		}()
+	}
+
+	var ids []string
+	for range requests {
+		r := <-results
+		if r.err != nil {
+			t.Fatalf("refund: %v", r.err)
+		}
+		ids = append(ids, r.refund.ID)
+	}
+	if ids[0] != ids[1] {
+		t.Errorf("refunds %s and %s for the same reference", ids[0], ids[1])
+	}
+	if stored, _ := s.FindByID(ctx, payment.ID); stored.RefundedAmount != 5 {
+		t.Errorf("refunded %v, want 5", stored.RefundedAmount)
+	}
+}
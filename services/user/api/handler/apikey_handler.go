@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/services/user/service"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// RateLimit is in requests per minute, 0 uses the default.
+	RateLimit int `json:"rateLimit,omitempty"`
+}
+
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+	logger        *slog.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService service.APIKeyService, logger *slog.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger.With("file", "apikey_handler.go"),
+	}
+}
+
+func (ah *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ah.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	principal := auth.PrincipalFromContext(r.Context())
+
+	reqLogger.Info("Processing new API key", "user_id", principal.UserID)
+
+	var req CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	key, err := ah.apiKeyService.CreateAPIKey(r.Context(), principal.UserID, req.Name, req.Scopes, req.RateLimit)
+	if err != nil {
+		reqLogger.Error("Error creating API key", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("API key created successfully", "api_key_id", key.ID)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
+
+func (ah *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ah.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	principal := auth.PrincipalFromContext(r.Context())
+
+	reqLogger.Info("Listing API keys", "user_id", principal.UserID)
+
+	keys, err := ah.apiKeyService.ListAPIKeys(r.Context(), principal.UserID)
+	if err != nil {
+		reqLogger.Error("Error listing API keys", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(keys)
+}
+
+func (ah *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ah.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	principal := auth.PrincipalFromContext(r.Context())
+	keyId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Revoking API key", "user_id", principal.UserID, "api_key_id", keyId)
+
+	if err := ah.apiKeyService.RevokeAPIKey(r.Context(), principal.UserID, keyId); err != nil {
+		reqLogger.Error("Error revoking API key", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (ah *APIKeyHandler) RotateAPIKey(w http.ResponseWriter, r *http.Request) {
+	reqLogger := ah.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	principal := auth.PrincipalFromContext(r.Context())
+	keyId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Rotating API key", "user_id", principal.UserID, "api_key_id", keyId)
+
+	key, err := ah.apiKeyService.RotateAPIKey(r.Context(), principal.UserID, keyId)
+	if err != nil {
+		reqLogger.Error("Error rotating API key", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("API key rotated successfully", "api_key_id", keyId, "replacement_id", key.ID)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(key)
+}
@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/services/user/service"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+type RegisterRequest struct {
+	Email    string  `json:"email"`
+	Password string  `json:"password"`
+	Name     *string `json:"name,omitempty"`
+	Locale   string  `json:"locale,omitempty"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+type PasswordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type PasswordResetConfirmRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+type SetRolesRequest struct {
+	Roles []string `json:"roles"`
+}
+
+type UserHandler struct {
+	userService service.UserService
+	logger      *slog.Logger
+}
+
+func NewUserHandler(userService service.UserService, logger *slog.Logger) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+		logger:      logger.With("file", "user_handler.go"),
+	}
+}
+
+func (uh *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
+	reqLogger := uh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	reqLogger.Info("Processing new registration")
+
+	var req RegisterRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	user, err := uh.userService.Register(r.Context(), req.Email, req.Password, req.Name, req.Locale)
+	if err != nil {
+		reqLogger.Error("Error registering user", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("User registered successfully", "user_id", user.ID)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (uh *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	reqLogger := uh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	principal := auth.PrincipalFromContext(r.Context())
+
+	reqLogger.Info("Retrieving current user", "user_id", principal.UserID)
+
+	user, err := uh.userService.GetUser(r.Context(), principal.UserID)
+	if err != nil {
+		reqLogger.Error("Error retrieving user", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (uh *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
+	reqLogger := uh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	reqLogger.Info("Processing login")
+
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	tokens, err := uh.userService.Login(r.Context(), req.Email, req.Password)
+	if err != nil {
+		reqLogger.Error("Error logging in", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tokens)
+}
+
+func (uh *UserHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	reqLogger := uh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	reqLogger.Info("Processing token refresh")
+
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	tokens, err := uh.userService.Refresh(r.Context(), req.RefreshToken)
+	if err != nil {
+		reqLogger.Error("Error refreshing tokens", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(tokens)
+}
+
+func (uh *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	reqLogger := uh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	reqLogger.Info("Processing logout")
+
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	if err := uh.userService.Logout(r.Context(), req.RefreshToken); err != nil {
+		reqLogger.Error("Error logging out", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (uh *UserHandler) RequestPasswordReset(w http.ResponseWriter, r *http.Request) {
+	reqLogger := uh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	reqLogger.Info("Processing password reset request")
+
+	var req PasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	if err := uh.userService.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		reqLogger.Error("Error requesting password reset", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	// Always accepted, whether the email is registered or not.
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (uh *UserHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	reqLogger := uh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	reqLogger.Info("Processing password reset")
+
+	var req PasswordResetConfirmRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	if err := uh.userService.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		reqLogger.Error("Error resetting password", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (uh *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	reqLogger := uh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	userId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Retrieving user by id", "user_id", userId)
+
+	user, err := uh.userService.GetUser(r.Context(), userId)
+	if err != nil {
+		reqLogger.Error("Error retrieving user", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (uh *UserHandler) SetRoles(w http.ResponseWriter, r *http.Request) {
+	reqLogger := uh.logger.With("request_id", middleware.GetReqID(r.Context()))
+
+	userId := chi.URLParam(r, "id")
+
+	reqLogger.Info("Processing role assignment", "user_id", userId)
+
+	var req SetRolesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		reqLogger.Error("Invalid request body")
+		apperr.Write(w, r, apperr.ErrInvalidRequest.Wrap(err))
+		return
+	}
+
+	user, err := uh.userService.SetRoles(r.Context(), userId, req.Roles)
+	if err != nil {
+		reqLogger.Error("Error assigning roles", "error", err)
+		apperr.Write(w, r, err)
+		return
+	}
+
+	reqLogger.Info("Roles assigned successfully", "user_id", userId, "roles", user.Roles)
+
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(user)
+}
+
+func (uh *UserHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	// Verifiers may cache the keys for a while; rotation publishes the new key
+	// before it is used for signing.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(uh.userService.JWKS())
+}
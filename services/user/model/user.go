@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+type User struct {
+	ID           string    `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	Name         *string   `json:"name,omitempty"`
+	Locale       string    `json:"locale"`
+	Roles        []string  `json:"roles"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+}
+
+type RefreshToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"userId"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type PasswordResetToken struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"userId"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	UsedAt    *time.Time `json:"usedAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// TokenPair is returned on login and refresh.
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	RefreshToken string `json:"refreshToken"`
+	TokenType    string `json:"tokenType"`
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn int `json:"expiresIn"`
+}
+
+// APIKey is a key partners use instead of logging in. The key itself is only
+// returned on creation, see CreatedAPIKey.
+type APIKey struct {
+	ID     string   `json:"id"`
+	UserID string   `json:"userId"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"`
+	Scopes []string `json:"scopes"`
+	// RateLimit is the number of allowed requests per minute.
+	RateLimit  int        `json:"rateLimit"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
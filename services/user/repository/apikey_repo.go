@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"ecommerce-platform/services/user/model"
+	"time"
+)
+
+// APIKeyRepository manages the API keys of users. Lookups are scoped to the
+// owner and fail with sql.ErrNoRows for keys of other users.
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey, keyHash string) error
+	FindByUserID(ctx context.Context, userID string) ([]model.APIKey, error)
+	FindByID(ctx context.Context, userID, id string) (*model.APIKey, error)
+	// Revoke revokes an active key.
+	Revoke(ctx context.Context, userID, id string, now time.Time) error
+	// Rotate stores the replacement of an active key and lets the old key
+	// expire at oldExpiresAt, in one transaction. Keys that were already
+	// rotated or revoked give sql.ErrNoRows.
+	Rotate(ctx context.Context, userID, id string, oldExpiresAt time.Time, replacement *model.APIKey, keyHash string) error
+}
@@ -0,0 +1,181 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/user/model"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const apiKeyColumns = `id, user_id, name, prefix, scopes, rate_limit, last_used_at, expires_at, revoked_at, created_at`
+
+// activeAPIKey matches keys that are neither revoked nor expired at $3.
+const activeAPIKey = `revoked_at IS NULL AND (expires_at IS NULL OR expires_at > $3)`
+
+type APIKeyPgRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func scanAPIKey(row rowScanner) (*model.APIKey, error) {
+	var key model.APIKey
+	err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.RateLimit,
+		&key.LastUsedAt, &key.ExpiresAt, &key.RevokedAt, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (ar *APIKeyPgRepository) Create(ctx context.Context, key *model.APIKey, keyHash string) error {
+	repoLogger := ar.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("Create started", "user_id", key.UserID)
+
+	if err := insertAPIKey(ctx, ar.db, key, keyHash); err != nil {
+		repoLogger.Error("Could not create record in database", "error", err)
+		return err
+	}
+
+	repoLogger.Info("Create successful", "api_key_id", key.ID)
+
+	return nil
+}
+
+func (ar *APIKeyPgRepository) FindByUserID(ctx context.Context, userID string) ([]model.APIKey, error) {
+	repoLogger := ar.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByUserID started", "user_id", userID)
+
+	rows, err := ar.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+	if err != nil {
+		repoLogger.Error("Error finding API keys", "error", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []model.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			repoLogger.Error("Error scanning API key", "error", err)
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	repoLogger.Info("FindByUserID successful", "user_id", userID, "count", len(keys))
+
+	return keys, nil
+}
+
+func (ar *APIKeyPgRepository) FindByID(ctx context.Context, userID, id string) (*model.APIKey, error) {
+	repoLogger := ar.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByID started", "api_key_id", id)
+
+	key, err := scanAPIKey(ar.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = $1 AND user_id = $2`, id, userID))
+	if err != nil {
+		repoLogger.Error("Error finding API key", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByID successful", "api_key_id", id)
+
+	return key, nil
+}
+
+func (ar *APIKeyPgRepository) Revoke(ctx context.Context, userID, id string, now time.Time) error {
+	repoLogger := ar.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("Revoke started", "api_key_id", id)
+
+	result, err := ar.db.ExecContext(ctx, `UPDATE api_keys SET revoked_at = $3 WHERE id = $1 AND user_id = $2 AND `+activeAPIKey, id, userID, now)
+	if err != nil {
+		repoLogger.Error("Could not revoke API key", "error", err)
+		return err
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		repoLogger.Error("API key is not active", "api_key_id", id)
+		return sql.ErrNoRows
+	}
+
+	repoLogger.Info("Revoke successful", "api_key_id", id)
+
+	return nil
+}
+
+func (ar *APIKeyPgRepository) Rotate(ctx context.Context, userID, id string, oldExpiresAt time.Time, replacement *model.APIKey, keyHash string) error {
+	repoLogger := ar.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("Rotate started", "api_key_id", id)
+
+	tx, err := ar.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Keys that expire were already rotated. Of two concurrent rotations of
+	// the same key only the first one finds it without expiry.
+	var oldID string
+	err = tx.QueryRowContext(ctx, `UPDATE api_keys SET expires_at = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at IS NULL RETURNING id`, id, userID, oldExpiresAt).Scan(&oldID)
+	if err != nil {
+		repoLogger.Error("Error finding active API key", "error", err)
+		return err
+	}
+
+	if err := insertAPIKey(ctx, tx, replacement, keyHash); err != nil {
+		repoLogger.Error("Could not create replacement", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
+	repoLogger.Info("Rotate successful", "api_key_id", id, "replacement_id", replacement.ID)
+
+	return nil
+}
+
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func insertAPIKey(ctx context.Context, db rowQuerier, key *model.APIKey, keyHash string) error {
+	exec := `INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, rate_limit) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING created_at`
+
+	key.ID = uuid.NewString()
+
+	return db.QueryRowContext(ctx, exec, key.ID, key.UserID, key.Name, key.Prefix, keyHash, pq.Array(key.Scopes), key.RateLimit).Scan(&key.CreatedAt)
+}
+
+func NewAPIKeyPgRepository(db *sql.DB, logger *slog.Logger) (*APIKeyPgRepository, error) {
+	if err := db.Ping(); err != nil {
+		return nil, errors.New("failed to connect to the database: " + err.Error())
+	}
+
+	return &APIKeyPgRepository{
+		db:     db,
+		logger: logger.With("file", "apikey_pg_repo.go"),
+	}, nil
+}
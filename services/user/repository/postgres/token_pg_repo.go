@@ -0,0 +1,130 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/user/model"
+	"ecommerce-platform/services/user/repository"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+)
+
+type TokenPgRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func (tr *TokenPgRepository) CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error {
+	repoLogger := tr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	exec := `INSERT INTO refresh_tokens (id, user_id, expires_at) VALUES ($1, $2, $3) RETURNING created_at`
+
+	err := tr.db.QueryRowContext(ctx, exec, token.ID, token.UserID, token.ExpiresAt).Scan(&token.CreatedAt)
+	if err != nil {
+		repoLogger.Error("Could not create refresh token", "user_id", token.UserID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (tr *TokenPgRepository) RevokeRefreshToken(ctx context.Context, id string, now time.Time) (*model.RefreshToken, error) {
+	repoLogger := tr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	// The conditions in the WHERE clause make sure a token is only revoked once,
+	// even if it is presented by two requests at the same time.
+	query := `UPDATE refresh_tokens SET revoked_at = $2 WHERE id = $1 AND revoked_at IS NULL AND expires_at > $2
+		RETURNING id, user_id, expires_at, revoked_at, created_at`
+
+	var token model.RefreshToken
+	err := tr.db.QueryRowContext(ctx, query, id, now).Scan(&token.ID, &token.UserID, &token.ExpiresAt, &token.RevokedAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Error("Refresh token is not active", "token_id", id)
+			return nil, repository.ErrTokenInvalid
+		}
+
+		repoLogger.Error("Could not revoke refresh token", "token_id", id, "error", err)
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (tr *TokenPgRepository) CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error {
+	repoLogger := tr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	exec := `INSERT INTO password_reset_tokens (id, user_id, token_hash, expires_at) VALUES ($1, $2, $3, $4) RETURNING created_at`
+
+	token.ID = uuid.NewString()
+
+	err := tr.db.QueryRowContext(ctx, exec, token.ID, token.UserID, token.TokenHash, token.ExpiresAt).Scan(&token.CreatedAt)
+	if err != nil {
+		repoLogger.Error("Could not create password reset token", "user_id", token.UserID, "error", err)
+		return err
+	}
+
+	return nil
+}
+
+func (tr *TokenPgRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (*model.User, error) {
+	repoLogger := tr.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("ResetPassword started")
+
+	tx, err := tr.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var userID string
+	err = tx.QueryRowContext(ctx, `UPDATE password_reset_tokens SET used_at = $2 WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2 RETURNING user_id`,
+		tokenHash, now).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			repoLogger.Error("Password reset token is not valid")
+			return nil, repository.ErrTokenInvalid
+		}
+
+		repoLogger.Error("Could not use password reset token", "error", err)
+		return nil, err
+	}
+
+	user, err := scanUser(tx.QueryRowContext(ctx, `UPDATE users SET password_hash = $2 WHERE id = $1 RETURNING `+userColumns, userID, passwordHash))
+	if err != nil {
+		repoLogger.Error("Could not update password", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	// A reset password must also end all existing sessions.
+	if _, err := tx.ExecContext(ctx, `UPDATE refresh_tokens SET revoked_at = $2 WHERE user_id = $1 AND revoked_at IS NULL`, userID, now); err != nil {
+		repoLogger.Error("Could not revoke refresh tokens", "user_id", userID, "error", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("ResetPassword successful", "user_id", userID)
+
+	return user, nil
+}
+
+func NewTokenPgRepository(db *sql.DB, logger *slog.Logger) (*TokenPgRepository, error) {
+	if err := db.Ping(); err != nil {
+		return nil, errors.New("failed to connect to the database: " + err.Error())
+	}
+
+	return &TokenPgRepository{
+		db:     db,
+		logger: logger.With("file", "token_pg_repo.go"),
+	}, nil
+}
@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/services/user/model"
+	"ecommerce-platform/services/user/repository"
+	"errors"
+	"log/slog"
+
+	"github.com/go-chi/chi/middleware"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const userColumns = `id, email, password_hash, name, locale, created_at, updated_at`
+
+type UserPgRepository struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanUser(row rowScanner) (*model.User, error) {
+	var user model.User
+	err := row.Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Locale, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (ur *UserPgRepository) Create(ctx context.Context, user *model.User) error {
+	repoLogger := ur.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("Create started", "email", user.Email)
+
+	exec := `INSERT INTO users (id, email, password_hash, name, locale) VALUES ($1, $2, $3, $4, $5) RETURNING created_at, updated_at`
+
+	user.ID = uuid.NewString()
+
+	tx, err := ur.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, exec, user.ID, user.Email, user.PasswordHash, user.Name, user.Locale).Scan(&user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			repoLogger.Error("Email already registered", "email", user.Email)
+			return repository.ErrEmailTaken
+		}
+
+		repoLogger.Error("Could not create record in database", "error", err)
+		return err
+	}
+
+	if err := insertRoles(ctx, tx, user.ID, user.Roles); err != nil {
+		repoLogger.Error("Could not assign roles", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
+	repoLogger.Info("Create successful", "user_id", user.ID)
+
+	return nil
+}
+
+func (ur *UserPgRepository) FindByID(ctx context.Context, id string) (*model.User, error) {
+	repoLogger := ur.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByID started", "user_id", id)
+
+	user, err := scanUser(ur.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE id = $1`, id))
+	if err != nil {
+		repoLogger.Error("Error finding user", "error", err)
+		return nil, err
+	}
+
+	if user.Roles, err = ur.findRoles(ctx, user.ID); err != nil {
+		repoLogger.Error("Error finding roles", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByID successful", "user_id", id)
+
+	return user, nil
+}
+
+func (ur *UserPgRepository) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	repoLogger := ur.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("FindByEmail started")
+
+	user, err := scanUser(ur.db.QueryRowContext(ctx, `SELECT `+userColumns+` FROM users WHERE email = $1`, email))
+	if err != nil {
+		repoLogger.Error("Error finding user", "error", err)
+		return nil, err
+	}
+
+	if user.Roles, err = ur.findRoles(ctx, user.ID); err != nil {
+		repoLogger.Error("Error finding roles", "error", err)
+		return nil, err
+	}
+
+	repoLogger.Info("FindByEmail successful", "user_id", user.ID)
+
+	return user, nil
+}
+
+func (ur *UserPgRepository) SetRoles(ctx context.Context, userID string, roles []string) error {
+	repoLogger := ur.logger.With("request_id", middleware.GetReqID(ctx))
+
+	repoLogger.Info("SetRoles started", "user_id", userID, "roles", roles)
+
+	tx, err := ur.db.BeginTx(ctx, nil)
+	if err != nil {
+		repoLogger.Error("Could not start transaction", "error", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	// Lock the user so concurrent updates don't interleave.
+	var id string
+	if err := tx.QueryRowContext(ctx, `SELECT id FROM users WHERE id = $1 FOR UPDATE`, userID).Scan(&id); err != nil {
+		repoLogger.Error("Error finding user", "error", err)
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM user_roles WHERE user_id = $1`, userID); err != nil {
+		repoLogger.Error("Could not remove roles", "error", err)
+		return err
+	}
+
+	if err := insertRoles(ctx, tx, userID, roles); err != nil {
+		repoLogger.Error("Could not assign roles", "error", err)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		repoLogger.Error("Could not commit transaction", "error", err)
+		return err
+	}
+
+	repoLogger.Info("SetRoles successful", "user_id", userID)
+
+	return nil
+}
+
+func (ur *UserPgRepository) findRoles(ctx context.Context, userID string) ([]string, error) {
+	rows, err := ur.db.QueryContext(ctx, `SELECT role FROM user_roles WHERE user_id = $1 ORDER BY role`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func insertRoles(ctx context.Context, tx *sql.Tx, userID string, roles []string) error {
+	for _, role := range roles {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO user_roles (user_id, role) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewUserPgRepository(db *sql.DB, logger *slog.Logger) (*UserPgRepository, error) {
+	if err := db.Ping(); err != nil {
+		return nil, errors.New("failed to connect to the database: " + err.Error())
+	}
+
+	return &UserPgRepository{
+		db:     db,
+		logger: logger.With("file", "user_pg_repo.go"),
+	}, nil
+}
@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"ecommerce-platform/services/user/model"
+	"errors"
+	"time"
+)
+
+var (
+	ErrEmailTaken   = errors.New("email address is already registered")
+	ErrTokenInvalid = errors.New("token is unknown, expired or was already used")
+)
+
+type UserRepository interface {
+	// Create stores the user together with its roles, failing with
+	// ErrEmailTaken if the email is in use.
+	Create(ctx context.Context, user *model.User) error
+	// FindByID and FindByEmail return the user including its roles.
+	FindByID(ctx context.Context, id string) (*model.User, error)
+	FindByEmail(ctx context.Context, email string) (*model.User, error)
+	// SetRoles replaces all roles of the user.
+	SetRoles(ctx context.Context, userID string, roles []string) error
+}
+
+type TokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *model.RefreshToken) error
+	// RevokeRefreshToken revokes an active refresh token, failing with
+	// ErrTokenInvalid if it is unknown, expired or already revoked. Only one
+	// of several concurrent calls for the same token succeeds.
+	RevokeRefreshToken(ctx context.Context, id string, now time.Time) (*model.RefreshToken, error)
+	CreatePasswordResetToken(ctx context.Context, token *model.PasswordResetToken) error
+	// ResetPassword consumes the reset token, sets the new password hash and
+	// revokes the user's refresh tokens in one transaction.
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (*model.User, error)
+}
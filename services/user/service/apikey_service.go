@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/internal/apikey"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/services/user/model"
+	"ecommerce-platform/services/user/repository"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+const (
+	// DefaultAPIKeyRateLimit and MaxAPIKeyRateLimit are requests per minute.
+	DefaultAPIKeyRateLimit = 60
+	MaxAPIKeyRateLimit     = 6000
+
+	// APIKeyRotationGracePeriod is how long a rotated key keeps working, so
+	// clients can switch to the new key without downtime.
+	APIKeyRotationGracePeriod = 24 * time.Hour
+)
+
+var (
+	ErrAPIKeyNotFound = apperr.New(apperr.NotFound, "api_key_not_found", "API key not found")
+	ErrInvalidAPIKey  = apperr.New(apperr.Validation, "invalid_api_key", "API keys need a name, a rate limit of at most 6000 requests per minute and scopes the owner is allowed to use")
+	// ErrAPIKeyNotRotatable is returned for keys that were revoked or
+	// already rotated, possibly by a concurrent request.
+	ErrAPIKeyNotRotatable = apperr.New(apperr.Conflict, "api_key_not_rotatable", "API key was revoked or already rotated")
+)
+
+type APIKeyService interface {
+	// CreateAPIKey creates a key acting as the user. Scopes have to be
+	// permissions the user has.
+	CreateAPIKey(ctx context.Context, userID, name string, scopes []string, rateLimit int) (*model.CreatedAPIKey, error)
+	ListAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, userID, id string) error
+	// RotateAPIKey replaces a key by a new one with the same settings. The old
+	// key keeps working for APIKeyRotationGracePeriod.
+	RotateAPIKey(ctx context.Context, userID, id string) (*model.CreatedAPIKey, error)
+}
+
+type apiKeyServiceImpl struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+	logger     *slog.Logger
+}
+
+func (as *apiKeyServiceImpl) CreateAPIKey(ctx context.Context, userID, name string, scopes []string, rateLimit int) (*model.CreatedAPIKey, error) {
+	serviceLogger := as.logger.With("request_id", middleware.GetReqID(ctx), "user_id", userID)
+
+	serviceLogger.Info("CreateAPIKey started", "scopes", scopes)
+
+	user, err := as.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if rateLimit == 0 {
+		rateLimit = DefaultAPIKeyRateLimit
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" || len(scopes) == 0 || rateLimit < 0 || rateLimit > MaxAPIKeyRateLimit {
+		serviceLogger.Error("Invalid API key")
+		return nil, ErrInvalidAPIKey
+	}
+
+	owner := auth.Principal{UserID: user.ID, Roles: user.Roles}
+	for _, scope := range scopes {
+		if !auth.ValidPermission(auth.Permission(scope)) || !owner.Can(auth.Permission(scope)) {
+			serviceLogger.Error("Scope not allowed", "scope", scope)
+			return nil, ErrInvalidAPIKey
+		}
+	}
+
+	created, hash, err := newAPIKey(model.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := as.apiKeyRepo.Create(ctx, &created.APIKey, hash); err != nil {
+		serviceLogger.Error("CreateAPIKey failed")
+		return nil, err
+	}
+
+	serviceLogger.Info("CreateAPIKey completed successfully", "api_key_id", created.ID)
+
+	return created, nil
+}
+
+func (as *apiKeyServiceImpl) ListAPIKeys(ctx context.Context, userID string) ([]model.APIKey, error) {
+	serviceLogger := as.logger.With("request_id", middleware.GetReqID(ctx), "user_id", userID)
+
+	serviceLogger.Info("ListAPIKeys started")
+
+	keys, err := as.apiKeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("ListAPIKeys completed successfully", "count", len(keys))
+
+	return keys, nil
+}
+
+func (as *apiKeyServiceImpl) RevokeAPIKey(ctx context.Context, userID, id string) error {
+	serviceLogger := as.logger.With("request_id", middleware.GetReqID(ctx), "user_id", userID, "api_key_id", id)
+
+	serviceLogger.Info("RevokeAPIKey started")
+
+	if err := as.apiKeyRepo.Revoke(ctx, userID, id, time.Now()); errors.Is(err, sql.ErrNoRows) {
+		return ErrAPIKeyNotFound.Wrap(err)
+	} else if err != nil {
+		return err
+	}
+
+	serviceLogger.Info("RevokeAPIKey completed successfully")
+
+	return nil
+}
+
+func (as *apiKeyServiceImpl) RotateAPIKey(ctx context.Context, userID, id string) (*model.CreatedAPIKey, error) {
+	serviceLogger := as.logger.With("request_id", middleware.GetReqID(ctx), "user_id", userID, "api_key_id", id)
+
+	serviceLogger.Info("RotateAPIKey started")
+
+	old, err := as.apiKeyRepo.FindByID(ctx, userID, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound.Wrap(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	created, hash, err := newAPIKey(model.APIKey{
+		UserID:    userID,
+		Name:      old.Name,
+		Scopes:    old.Scopes,
+		RateLimit: old.RateLimit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = as.apiKeyRepo.Rotate(ctx, userID, id, time.Now().Add(APIKeyRotationGracePeriod), &created.APIKey, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotRotatable.Wrap(err)
+	}
+	if err != nil {
+		serviceLogger.Error("RotateAPIKey failed")
+		return nil, err
+	}
+
+	serviceLogger.Info("RotateAPIKey completed successfully", "replacement_id", created.ID)
+
+	return created, nil
+}
+
+func newAPIKey(key model.APIKey) (*model.CreatedAPIKey, string, error) {
+	raw, prefix, hash, err := apikey.Generate()
+	if err != nil {
+		return nil, "", err
+	}
+
+	key.Prefix = prefix
+
+	return &model.CreatedAPIKey{APIKey: key, Key: raw}, hash, nil
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, userRepo repository.UserRepository, logger *slog.Logger) *apiKeyServiceImpl {
+	return &apiKeyServiceImpl{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		logger:     logger.With("file", "apikey_service.go"),
+	}
+}
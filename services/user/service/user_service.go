@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"ecommerce-platform/internal/apperr"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/internal/messaging"
+	"ecommerce-platform/pkg/events"
+	"ecommerce-platform/services/user/model"
+	"ecommerce-platform/services/user/password"
+	"ecommerce-platform/services/user/repository"
+	"ecommerce-platform/services/user/token"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/middleware"
+)
+
+const (
+	MinPasswordLength = 8
+
+	passwordResetTTL = time.Hour
+)
+
+var (
+	ErrUserNotFound        = apperr.New(apperr.NotFound, "user_not_found", "User not found")
+	ErrInvalidRegistration = apperr.New(apperr.Validation, "invalid_registration", "Registration needs a valid email address and a password of at least 8 characters")
+	ErrEmailTaken          = apperr.New(apperr.Conflict, "email_taken", "Email address is already registered")
+	ErrInvalidCredentials  = apperr.New(apperr.Unauthenticated, "invalid_credentials", "Email or password is wrong")
+	ErrInvalidToken        = apperr.New(apperr.Unauthenticated, "invalid_token", "Token is invalid or expired")
+	ErrWeakPassword        = apperr.New(apperr.Validation, "weak_password", "Password needs at least 8 characters")
+	ErrInvalidRoles        = apperr.New(apperr.Validation, "invalid_roles", "Unknown role")
+)
+
+type UserService interface {
+	Register(ctx context.Context, email, password string, name *string, locale string) (*model.User, error)
+	GetUser(ctx context.Context, id string) (*model.User, error)
+	Login(ctx context.Context, email, password string) (*model.TokenPair, error)
+	// Refresh exchanges a refresh token for a new token pair. The presented
+	// refresh token is revoked, so it can only be used once.
+	Refresh(ctx context.Context, refreshToken string) (*model.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	// RequestPasswordReset sends a reset token to the user if the email is
+	// registered. It doesn't tell the caller whether it is.
+	RequestPasswordReset(ctx context.Context, email string) error
+	ResetPassword(ctx context.Context, resetToken, newPassword string) error
+	// SetRoles replaces the roles of a user. They are part of the access
+	// token, so the user gets them with the next login or refresh.
+	SetRoles(ctx context.Context, userID string, roles []string) (*model.User, error)
+	JWKS() auth.JWKS
+}
+
+type userServiceImpl struct {
+	userRepo  repository.UserRepository
+	tokenRepo repository.TokenRepository
+	signer    *token.Signer
+	publisher messaging.Publisher
+	logger    *slog.Logger
+}
+
+func (us *userServiceImpl) Register(ctx context.Context, email, pw string, name *string, locale string) (*model.User, error) {
+	email = normalizeEmail(email)
+	serviceLogger := us.logger.With("request_id", middleware.GetReqID(ctx), "email", email)
+
+	serviceLogger.Info("Register started")
+
+	if _, err := mail.ParseAddress(email); err != nil || len(pw) < MinPasswordLength {
+		serviceLogger.Error("Invalid registration")
+		return nil, ErrInvalidRegistration
+	}
+
+	hash, err := password.Hash(pw)
+	if err != nil {
+		return nil, err
+	}
+
+	if locale == "" {
+		locale = "en"
+	}
+
+	user := model.User{
+		Email:        email,
+		PasswordHash: hash,
+		Name:         name,
+		Locale:       locale,
+		Roles:        []string{string(auth.RoleCustomer)},
+	}
+
+	err = us.userRepo.Create(ctx, &user)
+	if errors.Is(err, repository.ErrEmailTaken) {
+		serviceLogger.Error("Email already registered")
+		return nil, ErrEmailTaken.Wrap(err)
+	}
+	if err != nil {
+		serviceLogger.Error("Register failed")
+		return nil, err
+	}
+
+	err = us.publisher.Publish(ctx, events.UserRegistered, events.UserRegisteredPayload{
+		UserID: user.ID,
+		Email:  user.Email,
+		Name:   user.Name,
+		Locale: user.Locale,
+	})
+	if err != nil {
+		serviceLogger.Error("Could not publish registration event", "user_id", user.ID, "error", err)
+	}
+
+	serviceLogger.Info("Register completed successfully", "user_id", user.ID)
+
+	return &user, nil
+}
+
+func (us *userServiceImpl) GetUser(ctx context.Context, id string) (*model.User, error) {
+	serviceLogger := us.logger.With("request_id", middleware.GetReqID(ctx), "user_id", id)
+
+	serviceLogger.Info("GetUser started")
+
+	user, err := us.userRepo.FindByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound.Wrap(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("GetUser completed successfully")
+
+	return user, nil
+}
+
+func (us *userServiceImpl) Login(ctx context.Context, email, pw string) (*model.TokenPair, error) {
+	email = normalizeEmail(email)
+	serviceLogger := us.logger.With("request_id", middleware.GetReqID(ctx))
+
+	serviceLogger.Info("Login started")
+
+	user, err := us.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			// Hash anyway, so the response time doesn't reveal whether the email is registered.
+			password.Hash(pw)
+			serviceLogger.Error("Login for unknown email")
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+
+	ok, err := password.Verify(pw, user.PasswordHash)
+	if err != nil {
+		serviceLogger.Error("Could not verify password", "user_id", user.ID, "error", err)
+		return nil, err
+	}
+	if !ok {
+		serviceLogger.Error("Wrong password", "user_id", user.ID)
+		return nil, ErrInvalidCredentials
+	}
+
+	pair, err := us.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("Login completed successfully", "user_id", user.ID)
+
+	return pair, nil
+}
+
+func (us *userServiceImpl) Refresh(ctx context.Context, refreshToken string) (*model.TokenPair, error) {
+	serviceLogger := us.logger.With("request_id", middleware.GetReqID(ctx))
+
+	serviceLogger.Info("Refresh started")
+
+	claims, err := us.signer.Parse(refreshToken, auth.TokenTypeRefresh)
+	if err != nil {
+		serviceLogger.Error("Invalid refresh token", "error", err)
+		return nil, ErrInvalidToken
+	}
+
+	if _, err := us.tokenRepo.RevokeRefreshToken(ctx, claims.ID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrTokenInvalid) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	user, err := us.userRepo.FindByID(ctx, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+
+	pair, err := us.issueTokens(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("Refresh completed successfully", "user_id", user.ID)
+
+	return pair, nil
+}
+
+func (us *userServiceImpl) Logout(ctx context.Context, refreshToken string) error {
+	serviceLogger := us.logger.With("request_id", middleware.GetReqID(ctx))
+
+	claims, err := us.signer.Parse(refreshToken, auth.TokenTypeRefresh)
+	if err != nil {
+		serviceLogger.Error("Invalid refresh token", "error", err)
+		return ErrInvalidToken
+	}
+
+	_, err = us.tokenRepo.RevokeRefreshToken(ctx, claims.ID, time.Now())
+	if err != nil && !errors.Is(err, repository.ErrTokenInvalid) {
+		return err
+	}
+
+	serviceLogger.Info("Logout completed successfully", "user_id", claims.Subject)
+
+	return nil
+}
+
+func (us *userServiceImpl) issueTokens(ctx context.Context, user *model.User) (*model.TokenPair, error) {
+	now := time.Now()
+
+	access, err := us.signer.IssueAccessToken(user.ID, user.Email, user.Roles, now)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, refreshID, expiresAt, err := us.signer.IssueRefreshToken(user.ID, now)
+	if err != nil {
+		return nil, err
+	}
+
+	err = us.tokenRepo.CreateRefreshToken(ctx, &model.RefreshToken{
+		ID:        refreshID,
+		UserID:    user.ID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(us.signer.AccessTTL().Seconds()),
+	}, nil
+}
+
+func (us *userServiceImpl) RequestPasswordReset(ctx context.Context, email string) error {
+	email = normalizeEmail(email)
+	serviceLogger := us.logger.With("request_id", middleware.GetReqID(ctx))
+
+	serviceLogger.Info("RequestPasswordReset started")
+
+	user, err := us.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			serviceLogger.Info("Password reset for unknown email")
+			return nil
+		}
+		return err
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	resetToken := base64.RawURLEncoding.EncodeToString(raw)
+
+	stored := model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hashToken(resetToken),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}
+	if err := us.tokenRepo.CreatePasswordResetToken(ctx, &stored); err != nil {
+		return err
+	}
+
+	err = us.publisher.Publish(ctx, events.UserPasswordResetRequested, events.UserPasswordResetRequestedPayload{
+		UserID:    user.ID,
+		Email:     user.Email,
+		Name:      user.Name,
+		Locale:    user.Locale,
+		Token:     resetToken,
+		ExpiresAt: stored.ExpiresAt,
+	})
+	if err != nil {
+		serviceLogger.Error("Could not publish password reset event", "user_id", user.ID, "error", err)
+		return fmt.Errorf("could not send password reset: %w", err)
+	}
+
+	serviceLogger.Info("RequestPasswordReset completed successfully", "user_id", user.ID)
+
+	return nil
+}
+
+func (us *userServiceImpl) ResetPassword(ctx context.Context, resetToken, newPassword string) error {
+	serviceLogger := us.logger.With("request_id", middleware.GetReqID(ctx))
+
+	serviceLogger.Info("ResetPassword started")
+
+	if len(newPassword) < MinPasswordLength {
+		return ErrWeakPassword
+	}
+
+	hash, err := password.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+
+	user, err := us.tokenRepo.ResetPassword(ctx, hashToken(resetToken), hash, time.Now())
+	if err != nil {
+		if errors.Is(err, repository.ErrTokenInvalid) {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	serviceLogger.Info("ResetPassword completed successfully", "user_id", user.ID)
+
+	return nil
+}
+
+func (us *userServiceImpl) SetRoles(ctx context.Context, userID string, roles []string) (*model.User, error) {
+	serviceLogger := us.logger.With("request_id", middleware.GetReqID(ctx), "user_id", userID, "roles", roles)
+
+	serviceLogger.Info("SetRoles started")
+
+	for _, role := range roles {
+		if !auth.ValidRole(role) {
+			serviceLogger.Error("Unknown role", "role", role)
+			return nil, ErrInvalidRoles
+		}
+	}
+
+	if err := us.userRepo.SetRoles(ctx, userID, roles); errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrUserNotFound.Wrap(err)
+	} else if err != nil {
+		return nil, err
+	}
+
+	serviceLogger.Info("SetRoles completed successfully")
+
+	return us.userRepo.FindByID(ctx, userID)
+}
+
+func (us *userServiceImpl) JWKS() auth.JWKS {
+	return us.signer.JWKS()
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func hashToken(t string) string {
+	sum := sha256.Sum256([]byte(t))
+	return hex.EncodeToString(sum[:])
+}
+
+func NewUserService(userRepo repository.UserRepository, tokenRepo repository.TokenRepository, signer *token.Signer, publisher messaging.Publisher, logger *slog.Logger) *userServiceImpl {
+	return &userServiceImpl{
+		userRepo:  userRepo,
+		tokenRepo: tokenRepo,
+		signer:    signer,
+		publisher: publisher,
+		logger:    logger.With("file", "user_service.go"),
+	}
+}
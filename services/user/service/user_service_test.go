@@ -0,0 +1,292 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-platform/internal/auth"
+	"ecommerce-platform/pkg/events"
+	"ecommerce-platform/services/user/model"
+	"ecommerce-platform/services/user/repository"
+	"ecommerce-platform/services/user/token"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// store keeps users and tokens like the Postgres repositories do.
+type store struct {
+	mu            sync.Mutex
+	users         map[string]*model.User
+	refreshTokens map[string]*model.RefreshToken
+	resetTokens   map[string]*model.PasswordResetToken
+}
+
+func newStore() *store {
+	return &store{
+		users:         make(map[string]*model.User),
+		refreshTokens: make(map[string]*model.RefreshToken),
+		resetTokens:   make(map[string]*model.PasswordResetToken),
+	}
+}
+
+func (s *store) Create(ctx context.Context, user *model.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, other := range s.users {
+		if other.Email == user.Email {
+			return repository.ErrEmailTaken
+		}
+	}
+	user.ID = uuid.NewString()
+	stored := *user
+	s.users[user.ID] = &stored
+	return nil
+}
+
+func (s *store) FindByID(ctx context.Context, id string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	u := *user
+	return &u, nil
+}
+
+func (s *store) FindByEmail(ctx context.Context, email string) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, user := range s.users {
+		if user.Email == email {
+			u := *user
+			return &u, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (s *store) SetRoles(ctx context.Context, userID string, roles []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	user.Roles = roles
+	return nil
+}
+
+func (s *store) CreateRefreshToken(ctx context.Context, t *model.RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored := *t
+	s.refreshTokens[t.ID] = &stored
+	return nil
+}
+
+func (s *store) RevokeRefreshToken(ctx context.Context, id string, now time.Time) (*model.RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.refreshTokens[id]
+	if !ok || t.RevokedAt != nil || !now.Before(t.ExpiresAt) {
+		return nil, repository.ErrTokenInvalid
+	}
+	t.RevokedAt = &now
+	revoked := *t
+	return &revoked, nil
+}
+
+func (s *store) CreatePasswordResetToken(ctx context.Context, t *model.PasswordResetToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t.ID = uuid.NewString()
+	stored := *t
+	s.resetTokens[t.TokenHash] = &stored
+	return nil
+}
+
+func (s *store) ResetPassword(ctx context.Context, tokenHash, passwordHash string, now time.Time) (*model.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.resetTokens[tokenHash]
+	if !ok || t.UsedAt != nil || !now.Before(t.ExpiresAt) {
+		return nil, repository.ErrTokenInvalid
+	}
+	t.UsedAt = &now
+
+	user := s.users[t.UserID]
+	user.PasswordHash = passwordHash
+	for _, refresh := range s.refreshTokens {
+		if refresh.UserID == user.ID && refresh.RevokedAt == nil {
+			refresh.RevokedAt = &now
+		}
+	}
+	u := *user
+	return &u, nil
+}
+
+// publisher keeps the published events.
+type publisher struct {
+	mu     sync.Mutex
+	events map[string][]any
+}
+
+func (p *publisher) Publish(ctx context.Context, eventType string, payload any) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.events == nil {
+		p.events = make(map[string][]any)
+	}
+	p.events[eventType] = append(p.events[eventType], payload)
+	return nil
+}
+
+func newUserService(t *testing.T) (*userServiceImpl, *publisher) {
+	t.Helper()
+	key, err := token.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := newStore()
+	pub := &publisher{}
+	return NewUserService(s, s, token.NewSigner(key, 15*time.Minute, 24*time.Hour), pub, slog.New(slog.DiscardHandler)), pub
+}
+
+func TestLogin(t *testing.T) {
+	us, _ := newUserService(t)
+	ctx := context.Background()
+
+	user, err := us.Register(ctx, "Jane@Example.com ", "correct horse", nil, "")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := us.Register(ctx, "jane@example.com", "another password", nil, ""); !errors.Is(err, ErrEmailTaken) {
+		t.Errorf("second registration: error %v, want ErrEmailTaken", err)
+	}
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		wantErr  error
+	}{
+		{name: "valid credentials", email: "jane@example.com", password: "correct horse"},
+		{name: "email in other case", email: "JANE@example.com", password: "correct horse"},
+		{name: "wrong password", email: "jane@example.com", password: "wrong horse", wantErr: ErrInvalidCredentials},
+		{name: "unknown email", email: "john@example.com", password: "correct horse", wantErr: ErrInvalidCredentials},
+	}
+
+	verifier := auth.NewVerifier(us.signer.Keys())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair, err := us.Login(ctx, tt.email, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			principal, err := verifier.Verify(ctx, pair.AccessToken)
+			if err != nil {
+				t.Fatalf("access token: %v", err)
+			}
+			if principal.UserID != user.ID || len(principal.Roles) != 1 || principal.Roles[0] != string(auth.RoleCustomer) {
+				t.Errorf("principal %+v", principal)
+			}
+			if _, err := verifier.Verify(ctx, pair.RefreshToken); !errors.Is(err, auth.ErrInvalidToken) {
+				t.Errorf("refresh token accepted as access token: %v", err)
+			}
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	us, _ := newUserService(t)
+	ctx := context.Background()
+
+	if _, err := us.Register(ctx, "jane@example.com", "correct horse", nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	first, err := us.Login(ctx, "jane@example.com", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := us.Refresh(ctx, first.RefreshToken)
+	if err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if _, err := us.Refresh(ctx, first.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("reused refresh token: error %v, want ErrInvalidToken", err)
+	}
+	if _, err := us.Refresh(ctx, second.AccessToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("access token as refresh token: error %v, want ErrInvalidToken", err)
+	}
+
+	if err := us.Logout(ctx, second.RefreshToken); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := us.Refresh(ctx, second.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("refresh after logout: error %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	us, pub := newUserService(t)
+	ctx := context.Background()
+
+	if _, err := us.Register(ctx, "jane@example.com", "correct horse", nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	session, err := us.Login(ctx, "jane@example.com", "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := us.RequestPasswordReset(ctx, "john@example.com"); err != nil {
+		t.Errorf("reset for unknown email: %v", err)
+	}
+	if err := us.RequestPasswordReset(ctx, "jane@example.com"); err != nil {
+		t.Fatalf("RequestPasswordReset: %v", err)
+	}
+	requested := pub.events[events.UserPasswordResetRequested]
+	if len(requested) != 1 {
+		t.Fatalf("%d reset events, want 1 for the registered email only", len(requested))
+	}
+	resetToken := requested[0].(events.UserPasswordResetRequestedPayload).Token
+
+	if err := us.ResetPassword(ctx, resetToken, "short"); !errors.Is(err, ErrWeakPassword) {
+		t.Errorf("weak password: error %v, want ErrWeakPassword", err)
+	}
+	if err := us.ResetPassword(ctx, resetToken, "battery staple"); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	if err := us.ResetPassword(ctx, resetToken, "battery staple"); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("reused reset token: error %v, want ErrInvalidToken", err)
+	}
+
+	if _, err := us.Login(ctx, "jane@example.com", "correct horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("old password: error %v, want ErrInvalidCredentials", err)
+	}
+	if _, err := us.Login(ctx, "jane@example.com", "battery staple"); err != nil {
+		t.Errorf("new password: %v", err)
+	}
+	if _, err := us.Refresh(ctx, session.RefreshToken); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("refresh token from before the reset: error %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,148 @@
+// Package token issues the signed JWTs of the users service and publishes the
+// public key as JWKS, so other services can verify tokens without calling us.
+package token
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"ecommerce-platform/internal/auth"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+type Signer struct {
+	key        *rsa.PrivateKey
+	kid        string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewSigner(key *rsa.PrivateKey, accessTTL, refreshTTL time.Duration) *Signer {
+	// The key ID is derived from the public key, so it stays the same across
+	// restarts and changes when the key is rotated.
+	der := x509.MarshalPKCS1PublicKey(&key.PublicKey)
+	sum := sha256.Sum256(der)
+
+	return &Signer{
+		key:        key,
+		kid:        base64.RawURLEncoding.EncodeToString(sum[:12]),
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// LoadKey reads a PEM encoded RSA private key (PKCS #1 or PKCS #8).
+func LoadKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key in %s is not an RSA key", path)
+	}
+
+	return key, nil
+}
+
+// GenerateKey creates a new signing key. Tokens signed with it become invalid
+// when the process restarts, so it is only meant for local development.
+func GenerateKey() (*rsa.PrivateKey, error) {
+	return rsa.GenerateKey(rand.Reader, 2048)
+}
+
+func (s *Signer) AccessTTL() time.Duration {
+	return s.accessTTL
+}
+
+// IssueAccessToken returns a signed access token for the user.
+func (s *Signer) IssueAccessToken(userID, email string, roles []string, now time.Time) (string, error) {
+	return s.sign(auth.Claims{
+		Email: email,
+		Roles: roles,
+		Type:  auth.TokenTypeAccess,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    auth.Issuer,
+			Subject:   userID,
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+		},
+	})
+}
+
+// IssueRefreshToken returns a signed refresh token for the user together with
+// its ID, which has to be stored so the token can be revoked.
+func (s *Signer) IssueRefreshToken(userID string, now time.Time) (string, string, time.Time, error) {
+	id := uuid.NewString()
+	expiresAt := now.Add(s.refreshTTL)
+
+	signed, err := s.sign(auth.Claims{
+		Type: auth.TokenTypeRefresh,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    auth.Issuer,
+			Subject:   userID,
+			ID:        id,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	})
+
+	return signed, id, expiresAt, err
+}
+
+func (s *Signer) sign(claims auth.Claims) (string, error) {
+	t := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	t.Header["kid"] = s.kid
+	return t.SignedString(s.key)
+}
+
+// Parse verifies the signature and expiry of a token issued by this signer and
+// checks that it has the expected type.
+func (s *Signer) Parse(tokenString, tokenType string) (*auth.Claims, error) {
+	var claims auth.Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (any, error) {
+		return &s.key.PublicKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg()}), jwt.WithIssuer(auth.Issuer), jwt.WithExpirationRequired())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", auth.ErrInvalidToken, err)
+	}
+
+	if claims.Type != tokenType {
+		return nil, fmt.Errorf("%w: expected %s token", auth.ErrInvalidToken, tokenType)
+	}
+
+	return &claims, nil
+}
+
+// JWKS returns the public signing key as key set.
+func (s *Signer) JWKS() auth.JWKS {
+	return auth.JWKS{Keys: []auth.JWK{auth.NewJWK(s.kid, &s.key.PublicKey)}}
+}
+
+// Keys returns the signer's public key for verifying its own access tokens.
+func (s *Signer) Keys() auth.StaticKeys {
+	return auth.StaticKeys{s.kid: &s.key.PublicKey}
+}